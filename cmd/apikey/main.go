@@ -0,0 +1,163 @@
+package main
+
+import (
+	utils "LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// apiKeyLength is the number of random bytes of an API key
+const apiKeyLength = 32
+
+const usage = `apikey manages the API keys of internal services.
+
+usage:
+  apikey create -name analytics -scopes users:read,scores:read [-rate-limit 60]
+  apikey list
+  apikey rotate -id <key id>
+  apikey revoke -id <key id>
+
+Keys are printed once on create and rotate, only their hash is stored.
+`
+
+// apikey is the admin CLI for API keys. It reads the same app.env as the server,
+// which must have run once to create the apikeys table.
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	logger := utils.NewLogger()
+	configs := utils.NewConfigurations(logger, utils.DeployLocal)
+	db, err := database.NewConnection(configs, logger)
+	if err != nil {
+		fatal("unable to connect to db: %v", err)
+	}
+	defer db.Close()
+	repository := database.NewPostgresRepository(db, logger)
+	ctx := context.Background()
+
+	command, args := os.Args[1], os.Args[2:]
+	switch command {
+	case "create":
+		fs := flag.NewFlagSet("create", flag.ExitOnError)
+		name := fs.String("name", "", "name of the service using the key")
+		scopes := fs.String("scopes", "", "comma separated scopes: "+strings.Join(database.APIKeyScopes, ", "))
+		rateLimit := fs.Int("rate-limit", configs.APIKeyRateLimit, "requests per minute")
+		fs.Parse(args)
+		if *name == "" || *rateLimit <= 0 {
+			fatal("a name and a positive rate limit are required")
+		}
+		scopeList, err := parseScopes(*scopes)
+		if err != nil {
+			fatal("%v", err)
+		}
+		key, rawKey, err := newAPIKey()
+		if err != nil {
+			fatal("unable to generate api key: %v", err)
+		}
+		key.Name = *name
+		key.Scopes = strings.Join(scopeList, ",")
+		key.RateLimit = *rateLimit
+		if err := repository.CreateAPIKey(ctx, key); err != nil {
+			fatal("unable to create api key: %v", err)
+		}
+		fmt.Printf("created api key %s (%s)\n%s\n", key.ID, key.Name, rawKey)
+	case "list":
+		keys, err := repository.GetAPIKeys(ctx)
+		if err != nil {
+			fatal("unable to list api keys: %v", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tPREFIX\tSCOPES\tRATE LIMIT\tLAST USED\tSTATUS")
+		for _, key := range keys {
+			status := "active"
+			if !key.IsActive() {
+				status = "revoked " + key.RevokedAt.Format(time.RFC3339)
+			}
+			lastUsed := "never"
+			if key.LastUsedAt != nil {
+				lastUsed = key.LastUsedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d/min\t%s\t%s\n", key.ID, key.Name, key.Prefix, key.Scopes, key.RateLimit, lastUsed, status)
+		}
+		w.Flush()
+	case "rotate":
+		fs := flag.NewFlagSet("rotate", flag.ExitOnError)
+		id := fs.String("id", "", "id of the key to replace")
+		fs.Parse(args)
+		if *id == "" {
+			fatal("an id is required")
+		}
+		key, rawKey, err := newAPIKey()
+		if err != nil {
+			fatal("unable to generate api key: %v", err)
+		}
+		if err := repository.RotateAPIKey(ctx, *id, key); err != nil {
+			fatal("unable to rotate api key %s, is it active? %v", *id, err)
+		}
+		fmt.Printf("revoked api key %s, replaced by %s (%s)\n%s\n", *id, key.ID, key.Name, rawKey)
+	case "revoke":
+		fs := flag.NewFlagSet("revoke", flag.ExitOnError)
+		id := fs.String("id", "", "id of the key to revoke")
+		fs.Parse(args)
+		if *id == "" {
+			fatal("an id is required")
+		}
+		if err := repository.RevokeAPIKey(ctx, *id); err != nil {
+			fatal("unable to revoke api key %s, is it active? %v", *id, err)
+		}
+		fmt.Printf("revoked api key %s\n", *id)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// newAPIKey returns a key with its hash and display prefix set, and the key itself
+func newAPIKey() (*database.APIKey, string, error) {
+	secret, err := utils.GenerateSecureToken(apiKeyLength)
+	if err != nil {
+		return nil, "", err
+	}
+	rawKey := database.APIKeyPrefix + secret
+	return &database.APIKey{
+		KeyHash: utils.HashToken(rawKey),
+		Prefix:  rawKey[:len(database.APIKeyPrefix)+6],
+	}, rawKey, nil
+}
+
+// parseScopes splits the scopes flag and rejects unknown scopes
+func parseScopes(value string) ([]string, error) {
+	var scopes []string
+	for _, scope := range strings.Split(value, ",") {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+		valid := false
+		for _, known := range database.APIKeyScopes {
+			valid = valid || scope == known
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown scope %q, valid scopes are %s", scope, strings.Join(database.APIKeyScopes, ", "))
+		}
+		scopes = append(scopes, scope)
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	return scopes, nil
+}
+
+func fatal(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
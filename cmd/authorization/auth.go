@@ -7,6 +7,7 @@ import (
 	"LoveLetterProject/pkg/authorization/endpoints"
 	"LoveLetterProject/pkg/authorization/middleware"
 	"LoveLetterProject/pkg/authorization/transport"
+	"LoveLetterProject/pkg/oidc"
 	"context"
 	"fmt"
 	"github.com/go-co-op/gocron"
@@ -36,6 +37,12 @@ const userSchema = `
 		);
 `
 
+// migration adding roles and guest device secrets to the user table
+const userRoleMigration = `
+		alter table users add column if not exists role Varchar(20) not null default 'user';
+		alter table users add column if not exists devicesecrethash Varchar(64) not null default '';
+`
+
 // schema for verification table
 const verificationSchema = `
 		create table if not exists verifications (
@@ -49,6 +56,18 @@ const verificationSchema = `
 		)
 `
 
+// migration allowing one verification code per email and type, so a login
+// code does not overwrite a pending password reset code
+const verificationTypeMigration = `
+		alter table verifications drop constraint if exists verifications_pkey;
+		create unique index if not exists verifications_email_type_idx on verifications (email, type);
+`
+
+// migration storing the target of a verification code, e.g. the phone number being verified
+const verificationTargetMigration = `
+		alter table verifications add column if not exists target Varchar(25) not null default '';
+`
+
 // schema for profile table
 const profileSchema = `
 		create table if not exists profiles (
@@ -72,6 +91,13 @@ const profileSchema = `
 		)
 `
 
+// migration adding phone verification to the profile table. A verified phone
+// belongs to one user only, so it can be used to reset the password.
+const profilePhoneMigration = `
+		alter table profiles add column if not exists phoneverified Boolean not null default false;
+		create unique index if not exists profiles_verified_phone_idx on profiles (phone) where phoneverified;
+`
+
 // schema for securityuser table
 const securityUserSchema = `
 		create table if not exists passworusers (
@@ -112,6 +138,30 @@ const multiratioSchema = `
 		)
 `
 
+// schema for multiratioversions table, replacing the unversioned multiratios table.
+// The ratios of multiratios, if any, become version 1 in effect since the epoch.
+const multiratioVersionSchema = `
+		create table if not exists multiratioversions (
+			version 	  Int not null,
+			waterratio 	  Int not null check (waterratio > 0),
+			lightratio 	  Int not null check (lightratio > 0),
+			seedratio 	  Int not null check (seedratio > 0),
+			effectivefrom Timestamptz not null,
+			createdat 	  Timestamp not null,
+			Primary Key (version)
+		);
+		insert into multiratioversions(version, waterratio, lightratio, seedratio, effectivefrom, createdat)
+			select 1, waterratio, lightratio, seedratio, 'epoch', now() from multiratios
+			where waterratio > 0 and lightratio > 0 and seedratio > 0
+				and not exists (select 1 from multiratioversions)
+			limit 1;
+`
+
+// migration recording the multi ratio version the scores of a focus session were validated against
+const focusSessionRatioVersionMigration = `
+		alter table focussessions add column if not exists ratioversion Int not null default 0;
+`
+
 // schema for earnscore table
 const earnscoreSchema = `
 		create table if not exists earnscores (			
@@ -127,6 +177,433 @@ const earnscoreSchema = `
 		)
 `
 
+// schema for focussessions table, one row per completed focus session.
+// createdat is stored with its time zone so statistics can be bucketed in the user's timezone.
+const focusSessionSchema = `
+		create table if not exists focussessions (
+			id 		   Varchar(36) not null,
+			userid 	   Varchar(36) not null,
+			minutes    Int not null default 0,
+			waterscore Int not null default 0,
+			lightscore Int not null default 0,
+			seedscore  Int not null default 0,
+			createdat  Timestamptz not null,
+			Primary Key (id),
+			Constraint fk_user_id Foreign Key(userid) References users(id)
+				On Delete Cascade On Update Cascade
+		);
+		create index if not exists focussessions_userid_createdat_idx on focussessions (userid, createdat);
+`
+
+// schema for streaks table
+const streakSchema = `
+		create table if not exists streaks (
+			userid 	      Varchar(36) not null,
+			currentstreak Int not null default 0,
+			beststreak    Int not null default 0,
+			freezes       Int not null default 0,
+			lastday       Date,
+			createdat     Timestamp not null,
+			updatedat     Timestamp not null,
+			Primary Key (userid),
+			Constraint fk_user_id Foreign Key(userid) References users(id)
+				On Delete Cascade On Update Cascade
+		)
+`
+
+// schema for userachievements table. The achievement catalog is declared in code.
+const userAchievementSchema = `
+		create table if not exists userachievements (
+			userid 	      Varchar(36) not null,
+			achievementid Varchar(50) not null,
+			unlockedat    Timestamp not null,
+			Primary Key (userid, achievementid),
+			Constraint fk_user_id Foreign Key(userid) References users(id)
+				On Delete Cascade On Update Cascade
+		)
+`
+
+// schema for leaderboardentries table, the materialized leaderboards refreshed periodically
+const leaderboardSchema = `
+		create table if not exists leaderboardentries (
+			board      Varchar(20) not null,
+			userid 	   Varchar(36) not null,
+			score      Int not null,
+			rank       Int not null,
+			computedat Timestamp not null,
+			Primary Key (board, userid),
+			Constraint fk_user_id Foreign Key(userid) References users(id)
+				On Delete Cascade On Update Cascade
+		);
+		create index if not exists leaderboardentries_board_rank_idx on leaderboardentries (board, rank);
+`
+
+// schema for privacysettings table
+const privacySettingsSchema = `
+		create table if not exists privacysettings (
+			userid 	             Varchar(36) not null,
+			hidefromleaderboards Boolean not null default false,
+			updatedat            Timestamp not null,
+			Primary Key (userid),
+			Constraint fk_user_id Foreign Key(userid) References users(id)
+				On Delete Cascade On Update Cascade
+		)
+`
+
+// schema for friendships table. A request is stored from the sender to the recipient,
+// an accepted friendship in both directions.
+const friendshipSchema = `
+		create table if not exists friendships (
+			userid 	  Varchar(36) not null,
+			friendid  Varchar(36) not null,
+			status    Varchar(10) not null,
+			createdat Timestamp not null,
+			updatedat Timestamp not null,
+			Primary Key (userid, friendid),
+			Constraint fk_user_id Foreign Key(userid) References users(id)
+				On Delete Cascade On Update Cascade,
+			Constraint fk_friend_id Foreign Key(friendid) References users(id)
+				On Delete Cascade On Update Cascade
+		);
+		create index if not exists friendships_friendid_idx on friendships (friendid);
+`
+
+// schema for plants table, the plants of the garden of a user.
+// Species and stages are declared in code, speciesid references the catalog.
+const plantSchema = `
+		create table if not exists plants (
+			id 		   Varchar(36) not null,
+			userid 	   Varchar(36) not null,
+			speciesid  Varchar(50) not null,
+			stage 	   Int not null default 0,
+			water 	   Int not null default 0,
+			createdat  Timestamp not null,
+			updatedat  Timestamp not null,
+			Primary Key (id),
+			Constraint fk_user_id Foreign Key(userid) References users(id)
+				On Delete Cascade On Update Cascade
+		);
+		create index if not exists plants_userid_idx on plants (userid);
+`
+
+// schema for shop tables. Shop items are managed by internal services and soft deleted,
+// purchases are the ledger of the shop and inventories the items users own.
+const shopSchema = `
+		create table if not exists shopitems (
+			id 			   Varchar(50) not null,
+			name 		   Varchar(100) not null,
+			description    Text not null default '',
+			kind 		   Varchar(20) not null,
+			waterprice 	   Int not null default 0,
+			lightprice 	   Int not null default 0,
+			seedprice 	   Int not null default 0,
+			availablefrom  Timestamptz,
+			availableuntil Timestamptz,
+			peruserlimit   Int not null default 0,
+			deletedat 	   Timestamp,
+			createdat 	   Timestamp not null,
+			updatedat 	   Timestamp not null,
+			Primary Key (id)
+		);
+		create table if not exists purchases (
+			id 			   Varchar(36) not null,
+			userid 		   Varchar(36) not null,
+			itemid 		   Varchar(50) not null,
+			quantity 	   Int not null,
+			currency 	   Varchar(10) not null,
+			price 		   Int not null,
+			idempotencykey Varchar(64) not null,
+			createdat 	   Timestamp not null,
+			Primary Key (id),
+			Unique (userid, idempotencykey),
+			Constraint fk_user_id Foreign Key(userid) References users(id)
+				On Delete Cascade On Update Cascade,
+			Constraint fk_item_id Foreign Key(itemid) References shopitems(id)
+		);
+		create index if not exists purchases_userid_itemid_idx on purchases (userid, itemid);
+		create table if not exists inventories (
+			userid 	  Varchar(36) not null,
+			itemid 	  Varchar(50) not null,
+			quantity  Int not null default 0,
+			createdat Timestamp not null,
+			updatedat Timestamp not null,
+			Primary Key (userid, itemid),
+			Constraint fk_user_id Foreign Key(userid) References users(id)
+				On Delete Cascade On Update Cascade,
+			Constraint fk_item_id Foreign Key(itemid) References shopitems(id)
+		);
+`
+
+// schema for goal tables. goals keeps every goal a user has set, so past periods are measured
+// against the goal in effect at the time. goalbonuses records the bonus credited per period.
+const goalSchema = `
+		create table if not exists goals (
+			id 			  Varchar(36) not null,
+			userid 		  Varchar(36) not null,
+			period 		  Varchar(10) not null,
+			metric 		  Varchar(10) not null,
+			target 		  Int not null default 0,
+			effectivefrom Timestamptz not null,
+			Primary Key (id),
+			Constraint fk_user_id Foreign Key(userid) References users(id)
+				On Delete Cascade On Update Cascade
+		);
+		create index if not exists goals_userid_effectivefrom_idx on goals (userid, effectivefrom);
+		create table if not exists goalbonuses (
+			userid 		Varchar(36) not null,
+			period 		Varchar(10) not null,
+			periodstart Date not null,
+			waterscore 	Int not null default 0,
+			createdat 	Timestamp not null,
+			Primary Key (userid, period, periodstart),
+			Constraint fk_user_id Foreign Key(userid) References users(id)
+				On Delete Cascade On Update Cascade
+		);
+`
+
+// schema for tag tables, the user defined tags and the tags of each focus session.
+// Tag names are unique per user regardless of case.
+const tagSchema = `
+		create table if not exists tags (
+			id 		  Varchar(36) not null,
+			userid 	  Varchar(36) not null,
+			name 	  Varchar(30) not null,
+			color 	  Varchar(7) not null,
+			archived  Boolean not null default false,
+			createdat Timestamp not null,
+			updatedat Timestamp not null,
+			Primary Key (id),
+			Constraint fk_user_id Foreign Key(userid) References users(id)
+				On Delete Cascade On Update Cascade
+		);
+		create unique index if not exists tags_userid_name_idx on tags (userid, lower(name));
+		create table if not exists focussessiontags (
+			sessionid Varchar(36) not null,
+			tagid 	  Varchar(36) not null,
+			Primary Key (sessionid, tagid),
+			Constraint fk_session_id Foreign Key(sessionid) References focussessions(id)
+				On Delete Cascade On Update Cascade,
+			Constraint fk_tag_id Foreign Key(tagid) References tags(id)
+				On Delete Cascade On Update Cascade
+		);
+		create index if not exists focussessiontags_tagid_idx on focussessiontags (tagid);
+`
+
+// schema for tasks table, the task list of a user. Open tasks are ordered by position.
+const taskSchema = `
+		create table if not exists tasks (
+			id 				   Varchar(36) not null,
+			userid 			   Varchar(36) not null,
+			title 			   Varchar(200) not null,
+			notes 			   Text not null default '',
+			estimatedpomodoros Int not null default 0,
+			duedate 		   Date,
+			completed 		   Boolean not null default false,
+			completedat 	   Timestamp,
+			position 		   Int not null default 0,
+			createdat 		   Timestamp not null,
+			updatedat 		   Timestamp not null,
+			Primary Key (id),
+			Constraint fk_user_id Foreign Key(userid) References users(id)
+				On Delete Cascade On Update Cascade
+		);
+		create index if not exists tasks_userid_position_idx on tasks (userid, position);
+`
+
+// migration linking focus sessions to the task they were spent on.
+// Deleting a task keeps its sessions, and their scores, without a task.
+const focusSessionTaskMigration = `
+		alter table focussessions add column if not exists taskid Varchar(36)
+			References tasks(id) On Delete Set Null On Update Cascade;
+		create index if not exists focussessions_taskid_idx on focussessions (taskid);
+`
+
+// migration numbering the grants of an oauth consent. Revoking a consent starts a new
+// generation, so access tokens of an earlier grant stay invalid after the client is approved again.
+const oauthConsentGenerationMigration = `
+		alter table oauthconsents add column if not exists generation integer not null default 1;
+`
+
+// migration adding the timezone statistics are reported in to the profile table
+const profileTimezoneMigration = `
+		alter table profiles add column if not exists timezone Varchar(64) not null default '';
+`
+
+// migration adding unique usernames to the user table
+const userUsernameMigration = `
+		alter table users add column if not exists usernamenormalized Varchar(225) not null default '';
+		alter table users add column if not exists usernamechangedat Timestamp;
+		create unique index if not exists users_usernamenormalized_idx on users (usernamenormalized)
+			where usernamenormalized <> '';
+`
+
+// migration making user emails case-insensitive. Only emails whose lowercase form belongs
+// to a single account are lowercased, in users and profiles alike. When several accounts
+// share an email up to case, creating the index fails and the startup stops until the
+// duplicate accounts are merged by hand.
+const userEmailLowerMigration = `
+		update users set email = lower(email)
+			where email <> lower(email)
+			and lower(email) in (select lower(email) from users group by lower(email) having count(*) = 1);
+		update profiles set email = lower(email)
+			where email <> lower(email)
+			and lower(email) in (select lower(email) from users group by lower(email) having count(*) = 1);
+		create unique index if not exists users_email_lower_idx on users (lower(email));
+`
+
+// schema for emailchange table
+const emailChangeSchema = `
+		create table if not exists emailchanges (
+			id 		   Varchar(36) not null,
+			userid 	Varchar(36) not null,
+			oldemail   Varchar(100) not null,
+			newemail   Varchar(100) not null,
+			code       Varchar(10) not null,
+			expiresat  Timestamp not null,
+			undotokenhash Varchar(64) default '',
+			undoexpiresat Timestamp,
+			status     Varchar(20) not null,
+			createdat  Timestamp not null,
+			updatedat  Timestamp not null,
+			Primary Key (id),
+			Constraint fk_user_id Foreign Key(userid) References users(id)
+				On Delete Cascade On Update Cascade
+		);
+		create index if not exists emailchanges_userid_status_idx on emailchanges (userid, status);
+		create index if not exists emailchanges_undotokenhash_idx on emailchanges (undotokenhash);
+`
+
+// schema for identity table, one row per linked OpenID Connect account
+const identitySchema = `
+		create table if not exists identities (
+			id 		   Varchar(36) not null,
+			userid 	Varchar(36) not null,
+			provider   Varchar(50) not null,
+			subject    Varchar(255) not null,
+			email      Varchar(100) default '',
+			createdat  Timestamp not null,
+			updatedat  Timestamp not null,
+			Primary Key (id),
+			unique (provider, subject),
+			Constraint fk_user_id Foreign Key(userid) References users(id)
+				On Delete Cascade On Update Cascade
+		);
+		create index if not exists identities_userid_idx on identities (userid);
+`
+
+// schema for personalaccesstoken table
+const personalAccessTokenSchema = `
+		create table if not exists personalaccesstokens (
+			id 		   Varchar(36) not null,
+			userid 	Varchar(36) not null,
+			name       Varchar(100) not null,
+			tokenhash  Varchar(64) not null unique,
+			prefix     Varchar(20) not null,
+			scopes     Varchar(255) not null default '',
+			expiresat  Timestamp,
+			lastusedat Timestamp,
+			revokedat  Timestamp,
+			createdat  Timestamp not null,
+			Primary Key (id),
+			Constraint fk_user_id Foreign Key(userid) References users(id)
+				On Delete Cascade On Update Cascade
+		);
+		create index if not exists personalaccesstokens_userid_idx on personalaccesstokens (userid);
+`
+
+// schema for apikey table, keys of internal services
+const apiKeySchema = `
+		create table if not exists apikeys (
+			id 		   Varchar(36) not null,
+			name       Varchar(100) not null,
+			keyhash    Varchar(64) not null unique,
+			prefix     Varchar(20) not null,
+			scopes     Varchar(255) not null default '',
+			ratelimit  Int not null,
+			lastusedat Timestamp,
+			revokedat  Timestamp,
+			createdat  Timestamp not null,
+			Primary Key (id)
+		);
+`
+
+// schema for the oauth tables: registered clients, user consents, authorization codes,
+// device authorization requests and refresh tokens
+const oauthSchema = `
+		create table if not exists oauthclients (
+			id 		     Varchar(36) not null,
+			name         Varchar(100) not null,
+			secrethash   Varchar(64) not null default '',
+			redirecturis Text not null default '',
+			scopes       Varchar(255) not null default '',
+			revokedat    Timestamp,
+			createdat    Timestamp not null,
+			Primary Key (id)
+		);
+		create table if not exists oauthconsents (
+			id 		   Varchar(36) not null,
+			userid 	Varchar(36) not null,
+			clientid   Varchar(36) not null,
+			scopes     Varchar(255) not null default '',
+			revokedat  Timestamp,
+			createdat  Timestamp not null,
+			updatedat  Timestamp not null,
+			Primary Key (id),
+			unique (userid, clientid),
+			Constraint fk_user_id Foreign Key(userid) References users(id)
+				On Delete Cascade On Update Cascade,
+			Constraint fk_client_id Foreign Key(clientid) References oauthclients(id)
+				On Delete Cascade
+		);
+		create table if not exists oauthcodes (
+			codehash      Varchar(64) not null,
+			clientid      Varchar(36) not null,
+			userid 	   Varchar(36) not null,
+			redirecturi   Text not null,
+			scopes        Varchar(255) not null default '',
+			codechallenge Varchar(128) not null,
+			expiresat     Timestamp not null,
+			usedat        Timestamp,
+			createdat     Timestamp not null,
+			Primary Key (codehash),
+			Constraint fk_user_id Foreign Key(userid) References users(id)
+				On Delete Cascade On Update Cascade,
+			Constraint fk_client_id Foreign Key(clientid) References oauthclients(id)
+				On Delete Cascade
+		);
+		create table if not exists oauthdevicecodes (
+			devicecodehash Varchar(64) not null,
+			usercode       Varchar(20) not null unique,
+			clientid       Varchar(36) not null,
+			scopes         Varchar(255) not null default '',
+			userid         Varchar(36) not null default '',
+			status         Varchar(20) not null,
+			pollinterval   Int not null,
+			lastpolledat   Timestamp,
+			expiresat      Timestamp not null,
+			createdat      Timestamp not null,
+			Primary Key (devicecodehash),
+			Constraint fk_client_id Foreign Key(clientid) References oauthclients(id)
+				On Delete Cascade
+		);
+		create table if not exists oauthrefreshtokens (
+			id 		   Varchar(36) not null,
+			tokenhash  Varchar(64) not null unique,
+			clientid   Varchar(36) not null,
+			userid 	Varchar(36) not null,
+			scopes     Varchar(255) not null default '',
+			expiresat  Timestamp not null,
+			revokedat  Timestamp,
+			createdat  Timestamp not null,
+			Primary Key (id),
+			Constraint fk_user_id Foreign Key(userid) References users(id)
+				On Delete Cascade On Update Cascade,
+			Constraint fk_client_id Foreign Key(clientid) References oauthclients(id)
+				On Delete Cascade
+		);
+		create index if not exists oauthrefreshtokens_userid_clientid_idx on oauthrefreshtokens (userid, clientid);
+`
+
 func main() {
 	logger := utils.NewLogger()
 
@@ -145,19 +622,75 @@ func main() {
 	}
 	// creation of user table.
 	db.MustExec(userSchema)
+	db.MustExec(userUsernameMigration)
+	db.MustExec(userRoleMigration)
 	db.MustExec(verificationSchema)
+	db.MustExec(verificationTypeMigration)
+	db.MustExec(verificationTargetMigration)
 	db.MustExec(profileSchema)
+	db.MustExec(profilePhoneMigration)
+	db.MustExec(profileTimezoneMigration)
+	db.MustExec(userEmailLowerMigration)
 	db.MustExec(securityUserSchema)
 	db.MustExec(limitSchema)
 	db.MustExec(multiratioSchema)
+	db.MustExec(multiratioVersionSchema)
 	db.MustExec(earnscoreSchema)
+	db.MustExec(focusSessionSchema)
+	db.MustExec(focusSessionRatioVersionMigration)
+	db.MustExec(streakSchema)
+	db.MustExec(userAchievementSchema)
+	db.MustExec(goalSchema)
+	db.MustExec(tagSchema)
+	db.MustExec(taskSchema)
+	db.MustExec(focusSessionTaskMigration)
+	db.MustExec(privacySettingsSchema)
+	db.MustExec(friendshipSchema)
+	db.MustExec(leaderboardSchema)
+	db.MustExec(plantSchema)
+	db.MustExec(shopSchema)
+	db.MustExec(emailChangeSchema)
+	db.MustExec(identitySchema)
+	db.MustExec(personalAccessTokenSchema)
+	db.MustExec(apiKeySchema)
+	db.MustExec(oauthSchema)
+	db.MustExec(oauthConsentGenerationMigration)
 
 	// repository contains all the methods that interact with DB to perform CURD operations for user.
 	repository := database.NewPostgresRepository(db, logger)
 	// mailService contains the utility methods to send an email
 	mailService := authorization.NewSGMailService(logger, configs)
+	// smsService sends phone verification and password reset codes
+	smsService := authorization.NewSMSService(logger, configs)
+	// hasher hashes and verifies passwords for every place that deals with them
+	hasher, err := utils.NewPasswordHasher(configs)
+	if err != nil {
+		logger.Error("unable to create password hasher", "error", err)
+		return
+	}
+	// passwordPolicy validates new passwords on signup, password change and reset
+	passwordPolicy := utils.NewPasswordPolicy(configs)
+	// usernamePolicy validates usernames against the reserved and profanity lists
+	usernamePolicy, err := utils.NewUsernamePolicy(configs)
+	if err != nil {
+		logger.Error("unable to load username lists", "error", err)
+		return
+	}
+	// disposableDomains rejects signups from throwaway email providers
+	disposableDomains, err := utils.NewDisposableDomains(configs.DisposableEmailDomainsPath)
+	if err != nil {
+		logger.Error("unable to load disposable email domains", "error", err)
+		return
+	}
+	// oidcVerifier validates ID tokens of the configured social login providers
+	oidcProviders, err := oidc.LoadProviders(configs.OIDCProvidersPath)
+	if err != nil {
+		logger.Error("unable to load oidc providers", "error", err)
+		return
+	}
+	oidcVerifier := oidc.NewVerifier(oidcProviders, nil)
 	// authService contains all methods that help in authorizing a user request
-	auth := middleware.NewAuthService(logger, configs)
+	auth := middleware.NewAuthService(logger, configs, hasher)
 
 	// Reset limit data for users.
 	s := gocron.NewScheduler(time.UTC)
@@ -169,6 +702,22 @@ func main() {
 			logger.Error("Error clearing limit data", "error", err)
 		}
 	})
+	// Delete expired oauth codes and refresh tokens.
+	s.Every(1).Hour().Do(func() {
+		ctx := context.Background()
+		err := repository.DeleteExpiredOAuthGrants(ctx)
+		if err != nil {
+			logger.Error("Error deleting expired oauth grants", "error", err)
+		}
+	})
+	// Materialize the leaderboards, so ranking does not scan every score per request.
+	s.Every(configs.LeaderboardRefreshInterval).Minutes().Do(func() {
+		ctx := context.Background()
+		err := repository.RefreshLeaderboards(ctx)
+		if err != nil {
+			logger.Error("Error refreshing leaderboards", "error", err)
+		}
+	})
 	s.StartAsync()
 
 	// Create rate limiter for users.
@@ -176,8 +725,8 @@ func main() {
 
 	var (
 		httpAddr    = net.JoinHostPort("localhost", configs.HttpPort)
-		service     = authorization.NewUserService(logger, configs, repository, mailService, auth)
-		eps         = endpoints.NewEndpointSet(service, auth, repository, logger, validator, rlBucket)
+		service     = authorization.NewUserService(logger, configs, repository, mailService, smsService, auth, hasher, passwordPolicy, usernamePolicy, disposableDomains, oidcVerifier)
+		eps         = endpoints.NewEndpointSet(service, auth, repository, logger, validator, rlBucket, configs)
 		httpHandler = transport.NewHTTPHandler(eps)
 	)
 
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"LoveLetterProject/pkg/oidc/oidctest"
+	"flag"
+	"log"
+	"net/http"
+)
+
+// testidp runs a stand-in OpenID Connect provider for local development.
+// Point a provider in OIDC_PROVIDERS_PATH at its issuer and get ID tokens from
+// http://localhost:9999/mint?sub=123&email=me@example.com&aud=focus-now
+func main() {
+	addr := flag.String("addr", "localhost:9999", "listen address")
+	flag.Parse()
+
+	idp, err := oidctest.NewIdP("http://" + *addr)
+	if err != nil {
+		log.Fatal("unable to create test idp: ", err)
+	}
+	log.Println("test idp issuer", idp.Issuer)
+	log.Fatal(http.ListenAndServe(*addr, idp))
+}
@@ -9,29 +9,84 @@ import (
 // Configurations wraps all the config variables required by the auth service
 type Configurations struct {
 	//ServerAddress              string `mapstructure:"SERVER_ADDRESS"`
-	DBHost                     string `mapstructure:"DB_HOST"`
-	DBName                     string `mapstructure:"DB_NAME"`
-	DBUser                     string `mapstructure:"DB_USER"`
-	DBPass                     string `mapstructure:"DB_PASSWORD"`
-	DBPort                     string `mapstructure:"DB_PORT"`
-	DBConn                     string
-	JwtExpiration              int    `mapstructure:"JWT_EXPIRATION"` // in minutes
-	AccessTokenPrivateKeyPath  string `mapstructure:"ACCESS_TOKEN_PRIVATE_KEY_PATH"`
-	AccessTokenPublicKeyPath   string `mapstructure:"ACCESS_TOKEN_PUBLIC_KEY_PATH"`
-	RefreshTokenPrivateKeyPath string `mapstructure:"REFRESH_TOKEN_PRIVATE_KEY_PATH"`
-	RefreshTokenPublicKeyPath  string `mapstructure:"REFRESH_TOKEN_PUBLIC_KEY_PATH"`
-	SendGridApiKey             string `mapstructure:"SENDGRID_API_KEY"`
-	MailVerifCodeExpiration    int    `mapstructure:"MAIL_VERIFICATION_CODE_EXPIRATION"` // in hours
-	PassResetCodeExpiration    int    `mapstructure:"PASSWORD_RESET_CODE_EXPIRATION"`    // in minutes
-	MailVerifTemplateID        string `mapstructure:"MAIL_VERIFICATION_TEMPLATE_ID"`
-	PassResetTemplateID        string `mapstructure:"PASSWORD_RESET_TEMPLATE_ID"`
-	MailSender                 string `mapstructure:"MAIL_SENDER"`
-	Issuer                     string `mapstructure:"ISSUER"`
-	HttpPort                   string `mapstructure:"HTTP_PORT"`
-	MailTitle                  string `mapstructure:"MAIL_TITLE"`
-	ChangePasswordLimit        int    `mapstructure:"CHANGE_PASSWORD_LIMIT"`
-	SendMailLimit              int    `mapstructure:"SEND_MAIL_LIMIT"`
-	LoginLimit                 int    `mapstructure:"LOGIN_LIMIT"`
+	DBHost                      string `mapstructure:"DB_HOST"`
+	DBName                      string `mapstructure:"DB_NAME"`
+	DBUser                      string `mapstructure:"DB_USER"`
+	DBPass                      string `mapstructure:"DB_PASSWORD"`
+	DBPort                      string `mapstructure:"DB_PORT"`
+	DBConn                      string
+	JwtExpiration               int    `mapstructure:"JWT_EXPIRATION"` // in minutes
+	AccessTokenPrivateKeyPath   string `mapstructure:"ACCESS_TOKEN_PRIVATE_KEY_PATH"`
+	AccessTokenPublicKeyPath    string `mapstructure:"ACCESS_TOKEN_PUBLIC_KEY_PATH"`
+	RefreshTokenPrivateKeyPath  string `mapstructure:"REFRESH_TOKEN_PRIVATE_KEY_PATH"`
+	RefreshTokenPublicKeyPath   string `mapstructure:"REFRESH_TOKEN_PUBLIC_KEY_PATH"`
+	SendGridApiKey              string `mapstructure:"SENDGRID_API_KEY"`
+	MailVerifCodeExpiration     int    `mapstructure:"MAIL_VERIFICATION_CODE_EXPIRATION"` // in hours
+	PassResetCodeExpiration     int    `mapstructure:"PASSWORD_RESET_CODE_EXPIRATION"`    // in minutes
+	MailVerifTemplateID         string `mapstructure:"MAIL_VERIFICATION_TEMPLATE_ID"`
+	PassResetTemplateID         string `mapstructure:"PASSWORD_RESET_TEMPLATE_ID"`
+	MailSender                  string `mapstructure:"MAIL_SENDER"`
+	Issuer                      string `mapstructure:"ISSUER"`
+	HttpPort                    string `mapstructure:"HTTP_PORT"`
+	MailTitle                   string `mapstructure:"MAIL_TITLE"`
+	ChangePasswordLimit         int    `mapstructure:"CHANGE_PASSWORD_LIMIT"`
+	SendMailLimit               int    `mapstructure:"SEND_MAIL_LIMIT"`
+	LoginLimit                  int    `mapstructure:"LOGIN_LIMIT"`
+	PasswordHashAlgorithm       string `mapstructure:"PASSWORD_HASH_ALGORITHM"` // argon2id or bcrypt
+	Argon2Memory                int    `mapstructure:"ARGON2_MEMORY"`           // in KiB
+	Argon2Iterations            int    `mapstructure:"ARGON2_ITERATIONS"`
+	Argon2Parallelism           int    `mapstructure:"ARGON2_PARALLELISM"`
+	BcryptCost                  int    `mapstructure:"BCRYPT_COST"`
+	PasswordMinLength           int    `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordMaxLength           int    `mapstructure:"PASSWORD_MAX_LENGTH"` // in bytes
+	PasswordRequireUpper        bool   `mapstructure:"PASSWORD_REQUIRE_UPPER"`
+	PasswordRequireLower        bool   `mapstructure:"PASSWORD_REQUIRE_LOWER"`
+	PasswordRequireDigit        bool   `mapstructure:"PASSWORD_REQUIRE_DIGIT"`
+	PasswordRequireSymbol       bool   `mapstructure:"PASSWORD_REQUIRE_SYMBOL"`
+	PasswordDisallowEmail       bool   `mapstructure:"PASSWORD_DISALLOW_EMAIL"`
+	BreachedPasswordsDir        string `mapstructure:"BREACHED_PASSWORDS_DIR"`
+	EnumerationSafeMode         bool   `mapstructure:"ENUMERATION_SAFE_MODE"`
+	AccountExistsTemplateID     string `mapstructure:"ACCOUNT_EXISTS_TEMPLATE_ID"`
+	ReauthMaxAge                int    `mapstructure:"REAUTH_MAX_AGE"`               // in minutes
+	EmailChangeCodeExpiration   int    `mapstructure:"EMAIL_CHANGE_CODE_EXPIRATION"` // in minutes
+	EmailChangeUndoExpiration   int    `mapstructure:"EMAIL_CHANGE_UNDO_EXPIRATION"` // in days
+	EmailChangeUndoURL          string `mapstructure:"EMAIL_CHANGE_UNDO_URL"`
+	EmailChangeCodeTemplateID   string `mapstructure:"EMAIL_CHANGE_CODE_TEMPLATE_ID"`
+	EmailChangeNoticeTemplateID string `mapstructure:"EMAIL_CHANGE_NOTICE_TEMPLATE_ID"`
+	EmailChangeUndoTemplateID   string `mapstructure:"EMAIL_CHANGE_UNDO_TEMPLATE_ID"`
+	ReservedUsernamesPath       string `mapstructure:"RESERVED_USERNAMES_PATH"`
+	ProfanityListPath           string `mapstructure:"PROFANITY_LIST_PATH"`
+	UsernameChangeCooldown      int    `mapstructure:"USERNAME_CHANGE_COOLDOWN"` // in days
+	DisposableEmailDomainsPath  string `mapstructure:"DISPOSABLE_EMAIL_DOMAINS_PATH"`
+	OIDCProvidersPath           string `mapstructure:"OIDC_PROVIDERS_PATH"`
+	LoginCodeExpiration         int    `mapstructure:"LOGIN_CODE_EXPIRATION"` // in minutes
+	LoginCodeTemplateID         string `mapstructure:"LOGIN_CODE_TEMPLATE_ID"`
+	MagicLinkURL                string `mapstructure:"MAGIC_LINK_URL"`
+	MagicLinkSecret             string `mapstructure:"MAGIC_LINK_SECRET"`
+	PersonalAccessTokenLimit    int    `mapstructure:"PERSONAL_ACCESS_TOKEN_LIMIT"`
+	APIKeyRateLimit             int    `mapstructure:"API_KEY_RATE_LIMIT"`           // default requests per minute of a new key
+	OAuthCodeExpiration         int    `mapstructure:"OAUTH_CODE_EXPIRATION"`        // in minutes
+	OAuthDeviceCodeExpiration   int    `mapstructure:"OAUTH_DEVICE_CODE_EXPIRATION"` // in minutes
+	OAuthDevicePollInterval     int    `mapstructure:"OAUTH_DEVICE_POLL_INTERVAL"`   // in seconds
+	OAuthDeviceVerificationURL  string `mapstructure:"OAUTH_DEVICE_VERIFICATION_URL"`
+	OAuthRefreshTokenExpiration int    `mapstructure:"OAUTH_REFRESH_TOKEN_EXPIRATION"` // in days
+	SMSProvider                 string `mapstructure:"SMS_PROVIDER"`                   // twilio, or fake to record messages locally
+	TwilioAccountSID            string `mapstructure:"TWILIO_ACCOUNT_SID"`
+	TwilioAuthToken             string `mapstructure:"TWILIO_AUTH_TOKEN"`
+	TwilioFromNumber            string `mapstructure:"TWILIO_FROM_NUMBER"`
+	PhoneCodeExpiration         int    `mapstructure:"PHONE_CODE_EXPIRATION"`      // in minutes
+	PhoneDefaultCountryCode     string `mapstructure:"PHONE_DEFAULT_COUNTRY_CODE"` // for national numbers, e.g. 84
+	StreakDailyMinutes          int    `mapstructure:"STREAK_DAILY_MINUTES"`       // focus minutes a day needs to count towards the streak
+	StreakFreezeEvery           int    `mapstructure:"STREAK_FREEZE_EVERY"`        // streak days to earn a freeze day
+	StreakMaxFreezes            int    `mapstructure:"STREAK_MAX_FREEZES"`
+	LeaderboardRefreshInterval  int    `mapstructure:"LEADERBOARD_REFRESH_INTERVAL"` // in minutes
+	LeaderboardSize             int    `mapstructure:"LEADERBOARD_SIZE"`             // entries returned at most
+	FriendLimit                 int    `mapstructure:"FRIEND_LIMIT"`                 // friends and sent requests of a user
+	FocusBackdateLimit          int    `mapstructure:"FOCUS_BACKDATE_LIMIT"`         // in hours, how long ago reported focus may have happened
+	GoalDailyBonusWater         int    `mapstructure:"GOAL_DAILY_BONUS_WATER"`       // credited the first time the daily goal is met each day
+	GoalWeeklyBonusWater        int    `mapstructure:"GOAL_WEEKLY_BONUS_WATER"`      // credited the first time the weekly goal is met each week
+	GoalMinMinutes              int    `mapstructure:"GOAL_MIN_MINUTES"`             // lowest target of a minutes goal, so bonuses cannot be met with a few minutes
+	TagLimit                    int    `mapstructure:"TAG_LIMIT"`                    // tags of a user that are not archived
 }
 
 // NewConfigurations returns a new Configuration object
@@ -62,6 +117,7 @@ func LoadConfig(deployType int) (config *Configurations, err error) {
 		viper.SetConfigName("app-product")
 	}
 	viper.SetConfigType("env")
+	setDefaults()
 	viper.AutomaticEnv()
 	err = viper.ReadInConfig()
 	if err != nil {
@@ -72,6 +128,46 @@ func LoadConfig(deployType int) (config *Configurations, err error) {
 	return
 }
 
+// setDefaults sets the values used when a key is missing from the config file.
+func setDefaults() {
+	viper.SetDefault("PASSWORD_HASH_ALGORITHM", HashAlgorithmArgon2id)
+	viper.SetDefault("ARGON2_MEMORY", 64*1024)
+	viper.SetDefault("ARGON2_ITERATIONS", 3)
+	viper.SetDefault("ARGON2_PARALLELISM", 2)
+	viper.SetDefault("BCRYPT_COST", 10)
+	viper.SetDefault("PASSWORD_MIN_LENGTH", 8)
+	viper.SetDefault("PASSWORD_MAX_LENGTH", 128)
+	viper.SetDefault("PASSWORD_REQUIRE_UPPER", true)
+	viper.SetDefault("PASSWORD_REQUIRE_LOWER", true)
+	viper.SetDefault("PASSWORD_REQUIRE_DIGIT", true)
+	viper.SetDefault("PASSWORD_REQUIRE_SYMBOL", false)
+	viper.SetDefault("PASSWORD_DISALLOW_EMAIL", true)
+	viper.SetDefault("REAUTH_MAX_AGE", 5)
+	viper.SetDefault("EMAIL_CHANGE_CODE_EXPIRATION", 30)
+	viper.SetDefault("EMAIL_CHANGE_UNDO_EXPIRATION", 7)
+	viper.SetDefault("USERNAME_CHANGE_COOLDOWN", 30)
+	viper.SetDefault("LOGIN_CODE_EXPIRATION", 15)
+	viper.SetDefault("PERSONAL_ACCESS_TOKEN_LIMIT", 10)
+	viper.SetDefault("API_KEY_RATE_LIMIT", 60)
+	viper.SetDefault("OAUTH_CODE_EXPIRATION", 10)
+	viper.SetDefault("OAUTH_DEVICE_CODE_EXPIRATION", 15)
+	viper.SetDefault("OAUTH_DEVICE_POLL_INTERVAL", 5)
+	viper.SetDefault("OAUTH_REFRESH_TOKEN_EXPIRATION", 30)
+	viper.SetDefault("SMS_PROVIDER", "fake")
+	viper.SetDefault("PHONE_CODE_EXPIRATION", 10)
+	viper.SetDefault("STREAK_DAILY_MINUTES", 25)
+	viper.SetDefault("STREAK_FREEZE_EVERY", 7)
+	viper.SetDefault("STREAK_MAX_FREEZES", 2)
+	viper.SetDefault("LEADERBOARD_REFRESH_INTERVAL", 5)
+	viper.SetDefault("LEADERBOARD_SIZE", 100)
+	viper.SetDefault("FRIEND_LIMIT", 200)
+	viper.SetDefault("FOCUS_BACKDATE_LIMIT", 24)
+	viper.SetDefault("GOAL_DAILY_BONUS_WATER", 2)
+	viper.SetDefault("GOAL_WEEKLY_BONUS_WATER", 5)
+	viper.SetDefault("GOAL_MIN_MINUTES", 15)
+	viper.SetDefault("TAG_LIMIT", 30)
+}
+
 const (
 	DeployLocal = 1
 	DeployStage = 2
@@ -0,0 +1,17 @@
+package database
+
+import "time"
+
+// UserAchievement is an achievement unlocked by a user. Achievements themselves are
+// declared in code, the row only records the unlock.
+type UserAchievement struct {
+	UserID        string    `json:"user_id" sql:"userid"`
+	AchievementID string    `json:"achievement_id" sql:"achievementid"`
+	UnlockedAt    time.Time `json:"unlocked_at" sql:"unlockedat"`
+}
+
+// FocusTotals is the lifetime count and length of the focus sessions of a user
+type FocusTotals struct {
+	Sessions int `json:"sessions" sql:"sessions"`
+	Minutes  int `json:"minutes" sql:"minutes"`
+}
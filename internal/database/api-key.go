@@ -0,0 +1,46 @@
+package database
+
+import "time"
+
+// APIKeyPrefix starts every API key so it can be told apart from user tokens
+const APIKeyPrefix = "fnk_"
+
+// Scopes an API key can be granted, besides ScopeScoresRead
+const (
+	ScopeUsersRead    = "users:read"
+	ScopeClientsWrite = "clients:write" // register OAuth clients
+	ScopeShopWrite    = "shop:write"    // manage the shop catalog
+	ScopeRatiosWrite  = "ratios:write"  // publish multi ratio versions
+)
+
+// APIKeyScopes lists every valid API key scope
+var APIKeyScopes = []string{ScopeUsersRead, ScopeScoresRead, ScopeClientsWrite, ScopeShopWrite, ScopeRatiosWrite}
+
+// APIKey is the data structure for apikeys table. API keys authenticate internal services
+// rather than users. Only the sha256 hash of the key is stored.
+type APIKey struct {
+	ID         string     `json:"id" sql:"id"`
+	Name       string     `json:"name" sql:"name"`
+	KeyHash    string     `json:"-" sql:"keyhash"`
+	Prefix     string     `json:"prefix" sql:"prefix"`
+	Scopes     string     `json:"scopes" sql:"scopes"`       // comma separated
+	RateLimit  int        `json:"ratelimit" sql:"ratelimit"` // requests per minute
+	LastUsedAt *time.Time `json:"lastusedat" sql:"lastusedat"`
+	RevokedAt  *time.Time `json:"revokedat" sql:"revokedat"`
+	CreatedAt  time.Time  `json:"createdat" sql:"createdat"`
+}
+
+// ScopeList returns the scopes of the key
+func (k *APIKey) ScopeList() []string {
+	return SplitScopes(k.Scopes)
+}
+
+// HasScopes reports whether the key was granted every given scope
+func (k *APIKey) HasScopes(scopes ...string) bool {
+	return ContainsScopes(k.ScopeList(), scopes)
+}
+
+// IsActive reports whether the key is not revoked
+func (k *APIKey) IsActive() bool {
+	return k.RevokedAt == nil
+}
@@ -0,0 +1,26 @@
+package database
+
+import "time"
+
+// Status of an email change request
+const (
+	EmailChangePending   = "pending"
+	EmailChangeConfirmed = "confirmed"
+	EmailChangeUndone    = "undone"
+	EmailChangeCancelled = "cancelled"
+)
+
+// EmailChange is the data structure for emailchanges table
+type EmailChange struct {
+	ID            string     `json:"id" sql:"id"`
+	UserID        string     `json:"user_id" sql:"userid"`
+	OldEmail      string     `json:"old_email" sql:"oldemail"`
+	NewEmail      string     `json:"new_email" sql:"newemail"`
+	Code          string     `json:"-" sql:"code"`
+	ExpiresAt     time.Time  `json:"expiresat" sql:"expiresat"`
+	UndoTokenHash string     `json:"-" sql:"undotokenhash"`
+	UndoExpiresAt *time.Time `json:"undoexpiresat" sql:"undoexpiresat"`
+	Status        string     `json:"status" sql:"status"`
+	CreatedAt     time.Time  `json:"createdat" sql:"createdat"`
+	UpdatedAt     time.Time  `json:"updatedat" sql:"updatedat"`
+}
@@ -0,0 +1,37 @@
+package database
+
+import "time"
+
+// Periods the focus statistics are bucketed by, as understood by date_trunc
+const (
+	StatsPeriodDay   = "day"
+	StatsPeriodWeek  = "week"
+	StatsPeriodMonth = "month"
+)
+
+// FocusSession is a completed focus session and the scores earned with it
+type FocusSession struct {
+	ID         string `json:"id" sql:"id"`
+	UserID     string `json:"user_id" sql:"userid"`
+	Minutes    int    `json:"minutes" sql:"minutes"`
+	WaterScore int    `json:"water_score" sql:"waterscore"`
+	LightScore int    `json:"light_score" sql:"lightscore"`
+	SeedScore  int    `json:"seed_score" sql:"seedscore"`
+	// CreatedAt is when the focus happened, statistics, streaks and goals count the session then
+	CreatedAt time.Time `json:"createdat" sql:"createdat"`
+	// RatioVersion is the multi ratio version the scores were validated against
+	RatioVersion int `json:"ratio_version" sql:"ratioversion"`
+	// TaskID is the task the session was spent on, if any
+	TaskID *string `json:"task_id" sql:"taskid"`
+}
+
+// FocusStats is the aggregate of the focus sessions started within one bucket
+type FocusStats struct {
+	// Bucket is the start of the bucket as a wall clock time of the requested timezone
+	Bucket     time.Time `json:"bucket" sql:"bucket"`
+	Minutes    int       `json:"minutes" sql:"minutes"`
+	Sessions   int       `json:"sessions" sql:"sessions"`
+	WaterScore int       `json:"water_score" sql:"waterscore"`
+	LightScore int       `json:"light_score" sql:"lightscore"`
+	SeedScore  int       `json:"seed_score" sql:"seedscore"`
+}
@@ -0,0 +1,22 @@
+package database
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInsufficientScore is returned when the earn score of a user cannot cover a cost
+var ErrInsufficientScore = errors.New("insufficient score")
+
+// Plant is the data structure for plants table. Species and their growth stages are
+// declared in code, the row records the species and how far the plant has grown.
+type Plant struct {
+	ID        string `json:"id" sql:"id"`
+	UserID    string `json:"user_id" sql:"userid"`
+	SpeciesID string `json:"species_id" sql:"speciesid"`
+	Stage     int    `json:"stage" sql:"stage"`
+	// Water is the water given towards the next stage
+	Water     int       `json:"water" sql:"water"`
+	CreatedAt time.Time `json:"createdat" sql:"createdat"`
+	UpdatedAt time.Time `json:"updatedat" sql:"updatedat"`
+}
@@ -0,0 +1,33 @@
+package database
+
+import "time"
+
+// Metrics a focus goal can be set in
+const (
+	GoalMetricMinutes  = "minutes"
+	GoalMetricSessions = "sessions"
+)
+
+// Goal is the data structure for goals table. Setting a goal adds a row instead of replacing
+// the previous one, so past days are measured against the goal in effect at the time.
+// A target of 0 clears the goal.
+type Goal struct {
+	ID     string `json:"id" sql:"id"`
+	UserID string `json:"user_id" sql:"userid"`
+	// Period is StatsPeriodDay or StatsPeriodWeek
+	Period        string    `json:"period" sql:"period"`
+	Metric        string    `json:"metric" sql:"metric"`
+	Target        int       `json:"target" sql:"target"`
+	EffectiveFrom time.Time `json:"effective_from" sql:"effectivefrom"`
+}
+
+// GoalBonus is the data structure for goalbonuses table, the bonus credited the first time
+// a goal was met in a period.
+type GoalBonus struct {
+	UserID string `json:"user_id" sql:"userid"`
+	Period string `json:"period" sql:"period"`
+	// PeriodStart is the first day of the period, as a date at midnight UTC
+	PeriodStart time.Time `json:"period_start" sql:"periodstart"`
+	WaterScore  int       `json:"water_score" sql:"waterscore"`
+	CreatedAt   time.Time `json:"createdat" sql:"createdat"`
+}
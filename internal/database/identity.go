@@ -0,0 +1,14 @@
+package database
+
+import "time"
+
+// Identity links a user to an account at an external OpenID Connect provider
+type Identity struct {
+	ID        string    `json:"id" sql:"id"`
+	UserID    string    `json:"user_id" sql:"userid"`
+	Provider  string    `json:"provider" sql:"provider"`
+	Subject   string    `json:"subject" sql:"subject"`
+	Email     string    `json:"email" sql:"email"`
+	CreatedAt time.Time `json:"createdat" sql:"createdat"`
+	UpdatedAt time.Time `json:"updatedat" sql:"updatedat"`
+}
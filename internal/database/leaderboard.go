@@ -0,0 +1,72 @@
+package database
+
+import "time"
+
+// Leaderboard metrics
+const (
+	LeaderboardMetricWater   = "water"
+	LeaderboardMetricMinutes = "minutes"
+)
+
+// Leaderboard periods. Weeks start on Monday and periods are computed in UTC,
+// as one board is shared by users of every timezone.
+const (
+	LeaderboardPeriodAll   = "all"
+	LeaderboardPeriodWeek  = "week"
+	LeaderboardPeriodMonth = "month"
+)
+
+// Friendship statuses
+const (
+	FriendshipPending  = "pending"
+	FriendshipAccepted = "accepted"
+)
+
+// LeaderboardMetrics and LeaderboardPeriods are every materialized combination
+var (
+	LeaderboardMetrics = []string{LeaderboardMetricWater, LeaderboardMetricMinutes}
+	LeaderboardPeriods = []string{LeaderboardPeriodAll, LeaderboardPeriodWeek, LeaderboardPeriodMonth}
+)
+
+// LeaderboardName returns the name a board is materialized under, e.g. water:week
+func LeaderboardName(metric string, period string) string {
+	return metric + ":" + period
+}
+
+// LeaderboardEntry is a materialized rank of a user on a board, with the username for display
+type LeaderboardEntry struct {
+	Board      string    `json:"board" sql:"board"`
+	UserID     string    `json:"user_id" sql:"userid"`
+	Username   string    `json:"username" sql:"username"`
+	Score      int       `json:"score" sql:"score"`
+	Rank       int       `json:"rank" sql:"rank"`
+	ComputedAt time.Time `json:"computed_at" sql:"computedat"`
+}
+
+// PrivacySettings is the data structure for privacysettings table. Users without a row
+// have the default settings.
+type PrivacySettings struct {
+	UserID               string    `json:"user_id" sql:"userid"`
+	HideFromLeaderboards bool      `json:"hide_from_leaderboards" sql:"hidefromleaderboards"`
+	UpdatedAt            time.Time `json:"updatedat" sql:"updatedat"`
+}
+
+// Friendship is a friend request, or one direction of an accepted friendship.
+// Accepted friendships are stored in both directions.
+type Friendship struct {
+	UserID    string    `json:"user_id" sql:"userid"`
+	FriendID  string    `json:"friend_id" sql:"friendid"`
+	Status    string    `json:"status" sql:"status"`
+	CreatedAt time.Time `json:"createdat" sql:"createdat"`
+	UpdatedAt time.Time `json:"updatedat" sql:"updatedat"`
+}
+
+// Friend is a friend or a pending friend request as listed to a user
+type Friend struct {
+	UserID   string `json:"user_id" sql:"userid"`
+	Username string `json:"username" sql:"username"`
+	Status   string `json:"status" sql:"status"`
+	// Incoming is true for a request sent to the user
+	Incoming  bool      `json:"incoming" sql:"incoming"`
+	CreatedAt time.Time `json:"createdat" sql:"createdat"`
+}
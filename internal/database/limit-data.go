@@ -12,11 +12,15 @@ type LimitData struct {
 	UpdatedAt           time.Time `json:"updatedat" sql:"updatedat"`
 }
 
-// MultiRatioData is the data structure for multiratio table
+// MultiRatioData is the data structure for multiratioversions table. A version applies to the
+// focus that happened from its effective time until the next version takes effect.
 type MultiRatioData struct {
-	WaterRatio int `json:"water_ratio" sql:"waterratio"`
-	LightRatio int `json:"light_ratio" sql:"lightratio"`
-	SeedRatio  int `json:"seed_ratio" sql:"seedratio"`
+	Version       int       `json:"version" sql:"version"`
+	WaterRatio    int       `json:"water_ratio" sql:"waterratio"`
+	LightRatio    int       `json:"light_ratio" sql:"lightratio"`
+	SeedRatio     int       `json:"seed_ratio" sql:"seedratio"`
+	EffectiveFrom time.Time `json:"effective_from" sql:"effectivefrom"`
+	CreatedAt     time.Time `json:"createdat" sql:"createdat"`
 }
 
 // EarnScore is the data structure for earnscore table
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"strings"
+	"time"
+)
+
+// OAuthRefreshTokenPrefix starts every OAuth refresh token
+const OAuthRefreshTokenPrefix = "fnr_"
+
+// Statuses of a device authorization request
+const (
+	DeviceCodePending  = "pending"
+	DeviceCodeApproved = "approved"
+	DeviceCodeDenied   = "denied"
+)
+
+// OAuthScopes lists the scopes third-party clients can request, the same as personal access tokens
+var OAuthScopes = PersonalAccessTokenScopes
+
+// OAuthClient is the data structure for oauthclients table. Public clients, such as a
+// browser extension, have no secret and rely on PKCE alone.
+type OAuthClient struct {
+	ID           string     `json:"id" sql:"id"`
+	Name         string     `json:"name" sql:"name"`
+	SecretHash   string     `json:"-" sql:"secrethash"`
+	RedirectURIs string     `json:"redirecturis" sql:"redirecturis"` // space separated
+	Scopes       string     `json:"scopes" sql:"scopes"`             // comma separated, the most the client may request
+	RevokedAt    *time.Time `json:"revokedat" sql:"revokedat"`
+	CreatedAt    time.Time  `json:"createdat" sql:"createdat"`
+}
+
+// IsPublic reports whether the client has no secret
+func (c *OAuthClient) IsPublic() bool {
+	return c.SecretHash == ""
+}
+
+// AllowsRedirectURI reports whether the uri exactly matches a registered redirect uri
+func (c *OAuthClient) AllowsRedirectURI(uri string) bool {
+	for _, registered := range strings.Fields(c.RedirectURIs) {
+		if registered == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// OAuthConsent is the data structure for oauthconsents table, the scopes a user granted a client
+type OAuthConsent struct {
+	ID        string     `json:"id" sql:"id"`
+	UserID    string     `json:"userid" sql:"userid"`
+	ClientID  string     `json:"clientid" sql:"clientid"`
+	Scopes    string     `json:"scopes" sql:"scopes"` // comma separated
+	RevokedAt *time.Time `json:"revokedat" sql:"revokedat"`
+	CreatedAt time.Time  `json:"createdat" sql:"createdat"`
+	UpdatedAt time.Time  `json:"updatedat" sql:"updatedat"`
+	// Generation counts the revocations of the consent, access tokens carry the generation they were issued in
+	Generation int `json:"generation" sql:"generation"`
+}
+
+// IsActive reports whether the consent is not revoked
+func (c *OAuthConsent) IsActive() bool {
+	return c.RevokedAt == nil
+}
+
+// OAuthAuthorizationCode is the data structure for oauthcodes table. Codes are single use
+// and bound to the PKCE challenge of the request that created them.
+type OAuthAuthorizationCode struct {
+	CodeHash      string     `json:"-" sql:"codehash"`
+	ClientID      string     `json:"clientid" sql:"clientid"`
+	UserID        string     `json:"userid" sql:"userid"`
+	RedirectURI   string     `json:"redirecturi" sql:"redirecturi"`
+	Scopes        string     `json:"scopes" sql:"scopes"`
+	CodeChallenge string     `json:"-" sql:"codechallenge"` // S256
+	ExpiresAt     time.Time  `json:"expiresat" sql:"expiresat"`
+	UsedAt        *time.Time `json:"usedat" sql:"usedat"`
+	CreatedAt     time.Time  `json:"createdat" sql:"createdat"`
+}
+
+// OAuthDeviceCode is the data structure for oauthdevicecodes table. The device polls with
+// the device code while the user approves the short user code on another screen.
+type OAuthDeviceCode struct {
+	DeviceCodeHash string     `json:"-" sql:"devicecodehash"`
+	UserCode       string     `json:"usercode" sql:"usercode"`
+	ClientID       string     `json:"clientid" sql:"clientid"`
+	Scopes         string     `json:"scopes" sql:"scopes"`
+	UserID         string     `json:"userid" sql:"userid"` // set on approval
+	Status         string     `json:"status" sql:"status"`
+	PollInterval   int        `json:"pollinterval" sql:"pollinterval"` // minimum seconds between polls
+	LastPolledAt   *time.Time `json:"lastpolledat" sql:"lastpolledat"`
+	ExpiresAt      time.Time  `json:"expiresat" sql:"expiresat"`
+	CreatedAt      time.Time  `json:"createdat" sql:"createdat"`
+}
+
+// OAuthRefreshToken is the data structure for oauthrefreshtokens table. Refresh tokens are
+// bound to one client and rotated on every use.
+type OAuthRefreshToken struct {
+	ID        string     `json:"id" sql:"id"`
+	TokenHash string     `json:"-" sql:"tokenhash"`
+	ClientID  string     `json:"clientid" sql:"clientid"`
+	UserID    string     `json:"userid" sql:"userid"`
+	Scopes    string     `json:"scopes" sql:"scopes"`
+	ExpiresAt time.Time  `json:"expiresat" sql:"expiresat"`
+	RevokedAt *time.Time `json:"revokedat" sql:"revokedat"`
+	CreatedAt time.Time  `json:"createdat" sql:"createdat"`
+}
+
+// IsActive reports whether the refresh token is neither revoked nor expired
+func (t *OAuthRefreshToken) IsActive() bool {
+	return t.RevokedAt == nil && t.ExpiresAt.After(time.Now())
+}
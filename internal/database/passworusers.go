@@ -15,4 +15,6 @@ type EarnScoreResponse struct {
 	WaterScore int `json:"water_score"`
 	LightScore int `json:"light_score"`
 	SeedScore  int `json:"seed_score"`
+	// Streak is the focus streak as of today in the user's timezone
+	Streak StreakResponse `json:"streak"`
 }
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"strings"
+	"time"
+)
+
+// PersonalAccessTokenPrefix starts every personal access token so it can be told apart from a JWT
+const PersonalAccessTokenPrefix = "fnp_"
+
+// Scopes a personal access token can be granted
+const (
+	ScopeScoresRead    = "scores:read"
+	ScopeSessionsWrite = "sessions:write"
+	ScopeProfileRead   = "profile:read"
+)
+
+// PersonalAccessTokenScopes lists every valid scope
+var PersonalAccessTokenScopes = []string{ScopeScoresRead, ScopeSessionsWrite, ScopeProfileRead}
+
+// PersonalAccessToken is the data structure for personalaccesstokens table. Only the
+// sha256 hash of the token is stored, the token itself is shown once at creation.
+type PersonalAccessToken struct {
+	ID         string     `json:"id" sql:"id"`
+	UserID     string     `json:"user_id" sql:"userid"`
+	Name       string     `json:"name" sql:"name"`
+	TokenHash  string     `json:"-" sql:"tokenhash"`
+	Prefix     string     `json:"prefix" sql:"prefix"`
+	Scopes     string     `json:"scopes" sql:"scopes"` // comma separated
+	ExpiresAt  *time.Time `json:"expiresat" sql:"expiresat"`
+	LastUsedAt *time.Time `json:"lastusedat" sql:"lastusedat"`
+	RevokedAt  *time.Time `json:"revokedat" sql:"revokedat"`
+	CreatedAt  time.Time  `json:"createdat" sql:"createdat"`
+}
+
+// ScopeList returns the scopes of the token
+func (t *PersonalAccessToken) ScopeList() []string {
+	return SplitScopes(t.Scopes)
+}
+
+// HasScopes reports whether the token was granted every given scope
+func (t *PersonalAccessToken) HasScopes(scopes ...string) bool {
+	return ContainsScopes(t.ScopeList(), scopes)
+}
+
+// IsActive reports whether the token is neither revoked nor expired
+func (t *PersonalAccessToken) IsActive() bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || t.ExpiresAt.After(time.Now()))
+}
+
+// SplitScopes returns the scopes of a comma separated scopes column
+func SplitScopes(scopes string) []string {
+	if scopes == "" {
+		return []string{}
+	}
+	return strings.Split(scopes, ",")
+}
+
+// ContainsScopes reports whether every scope is in granted
+func ContainsScopes(granted []string, scopes []string) bool {
+	for _, scope := range scopes {
+		found := false
+		for _, g := range granted {
+			if g == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -43,23 +43,27 @@ func (repo *postgresRepository) UpdateUserVerificationStatus(ctx context.Context
 	return nil
 }
 
-// StoreVerificationData adds a mail verification data to db
+// StoreVerificationData adds a mail verification data to db. When isInsert is false
+// the code of the same type replaces the previous one, or is inserted if there is none.
 func (repo *postgresRepository) StoreVerificationData(ctx context.Context, verificationData *VerificationData, isInsert bool) error {
 	if isInsert {
-		query := "insert into verifications(email, code, expiresat, type) values($1, $2, $3, $4)"
+		query := "insert into verifications(email, code, expiresat, type, target) values($1, $2, $3, $4, $5)"
 		_, err := repo.db.ExecContext(ctx, query,
 			verificationData.Email,
 			verificationData.Code,
 			verificationData.ExpiresAt,
-			verificationData.Type)
+			verificationData.Type,
+			verificationData.Target)
 		return err
 	} else {
-		query := "update verifications set code=$1, expiresat=$2, type=$3 where email=$4"
+		query := "insert into verifications(email, code, expiresat, type, target) values($1, $2, $3, $4, $5) " +
+			"on conflict (email, type) do update set code = excluded.code, expiresat = excluded.expiresat, target = excluded.target"
 		_, err := repo.db.ExecContext(ctx, query,
+			verificationData.Email,
 			verificationData.Code,
 			verificationData.ExpiresAt,
 			verificationData.Type,
-			verificationData.Email)
+			verificationData.Target)
 		return err
 	}
 }
@@ -84,7 +88,7 @@ func (repo *postgresRepository) DeleteVerificationData(ctx context.Context, emai
 
 // GetUserByEmail returns the user with the given email.
 func (repo *postgresRepository) GetUserByEmail(ctx context.Context, email string) (*User, error) {
-	query := "select * from users where email = $1"
+	query := "select * from users where lower(email) = lower($1)"
 	user := &User{}
 	err := repo.db.GetContext(ctx, user, query, email)
 	return user, err
@@ -98,6 +102,21 @@ func (repo *postgresRepository) GetUserByID(ctx context.Context, id string) (*Us
 	return user, err
 }
 
+// UpdateUsername sets the username of the user and records when it was changed
+func (repo *postgresRepository) UpdateUsername(ctx context.Context, userID string, username string, normalized string) error {
+	query := "update users set username = $1, usernamenormalized = $2, usernamechangedat = $3, updatedat = $3 where id = $4"
+	_, err := repo.db.ExecContext(ctx, query, username, normalized, time.Now(), userID)
+	return err
+}
+
+// GetUserByUsername returns the user with the given normalized username.
+func (repo *postgresRepository) GetUserByUsername(ctx context.Context, normalized string) (*User, error) {
+	query := "select * from users where usernamenormalized = $1"
+	user := &User{}
+	err := repo.db.GetContext(ctx, user, query, normalized)
+	return user, err
+}
+
 // UpdateUser updates the user with the given id.
 func (repo *postgresRepository) UpdateUser(ctx context.Context, user *User) error {
 	user.UpdatedAt = time.Now()
@@ -151,7 +170,7 @@ func (repo *postgresRepository) GetProfileByID(ctx context.Context, userId strin
 // UpdateProfile updates the profile data.
 func (repo *postgresRepository) UpdateProfile(ctx context.Context, profile *ProfileData) error {
 	profile.UpdatedAt = time.Now()
-	query := "update profiles set firstname = $1, lastname = $2, avatarurl = $3, phone = $4, street = $5, city = $6, state = $7, zipcode = $8, country = $9, updatedat = $10 where userid = $11"
+	query := "update profiles set firstname = $1, lastname = $2, avatarurl = $3, phone = $4, street = $5, city = $6, state = $7, zipcode = $8, country = $9, updatedat = $10, phoneverified = $11, timezone = $12 where userid = $13"
 	_, err := repo.db.ExecContext(ctx, query,
 		profile.FirstName,
 		profile.LastName,
@@ -163,6 +182,8 @@ func (repo *postgresRepository) UpdateProfile(ctx context.Context, profile *Prof
 		profile.ZipCode,
 		profile.Country,
 		profile.UpdatedAt,
+		profile.PhoneVerified,
+		profile.Timezone,
 		profile.UserID)
 	return err
 }
@@ -174,6 +195,13 @@ func (repo *postgresRepository) UpdatePassword(ctx context.Context, userID strin
 	return err
 }
 
+// UpdatePasswordHash replaces the stored password hash, e.g. after a rehash on login
+func (repo *postgresRepository) UpdatePasswordHash(ctx context.Context, userID string, password string) error {
+	query := "update users set password = $1, updatedat = $2 where id = $3"
+	_, err := repo.db.ExecContext(ctx, query, password, time.Now(), userID)
+	return err
+}
+
 // GetListOfPasswords returns the list of passwords
 func (repo *postgresRepository) GetListOfPasswords(ctx context.Context, userID string) ([]string, error) {
 	query := "select password from passworusers where userid = $1"
@@ -262,42 +290,1563 @@ func (repo *postgresRepository) ClearAllLimitData(ctx context.Context) error {
 	return err
 }
 
-// GetMultiRatioData returns the multi ratio data
-func (repo *postgresRepository) GetMultiRatioData(ctx context.Context) (*MultiRatioData, error) {
-	query := "select * from multiratios"
+// GetMultiRatioData returns the multi ratio version in effect at the given time. Of versions
+// taking effect at the same time the latest published wins.
+func (repo *postgresRepository) GetMultiRatioData(ctx context.Context, at time.Time) (*MultiRatioData, error) {
+	query := "select * from multiratioversions where effectivefrom <= $1 order by effectivefrom desc, version desc limit 1"
 	multiRatioData := &MultiRatioData{}
-	err := repo.db.GetContext(ctx, multiRatioData, query)
+	err := repo.db.GetContext(ctx, multiRatioData, query, at)
 	return multiRatioData, err
 }
 
-//userid 	Varchar(36) not null,
-//waterscore 	   Int default 0,
-//lightscore Int default 0,
-//seedscore 	   Int default 0,
-//createdat  Timestamp not null,
-//updatedat  Timestamp not null,
+// GetMultiRatios returns every multi ratio version, oldest first
+func (repo *postgresRepository) GetMultiRatios(ctx context.Context) ([]MultiRatioData, error) {
+	query := "select * from multiratioversions order by version"
+	ratios := []MultiRatioData{}
+	err := repo.db.SelectContext(ctx, &ratios, query)
+	return ratios, err
+}
+
+// PublishMultiRatio inserts the ratios as the next version. Versions are numbered in the
+// statement, a concurrent publish fails on the primary key instead of sharing a number.
+func (repo *postgresRepository) PublishMultiRatio(ctx context.Context, ratio *MultiRatioData) error {
+	ratio.CreatedAt = time.Now()
+	query := "insert into multiratioversions(version, waterratio, lightratio, seedratio, effectivefrom, createdat) " +
+		"select coalesce(max(version), 0) + 1, $1, $2, $3, $4, $5 from multiratioversions returning version"
+	return repo.db.GetContext(ctx, &ratio.Version, query,
+		ratio.WaterRatio,
+		ratio.LightRatio,
+		ratio.SeedRatio,
+		ratio.EffectiveFrom,
+		ratio.CreatedAt)
+}
+
+// RecordFocusSession inserts the focus session with its tags and adds its scores to the earn
+// score of the user. The session is recorded at its creation time, now when it is unset.
+func (repo *postgresRepository) RecordFocusSession(ctx context.Context, session *FocusSession, tagIDs []string) error {
+	session.ID = uuid.NewV4().String()
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = time.Now()
+	}
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "insert into focussessions(id, userid, minutes, waterscore, lightscore, seedscore, createdat, ratioversion, taskid) " +
+		"values($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+	_, err = tx.ExecContext(ctx, query,
+		session.ID,
+		session.UserID,
+		session.Minutes,
+		session.WaterScore,
+		session.LightScore,
+		session.SeedScore,
+		session.CreatedAt,
+		session.RatioVersion,
+		session.TaskID)
+	if err != nil {
+		return err
+	}
+	for _, tagID := range tagIDs {
+		query = "insert into focussessiontags(sessionid, tagid) values($1, $2) on conflict do nothing"
+		if _, err := tx.ExecContext(ctx, query, session.ID, tagID); err != nil {
+			return err
+		}
+	}
+	earnScore := &EarnScore{
+		UserID:     session.UserID,
+		WaterScore: session.WaterScore,
+		LightScore: session.LightScore,
+		SeedScore:  session.SeedScore,
+	}
+	if err := addEarnScore(ctx, tx, earnScore); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
 
-// InsertEarnScore inserts to earn score
-func (repo *postgresRepository) InsertEarnScore(ctx context.Context, earnScore *EarnScore) error {
-	earnScore.CreatedAt = time.Now()
+// addEarnScore adds the scores to the earn score of the user with the given executor, a db or
+// a transaction. The scores are added in the statement, so concurrent credits do not overwrite
+// each other.
+func addEarnScore(ctx context.Context, exec sqlx.ExecerContext, earnScore *EarnScore) error {
 	earnScore.UpdatedAt = time.Now()
-	// insert to earn score, update if already exists userid
-	query := "insert into earnscores(userid, waterscore, lightscore, seedscore, createdat, updatedat) values($1, $2, $3, $4, $5, $6) on conflict(userid) do update set waterscore = $2, lightscore = $3, seedscore = $4, updatedat = $6"
-	_, err := repo.db.ExecContext(ctx, query,
+	query := "insert into earnscores(userid, waterscore, lightscore, seedscore, createdat, updatedat) values($1, $2, $3, $4, $5, $5) " +
+		"on conflict(userid) do update set waterscore = earnscores.waterscore + excluded.waterscore, " +
+		"lightscore = earnscores.lightscore + excluded.lightscore, seedscore = earnscores.seedscore + excluded.seedscore, updatedat = excluded.updatedat"
+	_, err := exec.ExecContext(ctx, query,
 		earnScore.UserID,
 		earnScore.WaterScore,
 		earnScore.LightScore,
 		earnScore.SeedScore,
-		earnScore.CreatedAt,
 		earnScore.UpdatedAt)
 	return err
+}
 
+// spendEarnScore debits the scores from the earn score of the user with the given executor.
+// The balance is checked in the statement, so concurrent spends cannot overdraw it, and
+// ErrInsufficientScore is returned when it does not cover the scores.
+func spendEarnScore(ctx context.Context, exec sqlx.ExecerContext, earnScore *EarnScore) error {
+	earnScore.UpdatedAt = time.Now()
+	query := "update earnscores set waterscore = waterscore - $2, lightscore = lightscore - $3, seedscore = seedscore - $4, updatedat = $5 " +
+		"where userid = $1 and waterscore >= $2 and lightscore >= $3 and seedscore >= $4"
+	result, err := exec.ExecContext(ctx, query,
+		earnScore.UserID,
+		earnScore.WaterScore,
+		earnScore.LightScore,
+		earnScore.SeedScore,
+		earnScore.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if err := expectOneRow(result); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrInsufficientScore
+		}
+		return err
+	}
+	return nil
 }
 
-// GetEarnScore returns the earn score
-func (repo *postgresRepository) GetEarnScore(ctx context.Context, userID string) (*EarnScore, error) {
-	query := "select * from earnscores where userid = $1"
-	earnScore := &EarnScore{}
-	err := repo.db.GetContext(ctx, earnScore, query, userID)
-	return earnScore, err
+// GetPlants returns the plants of the user, oldest first
+func (repo *postgresRepository) GetPlants(ctx context.Context, userID string) ([]Plant, error) {
+	query := "select * from plants where userid = $1 order by createdat"
+	plants := []Plant{}
+	err := repo.db.SelectContext(ctx, &plants, query, userID)
+	return plants, err
+}
+
+// GetPlant returns a plant of the user
+func (repo *postgresRepository) GetPlant(ctx context.Context, userID string, plantID string) (*Plant, error) {
+	query := "select * from plants where id = $1 and userid = $2"
+	plant := &Plant{}
+	err := repo.db.GetContext(ctx, plant, query, plantID, userID)
+	return plant, err
+}
+
+// PlantSeed debits the seed cost and inserts the plant in one transaction
+func (repo *postgresRepository) PlantSeed(ctx context.Context, plant *Plant, cost *EarnScore) error {
+	plant.ID = uuid.NewV4().String()
+	plant.CreatedAt = time.Now()
+	plant.UpdatedAt = plant.CreatedAt
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	cost.UserID = plant.UserID
+	if err := spendEarnScore(ctx, tx, cost); err != nil {
+		return err
+	}
+	query := "insert into plants(id, userid, speciesid, stage, water, createdat, updatedat) values($1, $2, $3, $4, $5, $6, $7)"
+	_, err = tx.ExecContext(ctx, query,
+		plant.ID,
+		plant.UserID,
+		plant.SpeciesID,
+		plant.Stage,
+		plant.Water,
+		plant.CreatedAt,
+		plant.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// WaterPlant debits the water and adds it to the plant in one transaction. The plant is
+// only updated when it is still as read, sql.ErrNoRows is returned when it changed meanwhile.
+func (repo *postgresRepository) WaterPlant(ctx context.Context, plant *Plant, water int) error {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	updatedAt := time.Now()
+	query := "update plants set water = water + $5, updatedat = $6 where id = $1 and userid = $2 and stage = $3 and water = $4"
+	result, err := tx.ExecContext(ctx, query, plant.ID, plant.UserID, plant.Stage, plant.Water, water, updatedAt)
+	if err != nil {
+		return err
+	}
+	if err := expectOneRow(result); err != nil {
+		return err
+	}
+	if err := spendEarnScore(ctx, tx, &EarnScore{UserID: plant.UserID, WaterScore: water}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	plant.Water += water
+	plant.UpdatedAt = updatedAt
+	return nil
+}
+
+// GrowPlant debits the cost and moves the plant to its next stage in one transaction, the
+// water given towards the stage is used up. As with WaterPlant, sql.ErrNoRows is returned
+// when the plant changed since it was read.
+func (repo *postgresRepository) GrowPlant(ctx context.Context, plant *Plant, cost *EarnScore) error {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	updatedAt := time.Now()
+	query := "update plants set stage = stage + 1, water = 0, updatedat = $5 where id = $1 and userid = $2 and stage = $3 and water = $4"
+	result, err := tx.ExecContext(ctx, query, plant.ID, plant.UserID, plant.Stage, plant.Water, updatedAt)
+	if err != nil {
+		return err
+	}
+	if err := expectOneRow(result); err != nil {
+		return err
+	}
+	cost.UserID = plant.UserID
+	if err := spendEarnScore(ctx, tx, cost); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	plant.Stage++
+	plant.Water = 0
+	plant.UpdatedAt = updatedAt
+	return nil
+}
+
+// GetShopItems returns the shop items, oldest first. Deleted items are left out unless asked for.
+func (repo *postgresRepository) GetShopItems(ctx context.Context, includeDeleted bool) ([]ShopItem, error) {
+	query := "select * from shopitems where $1 or deletedat is null order by createdat"
+	items := []ShopItem{}
+	err := repo.db.SelectContext(ctx, &items, query, includeDeleted)
+	return items, err
+}
+
+// GetShopItem returns the shop item with the given id, deleted or not
+func (repo *postgresRepository) GetShopItem(ctx context.Context, id string) (*ShopItem, error) {
+	query := "select * from shopitems where id = $1"
+	item := &ShopItem{}
+	err := repo.db.GetContext(ctx, item, query, id)
+	return item, err
+}
+
+// CreateShopItem inserts the shop item, its id is chosen by the caller
+func (repo *postgresRepository) CreateShopItem(ctx context.Context, item *ShopItem) error {
+	item.CreatedAt = time.Now()
+	item.UpdatedAt = item.CreatedAt
+	query := "insert into shopitems(id, name, description, kind, waterprice, lightprice, seedprice, availablefrom, availableuntil, peruserlimit, createdat, updatedat) " +
+		"values($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)"
+	_, err := repo.db.ExecContext(ctx, query,
+		item.ID,
+		item.Name,
+		item.Description,
+		item.Kind,
+		item.WaterPrice,
+		item.LightPrice,
+		item.SeedPrice,
+		item.AvailableFrom,
+		item.AvailableUntil,
+		item.PerUserLimit,
+		item.CreatedAt,
+		item.UpdatedAt)
+	return err
+}
+
+// UpdateShopItem replaces the fields of the shop item, sql.ErrNoRows is returned when it does
+// not exist or is deleted
+func (repo *postgresRepository) UpdateShopItem(ctx context.Context, item *ShopItem) error {
+	item.UpdatedAt = time.Now()
+	query := "update shopitems set name = $2, description = $3, kind = $4, waterprice = $5, lightprice = $6, seedprice = $7, " +
+		"availablefrom = $8, availableuntil = $9, peruserlimit = $10, updatedat = $11 where id = $1 and deletedat is null"
+	result, err := repo.db.ExecContext(ctx, query,
+		item.ID,
+		item.Name,
+		item.Description,
+		item.Kind,
+		item.WaterPrice,
+		item.LightPrice,
+		item.SeedPrice,
+		item.AvailableFrom,
+		item.AvailableUntil,
+		item.PerUserLimit,
+		item.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result)
+}
+
+// DeleteShopItem soft deletes the shop item, sql.ErrNoRows is returned when it does not exist
+// or is deleted already
+func (repo *postgresRepository) DeleteShopItem(ctx context.Context, id string) error {
+	now := time.Now()
+	query := "update shopitems set deletedat = $2, updatedat = $2 where id = $1 and deletedat is null"
+	result, err := repo.db.ExecContext(ctx, query, id, now)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result)
+}
+
+// Purchase records the purchase, debits its price and grants the item in one transaction.
+// The earn score of the user is locked first, so concurrent purchases of the user are
+// serialized and cannot exceed the per user limit of the item. Bought streak freezes share
+// the maxFreezes cap of earned ones. sql.ErrNoRows is returned when a purchase with the
+// idempotency key was recorded already, ErrPurchaseLimitReached and ErrInsufficientScore
+// when the purchase is not allowed.
+func (repo *postgresRepository) Purchase(ctx context.Context, purchase *Purchase, item *ShopItem, maxFreezes int) error {
+	purchase.ID = uuid.NewV4().String()
+	purchase.CreatedAt = time.Now()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var locked string
+	err = tx.GetContext(ctx, &locked, "select userid from earnscores where userid = $1 for update", purchase.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrInsufficientScore
+		}
+		return err
+	}
+	query := "insert into purchases(id, userid, itemid, quantity, currency, price, idempotencykey, createdat) values($1, $2, $3, $4, $5, $6, $7, $8) " +
+		"on conflict (userid, idempotencykey) do nothing"
+	result, err := tx.ExecContext(ctx, query,
+		purchase.ID,
+		purchase.UserID,
+		purchase.ItemID,
+		purchase.Quantity,
+		purchase.Currency,
+		purchase.Price,
+		purchase.IdempotencyKey,
+		purchase.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if err := expectOneRow(result); err != nil {
+		return err
+	}
+	if item.PerUserLimit > 0 {
+		var bought int
+		query = "select coalesce(sum(quantity), 0) from purchases where userid = $1 and itemid = $2"
+		if err := tx.GetContext(ctx, &bought, query, purchase.UserID, purchase.ItemID); err != nil {
+			return err
+		}
+		if bought > item.PerUserLimit {
+			return ErrPurchaseLimitReached
+		}
+	}
+	if err := spendEarnScore(ctx, tx, purchase.Cost()); err != nil {
+		return err
+	}
+	// Streak freezes are added to the streak up to the cap, other items to the inventory
+	if item.Kind == ShopItemStreakFreeze {
+		query = "insert into streaks(userid, freezes, createdat, updatedat) select $1, $2, $3, $3 where $2 <= $4 " +
+			"on conflict(userid) do update set freezes = streaks.freezes + excluded.freezes, updatedat = excluded.updatedat " +
+			"where streaks.freezes + excluded.freezes <= $4"
+		result, err = tx.ExecContext(ctx, query, purchase.UserID, purchase.Quantity, purchase.CreatedAt, maxFreezes)
+		if err != nil {
+			return err
+		}
+		if err := expectOneRow(result); err != nil {
+			return ErrPurchaseLimitReached
+		}
+	} else {
+		query = "insert into inventories(userid, itemid, quantity, createdat, updatedat) values($1, $2, $3, $4, $4) " +
+			"on conflict(userid, itemid) do update set quantity = inventories.quantity + excluded.quantity, updatedat = excluded.updatedat"
+		if _, err := tx.ExecContext(ctx, query, purchase.UserID, purchase.ItemID, purchase.Quantity, purchase.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetPurchaseByKey returns the purchase of the user with the idempotency key
+func (repo *postgresRepository) GetPurchaseByKey(ctx context.Context, userID string, key string) (*Purchase, error) {
+	query := "select * from purchases where userid = $1 and idempotencykey = $2"
+	purchase := &Purchase{}
+	err := repo.db.GetContext(ctx, purchase, query, userID, key)
+	return purchase, err
+}
+
+// GetInventory returns the items owned by the user, oldest first
+func (repo *postgresRepository) GetInventory(ctx context.Context, userID string) ([]InventoryItem, error) {
+	query := "select * from inventories where userid = $1 and quantity > 0 order by createdat"
+	items := []InventoryItem{}
+	err := repo.db.SelectContext(ctx, &items, query, userID)
+	return items, err
+}
+
+// SetGoal inserts the goal, it takes effect from now on
+func (repo *postgresRepository) SetGoal(ctx context.Context, goal *Goal) error {
+	goal.ID = uuid.NewV4().String()
+	goal.EffectiveFrom = time.Now()
+	query := "insert into goals(id, userid, period, metric, target, effectivefrom) values($1, $2, $3, $4, $5, $6)"
+	_, err := repo.db.ExecContext(ctx, query, goal.ID, goal.UserID, goal.Period, goal.Metric, goal.Target, goal.EffectiveFrom)
+	return err
+}
+
+// GetGoals returns the goals the user has set, oldest first
+func (repo *postgresRepository) GetGoals(ctx context.Context, userID string) ([]Goal, error) {
+	query := "select * from goals where userid = $1 order by effectivefrom"
+	goals := []Goal{}
+	err := repo.db.SelectContext(ctx, &goals, query, userID)
+	return goals, err
+}
+
+// GetGoalBonuses returns the goal bonuses of the user for the periods starting within [from, to]
+func (repo *postgresRepository) GetGoalBonuses(ctx context.Context, userID string, from time.Time, to time.Time) ([]GoalBonus, error) {
+	query := "select * from goalbonuses where userid = $1 and periodstart >= $2 and periodstart <= $3 order by periodstart"
+	bonuses := []GoalBonus{}
+	err := repo.db.SelectContext(ctx, &bonuses, query, userID, from, to)
+	return bonuses, err
+}
+
+// AwardGoalBonus records the bonus and adds it to the earn score in one transaction. As with
+// achievements, a bonus is credited once per period and false is returned when it was already.
+func (repo *postgresRepository) AwardGoalBonus(ctx context.Context, bonus *GoalBonus) (bool, error) {
+	bonus.CreatedAt = time.Now()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query := "insert into goalbonuses(userid, period, periodstart, waterscore, createdat) values($1, $2, $3, $4, $5) " +
+		"on conflict (userid, period, periodstart) do nothing"
+	result, err := tx.ExecContext(ctx, query, bonus.UserID, bonus.Period, bonus.PeriodStart, bonus.WaterScore, bonus.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	if err := expectOneRow(result); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	if bonus.WaterScore > 0 {
+		if err := addEarnScore(ctx, tx, &EarnScore{UserID: bonus.UserID, WaterScore: bonus.WaterScore}); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// CreateTag inserts the tag. Tag names are unique per user regardless of case.
+func (repo *postgresRepository) CreateTag(ctx context.Context, tag *Tag) error {
+	tag.ID = uuid.NewV4().String()
+	tag.CreatedAt = time.Now()
+	tag.UpdatedAt = tag.CreatedAt
+	query := "insert into tags(id, userid, name, color, archived, createdat, updatedat) values($1, $2, $3, $4, $5, $6, $7)"
+	_, err := repo.db.ExecContext(ctx, query, tag.ID, tag.UserID, tag.Name, tag.Color, tag.Archived, tag.CreatedAt, tag.UpdatedAt)
+	return err
+}
+
+// GetTags returns the tags of the user, oldest first
+func (repo *postgresRepository) GetTags(ctx context.Context, userID string) ([]Tag, error) {
+	query := "select * from tags where userid = $1 order by createdat"
+	tags := []Tag{}
+	err := repo.db.SelectContext(ctx, &tags, query, userID)
+	return tags, err
+}
+
+// UpdateTag replaces the name, color and archived state of the tag, sql.ErrNoRows is returned
+// when the user has no such tag
+func (repo *postgresRepository) UpdateTag(ctx context.Context, tag *Tag) error {
+	tag.UpdatedAt = time.Now()
+	query := "update tags set name = $3, color = $4, archived = $5, updatedat = $6 where id = $1 and userid = $2"
+	result, err := repo.db.ExecContext(ctx, query, tag.ID, tag.UserID, tag.Name, tag.Color, tag.Archived, tag.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result)
+}
+
+// CountActiveTags returns the number of tags of the user that are not archived
+func (repo *postgresRepository) CountActiveTags(ctx context.Context, userID string) (int, error) {
+	query := "select count(*) from tags where userid = $1 and not archived"
+	var count int
+	err := repo.db.GetContext(ctx, &count, query, userID)
+	return count, err
+}
+
+// GetFocusStatsByTag aggregates the focus sessions of the user started within [from, to) per
+// tag. A session with several tags counts towards each of them, sessions without tags are
+// aggregated under a nil tag.
+func (repo *postgresRepository) GetFocusStatsByTag(ctx context.Context, userID string, from time.Time, to time.Time) ([]TagFocusStats, error) {
+	query := "select t.tagid, sum(s.minutes) as minutes, count(*) as sessions from focussessions s " +
+		"left join focussessiontags t on t.sessionid = s.id " +
+		"where s.userid = $1 and s.createdat >= $2 and s.createdat < $3 group by t.tagid"
+	stats := []TagFocusStats{}
+	err := repo.db.SelectContext(ctx, &stats, query, userID, from, to)
+	return stats, err
+}
+
+// taskColumns selects a task with the number of focus sessions linked to it
+const taskColumns = "t.*, (select count(*) from focussessions s where s.taskid = t.id) as actualpomodoros"
+
+// CreateTask inserts the task at the end of the tasks of the user
+func (repo *postgresRepository) CreateTask(ctx context.Context, task *Task) error {
+	task.ID = uuid.NewV4().String()
+	task.CreatedAt = time.Now()
+	task.UpdatedAt = task.CreatedAt
+	query := "insert into tasks(id, userid, title, notes, estimatedpomodoros, duedate, completed, position, createdat, updatedat) " +
+		"select $1, $2, $3, $4, $5, $6, false, coalesce(max(position), -1) + 1, $7, $7 from tasks where userid = $2 returning position"
+	return repo.db.GetContext(ctx, &task.Position, query,
+		task.ID,
+		task.UserID,
+		task.Title,
+		task.Notes,
+		task.EstimatedPomodoros,
+		task.DueDate,
+		task.CreatedAt)
+}
+
+// GetTasks returns the tasks of the user, open tasks by position then completed tasks
+func (repo *postgresRepository) GetTasks(ctx context.Context, userID string) ([]Task, error) {
+	query := "select " + taskColumns + " from tasks t where t.userid = $1 order by t.completed, t.position"
+	tasks := []Task{}
+	err := repo.db.SelectContext(ctx, &tasks, query, userID)
+	return tasks, err
+}
+
+// GetTask returns a task of the user
+func (repo *postgresRepository) GetTask(ctx context.Context, userID string, taskID string) (*Task, error) {
+	query := "select " + taskColumns + " from tasks t where t.id = $1 and t.userid = $2"
+	task := &Task{}
+	err := repo.db.GetContext(ctx, task, query, taskID, userID)
+	return task, err
+}
+
+// UpdateTask replaces the fields of the task, sql.ErrNoRows is returned when the user has no such task
+func (repo *postgresRepository) UpdateTask(ctx context.Context, task *Task) error {
+	task.UpdatedAt = time.Now()
+	query := "update tasks set title = $3, notes = $4, estimatedpomodoros = $5, duedate = $6, completed = $7, completedat = $8, updatedat = $9 " +
+		"where id = $1 and userid = $2"
+	result, err := repo.db.ExecContext(ctx, query,
+		task.ID,
+		task.UserID,
+		task.Title,
+		task.Notes,
+		task.EstimatedPomodoros,
+		task.DueDate,
+		task.Completed,
+		task.CompletedAt,
+		task.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result)
+}
+
+// DeleteTask deletes the task, the focus sessions spent on it are kept without a task.
+// sql.ErrNoRows is returned when the user has no such task.
+func (repo *postgresRepository) DeleteTask(ctx context.Context, userID string, taskID string) error {
+	query := "delete from tasks where id = $1 and userid = $2"
+	result, err := repo.db.ExecContext(ctx, query, taskID, userID)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result)
+}
+
+// ReorderTasks sets the position of every given task of the user to its index in one
+// transaction. sql.ErrNoRows is returned when one of the tasks does not belong to the user.
+func (repo *postgresRepository) ReorderTasks(ctx context.Context, userID string, taskIDs []string) error {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query := "update tasks set position = $3, updatedat = $4 where id = $1 and userid = $2"
+	for position, taskID := range taskIDs {
+		result, err := tx.ExecContext(ctx, query, taskID, userID, position, now)
+		if err != nil {
+			return err
+		}
+		if err := expectOneRow(result); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetFocusTotals returns the number of focus sessions of the user and their minutes
+func (repo *postgresRepository) GetFocusTotals(ctx context.Context, userID string) (*FocusTotals, error) {
+	query := "select count(*) as sessions, coalesce(sum(minutes), 0) as minutes from focussessions where userid = $1"
+	totals := &FocusTotals{}
+	err := repo.db.GetContext(ctx, totals, query, userID)
+	return totals, err
+}
+
+// GetUserAchievements returns the achievements unlocked by the user
+func (repo *postgresRepository) GetUserAchievements(ctx context.Context, userID string) ([]UserAchievement, error) {
+	query := "select * from userachievements where userid = $1 order by unlockedat"
+	achievements := []UserAchievement{}
+	err := repo.db.SelectContext(ctx, &achievements, query, userID)
+	return achievements, err
+}
+
+// UnlockAchievement inserts the unlocked achievement and adds the reward, if any, to the earn
+// score in one transaction. Unlocking is idempotent: an achievement that was unlocked already
+// returns false and credits nothing.
+func (repo *postgresRepository) UnlockAchievement(ctx context.Context, achievement *UserAchievement, reward *EarnScore) (bool, error) {
+	achievement.UnlockedAt = time.Now()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query := "insert into userachievements(userid, achievementid, unlockedat) values($1, $2, $3) on conflict (userid, achievementid) do nothing"
+	result, err := tx.ExecContext(ctx, query, achievement.UserID, achievement.AchievementID, achievement.UnlockedAt)
+	if err != nil {
+		return false, err
+	}
+	if err := expectOneRow(result); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	if reward != nil {
+		reward.UserID = achievement.UserID
+		if err := addEarnScore(ctx, tx, reward); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// GetFocusStats aggregates the focus sessions of the user started within [from, to) into
+// buckets of the period, truncated in the given IANA timezone. Empty buckets are omitted.
+func (repo *postgresRepository) GetFocusStats(ctx context.Context, userID string, period string, timezone string, from time.Time, to time.Time) ([]FocusStats, error) {
+	query := "select date_trunc($2, createdat at time zone $3) as bucket, sum(minutes) as minutes, count(*) as sessions, " +
+		"sum(waterscore) as waterscore, sum(lightscore) as lightscore, sum(seedscore) as seedscore " +
+		"from focussessions where userid = $1 and createdat >= $4 and createdat < $5 group by bucket order by bucket"
+	stats := []FocusStats{}
+	err := repo.db.SelectContext(ctx, &stats, query, userID, period, timezone, from, to)
+	return stats, err
+}
+
+// GetFocusMinutes returns the focus minutes of the sessions of the user started within [from, to)
+func (repo *postgresRepository) GetFocusMinutes(ctx context.Context, userID string, from time.Time, to time.Time) (int, error) {
+	query := "select coalesce(sum(minutes), 0) from focussessions where userid = $1 and createdat >= $2 and createdat < $3"
+	var minutes int
+	err := repo.db.GetContext(ctx, &minutes, query, userID, from, to)
+	return minutes, err
+}
+
+// GetStreak returns the focus streak of the user
+func (repo *postgresRepository) GetStreak(ctx context.Context, userID string) (*Streak, error) {
+	query := "select * from streaks where userid = $1"
+	streak := &Streak{}
+	err := repo.db.GetContext(ctx, streak, query, userID)
+	return streak, err
+}
+
+// SaveStreak inserts or updates the streak of the user. A streak is only moved forward, so
+// concurrent sessions crediting the same day store it once. Freezes is the change of the
+// freezes since the streak was read and is added to the stored count, so freezes bought
+// meanwhile are kept.
+func (repo *postgresRepository) SaveStreak(ctx context.Context, streak *Streak, freezes int) error {
+	streak.UpdatedAt = time.Now()
+	query := "insert into streaks(userid, currentstreak, beststreak, freezes, lastday, createdat, updatedat) values($1, $2, $3, $4, $5, $6, $6) " +
+		"on conflict(userid) do update set currentstreak = excluded.currentstreak, beststreak = excluded.beststreak, " +
+		"freezes = streaks.freezes + $7, lastday = excluded.lastday, updatedat = excluded.updatedat " +
+		"where streaks.lastday is null or streaks.lastday < excluded.lastday"
+	_, err := repo.db.ExecContext(ctx, query,
+		streak.UserID,
+		streak.CurrentStreak,
+		streak.BestStreak,
+		streak.Freezes,
+		streak.LastDay,
+		streak.UpdatedAt,
+		freezes)
+	return err
+}
+
+// leaderboardSources are the queries of the score of every user on a board. Period boards
+// take the start of the period as $4.
+var leaderboardSources = map[string]string{
+	LeaderboardName(LeaderboardMetricWater, LeaderboardPeriodAll):     "select userid, waterscore as score from earnscores",
+	LeaderboardName(LeaderboardMetricWater, LeaderboardPeriodWeek):    "select userid, sum(waterscore) as score from focussessions where createdat >= $4 group by userid",
+	LeaderboardName(LeaderboardMetricWater, LeaderboardPeriodMonth):   "select userid, sum(waterscore) as score from focussessions where createdat >= $4 group by userid",
+	LeaderboardName(LeaderboardMetricMinutes, LeaderboardPeriodAll):   "select userid, sum(minutes) as score from focussessions group by userid",
+	LeaderboardName(LeaderboardMetricMinutes, LeaderboardPeriodWeek):  "select userid, sum(minutes) as score from focussessions where createdat >= $4 group by userid",
+	LeaderboardName(LeaderboardMetricMinutes, LeaderboardPeriodMonth): "select userid, sum(minutes) as score from focussessions where createdat >= $4 group by userid",
+}
+
+// RefreshLeaderboards recomputes every board in one transaction, so readers see either the
+// previous or the new ranking. Banned users, guests and users hiding from leaderboards are left out.
+func (repo *postgresRepository) RefreshLeaderboards(ctx context.Context) error {
+	now := time.Now().UTC()
+	periodStarts := map[string]time.Time{
+		LeaderboardPeriodWeek:  time.Date(now.Year(), now.Month(), now.Day()-(int(now.Weekday())+6)%7, 0, 0, 0, 0, time.UTC),
+		LeaderboardPeriodMonth: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "delete from leaderboardentries"); err != nil {
+		return err
+	}
+	for _, metric := range LeaderboardMetrics {
+		for _, period := range LeaderboardPeriods {
+			board := LeaderboardName(metric, period)
+			query := "insert into leaderboardentries(board, userid, score, rank, computedat) " +
+				"select $1, s.userid, s.score, rank() over (order by s.score desc), $2 from (" + leaderboardSources[board] + ") s " +
+				"join users u on u.id = s.userid left join privacysettings p on p.userid = s.userid " +
+				"where s.score > 0 and u.banned = false and u.role <> $3 and coalesce(p.hidefromleaderboards, false) = false"
+			args := []interface{}{board, now, RoleGuest}
+			if start, ok := periodStarts[period]; ok {
+				args = append(args, start)
+			}
+			if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// GetLeaderboard returns the top entries of the board
+func (repo *postgresRepository) GetLeaderboard(ctx context.Context, board string, limit int) ([]LeaderboardEntry, error) {
+	query := "select e.board, e.userid, u.username, e.score, e.rank, e.computedat from leaderboardentries e " +
+		"join users u on u.id = e.userid where e.board = $1 order by e.rank, u.username limit $2"
+	entries := []LeaderboardEntry{}
+	err := repo.db.SelectContext(ctx, &entries, query, board, limit)
+	return entries, err
+}
+
+// GetLeaderboardEntry returns the entry of the user on the board
+func (repo *postgresRepository) GetLeaderboardEntry(ctx context.Context, board string, userID string) (*LeaderboardEntry, error) {
+	query := "select e.board, e.userid, u.username, e.score, e.rank, e.computedat from leaderboardentries e " +
+		"join users u on u.id = e.userid where e.board = $1 and e.userid = $2"
+	entry := &LeaderboardEntry{}
+	err := repo.db.GetContext(ctx, entry, query, board, userID)
+	return entry, err
+}
+
+// GetFriendsLeaderboard returns the entries of the user and their accepted friends on the
+// board, ranked among themselves
+func (repo *postgresRepository) GetFriendsLeaderboard(ctx context.Context, board string, userID string) ([]LeaderboardEntry, error) {
+	query := "select e.board, e.userid, u.username, e.score, rank() over (order by e.score desc) as rank, e.computedat " +
+		"from leaderboardentries e join users u on u.id = e.userid where e.board = $1 and (e.userid = $2 or e.userid in " +
+		"(select friendid from friendships where userid = $2 and status = $3)) order by rank, u.username"
+	entries := []LeaderboardEntry{}
+	err := repo.db.SelectContext(ctx, &entries, query, board, userID, FriendshipAccepted)
+	return entries, err
+}
+
+// GetPrivacySettings returns the privacy settings of the user
+func (repo *postgresRepository) GetPrivacySettings(ctx context.Context, userID string) (*PrivacySettings, error) {
+	query := "select * from privacysettings where userid = $1"
+	settings := &PrivacySettings{}
+	err := repo.db.GetContext(ctx, settings, query, userID)
+	return settings, err
+}
+
+// UpdatePrivacySettings inserts or updates the privacy settings of the user. Hiding from
+// leaderboards removes the user's entries right away instead of at the next refresh.
+func (repo *postgresRepository) UpdatePrivacySettings(ctx context.Context, settings *PrivacySettings) error {
+	settings.UpdatedAt = time.Now()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "insert into privacysettings(userid, hidefromleaderboards, updatedat) values($1, $2, $3) " +
+		"on conflict(userid) do update set hidefromleaderboards = excluded.hidefromleaderboards, updatedat = excluded.updatedat"
+	if _, err := tx.ExecContext(ctx, query, settings.UserID, settings.HideFromLeaderboards, settings.UpdatedAt); err != nil {
+		return err
+	}
+	if settings.HideFromLeaderboards {
+		if _, err := tx.ExecContext(ctx, "delete from leaderboardentries where userid = $1", settings.UserID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetFriendship returns the friendship or friend request from the user to the friend
+func (repo *postgresRepository) GetFriendship(ctx context.Context, userID string, friendID string) (*Friendship, error) {
+	query := "select * from friendships where userid = $1 and friendid = $2"
+	friendship := &Friendship{}
+	err := repo.db.GetContext(ctx, friendship, query, userID, friendID)
+	return friendship, err
+}
+
+// CountFriendships returns the number of friends and sent friend requests of the user
+func (repo *postgresRepository) CountFriendships(ctx context.Context, userID string) (int, error) {
+	query := "select count(*) from friendships where userid = $1"
+	var count int
+	err := repo.db.GetContext(ctx, &count, query, userID)
+	return count, err
+}
+
+// RequestFriendship inserts a pending friend request, sql.ErrNoRows when one exists already
+func (repo *postgresRepository) RequestFriendship(ctx context.Context, friendship *Friendship) error {
+	friendship.Status = FriendshipPending
+	friendship.CreatedAt = time.Now()
+	friendship.UpdatedAt = friendship.CreatedAt
+	query := "insert into friendships(userid, friendid, status, createdat, updatedat) values($1, $2, $3, $4, $5) " +
+		"on conflict (userid, friendid) do nothing"
+	result, err := repo.db.ExecContext(ctx, query,
+		friendship.UserID,
+		friendship.FriendID,
+		friendship.Status,
+		friendship.CreatedAt,
+		friendship.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result)
+}
+
+// AcceptFriendship accepts the pending request sent by the friend to the user and stores the
+// friendship in both directions. sql.ErrNoRows means there is no pending request.
+func (repo *postgresRepository) AcceptFriendship(ctx context.Context, userID string, friendID string) error {
+	now := time.Now()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "update friendships set status = $1, updatedat = $2 where userid = $3 and friendid = $4 and status = $5"
+	result, err := tx.ExecContext(ctx, query, FriendshipAccepted, now, friendID, userID, FriendshipPending)
+	if err != nil {
+		return err
+	}
+	if err := expectOneRow(result); err != nil {
+		return err
+	}
+	query = "insert into friendships(userid, friendid, status, createdat, updatedat) values($1, $2, $3, $4, $4) " +
+		"on conflict (userid, friendid) do update set status = excluded.status, updatedat = excluded.updatedat"
+	if _, err := tx.ExecContext(ctx, query, userID, friendID, FriendshipAccepted, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteFriendship removes the friendship or friend requests between the users,
+// sql.ErrNoRows when there is none
+func (repo *postgresRepository) DeleteFriendship(ctx context.Context, userID string, friendID string) error {
+	query := "delete from friendships where (userid = $1 and friendid = $2) or (userid = $2 and friendid = $1)"
+	result, err := repo.db.ExecContext(ctx, query, userID, friendID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetFriends returns the friends of the user with the friend requests sent and received
+func (repo *postgresRepository) GetFriends(ctx context.Context, userID string) ([]Friend, error) {
+	query := "select u.id as userid, u.username, f.status, f.userid <> $1 as incoming, f.createdat from friendships f " +
+		"join users u on u.id = case when f.userid = $1 then f.friendid else f.userid end " +
+		"where f.userid = $1 or (f.friendid = $1 and f.status = $2) order by f.status, u.username"
+	friends := []Friend{}
+	err := repo.db.SelectContext(ctx, &friends, query, userID, FriendshipPending)
+	return friends, err
+}
+
+// GetEarnScore returns the earn score
+func (repo *postgresRepository) GetEarnScore(ctx context.Context, userID string) (*EarnScore, error) {
+	query := "select * from earnscores where userid = $1"
+	earnScore := &EarnScore{}
+	err := repo.db.GetContext(ctx, earnScore, query, userID)
+	return earnScore, err
+}
+
+// CreateEmailChange stores a new pending email change and cancels the older pending ones of the user
+func (repo *postgresRepository) CreateEmailChange(ctx context.Context, change *EmailChange) error {
+	change.ID = uuid.NewV4().String()
+	change.Status = EmailChangePending
+	change.CreatedAt = time.Now()
+	change.UpdatedAt = time.Now()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "update emailchanges set status = $1, updatedat = $2 where userid = $3 and status = $4"
+	if _, err := tx.ExecContext(ctx, query, EmailChangeCancelled, change.UpdatedAt, change.UserID, EmailChangePending); err != nil {
+		return err
+	}
+	query = "insert into emailchanges(id, userid, oldemail, newemail, code, expiresat, status, createdat, updatedat) values($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+	_, err = tx.ExecContext(ctx, query,
+		change.ID,
+		change.UserID,
+		change.OldEmail,
+		change.NewEmail,
+		change.Code,
+		change.ExpiresAt,
+		change.Status,
+		change.CreatedAt,
+		change.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetPendingEmailChange returns the pending email change of the user
+func (repo *postgresRepository) GetPendingEmailChange(ctx context.Context, userID string) (*EmailChange, error) {
+	query := "select * from emailchanges where userid = $1 and status = $2 order by createdat desc limit 1"
+	change := &EmailChange{}
+	err := repo.db.GetContext(ctx, change, query, userID, EmailChangePending)
+	return change, err
+}
+
+// ConfirmEmailChange updates users.email and profiles.email in one transaction
+// and stores the undo token for the old address
+func (repo *postgresRepository) ConfirmEmailChange(ctx context.Context, change *EmailChange) error {
+	change.Status = EmailChangeConfirmed
+	change.UpdatedAt = time.Now()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "update users set email = $1, verified = true, updatedat = $2 where id = $3 and email = $4"
+	result, err := tx.ExecContext(ctx, query, change.NewEmail, change.UpdatedAt, change.UserID, change.OldEmail)
+	if err != nil {
+		return err
+	}
+	if err := expectOneRow(result); err != nil {
+		return err
+	}
+	query = "update profiles set email = $1, updatedat = $2 where userid = $3"
+	if _, err := tx.ExecContext(ctx, query, change.NewEmail, change.UpdatedAt, change.UserID); err != nil {
+		return err
+	}
+	query = "update emailchanges set status = $1, undotokenhash = $2, undoexpiresat = $3, updatedat = $4 where id = $5"
+	_, err = tx.ExecContext(ctx, query, change.Status, change.UndoTokenHash, change.UndoExpiresAt, change.UpdatedAt, change.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetEmailChangeByUndoToken returns the confirmed email change with the given undo token hash
+func (repo *postgresRepository) GetEmailChangeByUndoToken(ctx context.Context, undoTokenHash string) (*EmailChange, error) {
+	query := "select * from emailchanges where undotokenhash = $1 and status = $2"
+	change := &EmailChange{}
+	err := repo.db.GetContext(ctx, change, query, undoTokenHash, EmailChangeConfirmed)
+	return change, err
+}
+
+// UndoEmailChange restores the old email and replaces the token hash so every session is signed out
+func (repo *postgresRepository) UndoEmailChange(ctx context.Context, change *EmailChange, tokenHash string) error {
+	change.Status = EmailChangeUndone
+	change.UpdatedAt = time.Now()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "update users set email = $1, tokenhash = $2, updatedat = $3 where id = $4 and email = $5"
+	result, err := tx.ExecContext(ctx, query, change.OldEmail, tokenHash, change.UpdatedAt, change.UserID, change.NewEmail)
+	if err != nil {
+		return err
+	}
+	if err := expectOneRow(result); err != nil {
+		return err
+	}
+	query = "update profiles set email = $1, updatedat = $2 where userid = $3"
+	if _, err := tx.ExecContext(ctx, query, change.OldEmail, change.UpdatedAt, change.UserID); err != nil {
+		return err
+	}
+	query = "update emailchanges set status = $1, updatedat = $2 where id = $3"
+	if _, err := tx.ExecContext(ctx, query, change.Status, change.UpdatedAt, change.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetIdentity returns the identity with the given provider and subject
+func (repo *postgresRepository) GetIdentity(ctx context.Context, provider string, subject string) (*Identity, error) {
+	query := "select * from identities where provider = $1 and subject = $2"
+	identity := &Identity{}
+	err := repo.db.GetContext(ctx, identity, query, provider, subject)
+	return identity, err
+}
+
+// CreateIdentity links the provider identity to identity.UserID
+func (repo *postgresRepository) CreateIdentity(ctx context.Context, identity *Identity) error {
+	return insertIdentity(ctx, repo.db, identity)
+}
+
+// CreateUserWithIdentity inserts the user and its identity in one transaction
+func (repo *postgresRepository) CreateUserWithIdentity(ctx context.Context, user *User, identity *Identity) error {
+	user.ID = uuid.NewV4().String()
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "insert into users (id, email, username, password, tokenhash, verified, createdat, updatedat) values ($1, $2, $3, $4, $5, $6, $7, $8)"
+	_, err = tx.ExecContext(ctx, query, user.ID, user.Email, user.Username, user.Password, user.TokenHash, user.Verified, user.CreatedAt, user.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	identity.UserID = user.ID
+	if err := insertIdentity(ctx, tx, identity); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetIdentitiesByUserID returns the identities linked to the user, oldest first
+func (repo *postgresRepository) GetIdentitiesByUserID(ctx context.Context, userID string) ([]Identity, error) {
+	query := "select * from identities where userid = $1 order by createdat"
+	identities := []Identity{}
+	err := repo.db.SelectContext(ctx, &identities, query, userID)
+	return identities, err
+}
+
+// DeleteIdentity deletes the identity of the user. It returns sql.ErrNoRows when the
+// identity does not exist or when the user would be left without a way to sign in.
+func (repo *postgresRepository) DeleteIdentity(ctx context.Context, userID string, identityID string) error {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockSignInMethods(ctx, tx, userID); err != nil {
+		return err
+	}
+	query := "delete from identities where id = $1 and userid = $2 and (" +
+		"exists (select 1 from users where id = $2 and password <> '') or " +
+		"exists (select 1 from identities where userid = $2 and id <> $1))"
+	result, err := tx.ExecContext(ctx, query, identityID, userID)
+	if err != nil {
+		return err
+	}
+	if err := expectOneRow(result); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemovePassword clears the password of the user. It returns sql.ErrNoRows when the
+// user has no linked identity to sign in with instead.
+func (repo *postgresRepository) RemovePassword(ctx context.Context, userID string) error {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockSignInMethods(ctx, tx, userID); err != nil {
+		return err
+	}
+	query := "update users set password = '', updatedat = $1 where id = $2 and password <> '' and " +
+		"exists (select 1 from identities where userid = $2)"
+	result, err := tx.ExecContext(ctx, query, time.Now(), userID)
+	if err != nil {
+		return err
+	}
+	if err := expectOneRow(result); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// lockSignInMethods locks the user and its identities until the transaction ends, so
+// concurrent unlinks are checked one after the other and cannot remove the last method.
+func lockSignInMethods(ctx context.Context, tx *sqlx.Tx, userID string) error {
+	var locked string
+	err := tx.GetContext(ctx, &locked, "select id from users where id = $1 for update", userID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "select id from identities where userid = $1 for update", userID)
+	return err
+}
+
+// CreatePersonalAccessToken inserts the given personal access token
+func (repo *postgresRepository) CreatePersonalAccessToken(ctx context.Context, token *PersonalAccessToken) error {
+	token.ID = uuid.NewV4().String()
+	token.CreatedAt = time.Now()
+	query := "insert into personalaccesstokens(id, userid, name, tokenhash, prefix, scopes, expiresat, createdat) values($1, $2, $3, $4, $5, $6, $7, $8)"
+	_, err := repo.db.ExecContext(ctx, query,
+		token.ID,
+		token.UserID,
+		token.Name,
+		token.TokenHash,
+		token.Prefix,
+		token.Scopes,
+		token.ExpiresAt,
+		token.CreatedAt)
+	return err
+}
+
+// GetPersonalAccessTokenByHash returns the personal access token with the given hash
+func (repo *postgresRepository) GetPersonalAccessTokenByHash(ctx context.Context, tokenHash string) (*PersonalAccessToken, error) {
+	query := "select * from personalaccesstokens where tokenhash = $1"
+	token := &PersonalAccessToken{}
+	err := repo.db.GetContext(ctx, token, query, tokenHash)
+	return token, err
+}
+
+// GetPersonalAccessTokensByUserID returns the personal access tokens of the user, newest first
+func (repo *postgresRepository) GetPersonalAccessTokensByUserID(ctx context.Context, userID string) ([]PersonalAccessToken, error) {
+	query := "select * from personalaccesstokens where userid = $1 order by createdat desc"
+	tokens := []PersonalAccessToken{}
+	err := repo.db.SelectContext(ctx, &tokens, query, userID)
+	return tokens, err
+}
+
+// CountActivePersonalAccessTokens returns the number of usable personal access tokens of the user
+func (repo *postgresRepository) CountActivePersonalAccessTokens(ctx context.Context, userID string) (int, error) {
+	query := "select count(*) from personalaccesstokens where userid = $1 and revokedat is null and (expiresat is null or expiresat > $2)"
+	var count int
+	err := repo.db.GetContext(ctx, &count, query, userID, time.Now())
+	return count, err
+}
+
+// RevokePersonalAccessToken revokes the token. It returns sql.ErrNoRows when the user
+// has no such token or it was already revoked.
+func (repo *postgresRepository) RevokePersonalAccessToken(ctx context.Context, userID string, id string) error {
+	query := "update personalaccesstokens set revokedat = $1 where id = $2 and userid = $3 and revokedat is null"
+	result, err := repo.db.ExecContext(ctx, query, time.Now(), id, userID)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result)
+}
+
+// TouchPersonalAccessToken sets the last used time, at most once a minute per token
+func (repo *postgresRepository) TouchPersonalAccessToken(ctx context.Context, id string) error {
+	now := time.Now()
+	query := "update personalaccesstokens set lastusedat = $1 where id = $2 and (lastusedat is null or lastusedat < $3)"
+	_, err := repo.db.ExecContext(ctx, query, now, id, now.Add(-time.Minute))
+	return err
+}
+
+// CreateAPIKey inserts the API key
+func (repo *postgresRepository) CreateAPIKey(ctx context.Context, key *APIKey) error {
+	key.ID = uuid.NewV4().String()
+	key.CreatedAt = time.Now()
+	query := "insert into apikeys(id, name, keyhash, prefix, scopes, ratelimit, createdat) values($1, $2, $3, $4, $5, $6, $7)"
+	_, err := repo.db.ExecContext(ctx, query,
+		key.ID,
+		key.Name,
+		key.KeyHash,
+		key.Prefix,
+		key.Scopes,
+		key.RateLimit,
+		key.CreatedAt)
+	return err
+}
+
+// GetAPIKeyByHash returns the API key with the given hash
+func (repo *postgresRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*APIKey, error) {
+	query := "select * from apikeys where keyhash = $1"
+	key := &APIKey{}
+	err := repo.db.GetContext(ctx, key, query, keyHash)
+	return key, err
+}
+
+// GetAPIKeys returns every API key, newest first
+func (repo *postgresRepository) GetAPIKeys(ctx context.Context) ([]APIKey, error) {
+	query := "select * from apikeys order by createdat desc"
+	var keys []APIKey
+	err := repo.db.SelectContext(ctx, &keys, query)
+	return keys, err
+}
+
+// RotateAPIKey inserts the new key with the name, scopes and rate limit of the active key
+// and revokes the old one in the same transaction.
+func (repo *postgresRepository) RotateAPIKey(ctx context.Context, id string, key *APIKey) error {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	old := &APIKey{}
+	query := "select * from apikeys where id = $1 and revokedat is null for update"
+	if err := tx.GetContext(ctx, old, query, id); err != nil {
+		return err
+	}
+	key.ID = uuid.NewV4().String()
+	key.Name = old.Name
+	key.Scopes = old.Scopes
+	key.RateLimit = old.RateLimit
+	key.CreatedAt = time.Now()
+	query = "insert into apikeys(id, name, keyhash, prefix, scopes, ratelimit, createdat) values($1, $2, $3, $4, $5, $6, $7)"
+	_, err = tx.ExecContext(ctx, query, key.ID, key.Name, key.KeyHash, key.Prefix, key.Scopes, key.RateLimit, key.CreatedAt)
+	if err != nil {
+		return err
+	}
+	query = "update apikeys set revokedat = $1 where id = $2"
+	if _, err := tx.ExecContext(ctx, query, key.CreatedAt, old.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RevokeAPIKey sets the revoked time of an active API key
+func (repo *postgresRepository) RevokeAPIKey(ctx context.Context, id string) error {
+	query := "update apikeys set revokedat = $1 where id = $2 and revokedat is null"
+	result, err := repo.db.ExecContext(ctx, query, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result)
+}
+
+// TouchAPIKey sets the last used time, at most once a minute per key
+func (repo *postgresRepository) TouchAPIKey(ctx context.Context, id string) error {
+	now := time.Now()
+	query := "update apikeys set lastusedat = $1 where id = $2 and (lastusedat is null or lastusedat < $3)"
+	_, err := repo.db.ExecContext(ctx, query, now, id, now.Add(-time.Minute))
+	return err
+}
+
+// CreateOAuthClient inserts the OAuth client
+func (repo *postgresRepository) CreateOAuthClient(ctx context.Context, client *OAuthClient) error {
+	client.ID = uuid.NewV4().String()
+	client.CreatedAt = time.Now()
+	query := "insert into oauthclients(id, name, secrethash, redirecturis, scopes, createdat) values($1, $2, $3, $4, $5, $6)"
+	_, err := repo.db.ExecContext(ctx, query, client.ID, client.Name, client.SecretHash, client.RedirectURIs, client.Scopes, client.CreatedAt)
+	return err
+}
+
+// GetOAuthClient returns the OAuth client with the given client id
+func (repo *postgresRepository) GetOAuthClient(ctx context.Context, id string) (*OAuthClient, error) {
+	query := "select * from oauthclients where id = $1"
+	client := &OAuthClient{}
+	err := repo.db.GetContext(ctx, client, query, id)
+	return client, err
+}
+
+// UpsertOAuthConsent inserts the consent of the user for the client, or replaces its
+// scopes and restores it when it was revoked
+func (repo *postgresRepository) UpsertOAuthConsent(ctx context.Context, consent *OAuthConsent) error {
+	consent.ID = uuid.NewV4().String()
+	consent.CreatedAt = time.Now()
+	consent.UpdatedAt = time.Now()
+	query := `insert into oauthconsents(id, userid, clientid, scopes, createdat, updatedat) values($1, $2, $3, $4, $5, $6)
+		on conflict (userid, clientid) do update set scopes = excluded.scopes, revokedat = null, updatedat = excluded.updatedat`
+	_, err := repo.db.ExecContext(ctx, query, consent.ID, consent.UserID, consent.ClientID, consent.Scopes, consent.CreatedAt, consent.UpdatedAt)
+	return err
+}
+
+// GetOAuthConsent returns the consent of the user for the client
+func (repo *postgresRepository) GetOAuthConsent(ctx context.Context, userID string, clientID string) (*OAuthConsent, error) {
+	query := "select * from oauthconsents where userid = $1 and clientid = $2"
+	consent := &OAuthConsent{}
+	err := repo.db.GetContext(ctx, consent, query, userID, clientID)
+	return consent, err
+}
+
+// GetOAuthConsentsByUserID returns the active consents of the user, newest first
+func (repo *postgresRepository) GetOAuthConsentsByUserID(ctx context.Context, userID string) ([]OAuthConsent, error) {
+	query := "select * from oauthconsents where userid = $1 and revokedat is null order by updatedat desc"
+	var consents []OAuthConsent
+	err := repo.db.SelectContext(ctx, &consents, query, userID)
+	return consents, err
+}
+
+// RevokeOAuthConsent revokes the active consent of the user for the client together with
+// the refresh tokens the client holds for the user
+func (repo *postgresRepository) RevokeOAuthConsent(ctx context.Context, userID string, clientID string) error {
+	now := time.Now()
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "update oauthconsents set revokedat = $1, updatedat = $1, generation = generation + 1 where userid = $2 and clientid = $3 and revokedat is null"
+	result, err := tx.ExecContext(ctx, query, now, userID, clientID)
+	if err != nil {
+		return err
+	}
+	if err := expectOneRow(result); err != nil {
+		return err
+	}
+	query = "update oauthrefreshtokens set revokedat = $1 where userid = $2 and clientid = $3 and revokedat is null"
+	if _, err := tx.ExecContext(ctx, query, now, userID, clientID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateOAuthAuthorizationCode inserts the authorization code
+func (repo *postgresRepository) CreateOAuthAuthorizationCode(ctx context.Context, code *OAuthAuthorizationCode) error {
+	code.CreatedAt = time.Now()
+	query := "insert into oauthcodes(codehash, clientid, userid, redirecturi, scopes, codechallenge, expiresat, createdat) values($1, $2, $3, $4, $5, $6, $7, $8)"
+	_, err := repo.db.ExecContext(ctx, query, code.CodeHash, code.ClientID, code.UserID, code.RedirectURI, code.Scopes, code.CodeChallenge, code.ExpiresAt, code.CreatedAt)
+	return err
+}
+
+// RedeemOAuthAuthorizationCode marks the code used and returns it, sql.ErrNoRows when it
+// does not exist or was already used
+func (repo *postgresRepository) RedeemOAuthAuthorizationCode(ctx context.Context, codeHash string) (*OAuthAuthorizationCode, error) {
+	query := "update oauthcodes set usedat = $1 where codehash = $2 and usedat is null returning *"
+	code := &OAuthAuthorizationCode{}
+	err := repo.db.GetContext(ctx, code, query, time.Now(), codeHash)
+	return code, err
+}
+
+// CreateOAuthDeviceCode inserts the device authorization request
+func (repo *postgresRepository) CreateOAuthDeviceCode(ctx context.Context, deviceCode *OAuthDeviceCode) error {
+	deviceCode.Status = DeviceCodePending
+	deviceCode.CreatedAt = time.Now()
+	query := "insert into oauthdevicecodes(devicecodehash, usercode, clientid, scopes, status, pollinterval, expiresat, createdat) values($1, $2, $3, $4, $5, $6, $7, $8)"
+	_, err := repo.db.ExecContext(ctx, query, deviceCode.DeviceCodeHash, deviceCode.UserCode, deviceCode.ClientID, deviceCode.Scopes, deviceCode.Status, deviceCode.PollInterval, deviceCode.ExpiresAt, deviceCode.CreatedAt)
+	return err
+}
+
+// GetOAuthDeviceCodeByHash returns the device authorization request with the given device code hash
+func (repo *postgresRepository) GetOAuthDeviceCodeByHash(ctx context.Context, deviceCodeHash string) (*OAuthDeviceCode, error) {
+	query := "select * from oauthdevicecodes where devicecodehash = $1"
+	deviceCode := &OAuthDeviceCode{}
+	err := repo.db.GetContext(ctx, deviceCode, query, deviceCodeHash)
+	return deviceCode, err
+}
+
+// GetOAuthDeviceCodeByUserCode returns the device authorization request with the given user code
+func (repo *postgresRepository) GetOAuthDeviceCodeByUserCode(ctx context.Context, userCode string) (*OAuthDeviceCode, error) {
+	query := "select * from oauthdevicecodes where usercode = $1"
+	deviceCode := &OAuthDeviceCode{}
+	err := repo.db.GetContext(ctx, deviceCode, query, userCode)
+	return deviceCode, err
+}
+
+// DecideOAuthDeviceCode sets the status and user of a pending device authorization request
+func (repo *postgresRepository) DecideOAuthDeviceCode(ctx context.Context, deviceCodeHash string, userID string, status string) error {
+	query := "update oauthdevicecodes set status = $1, userid = $2 where devicecodehash = $3 and status = $4"
+	result, err := repo.db.ExecContext(ctx, query, status, userID, deviceCodeHash, DeviceCodePending)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result)
+}
+
+// TouchOAuthDeviceCode sets the last poll time of the device authorization request
+func (repo *postgresRepository) TouchOAuthDeviceCode(ctx context.Context, deviceCodeHash string) error {
+	query := "update oauthdevicecodes set lastpolledat = $1 where devicecodehash = $2"
+	_, err := repo.db.ExecContext(ctx, query, time.Now(), deviceCodeHash)
+	return err
+}
+
+// RedeemOAuthDeviceCode deletes the approved device authorization request and returns it,
+// sql.ErrNoRows when it is not approved or was already redeemed
+func (repo *postgresRepository) RedeemOAuthDeviceCode(ctx context.Context, deviceCodeHash string) (*OAuthDeviceCode, error) {
+	query := "delete from oauthdevicecodes where devicecodehash = $1 and status = $2 returning *"
+	deviceCode := &OAuthDeviceCode{}
+	err := repo.db.GetContext(ctx, deviceCode, query, deviceCodeHash, DeviceCodeApproved)
+	return deviceCode, err
+}
+
+// CreateOAuthRefreshToken inserts the OAuth refresh token
+func (repo *postgresRepository) CreateOAuthRefreshToken(ctx context.Context, token *OAuthRefreshToken) error {
+	return insertOAuthRefreshToken(ctx, repo.db, token)
+}
+
+// GetOAuthRefreshTokenByHash returns the OAuth refresh token with the given hash
+func (repo *postgresRepository) GetOAuthRefreshTokenByHash(ctx context.Context, tokenHash string) (*OAuthRefreshToken, error) {
+	query := "select * from oauthrefreshtokens where tokenhash = $1"
+	token := &OAuthRefreshToken{}
+	err := repo.db.GetContext(ctx, token, query, tokenHash)
+	return token, err
+}
+
+// RotateOAuthRefreshToken revokes the active refresh token with the old hash and inserts its
+// replacement. sql.ErrNoRows means the old token was used concurrently.
+func (repo *postgresRepository) RotateOAuthRefreshToken(ctx context.Context, oldTokenHash string, token *OAuthRefreshToken) error {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "update oauthrefreshtokens set revokedat = $1 where tokenhash = $2 and revokedat is null"
+	result, err := tx.ExecContext(ctx, query, time.Now(), oldTokenHash)
+	if err != nil {
+		return err
+	}
+	if err := expectOneRow(result); err != nil {
+		return err
+	}
+	if err := insertOAuthRefreshToken(ctx, tx, token); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertOAuthRefreshToken inserts the refresh token with the given executor, a db or a transaction
+func insertOAuthRefreshToken(ctx context.Context, exec sqlx.ExecerContext, token *OAuthRefreshToken) error {
+	token.ID = uuid.NewV4().String()
+	token.CreatedAt = time.Now()
+	query := "insert into oauthrefreshtokens(id, tokenhash, clientid, userid, scopes, expiresat, createdat) values($1, $2, $3, $4, $5, $6, $7)"
+	_, err := exec.ExecContext(ctx, query, token.ID, token.TokenHash, token.ClientID, token.UserID, token.Scopes, token.ExpiresAt, token.CreatedAt)
+	return err
+}
+
+// DeleteExpiredOAuthGrants deletes expired authorization codes, device codes and refresh tokens
+func (repo *postgresRepository) DeleteExpiredOAuthGrants(ctx context.Context) error {
+	now := time.Now()
+	for _, query := range []string{
+		"delete from oauthcodes where expiresat < $1",
+		"delete from oauthdevicecodes where expiresat < $1",
+		"delete from oauthrefreshtokens where expiresat < $1",
+	} {
+		if _, err := repo.db.ExecContext(ctx, query, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyPhone sets the phone of the user's profile and marks it verified. Profiles are not
+// created at signup, so a missing profile is inserted.
+func (repo *postgresRepository) VerifyPhone(ctx context.Context, userID string, email string, phone string) error {
+	now := time.Now()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "update profiles set phone = $1, phoneverified = true, updatedat = $2 where userid = $3"
+	result, err := tx.ExecContext(ctx, query, phone, now, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		query = "insert into profiles(id, userid, email, phone, phoneverified, createdat, updatedat) values($1, $2, $3, $4, true, $5, $6)"
+		if _, err := tx.ExecContext(ctx, query, uuid.NewV4().String(), userID, email, phone, now, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetProfileByVerifiedPhone returns the profile that verified the given phone
+func (repo *postgresRepository) GetProfileByVerifiedPhone(ctx context.Context, phone string) (*ProfileData, error) {
+	query := "select * from profiles where phone = $1 and phoneverified = true"
+	profile := &ProfileData{}
+	err := repo.db.GetContext(ctx, profile, query, phone)
+	return profile, err
+}
+
+// CreateGuestUser inserts a guest user. Guests have no password and a placeholder
+// email on the reserved guest domain, as emails are required and unique.
+func (repo *postgresRepository) CreateGuestUser(ctx context.Context, user *User) error {
+	user.ID = uuid.NewV4().String()
+	user.Email = "guest-" + user.ID + "@" + GuestEmailDomain
+	user.Password = ""
+	user.Role = RoleGuest
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+	query := "insert into users (id, email, username, password, tokenhash, role, devicesecrethash, createdat, updatedat) values ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+	_, err := repo.db.ExecContext(ctx, query, user.ID, user.Email, user.Username, user.Password, user.TokenHash, user.Role, user.DeviceSecretHash, user.CreatedAt, user.UpdatedAt)
+	return err
+}
+
+// UpgradeGuest sets the email, password and verified status of a guest user and makes it
+// a full user. The user id is kept so every score and session stays with the account.
+func (repo *postgresRepository) UpgradeGuest(ctx context.Context, user *User, identity *Identity) error {
+	user.Role = RoleUser
+	user.DeviceSecretHash = ""
+	user.UpdatedAt = time.Now()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "update users set email = $1, password = $2, verified = $3, role = $4, devicesecrethash = $5, updatedat = $6 where id = $7 and role = $8"
+	result, err := tx.ExecContext(ctx, query, user.Email, user.Password, user.Verified, user.Role, user.DeviceSecretHash, user.UpdatedAt, user.ID, RoleGuest)
+	if err != nil {
+		return err
+	}
+	if err := expectOneRow(result); err != nil {
+		return err
+	}
+	if identity != nil {
+		identity.UserID = user.ID
+		if err := insertIdentity(ctx, tx, identity); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// insertIdentity inserts the identity with the given executor, a db or a transaction
+func insertIdentity(ctx context.Context, exec sqlx.ExecerContext, identity *Identity) error {
+	identity.ID = uuid.NewV4().String()
+	identity.CreatedAt = time.Now()
+	identity.UpdatedAt = time.Now()
+	query := "insert into identities(id, userid, provider, subject, email, createdat, updatedat) values($1, $2, $3, $4, $5, $6, $7)"
+	_, err := exec.ExecContext(ctx, query,
+		identity.ID,
+		identity.UserID,
+		identity.Provider,
+		identity.Subject,
+		identity.Email,
+		identity.CreatedAt,
+		identity.UpdatedAt)
+	return err
+}
+
+// expectOneRow returns sql.ErrNoRows when a guarded update did not match exactly one row
+func expectOneRow(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return sql.ErrNoRows
+	}
+	return nil
 }
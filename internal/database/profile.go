@@ -10,7 +10,7 @@ type ProfileData struct {
 	FirstName string    `json:"firstname" sql:"firstname"`
 	LastName  string    `json:"lastname" sql:"lastname"`
 	AvatarURL string    `json:"avatar_url" sql:"avatarurl"`
-	Phone     string    `json:"phone"sql:"phone"`
+	Phone     string    `json:"phone" sql:"phone"`
 	Street    string    `json:"street" sql:"street"`
 	City      string    `json:"city" sql:"city"`
 	State     string    `json:"state" sql:"state"`
@@ -18,4 +18,8 @@ type ProfileData struct {
 	Country   string    `json:"country" sql:"country"`
 	CreatedAt time.Time `json:"createdat" sql:"createdat"`
 	UpdatedAt time.Time `json:"updatedat" sql:"updatedat"`
+	// PhoneVerified is set once the user confirmed a code sent to Phone
+	PhoneVerified bool `json:"phone_verified" sql:"phoneverified"`
+	// Timezone is the IANA timezone statistics are reported in, UTC when empty
+	Timezone string `json:"timezone" sql:"timezone"`
 }
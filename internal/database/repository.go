@@ -1,6 +1,9 @@
 package database
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type UserRepository interface {
 	// CreateUser Create  new user
@@ -29,6 +32,8 @@ type UserRepository interface {
 	UpdateProfile(ctx context.Context, profile *ProfileData) error
 	// UpdatePassword Update password
 	UpdatePassword(ctx context.Context, userID string, password string, tokenHash string) error
+	// UpdatePasswordHash Update password hash without invalidating tokens
+	UpdatePasswordHash(ctx context.Context, userID string, password string) error
 	// GetListOfPasswords Get list of passwords
 	GetListOfPasswords(ctx context.Context, userID string) ([]string, error)
 	// InsertListOfPasswords Update password into list of passwords
@@ -39,10 +44,202 @@ type UserRepository interface {
 	InsertOrUpdateLimitData(ctx context.Context, limitData *LimitData, isInsert bool) error
 	// ClearAllLimitData Clear all limit data
 	ClearAllLimitData(ctx context.Context) error
-	// GetMultiRatioData Get multi ratio data
-	GetMultiRatioData(ctx context.Context) (*MultiRatioData, error)
-	// InsertEarnScore Insert earn score
-	InsertEarnScore(ctx context.Context, earnScore *EarnScore) error
+	// GetMultiRatioData Get the multi ratio version in effect at a time
+	GetMultiRatioData(ctx context.Context, at time.Time) (*MultiRatioData, error)
+	// GetMultiRatios Get every multi ratio version
+	GetMultiRatios(ctx context.Context) ([]MultiRatioData, error)
+	// PublishMultiRatio Insert the ratios as the next version
+	PublishMultiRatio(ctx context.Context, ratio *MultiRatioData) error
+	// RecordFocusSession Store a completed focus session and add its scores to the earn score
+	RecordFocusSession(ctx context.Context, session *FocusSession, tagIDs []string) error
+	// GetFocusMinutes Sum the focus minutes of a user within a time range
+	GetFocusMinutes(ctx context.Context, userID string, from time.Time, to time.Time) (int, error)
+	// GetFocusTotals Count the focus sessions and minutes of a user
+	GetFocusTotals(ctx context.Context, userID string) (*FocusTotals, error)
+	// GetUserAchievements Get the achievements unlocked by a user
+	GetUserAchievements(ctx context.Context, userID string) ([]UserAchievement, error)
+	// UnlockAchievement Record an achievement and credit its reward, false when it was unlocked already
+	UnlockAchievement(ctx context.Context, achievement *UserAchievement, reward *EarnScore) (bool, error)
+	// GetPlants Get the plants of a user
+	GetPlants(ctx context.Context, userID string) ([]Plant, error)
+	// GetPlant Get a plant of a user
+	GetPlant(ctx context.Context, userID string, plantID string) (*Plant, error)
+	// PlantSeed Debit the seed cost and insert the plant
+	PlantSeed(ctx context.Context, plant *Plant, cost *EarnScore) error
+	// WaterPlant Debit the water and give it to the plant
+	WaterPlant(ctx context.Context, plant *Plant, water int) error
+	// GrowPlant Debit the cost and move the plant to its next stage
+	GrowPlant(ctx context.Context, plant *Plant, cost *EarnScore) error
+	// GetShopItems Get the shop items, deleted items only when asked
+	GetShopItems(ctx context.Context, includeDeleted bool) ([]ShopItem, error)
+	// GetShopItem Get a shop item, deleted or not
+	GetShopItem(ctx context.Context, id string) (*ShopItem, error)
+	// CreateShopItem Insert a shop item
+	CreateShopItem(ctx context.Context, item *ShopItem) error
+	// UpdateShopItem Replace a shop item that is not deleted
+	UpdateShopItem(ctx context.Context, item *ShopItem) error
+	// DeleteShopItem Soft delete a shop item
+	DeleteShopItem(ctx context.Context, id string) error
+	// Purchase Record a purchase, debit its price and grant the item, streak freezes up to maxFreezes
+	Purchase(ctx context.Context, purchase *Purchase, item *ShopItem, maxFreezes int) error
+	// GetPurchaseByKey Get the purchase of a user with the idempotency key
+	GetPurchaseByKey(ctx context.Context, userID string, key string) (*Purchase, error)
+	// GetInventory Get the items a user owns
+	GetInventory(ctx context.Context, userID string) ([]InventoryItem, error)
+	// SetGoal Insert a goal, taking effect over the previous goal of its period
+	SetGoal(ctx context.Context, goal *Goal) error
+	// GetGoals Get the goal history of a user
+	GetGoals(ctx context.Context, userID string) ([]Goal, error)
+	// GetGoalBonuses Get the goal bonuses of a user credited for periods starting within a date range
+	GetGoalBonuses(ctx context.Context, userID string, from time.Time, to time.Time) ([]GoalBonus, error)
+	// AwardGoalBonus Record a goal bonus and credit it, false when it was awarded already
+	AwardGoalBonus(ctx context.Context, bonus *GoalBonus) (bool, error)
+	// CreateTag Insert a tag
+	CreateTag(ctx context.Context, tag *Tag) error
+	// GetTags Get the tags of a user, archived included
+	GetTags(ctx context.Context, userID string) ([]Tag, error)
+	// UpdateTag Replace the name, color and archived state of a tag of a user
+	UpdateTag(ctx context.Context, tag *Tag) error
+	// CountActiveTags Count the tags of a user that are not archived
+	CountActiveTags(ctx context.Context, userID string) (int, error)
+	// GetFocusStatsByTag Aggregate the focus sessions of a user within a time range per tag
+	GetFocusStatsByTag(ctx context.Context, userID string, from time.Time, to time.Time) ([]TagFocusStats, error)
+	// CreateTask Insert a task after the other tasks of the user
+	CreateTask(ctx context.Context, task *Task) error
+	// GetTasks Get the tasks of a user with their actual pomodoros
+	GetTasks(ctx context.Context, userID string) ([]Task, error)
+	// GetTask Get a task of a user with its actual pomodoros
+	GetTask(ctx context.Context, userID string, taskID string) (*Task, error)
+	// UpdateTask Replace the fields of a task of a user
+	UpdateTask(ctx context.Context, task *Task) error
+	// DeleteTask Delete a task of a user, its focus sessions are kept
+	DeleteTask(ctx context.Context, userID string, taskID string) error
+	// ReorderTasks Set the position of the tasks of a user to their index
+	ReorderTasks(ctx context.Context, userID string, taskIDs []string) error
+	// RefreshLeaderboards Recompute every materialized leaderboard
+	RefreshLeaderboards(ctx context.Context) error
+	// GetLeaderboard Get the top entries of a board
+	GetLeaderboard(ctx context.Context, board string, limit int) ([]LeaderboardEntry, error)
+	// GetLeaderboardEntry Get the entry of a user on a board
+	GetLeaderboardEntry(ctx context.Context, board string, userID string) (*LeaderboardEntry, error)
+	// GetFriendsLeaderboard Get the entries of a user and their friends on a board, ranked among them
+	GetFriendsLeaderboard(ctx context.Context, board string, userID string) ([]LeaderboardEntry, error)
+	// GetPrivacySettings Get the privacy settings of a user
+	GetPrivacySettings(ctx context.Context, userID string) (*PrivacySettings, error)
+	// UpdatePrivacySettings Store the privacy settings of a user
+	UpdatePrivacySettings(ctx context.Context, settings *PrivacySettings) error
+	// GetFriendship Get the friendship or friend request from a user to another
+	GetFriendship(ctx context.Context, userID string, friendID string) (*Friendship, error)
+	// CountFriendships Count the friends and sent friend requests of a user
+	CountFriendships(ctx context.Context, userID string) (int, error)
+	// RequestFriendship Store a friend request
+	RequestFriendship(ctx context.Context, friendship *Friendship) error
+	// AcceptFriendship Accept the friend request sent to a user
+	AcceptFriendship(ctx context.Context, userID string, friendID string) error
+	// DeleteFriendship Remove a friendship or friend request in both directions
+	DeleteFriendship(ctx context.Context, userID string, friendID string) error
+	// GetFriends Get the friends and pending friend requests of a user
+	GetFriends(ctx context.Context, userID string) ([]Friend, error)
+	// GetStreak Get the focus streak of a user
+	GetStreak(ctx context.Context, userID string) (*Streak, error)
+	// SaveStreak Store the streak unless a later day was already stored
+	SaveStreak(ctx context.Context, streak *Streak, freezes int) error
+	// GetFocusStats Aggregate the focus sessions of a user by period in the given timezone
+	GetFocusStats(ctx context.Context, userID string, period string, timezone string, from time.Time, to time.Time) ([]FocusStats, error)
 	// GetEarnScore Get earn score
 	GetEarnScore(ctx context.Context, userID string) (*EarnScore, error)
+	// CreateEmailChange Create a pending email change, cancelling older pending ones
+	CreateEmailChange(ctx context.Context, change *EmailChange) error
+	// GetPendingEmailChange Get the pending email change of a user
+	GetPendingEmailChange(ctx context.Context, userID string) (*EmailChange, error)
+	// ConfirmEmailChange Move the user and profile to the new email
+	ConfirmEmailChange(ctx context.Context, change *EmailChange) error
+	// GetEmailChangeByUndoToken Get a confirmed email change by its undo token hash
+	GetEmailChangeByUndoToken(ctx context.Context, undoTokenHash string) (*EmailChange, error)
+	// UndoEmailChange Move the user and profile back to the old email
+	UndoEmailChange(ctx context.Context, change *EmailChange, tokenHash string) error
+	// UpdateUsername Set the username and its normalized form
+	UpdateUsername(ctx context.Context, userID string, username string, normalized string) error
+	// GetUserByUsername Get user by normalized username
+	GetUserByUsername(ctx context.Context, normalized string) (*User, error)
+	// GetIdentity Get the identity of a provider subject
+	GetIdentity(ctx context.Context, provider string, subject string) (*Identity, error)
+	// CreateIdentity Link a provider identity to an existing user
+	CreateIdentity(ctx context.Context, identity *Identity) error
+	// CreateUserWithIdentity Create a new user together with its provider identity
+	CreateUserWithIdentity(ctx context.Context, user *User, identity *Identity) error
+	// CreateGuestUser Create a guest user with a placeholder email
+	CreateGuestUser(ctx context.Context, user *User) error
+	// UpgradeGuest Turn a guest user into a full user, linking the identity when given
+	UpgradeGuest(ctx context.Context, user *User, identity *Identity) error
+	// GetIdentitiesByUserID Get the provider identities linked to a user
+	GetIdentitiesByUserID(ctx context.Context, userID string) ([]Identity, error)
+	// DeleteIdentity Unlink an identity unless it is the last sign-in method of the user
+	DeleteIdentity(ctx context.Context, userID string, identityID string) error
+	// RemovePassword Remove the password unless it is the last sign-in method of the user
+	RemovePassword(ctx context.Context, userID string) error
+	// CreatePersonalAccessToken Store a new personal access token
+	CreatePersonalAccessToken(ctx context.Context, token *PersonalAccessToken) error
+	// GetPersonalAccessTokenByHash Get a personal access token by the hash of the token
+	GetPersonalAccessTokenByHash(ctx context.Context, tokenHash string) (*PersonalAccessToken, error)
+	// GetPersonalAccessTokensByUserID Get the personal access tokens of a user
+	GetPersonalAccessTokensByUserID(ctx context.Context, userID string) ([]PersonalAccessToken, error)
+	// CountActivePersonalAccessTokens Count the tokens of a user that are neither revoked nor expired
+	CountActivePersonalAccessTokens(ctx context.Context, userID string) (int, error)
+	// RevokePersonalAccessToken Revoke a personal access token of a user
+	RevokePersonalAccessToken(ctx context.Context, userID string, id string) error
+	// TouchPersonalAccessToken Record the use of a personal access token
+	TouchPersonalAccessToken(ctx context.Context, id string) error
+	// CreateAPIKey Store a new API key
+	CreateAPIKey(ctx context.Context, key *APIKey) error
+	// GetAPIKeyByHash Get an API key by the hash of the key
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*APIKey, error)
+	// GetAPIKeys Get every API key
+	GetAPIKeys(ctx context.Context) ([]APIKey, error)
+	// RotateAPIKey Replace an active API key with a new key keeping its name, scopes and rate limit
+	RotateAPIKey(ctx context.Context, id string, key *APIKey) error
+	// RevokeAPIKey Revoke an API key
+	RevokeAPIKey(ctx context.Context, id string) error
+	// TouchAPIKey Record the use of an API key
+	TouchAPIKey(ctx context.Context, id string) error
+	// CreateOAuthClient Register a third-party OAuth client
+	CreateOAuthClient(ctx context.Context, client *OAuthClient) error
+	// GetOAuthClient Get an OAuth client by client id
+	GetOAuthClient(ctx context.Context, id string) (*OAuthClient, error)
+	// UpsertOAuthConsent Record the scopes a user granted a client
+	UpsertOAuthConsent(ctx context.Context, consent *OAuthConsent) error
+	// GetOAuthConsent Get the consent of a user for a client
+	GetOAuthConsent(ctx context.Context, userID string, clientID string) (*OAuthConsent, error)
+	// GetOAuthConsentsByUserID Get the active consents of a user
+	GetOAuthConsentsByUserID(ctx context.Context, userID string) ([]OAuthConsent, error)
+	// RevokeOAuthConsent Revoke the consent of a user for a client and its refresh tokens
+	RevokeOAuthConsent(ctx context.Context, userID string, clientID string) error
+	// CreateOAuthAuthorizationCode Store a new authorization code
+	CreateOAuthAuthorizationCode(ctx context.Context, code *OAuthAuthorizationCode) error
+	// RedeemOAuthAuthorizationCode Mark an unused authorization code used and return it
+	RedeemOAuthAuthorizationCode(ctx context.Context, codeHash string) (*OAuthAuthorizationCode, error)
+	// CreateOAuthDeviceCode Store a new device authorization request
+	CreateOAuthDeviceCode(ctx context.Context, deviceCode *OAuthDeviceCode) error
+	// GetOAuthDeviceCodeByHash Get a device authorization request by the hash of its device code
+	GetOAuthDeviceCodeByHash(ctx context.Context, deviceCodeHash string) (*OAuthDeviceCode, error)
+	// GetOAuthDeviceCodeByUserCode Get a device authorization request by its user code
+	GetOAuthDeviceCodeByUserCode(ctx context.Context, userCode string) (*OAuthDeviceCode, error)
+	// DecideOAuthDeviceCode Approve or deny a pending device authorization request
+	DecideOAuthDeviceCode(ctx context.Context, deviceCodeHash string, userID string, status string) error
+	// TouchOAuthDeviceCode Record a poll of a device authorization request
+	TouchOAuthDeviceCode(ctx context.Context, deviceCodeHash string) error
+	// RedeemOAuthDeviceCode Delete an approved device authorization request and return it
+	RedeemOAuthDeviceCode(ctx context.Context, deviceCodeHash string) (*OAuthDeviceCode, error)
+	// CreateOAuthRefreshToken Store a new OAuth refresh token
+	CreateOAuthRefreshToken(ctx context.Context, token *OAuthRefreshToken) error
+	// GetOAuthRefreshTokenByHash Get an OAuth refresh token by the hash of the token
+	GetOAuthRefreshTokenByHash(ctx context.Context, tokenHash string) (*OAuthRefreshToken, error)
+	// RotateOAuthRefreshToken Revoke an active refresh token and store its replacement
+	RotateOAuthRefreshToken(ctx context.Context, oldTokenHash string, token *OAuthRefreshToken) error
+	// DeleteExpiredOAuthGrants Delete expired authorization codes, device codes and refresh tokens
+	DeleteExpiredOAuthGrants(ctx context.Context) error
+	// VerifyPhone Set the verified phone of a user, creating the profile when missing
+	VerifyPhone(ctx context.Context, userID string, email string, phone string) error
+	// GetProfileByVerifiedPhone Get the profile that verified the phone
+	GetProfileByVerifiedPhone(ctx context.Context, phone string) (*ProfileData, error)
 }
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrPurchaseLimitReached is returned when a purchase would exceed the per user limit of an item
+var ErrPurchaseLimitReached = errors.New("purchase limit reached")
+
+// Kinds of shop items
+const (
+	ShopItemPot          = "pot"
+	ShopItemTheme        = "theme"
+	ShopItemStreakFreeze = "streak_freeze" // granted as streak freezes rather than kept in the inventory
+)
+
+// Currencies shop items are priced in, named after the earn scores
+const (
+	CurrencyWater = "water"
+	CurrencyLight = "light"
+	CurrencySeed  = "seed"
+)
+
+// ShopItem is the data structure for shopitems table. Items are soft deleted, so the
+// purchases and inventories referencing them are kept.
+type ShopItem struct {
+	ID          string `json:"id" sql:"id"`
+	Name        string `json:"name" sql:"name"`
+	Description string `json:"description" sql:"description"`
+	Kind        string `json:"kind" sql:"kind"`
+	// Prices per currency, an item cannot be bought with a currency it has no price in
+	WaterPrice int `json:"water_price" sql:"waterprice"`
+	LightPrice int `json:"light_price" sql:"lightprice"`
+	SeedPrice  int `json:"seed_price" sql:"seedprice"`
+	// AvailableFrom and AvailableUntil bound the availability window, open when null
+	AvailableFrom  *time.Time `json:"available_from" sql:"availablefrom"`
+	AvailableUntil *time.Time `json:"available_until" sql:"availableuntil"`
+	// PerUserLimit is the quantity a user can buy in total, unlimited when 0
+	PerUserLimit int        `json:"per_user_limit" sql:"peruserlimit"`
+	DeletedAt    *time.Time `json:"deletedat" sql:"deletedat"`
+	CreatedAt    time.Time  `json:"createdat" sql:"createdat"`
+	UpdatedAt    time.Time  `json:"updatedat" sql:"updatedat"`
+}
+
+// IsAvailable reports whether the item can be bought at the given time
+func (item *ShopItem) IsAvailable(now time.Time) bool {
+	if item.DeletedAt != nil {
+		return false
+	}
+	if item.AvailableFrom != nil && now.Before(*item.AvailableFrom) {
+		return false
+	}
+	return item.AvailableUntil == nil || now.Before(*item.AvailableUntil)
+}
+
+// PriceIn returns the unit price of the item in the currency, 0 when it has none
+func (item *ShopItem) PriceIn(currency string) int {
+	switch currency {
+	case CurrencyWater:
+		return item.WaterPrice
+	case CurrencyLight:
+		return item.LightPrice
+	case CurrencySeed:
+		return item.SeedPrice
+	default:
+		return 0
+	}
+}
+
+// Purchase is the data structure for purchases table, the ledger of the shop.
+// The idempotency key is unique per user, so a retried purchase is recorded once.
+type Purchase struct {
+	ID       string `json:"id" sql:"id"`
+	UserID   string `json:"user_id" sql:"userid"`
+	ItemID   string `json:"item_id" sql:"itemid"`
+	Quantity int    `json:"quantity" sql:"quantity"`
+	Currency string `json:"currency" sql:"currency"`
+	// Price is the total debited in the currency
+	Price          int       `json:"price" sql:"price"`
+	IdempotencyKey string    `json:"idempotency_key" sql:"idempotencykey"`
+	CreatedAt      time.Time `json:"createdat" sql:"createdat"`
+}
+
+// Cost returns the earn score debited by the purchase
+func (purchase *Purchase) Cost() *EarnScore {
+	cost := &EarnScore{UserID: purchase.UserID}
+	switch purchase.Currency {
+	case CurrencyWater:
+		cost.WaterScore = purchase.Price
+	case CurrencyLight:
+		cost.LightScore = purchase.Price
+	case CurrencySeed:
+		cost.SeedScore = purchase.Price
+	}
+	return cost
+}
+
+// InventoryItem is the data structure for inventories table, the quantity of an item a user owns
+type InventoryItem struct {
+	UserID    string    `json:"user_id" sql:"userid"`
+	ItemID    string    `json:"item_id" sql:"itemid"`
+	Quantity  int       `json:"quantity" sql:"quantity"`
+	CreatedAt time.Time `json:"createdat" sql:"createdat"`
+	UpdatedAt time.Time `json:"updatedat" sql:"updatedat"`
+}
@@ -0,0 +1,28 @@
+package database
+
+import "time"
+
+// Streak is the data structure for streaks table. A day counts towards the streak when the
+// focus minutes of the day, in the user's timezone, reach the daily threshold.
+type Streak struct {
+	UserID        string `json:"user_id" sql:"userid"`
+	CurrentStreak int    `json:"current_streak" sql:"currentstreak"`
+	BestStreak    int    `json:"best_streak" sql:"beststreak"`
+	// Freezes are earned streak freeze days, each covers one missed day
+	Freezes int `json:"freezes" sql:"freezes"`
+	// LastDay is the last day that counted towards the streak, as a date at midnight UTC
+	LastDay   *time.Time `json:"last_day" sql:"lastday"`
+	CreatedAt time.Time  `json:"createdat" sql:"createdat"`
+	UpdatedAt time.Time  `json:"updatedat" sql:"updatedat"`
+}
+
+// StreakResponse is the streak state returned alongside the earn score
+type StreakResponse struct {
+	CurrentStreak int `json:"current_streak"`
+	BestStreak    int `json:"best_streak"`
+	Freezes       int `json:"freezes"`
+	// ExtendedToday is true once today already counts towards the streak
+	ExtendedToday bool `json:"extended_today"`
+	// DailyMinutes is the focus minutes a day needs to count towards the streak
+	DailyMinutes int `json:"daily_minutes"`
+}
@@ -0,0 +1,23 @@
+package database
+
+import "time"
+
+// Tag is the data structure for tags table, a user defined category of focus sessions.
+// Archived tags cannot be attached to new sessions but keep their statistics.
+type Tag struct {
+	ID        string    `json:"id" sql:"id"`
+	UserID    string    `json:"user_id" sql:"userid"`
+	Name      string    `json:"name" sql:"name"`
+	Color     string    `json:"color" sql:"color"`
+	Archived  bool      `json:"archived" sql:"archived"`
+	CreatedAt time.Time `json:"createdat" sql:"createdat"`
+	UpdatedAt time.Time `json:"updatedat" sql:"updatedat"`
+}
+
+// TagFocusStats is the aggregate of the focus sessions with a tag, TagID is nil for the
+// sessions without tags
+type TagFocusStats struct {
+	TagID    *string `json:"tag_id" sql:"tagid"`
+	Minutes  int     `json:"minutes" sql:"minutes"`
+	Sessions int     `json:"sessions" sql:"sessions"`
+}
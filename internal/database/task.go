@@ -0,0 +1,20 @@
+package database
+
+import "time"
+
+// Task is the data structure for tasks table. Open tasks are ordered by position.
+type Task struct {
+	ID                 string     `json:"id" sql:"id"`
+	UserID             string     `json:"user_id" sql:"userid"`
+	Title              string     `json:"title" sql:"title"`
+	Notes              string     `json:"notes" sql:"notes"`
+	EstimatedPomodoros int        `json:"estimated_pomodoros" sql:"estimatedpomodoros"`
+	DueDate            *time.Time `json:"due_date" sql:"duedate"`
+	Completed          bool       `json:"completed" sql:"completed"`
+	CompletedAt        *time.Time `json:"completedat" sql:"completedat"`
+	Position           int        `json:"position" sql:"position"`
+	CreatedAt          time.Time  `json:"createdat" sql:"createdat"`
+	UpdatedAt          time.Time  `json:"updatedat" sql:"updatedat"`
+	// ActualPomodoros is the number of focus sessions linked to the task, it is not stored
+	ActualPomodoros int `json:"actual_pomodoros" sql:"actualpomodoros"`
+}
@@ -1,10 +1,16 @@
 package database
 
-import (
-	"golang.org/x/crypto/bcrypt"
-	"time"
+import "time"
+
+// Roles of a user
+const (
+	RoleUser  = "user"
+	RoleGuest = "guest"
 )
 
+// GuestEmailDomain is the reserved domain of the placeholder emails of guest users
+const GuestEmailDomain = "guest.invalid"
+
 // User is the data type for user object
 type User struct {
 	ID        string    `json:"id" sql:"id"`
@@ -16,14 +22,10 @@ type User struct {
 	Banned    bool      `json:"banned" sql:"banned"`
 	CreatedAt time.Time `json:"createdat" sql:"createdat"`
 	UpdatedAt time.Time `json:"updatedat" sql:"updatedat"`
-}
-
-// HashPassword hashes the password
-func (u User) HashPassword() (string, error) {
-	hashedPass, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
-	}
-
-	return string(hashedPass), nil
+	// UsernameNormalized is the NFKC case folded username used for uniqueness
+	UsernameNormalized string     `json:"-" sql:"usernamenormalized"`
+	UsernameChangedAt  *time.Time `json:"usernamechangedat" sql:"usernamechangedat"`
+	Role               string     `json:"role" sql:"role"`
+	// DeviceSecretHash binds a guest user to the device that created it
+	DeviceSecretHash string `json:"-" sql:"devicesecrethash"`
 }
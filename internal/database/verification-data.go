@@ -7,6 +7,8 @@ type VerificationDataType int
 const (
 	MailConfirmation VerificationDataType = iota + 1
 	PassReset
+	LoginCode
+	PhoneVerification
 )
 
 // Type of verification data
@@ -21,4 +23,6 @@ type VerificationData struct {
 	Code      string               `json:"code" validate:"required" sql:"code"`
 	ExpiresAt time.Time            `json:"expiresat" sql:"expiresat"`
 	Type      VerificationDataType `json:"type" sql:"type"`
+	// Target is what the code is sent to when it is not the email, e.g. the phone number being verified
+	Target string `json:"target" sql:"target"`
 }
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"strings"
+)
+
+// NormalizeEmail returns the canonical form of an email used for storage and lookups
+func NormalizeEmail(email string) string {
+	return strings.ToLower(norm.NFKC.String(strings.TrimSpace(email)))
+}
+
+// DisposableDomains is a locally maintained blocklist of disposable email domains
+type DisposableDomains struct {
+	domains map[string]bool
+}
+
+// NewDisposableDomains loads the blocklist file (one domain per line).
+// An empty path returns an empty blocklist.
+func NewDisposableDomains(path string) (*DisposableDomains, error) {
+	list, err := readWordList(path)
+	if err != nil {
+		return nil, err
+	}
+	domains := make(map[string]bool, len(list))
+	for _, domain := range list {
+		domains[strings.TrimPrefix(domain, "@")] = true
+	}
+	return &DisposableDomains{domains: domains}, nil
+}
+
+// IsDisposable reports whether the email domain or one of its parent domains is blocklisted
+func (d *DisposableDomains) IsDisposable(email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 || len(d.domains) == 0 {
+		return false
+	}
+	domain := NormalizeEmail(email[at+1:])
+	for domain != "" {
+		if d.domains[domain] {
+			return true
+		}
+		dot := strings.Index(domain, ".")
+		if dot < 0 {
+			break
+		}
+		domain = domain[dot+1:]
+	}
+	return false
+}
@@ -56,6 +56,55 @@ const (
 	AccountIsNotNeedToCancelDelete = 36
 	PassCodeRequired               = 37
 	UserDeleted                    = 38
+	PasswordPolicyViolated         = 39
+	InvalidCredentials             = 40
+	ReauthenticationRequired       = 41
+	SameEmail                      = 42
+	InvalidUsername                = 43
+	UsernameNotAllowed             = 44
+	UsernameChangeCooldown         = 45
+	DisposableEmail                = 46
+	IDTokenRequired                = 47
+	InvalidIDToken                 = 48
+	UnknownProvider                = 49
+	GuestNotAllowed                = 50
+	NotGuest                       = 51
+	DeviceSecretRequired           = 52
+	LastSignInMethod               = 53
+	IdentityAlreadyLinked          = 54
+	IdentityLinkRequired           = 55
+	PasswordAlreadySet             = 56
+	InsufficientScope              = 57
+	InvalidScope                   = 58
+	TokenLimitReached              = 59
+	InvalidAPIKey                  = 60
+	InvalidClient                  = 61
+	InvalidRedirectURI             = 62
+	InvalidUserCode                = 63
+	InvalidPhone                   = 64
+	PhoneRequired                  = 65
+	PhoneInUse                     = 66
+	InvalidTimezone                = 67
+	InvalidStatsRange              = 68
+	FriendLimitReached             = 69
+	AlreadyFriends                 = 70
+	InsufficientScore              = 71
+	UnknownSpecies                 = 72
+	PlantNotReady                  = 73
+	PlantFullyGrown                = 74
+	ItemNotAvailable               = 75
+	PurchaseLimitReached           = 76
+	CurrencyNotAccepted            = 77
+	InvalidShopItem                = 78
+	RatioNotConfigured             = 79
+	RatioVersionMismatch           = 80
+	InvalidFocusTime               = 81
+	InvalidMultiRatio              = 82
+	TagLimitReached                = 83
+	TagExists                      = 84
+	InvalidTag                     = 85
+	InvalidTask                    = 86
+	InvalidGoal                    = 87
 )
 
 func (e ErrorResponse) Error() string {
@@ -154,15 +203,114 @@ func (e ErrorResponse) Error() string {
 		return "pass code required"
 	case UserDeleted:
 		return "user is on the deletion schedule."
+	case PasswordPolicyViolated:
+		return "password does not satisfy the password policy"
+	case InvalidCredentials:
+		return "Invalid email or password"
+	case ReauthenticationRequired:
+		return "Please confirm your password to continue"
+	case SameEmail:
+		return "new email is the same as the current email"
+	case InvalidUsername:
+		return "username must be 3 to 30 letters, digits, '_' or '.' and start with a letter or digit"
+	case UsernameNotAllowed:
+		return "username is not allowed"
+	case UsernameChangeCooldown:
+		return "username was changed recently. Please try again later."
+	case DisposableEmail:
+		return "disposable email addresses are not allowed"
+	case IDTokenRequired:
+		return "id token is required"
+	case InvalidIDToken:
+		return "id token is invalid or expired"
+	case UnknownProvider:
+		return "unknown identity provider"
+	case GuestNotAllowed:
+		return "please create an account to use this feature"
+	case NotGuest:
+		return "account is not a guest account"
+	case DeviceSecretRequired:
+		return "device secret is required"
+	case LastSignInMethod:
+		return "cannot remove the last sign-in method of the account"
+	case IdentityAlreadyLinked:
+		return "this sign-in method is already linked to an account"
+	case IdentityLinkRequired:
+		return "an account with this email already exists. Please sign in with your password and link this sign-in method from your settings"
+	case PasswordAlreadySet:
+		return "account already has a password"
+	case InsufficientScope:
+		return "token does not have the scope required by this endpoint"
+	case InvalidScope:
+		return "unknown scope"
+	case TokenLimitReached:
+		return "you have reached the maximum number of personal access tokens"
+	case InvalidAPIKey:
+		return "api key is missing, invalid or revoked"
+	case InvalidClient:
+		return "unknown or revoked oauth client"
+	case InvalidRedirectURI:
+		return "redirect uri is not registered for this client"
+	case InvalidUserCode:
+		return "device code is invalid, expired or already used"
+	case InvalidPhone:
+		return "phone number is invalid"
+	case PhoneRequired:
+		return "phone number is required"
+	case PhoneInUse:
+		return "phone number is verified by another account"
+	case InvalidTimezone:
+		return "timezone is not a valid IANA timezone"
+	case InvalidStatsRange:
+		return "statistics range is invalid or too long"
+	case FriendLimitReached:
+		return "maximum number of friends reached"
+	case AlreadyFriends:
+		return "friendship or friend request already exists"
+	case InsufficientScore:
+		return "not enough scores"
+	case UnknownSpecies:
+		return "plant species does not exist"
+	case PlantNotReady:
+		return "plant needs more water before it can grow"
+	case PlantFullyGrown:
+		return "plant is fully grown"
+	case ItemNotAvailable:
+		return "item is not available"
+	case PurchaseLimitReached:
+		return "maximum number of purchases of the item reached"
+	case CurrencyNotAccepted:
+		return "item cannot be bought with this currency"
+	case InvalidShopItem:
+		return "shop item is invalid"
+	case RatioNotConfigured:
+		return "no multi ratio is in effect"
+	case RatioVersionMismatch:
+		return "multi ratio version is outdated, please refresh the multi ratio"
+	case InvalidFocusTime:
+		return "focus time is in the future or too long ago"
+	case InvalidMultiRatio:
+		return "multi ratio is invalid or takes effect before the latest version"
+	case TagLimitReached:
+		return "maximum number of tags reached"
+	case TagExists:
+		return "tag with this name already exists"
+	case InvalidTag:
+		return "tag does not exist or is archived"
+	case InvalidTask:
+		return "task does not exist or is completed"
+	case InvalidGoal:
+		return "goal target is below the minimum"
 	default:
 		return "Unknown Error"
 	}
 }
 
 type CustomErrorWrapper struct {
-	Message string `json:"message"` // Human-readable message for clients
-	Code    int    `json:"-"`       // HTTP Status code. We use `-` to skip json marshaling.
-	Err     error  `json:"-"`       // The original error. Same reason as above.
+	Message string   `json:"message"`           // Human-readable message for clients
+	Details []string `json:"details,omitempty"` // Optional list of detailed problems, e.g. password policy violations
+	Code    int      `json:"-"`                 // HTTP Status code. We use `-` to skip json marshaling.
+	Err     error    `json:"-"`                 // The original error. Same reason as above.
 }
 
 func NewErrorWrapper(code int, err error, message string) CustomErrorWrapper {
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+// Supported password hashing algorithms
+const (
+	HashAlgorithmArgon2id = "argon2id"
+	HashAlgorithmBcrypt   = "bcrypt"
+)
+
+var ErrInvalidPasswordHash = errors.New("invalid encoded password hash")
+
+// PasswordHasher hashes passwords into a self-describing encoded string and
+// verifies passwords against hashes produced by any supported algorithm.
+type PasswordHasher interface {
+	// Hash hashes the password with the configured algorithm and parameters
+	Hash(password string) (string, error)
+	// Verify reports whether the password matches the encoded hash
+	Verify(encodedHash string, password string) (bool, error)
+	// NeedsRehash reports whether the encoded hash was produced with another
+	// algorithm or with outdated parameters
+	NeedsRehash(encodedHash string) bool
+}
+
+// Argon2Params holds the argon2id cost parameters
+type Argon2Params struct {
+	Memory      uint32 // in KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// passwordHasher is the default PasswordHasher implementation
+type passwordHasher struct {
+	algorithm  string
+	argon2     Argon2Params
+	bcryptCost int
+}
+
+// NewPasswordHasher returns a PasswordHasher configured from the given configurations.
+// An unknown algorithm is an error rather than a silent fallback to argon2id.
+func NewPasswordHasher(configs *Configurations) (PasswordHasher, error) {
+	switch configs.PasswordHashAlgorithm {
+	case HashAlgorithmArgon2id, HashAlgorithmBcrypt:
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm %q", configs.PasswordHashAlgorithm)
+	}
+	return &passwordHasher{
+		algorithm: configs.PasswordHashAlgorithm,
+		argon2: Argon2Params{
+			Memory:      uint32(configs.Argon2Memory),
+			Iterations:  uint32(configs.Argon2Iterations),
+			Parallelism: uint8(configs.Argon2Parallelism),
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+		bcryptCost: configs.BcryptCost,
+	}, nil
+}
+
+// Hash hashes the password with the configured algorithm
+func (h *passwordHasher) Hash(password string) (string, error) {
+	if h.algorithm == HashAlgorithmBcrypt {
+		hashedPass, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hashedPass), nil
+	}
+
+	salt := make([]byte, h.argon2.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.argon2.Iterations, h.argon2.Memory, h.argon2.Parallelism, h.argon2.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.argon2.Memory,
+		h.argon2.Iterations,
+		h.argon2.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify checks the password against an argon2id or bcrypt encoded hash
+func (h *passwordHasher) Verify(encodedHash string, password string) (bool, error) {
+	switch {
+	case encodedHash == "":
+		// Accounts without a password (guests, social sign-in) never match
+		return false, nil
+	case strings.HasPrefix(encodedHash, "$argon2id$"):
+		params, salt, key, err := decodeArgon2Hash(encodedHash)
+		if err != nil {
+			return false, err
+		}
+		otherKey := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+		return subtle.ConstantTimeCompare(key, otherKey) == 1, nil
+	case isBcryptHash(encodedHash):
+		err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	default:
+		return false, ErrInvalidPasswordHash
+	}
+}
+
+// NeedsRehash reports whether the hash differs from the configured algorithm or parameters
+func (h *passwordHasher) NeedsRehash(encodedHash string) bool {
+	if encodedHash == "" {
+		return false
+	}
+	if h.algorithm == HashAlgorithmBcrypt {
+		if !isBcryptHash(encodedHash) {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(encodedHash))
+		return err != nil || cost != h.bcryptCost
+	}
+
+	if !strings.HasPrefix(encodedHash, "$argon2id$") {
+		return true
+	}
+	params, _, key, err := decodeArgon2Hash(encodedHash)
+	if err != nil {
+		return true
+	}
+	return params.Memory != h.argon2.Memory ||
+		params.Iterations != h.argon2.Iterations ||
+		params.Parallelism != h.argon2.Parallelism ||
+		uint32(len(key)) != h.argon2.KeyLength
+}
+
+// decodeArgon2Hash parses a hash in the $argon2id$v=19$m=..,t=..,p=..$salt$key format
+func decodeArgon2Hash(encodedHash string) (*Argon2Params, []byte, []byte, error) {
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 6 {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+
+	params := &Argon2Params{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
+
+// isBcryptHash reports whether the hash uses one of the bcrypt prefixes
+func isBcryptHash(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$2a$") ||
+		strings.HasPrefix(encodedHash, "$2b$") ||
+		strings.HasPrefix(encodedHash, "$2y$")
+}
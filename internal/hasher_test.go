@@ -0,0 +1,23 @@
+package utils
+
+import "testing"
+
+func TestNewPasswordHasherAlgorithm(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		valid     bool
+	}{
+		{HashAlgorithmArgon2id, true},
+		{HashAlgorithmBcrypt, true},
+		{"", false},
+		{"bcrypt ", false},
+		{"argon2", false},
+	}
+	for _, test := range tests {
+		hasher, err := NewPasswordHasher(&Configurations{PasswordHashAlgorithm: test.algorithm, BcryptCost: 4,
+			Argon2Memory: 64, Argon2Iterations: 1, Argon2Parallelism: 1})
+		if test.valid && (err != nil || hasher == nil) || !test.valid && err == nil {
+			t.Fatalf("algorithm %q: got %v, want valid %v", test.algorithm, err, test.valid)
+		}
+	}
+}
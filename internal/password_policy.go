@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// bcryptMaxLength is the number of bytes bcrypt takes into account
+const bcryptMaxLength = 72
+
+// PasswordPolicyError lists every rule a password failed
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e PasswordPolicyError) Error() string {
+	return "password does not satisfy the password policy: " + strings.Join(e.Violations, "; ")
+}
+
+// PasswordPolicy checks new passwords against the configured rules
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	DisallowEmail bool
+	// BreachedDir holds breached password hashes in the k-anonymity range format:
+	// one file per 5 character SHA-1 prefix containing "SUFFIX:COUNT" lines.
+	BreachedDir string
+}
+
+// NewPasswordPolicy returns the password policy from the given configurations
+func NewPasswordPolicy(configs *Configurations) *PasswordPolicy {
+	maxLength := configs.PasswordMaxLength
+	if configs.PasswordHashAlgorithm == HashAlgorithmBcrypt && (maxLength == 0 || maxLength > bcryptMaxLength) {
+		maxLength = bcryptMaxLength
+	}
+	return &PasswordPolicy{
+		MinLength:     configs.PasswordMinLength,
+		MaxLength:     maxLength,
+		RequireUpper:  configs.PasswordRequireUpper,
+		RequireLower:  configs.PasswordRequireLower,
+		RequireDigit:  configs.PasswordRequireDigit,
+		RequireSymbol: configs.PasswordRequireSymbol,
+		DisallowEmail: configs.PasswordDisallowEmail,
+		BreachedDir:   configs.BreachedPasswordsDir,
+	}
+}
+
+// Validate returns a PasswordPolicyError when the password breaks any rule
+func (p *PasswordPolicy) Validate(password string, email string) error {
+	violations := p.Check(password, email)
+	if len(violations) != 0 {
+		return PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// Check returns the list of rules the password breaks
+func (p *PasswordPolicy) Check(password string, email string) []string {
+	var violations []string
+
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		violations = append(violations, fmt.Sprintf("password must be at least %d characters long", p.MinLength))
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("password must be at most %d bytes long", p.MaxLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		violations = append(violations, "password must contain an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		violations = append(violations, "password must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, "password must contain a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, "password must contain a symbol")
+	}
+
+	if p.DisallowEmail && containsEmail(password, email) {
+		violations = append(violations, "password must not contain your email address")
+	}
+
+	if p.isBreached(password) {
+		violations = append(violations, "password has appeared in a data breach, please choose another one")
+	}
+	return violations
+}
+
+// containsEmail reports whether the password contains the email or its local part
+func containsEmail(password string, email string) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return false
+	}
+	password = strings.ToLower(password)
+	if strings.Contains(password, email) {
+		return true
+	}
+	localPart := email
+	if at := strings.Index(email, "@"); at >= 0 {
+		localPart = email[:at]
+	}
+	// very short local parts would reject too many passwords
+	return len(localPart) >= 3 && strings.Contains(password, localPart)
+}
+
+// isBreached looks the password up in the local breached password range files.
+// A missing directory or range file means the password is not known to be breached.
+func (p *PasswordPolicy) isBreached(password string) bool {
+	if p.BreachedDir == "" {
+		return false
+	}
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, err := os.Open(filepath.Join(p.BreachedDir, prefix))
+	if os.IsNotExist(err) {
+		file, err = os.Open(filepath.Join(p.BreachedDir, prefix+".txt"))
+	}
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		candidate := line
+		if colon := strings.Index(line, ":"); colon >= 0 {
+			candidate = line[:colon]
+		}
+		if strings.EqualFold(candidate, suffix) {
+			return true
+		}
+	}
+	return false
+}
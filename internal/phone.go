@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// e164Pattern matches a phone number in E.164 format: a plus sign and up to 15 digits
+var e164Pattern = regexp.MustCompile(`^\+[1-9]\d{6,14}$`)
+
+var errInvalidPhone = errors.New("invalid phone number")
+
+// NormalizePhone returns the E.164 form of a phone number used for storage and lookups.
+// Spaces, dashes, dots and parentheses are ignored, a 00 prefix is read as the
+// international prefix and a national number starting with 0 gets the default
+// country code, which is rejected when no default is configured.
+func NormalizePhone(phone string, defaultCountryCode string) (string, error) {
+	phone = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phone))
+
+	switch {
+	case strings.HasPrefix(phone, "+"):
+	case strings.HasPrefix(phone, "00"):
+		phone = "+" + strings.TrimPrefix(phone, "00")
+	case strings.HasPrefix(phone, "0") && defaultCountryCode != "":
+		phone = "+" + strings.TrimPrefix(defaultCountryCode, "+") + strings.TrimPrefix(phone, "0")
+	default:
+		return "", errInvalidPhone
+	}
+	if !e164Pattern.MatchString(phone) {
+		return "", errInvalidPhone
+	}
+	return phone, nil
+}
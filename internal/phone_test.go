@@ -0,0 +1,40 @@
+package utils
+
+import "testing"
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		name           string
+		phone          string
+		defaultCountry string
+		want           string
+		valid          bool
+	}{
+		{"e164", "+84912345678", "", "+84912345678", true},
+		{"separators", " +84 (91) 234-56.78 ", "", "+84912345678", true},
+		{"00 prefix", "0084912345678", "", "+84912345678", true},
+		{"national with default country", "0912345678", "84", "+84912345678", true},
+		{"national with plus in default country", "0912345678", "+84", "+84912345678", true},
+		{"national without default country", "0912345678", "", "", false},
+		{"no prefix", "912345678", "84", "", false},
+		{"too short", "+84123", "", "", false},
+		{"too long", "+8491234567890123", "", "", false},
+		{"country code 0", "+0912345678", "", "", false},
+		{"letters", "+84912abc678", "", "", false},
+		{"empty", "", "84", "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := NormalizePhone(test.phone, test.defaultCountry)
+			if !test.valid {
+				if err == nil {
+					t.Fatalf("got %q, want an error", got)
+				}
+				return
+			}
+			if err != nil || got != test.want {
+				t.Fatalf("got %q (%v), want %q", got, err, test.want)
+			}
+		})
+	}
+}
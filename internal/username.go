@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"bufio"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	usernameMinLength = 3
+	usernameMaxLength = 30
+)
+
+// defaultReservedUsernames are never available, whatever the reserved list file contains
+var defaultReservedUsernames = []string{
+	"admin", "administrator", "root", "system", "support", "help", "api", "auth",
+	"login", "logout", "signup", "settings", "account", "accounts", "profile",
+	"security", "moderator", "staff", "official", "focusnow", "focus_now", "null", "undefined",
+}
+
+// NormalizeUsername returns the form used for uniqueness checks: NFKC normalized and case folded
+func NormalizeUsername(username string) string {
+	return cases.Fold().String(norm.NFKC.String(strings.TrimSpace(username)))
+}
+
+// UsernamePolicy validates usernames against the format rules, reserved names and profanity list
+type UsernamePolicy struct {
+	reserved  map[string]bool
+	profanity []string
+}
+
+// NewUsernamePolicy returns the username policy, loading the optional
+// reserved names and profanity files (one entry per line)
+func NewUsernamePolicy(configs *Configurations) (*UsernamePolicy, error) {
+	policy := &UsernamePolicy{reserved: map[string]bool{}}
+	for _, name := range defaultReservedUsernames {
+		policy.reserved[NormalizeUsername(name)] = true
+	}
+
+	reserved, err := readWordList(configs.ReservedUsernamesPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range reserved {
+		policy.reserved[name] = true
+	}
+
+	policy.profanity, err = readWordList(configs.ProfanityListPath)
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// Validate checks the username and returns its NFKC display form and its normalized form
+func (p *UsernamePolicy) Validate(username string) (string, string, error) {
+	username = norm.NFKC.String(strings.TrimSpace(username))
+	if username == "" {
+		return "", "", NewErrorResponse(UsernameRequired)
+	}
+	length := utf8.RuneCountInString(username)
+	if length < usernameMinLength || length > usernameMaxLength {
+		return "", "", NewErrorResponse(InvalidUsername)
+	}
+	for i, r := range username {
+		isAlnum := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if i == 0 && !isAlnum {
+			return "", "", NewErrorResponse(InvalidUsername)
+		}
+		if !isAlnum && r != '_' && r != '.' {
+			return "", "", NewErrorResponse(InvalidUsername)
+		}
+	}
+
+	normalized := NormalizeUsername(username)
+	if p.reserved[normalized] {
+		return "", "", NewErrorResponse(UsernameNotAllowed)
+	}
+	// compare without separators so "b.a.d" does not get around the list
+	compact := strings.NewReplacer("_", "", ".", "").Replace(normalized)
+	for _, word := range p.profanity {
+		if strings.Contains(compact, word) {
+			return "", "", NewErrorResponse(UsernameNotAllowed)
+		}
+	}
+	return username, normalized, nil
+}
+
+// readWordList reads a file of one normalized entry per line, ignoring blank lines and # comments.
+// An empty path returns an empty list.
+func readWordList(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, NormalizeUsername(line))
+	}
+	return words, scanner.Err()
+}
@@ -1,6 +1,10 @@
 package utils
 
 import (
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
 	"math/rand"
 	"strings"
 )
@@ -17,3 +21,32 @@ func GenerateRandomString(n int) string {
 	}
 	return sb.String()
 }
+
+// GenerateSecureToken returns a hex encoded token made of n cryptographically random bytes
+func GenerateSecureToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := crand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GenerateSecureCode returns a code of n cryptographically random digits, easy to type from a mail
+func GenerateSecureCode(n int) (string, error) {
+	sb := strings.Builder{}
+	sb.Grow(n)
+	for i := 0; i < n; i++ {
+		digit, err := crand.Int(crand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(byte('0' + digit.Int64()))
+	}
+	return sb.String(), nil
+}
+
+// HashToken returns the sha256 hex digest of a token, used to store tokens at rest
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,196 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"LoveLetterProject/pkg/authorization/middleware"
+	"context"
+	"time"
+)
+
+// AchievementMetric is the user statistic an achievement goal is measured against
+type AchievementMetric string
+
+// Metrics of the achievement goals
+const (
+	MetricSessions   AchievementMetric = "sessions"
+	MetricMinutes    AchievementMetric = "minutes"
+	MetricBestStreak AchievementMetric = "best_streak"
+	// MetricPlantsPlanted counts every plant of the garden, MetricPlantsGrown the fully grown ones
+	MetricPlantsPlanted AchievementMetric = "plants_planted"
+	MetricPlantsGrown   AchievementMetric = "plants_grown"
+)
+
+// AchievementReward is the scores credited when an achievement is unlocked
+type AchievementReward struct {
+	WaterScore int `json:"water_score"`
+	LightScore int `json:"light_score"`
+	SeedScore  int `json:"seed_score"`
+}
+
+// Achievement is an entry of the achievement catalog. The ID is stored with every unlock,
+// so it must never change once released.
+type Achievement struct {
+	ID          string
+	Name        string
+	Description string
+	Metric      AchievementMetric
+	Goal        int
+	Reward      AchievementReward
+}
+
+// Achievements is the achievement catalog in the order it is listed to users
+var Achievements = []Achievement{
+	{ID: "first_session", Name: "First Focus", Description: "Complete your first focus session",
+		Metric: MetricSessions, Goal: 1, Reward: AchievementReward{WaterScore: 1}},
+	{ID: "sessions_100", Name: "Centurion", Description: "Complete 100 focus sessions",
+		Metric: MetricSessions, Goal: 100, Reward: AchievementReward{LightScore: 2}},
+	{ID: "hours_10", Name: "Getting Started", Description: "Focus for 10 hours in total",
+		Metric: MetricMinutes, Goal: 10 * 60, Reward: AchievementReward{SeedScore: 1}},
+	{ID: "hours_100", Name: "Deep Roots", Description: "Focus for 100 hours in total",
+		Metric: MetricMinutes, Goal: 100 * 60, Reward: AchievementReward{SeedScore: 5}},
+	{ID: "streak_7", Name: "One Week Strong", Description: "Reach a 7-day focus streak",
+		Metric: MetricBestStreak, Goal: 7, Reward: AchievementReward{LightScore: 1}},
+	{ID: "streak_30", Name: "Unbroken", Description: "Reach a 30-day focus streak",
+		Metric: MetricBestStreak, Goal: 30, Reward: AchievementReward{SeedScore: 3}},
+	{ID: "first_plant", Name: "Green Thumb", Description: "Plant your first tree",
+		Metric: MetricPlantsPlanted, Goal: 1, Reward: AchievementReward{WaterScore: 1}},
+	{ID: "plants_grown_5", Name: "Gardener", Description: "Grow 5 plants to their last stage",
+		Metric: MetricPlantsGrown, Goal: 5, Reward: AchievementReward{SeedScore: 2}},
+}
+
+// GetAchievements lists the achievement catalog with the progress of the user.
+// Achievements are evaluated first, so entries added to the catalog later are unlocked for
+// users who already met them.
+func (s *userService) GetAchievements(ctx context.Context) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	if _, err := s.evaluateAchievements(ctx, user.ID); err != nil {
+		s.logger.Error("Cannot evaluate achievements", "error", err, "userID", user.ID)
+	}
+
+	progress, err := s.achievementProgressOf(ctx, user.ID)
+	if err != nil {
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	unlocked, err := s.unlockedAchievementsOf(ctx, user.ID)
+	if err != nil {
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	response := make([]AchievementResponse, 0, len(Achievements))
+	for _, achievement := range Achievements {
+		item := AchievementResponse{
+			ID:          achievement.ID,
+			Name:        achievement.Name,
+			Description: achievement.Description,
+			Goal:        achievement.Goal,
+			Progress:    progress[achievement.Metric],
+			Reward:      achievement.Reward,
+		}
+		if item.Progress > item.Goal {
+			item.Progress = item.Goal
+		}
+		if unlockedAt, ok := unlocked[achievement.ID]; ok {
+			item.Unlocked = true
+			item.UnlockedAt = &unlockedAt
+			item.Progress = item.Goal
+		}
+		response = append(response, item)
+	}
+	return response, nil
+}
+
+// evaluateAchievements unlocks every achievement whose goal the user reached and credits its
+// reward. It is called whenever focus is credited, scores change or the garden grows and
+// returns the newly unlocked achievements.
+func (s *userService) evaluateAchievements(ctx context.Context, userID string) ([]Achievement, error) {
+	unlocked, err := s.unlockedAchievementsOf(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := s.achievementProgressOf(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	newlyUnlocked := []Achievement{}
+	for _, achievement := range Achievements {
+		if _, ok := unlocked[achievement.ID]; ok || progress[achievement.Metric] < achievement.Goal {
+			continue
+		}
+		userAchievement := &database.UserAchievement{UserID: userID, AchievementID: achievement.ID}
+		var reward *database.EarnScore
+		if achievement.Reward != (AchievementReward{}) {
+			reward = &database.EarnScore{
+				WaterScore: achievement.Reward.WaterScore,
+				LightScore: achievement.Reward.LightScore,
+				SeedScore:  achievement.Reward.SeedScore,
+			}
+		}
+		// A concurrent evaluation may have unlocked it first, the reward is credited once
+		ok, err := s.repo.UnlockAchievement(ctx, userAchievement, reward)
+		if err != nil {
+			s.logger.Error("Cannot unlock achievement", "error", err, "achievement", achievement.ID)
+			return newlyUnlocked, err
+		}
+		if ok {
+			s.logger.Info("Achievement unlocked", "userID", userID, "achievement", achievement.ID)
+			newlyUnlocked = append(newlyUnlocked, achievement)
+		}
+	}
+	return newlyUnlocked, nil
+}
+
+// achievementProgressOf returns the current value of every achievement metric of the user
+func (s *userService) achievementProgressOf(ctx context.Context, userID string) (map[AchievementMetric]int, error) {
+	totals, err := s.repo.GetFocusTotals(ctx, userID)
+	if err != nil {
+		s.logger.Error("Cannot get focus totals", "error", err)
+		return nil, err
+	}
+	streak, err := s.streakOf(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	plants, err := s.repo.GetPlants(ctx, userID)
+	if err != nil {
+		s.logger.Error("Cannot get plants", "error", err)
+		return nil, err
+	}
+	grown := 0
+	for _, plant := range plants {
+		if species, ok := plantSpeciesByID(plant.SpeciesID); ok && plant.Stage >= len(species.Stages)-1 {
+			grown++
+		}
+	}
+	return map[AchievementMetric]int{
+		MetricSessions:      totals.Sessions,
+		MetricMinutes:       totals.Minutes,
+		MetricBestStreak:    streak.BestStreak,
+		MetricPlantsPlanted: len(plants),
+		MetricPlantsGrown:   grown,
+	}, nil
+}
+
+// unlockedAchievementsOf returns the unlock time of the achievements unlocked by the user
+func (s *userService) unlockedAchievementsOf(ctx context.Context, userID string) (map[string]time.Time, error) {
+	achievements, err := s.repo.GetUserAchievements(ctx, userID)
+	if err != nil {
+		s.logger.Error("Cannot get user achievements", "error", err)
+		return nil, err
+	}
+	unlocked := make(map[string]time.Time, len(achievements))
+	for _, achievement := range achievements {
+		unlocked[achievement.AchievementID] = achievement.UnlockedAt
+	}
+	return unlocked, nil
+}
@@ -0,0 +1,133 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal/database"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func (repo *fakeRepo) GetFocusTotals(ctx context.Context, userID string) (*database.FocusTotals, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	// Totals set by a test stand for sessions it did not store
+	totals := database.FocusTotals{}
+	if stored, ok := repo.totals[userID]; ok {
+		totals = *stored
+	}
+	for _, session := range repo.sessions {
+		if session.UserID == userID {
+			totals.Sessions++
+			totals.Minutes += session.Minutes
+		}
+	}
+	return &totals, nil
+}
+
+func (repo *fakeRepo) GetStreak(ctx context.Context, userID string) (*database.Streak, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	streak, ok := repo.streaks[userID]
+	if !ok {
+		return &database.Streak{}, sql.ErrNoRows
+	}
+	copied := *streak
+	return &copied, nil
+}
+
+func (repo *fakeRepo) GetUserAchievements(ctx context.Context, userID string) ([]database.UserAchievement, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	return append([]database.UserAchievement{}, repo.achievements[userID]...), nil
+}
+
+func (repo *fakeRepo) UnlockAchievement(ctx context.Context, achievement *database.UserAchievement, reward *database.EarnScore) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, unlocked := range repo.achievements[achievement.UserID] {
+		if unlocked.AchievementID == achievement.AchievementID {
+			return false, nil
+		}
+	}
+	achievement.UnlockedAt = time.Now()
+	repo.achievements[achievement.UserID] = append(repo.achievements[achievement.UserID], *achievement)
+	if reward != nil {
+		repo.addScore(achievement.UserID, reward.WaterScore, reward.LightScore, reward.SeedScore)
+	}
+	return true, nil
+}
+
+// achievementIDs returns the ids of the achievements
+func achievementIDs(achievements []Achievement) []string {
+	ids := make([]string, 0, len(achievements))
+	for _, achievement := range achievements {
+		ids = append(ids, achievement.ID)
+	}
+	return ids
+}
+
+func TestEvaluateAchievementsIsIdempotent(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	repo.totals[user.ID] = &database.FocusTotals{Sessions: 1, Minutes: 10 * 60}
+
+	unlocked, err := s.evaluateAchievements(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := achievementIDs(unlocked); len(ids) != 2 || ids[0] != "first_session" || ids[1] != "hours_10" {
+		t.Fatalf("got %v, want first_session and hours_10", ids)
+	}
+	score := *repo.scores[user.ID]
+	if score.WaterScore != 1 || score.SeedScore != 1 {
+		t.Fatalf("got %+v, want the rewards credited", score)
+	}
+	// Evaluating again unlocks and credits nothing
+	unlocked, err = s.evaluateAchievements(ctx, user.ID)
+	if err != nil || len(unlocked) != 0 {
+		t.Fatalf("got %v (%v), want nothing unlocked", achievementIDs(unlocked), err)
+	}
+	if *repo.scores[user.ID] != score {
+		t.Fatalf("got %+v, want %+v", *repo.scores[user.ID], score)
+	}
+	if len(repo.achievements[user.ID]) != 2 {
+		t.Fatalf("got %d unlocks, want 2", len(repo.achievements[user.ID]))
+	}
+}
+
+func TestGetAchievements(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	repo.totals[user.ID] = &database.FocusTotals{Sessions: 40, Minutes: 2000}
+	repo.streaks[user.ID] = &database.Streak{UserID: user.ID, CurrentStreak: 3, BestStreak: 9}
+
+	response, err := s.GetAchievements(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	achievements := map[string]AchievementResponse{}
+	for _, achievement := range response.([]AchievementResponse) {
+		achievements[achievement.ID] = achievement
+	}
+	tests := []struct {
+		id       string
+		unlocked bool
+		progress int
+	}{
+		{"first_session", true, 1},
+		{"sessions_100", false, 40},
+		{"hours_10", true, 600},
+		{"hours_100", false, 2000},
+		{"streak_7", true, 7},
+		{"streak_30", false, 9},
+		{"first_plant", false, 0},
+	}
+	for _, test := range tests {
+		achievement := achievements[test.id]
+		if achievement.Unlocked != test.unlocked || achievement.Progress != test.progress {
+			t.Errorf("%s: got unlocked %v progress %d, want %v %d", test.id, achievement.Unlocked, achievement.Progress, test.unlocked, test.progress)
+		}
+		if achievement.Unlocked && achievement.UnlockedAt == nil {
+			t.Errorf("%s: unlocked without unlock time", test.id)
+		}
+	}
+}
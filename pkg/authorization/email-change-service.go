@@ -0,0 +1,213 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"LoveLetterProject/pkg/authorization/middleware"
+	"context"
+	"crypto/subtle"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// emailChangeCodeLength is the number of digits of a mailed email change code
+const emailChangeCodeLength = 8
+
+// RequestEmailChange checks the password and mails a confirmation code to the new email
+// and a notice to the current one.
+func (s *userService) RequestEmailChange(ctx context.Context, request *RequestEmailChangeRequest) (string, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	// Password guesses count towards the login limit
+	limitData, err := s.increaseLoginCount(ctx, user.ID)
+	if err != nil {
+		return err.Error(), err
+	}
+	if isSame := s.auth.ComparePassword(user.Password, request.Password); !isSame {
+		s.logger.Error("Password is incorrect", "userID", userID)
+		cusErr := utils.NewErrorResponse(utils.PasswordIncorrect)
+		return cusErr.Error(), cusErr
+	}
+	// Reset limit data
+	limitData.NumOfLogin = 0
+	err = s.repo.InsertOrUpdateLimitData(ctx, limitData, false)
+	if err != nil {
+		s.logger.Error("Cannot reset number of login", "error", err)
+	}
+	if request.NewEmail == user.Email {
+		cusErr := utils.NewErrorResponse(utils.SameEmail)
+		return cusErr.Error(), cusErr
+	}
+	if s.disposableDomains.IsDisposable(request.NewEmail) {
+		s.logger.Error("Disposable email rejected", "userID", userID)
+		cusErr := utils.NewErrorResponse(utils.DisposableEmail)
+		return cusErr.Error(), cusErr
+	}
+	if _, err := s.repo.GetUserByEmail(ctx, request.NewEmail); err == nil {
+		s.logger.Error("New email is already registered", "userID", userID)
+		cusErr := utils.NewErrorResponse(utils.ExistUser)
+		return cusErr.Error(), cusErr
+	}
+	if err := s.increaseSendMailCount(ctx, userID); err != nil {
+		return err.Error(), err
+	}
+
+	code, err := utils.GenerateSecureCode(emailChangeCodeLength)
+	if err != nil {
+		s.logger.Error("unable to generate email change code", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	change := &database.EmailChange{
+		UserID:    userID,
+		OldEmail:  user.Email,
+		NewEmail:  request.NewEmail,
+		Code:      code,
+		ExpiresAt: time.Now().Add(time.Minute * time.Duration(s.configs.EmailChangeCodeExpiration)),
+	}
+	err = s.repo.CreateEmailChange(ctx, change)
+	if err != nil {
+		s.logger.Error("unable to store email change", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+
+	err = s.sendMail(change.NewEmail, EmailChangeCode, &MailData{Username: user.Username, Code: change.Code})
+	if err != nil {
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	// The notice is informational, the change can still be confirmed without it
+	_ = s.sendMail(change.OldEmail, EmailChangeNotice, &MailData{Username: user.Username, Email: change.NewEmail})
+
+	s.logger.Info("Email change requested", "userID", userID)
+	return "successfully mailed email change code. Please check your new email.", nil
+}
+
+// ConfirmEmailChange moves the account to the new email and mails an undo link to the old one.
+func (s *userService) ConfirmEmailChange(ctx context.Context, request *ConfirmEmailChangeRequest) (string, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	// Code guesses count towards the login limit
+	limitData, err := s.increaseLoginCount(ctx, user.ID)
+	if err != nil {
+		return err.Error(), err
+	}
+	change, err := s.repo.GetPendingEmailChange(ctx, userID)
+	if err != nil {
+		s.logger.Error("unable to get pending email change", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InvalidCode)
+		return cusErr.Error(), cusErr
+	}
+	if change.ExpiresAt.Before(time.Now()) {
+		s.logger.Error("email change code is expired", "userID", userID)
+		cusErr := utils.NewErrorResponse(utils.ExpiredCode)
+		return cusErr.Error(), cusErr
+	}
+	if subtle.ConstantTimeCompare([]byte(change.Code), []byte(request.Code)) != 1 {
+		s.logger.Error("email change code is invalid", "userID", userID)
+		cusErr := utils.NewErrorResponse(utils.InvalidCode)
+		return cusErr.Error(), cusErr
+	}
+
+	undoToken, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		s.logger.Error("unable to generate undo token", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	undoExpiresAt := time.Now().AddDate(0, 0, s.configs.EmailChangeUndoExpiration)
+	change.UndoTokenHash = utils.HashToken(undoToken)
+	change.UndoExpiresAt = &undoExpiresAt
+	err = s.repo.ConfirmEmailChange(ctx, change)
+	if err != nil {
+		s.logger.Error("unable to confirm email change", "error", err)
+		if strings.Contains(err.Error(), utils.PgDuplicateKeyMsg) {
+			cusErr := utils.NewErrorResponse(utils.ExistUser)
+			return cusErr.Error(), cusErr
+		}
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	// Reset limit data
+	limitData.NumOfLogin = 0
+	err = s.repo.InsertOrUpdateLimitData(ctx, limitData, false)
+	if err != nil {
+		s.logger.Error("Cannot reset number of login", "error", err)
+	}
+
+	link := s.configs.EmailChangeUndoURL + "?token=" + url.QueryEscape(undoToken)
+	_ = s.sendMail(change.OldEmail, EmailChangeUndo, &MailData{Username: user.Username, Email: change.NewEmail, Link: link})
+
+	s.logger.Info("Email changed", "userID", userID)
+	return "Email has been successfully changed.", nil
+}
+
+// UndoEmailChange restores the old email from the undo link and signs out every session.
+func (s *userService) UndoEmailChange(ctx context.Context, request *UndoEmailChangeRequest) (string, error) {
+	change, err := s.repo.GetEmailChangeByUndoToken(ctx, utils.HashToken(request.Token))
+	if err != nil {
+		s.logger.Error("unable to get email change by undo token", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InvalidCode)
+		return cusErr.Error(), cusErr
+	}
+	if change.UndoExpiresAt == nil || change.UndoExpiresAt.Before(time.Now()) {
+		s.logger.Error("undo token is expired", "userID", change.UserID)
+		cusErr := utils.NewErrorResponse(utils.ExpiredCode)
+		return cusErr.Error(), cusErr
+	}
+	// A new token hash invalidates every refresh and access token of the account
+	err = s.repo.UndoEmailChange(ctx, change, utils.GenerateRandomString(15))
+	if err != nil {
+		s.logger.Error("unable to undo email change", "error", err)
+		if strings.Contains(err.Error(), utils.PgDuplicateKeyMsg) {
+			cusErr := utils.NewErrorResponse(utils.ExistUser)
+			return cusErr.Error(), cusErr
+		}
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("Email change undone", "userID", change.UserID)
+	return "Email change has been undone. Please sign in again.", nil
+}
+
+// increaseSendMailCount counts a sent mail towards the daily send mail limit.
+func (s *userService) increaseSendMailCount(ctx context.Context, userID string) error {
+	isInsert := false
+	limitData, err := s.repo.GetLimitData(ctx, userID)
+	if err != nil {
+		// No row, need insert
+		isInsert = true
+		limitData.UserID = userID
+		limitData.NumOfSendMail = 1
+	} else {
+		limitData.NumOfSendMail += 1
+	}
+	if limitData.NumOfSendMail > s.configs.SendMailLimit {
+		s.logger.Error("User has reached limit send mail.", "userID", userID)
+		return utils.NewErrorResponse(utils.TooManyRequests)
+	}
+	err = s.repo.InsertOrUpdateLimitData(ctx, limitData, isInsert)
+	if err != nil {
+		s.logger.Error("Cannot update limit data", "error", err)
+		return utils.NewErrorResponse(utils.InternalServerError)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+)
+
+func (repo *fakeRepo) CreateEmailChange(ctx context.Context, change *database.EmailChange) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	copied := *change
+	copied.Status = database.EmailChangePending
+	repo.emailChanges[change.UserID] = &copied
+	return nil
+}
+
+func (repo *fakeRepo) GetPendingEmailChange(ctx context.Context, userID string) (*database.EmailChange, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	change, ok := repo.emailChanges[userID]
+	if !ok || change.Status != database.EmailChangePending {
+		return &database.EmailChange{}, sql.ErrNoRows
+	}
+	copied := *change
+	return &copied, nil
+}
+
+func (repo *fakeRepo) ConfirmEmailChange(ctx context.Context, change *database.EmailChange) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	user, ok := repo.users[change.UserID]
+	if !ok || user.Email != change.OldEmail {
+		return sql.ErrNoRows
+	}
+	user.Email = change.NewEmail
+	copied := *change
+	copied.Status = database.EmailChangeConfirmed
+	repo.emailChanges[change.UserID] = &copied
+	return nil
+}
+
+// requestEmailChange returns the code of the email change to new@example.com requested by the user.
+func requestEmailChange(t *testing.T, s *userService, repo *fakeRepo, ctx context.Context, userID string) string {
+	t.Helper()
+	if _, err := s.RequestEmailChange(ctx, &RequestEmailChangeRequest{Password: "Correct-Password1", NewEmail: "new@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	return repo.emailChanges[userID].Code
+}
+
+func TestRequestEmailChange(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	s.configs.EmailChangeCodeExpiration = 10
+	repo.addUser(t, s, "taken@example.com", "Correct-Password1")
+
+	tests := []struct {
+		name    string
+		request RequestEmailChangeRequest
+		want    utils.ErrorType
+	}{
+		{"same email", RequestEmailChangeRequest{Password: "Correct-Password1", NewEmail: "known@example.com"}, utils.SameEmail},
+		{"disposable email", RequestEmailChangeRequest{Password: "Correct-Password1", NewEmail: "new@throwaway.example"}, utils.DisposableEmail},
+		{"registered email", RequestEmailChangeRequest{Password: "Correct-Password1", NewEmail: "taken@example.com"}, utils.ExistUser},
+	}
+	for _, test := range tests {
+		if _, err := s.RequestEmailChange(ctx, &test.request); errorType(err) != test.want {
+			t.Fatalf("%s: got %v, want %v", test.name, err, utils.NewErrorResponse(test.want))
+		}
+	}
+	code := requestEmailChange(t, s, repo, ctx, user.ID)
+	if !regexp.MustCompile(`^[0-9]{8}$`).MatchString(code) {
+		t.Fatalf("got code %q, want 8 digits", code)
+	}
+}
+
+func TestRequestEmailChangePasswordGuessesAreLimited(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	s.configs.EmailChangeCodeExpiration = 10
+
+	for i := 0; i < s.configs.LoginLimit-1; i++ {
+		if _, err := s.RequestEmailChange(ctx, &RequestEmailChangeRequest{Password: "Wrong-Password1", NewEmail: "new@example.com"}); errorType(err) != utils.PasswordIncorrect {
+			t.Fatalf("attempt %d: got %v, want password incorrect", i+1, err)
+		}
+	}
+	// A success resets the count, so the limit applies to consecutive guesses only
+	requestEmailChange(t, s, repo, ctx, user.ID)
+	for i := 0; i < s.configs.LoginLimit; i++ {
+		if _, err := s.RequestEmailChange(ctx, &RequestEmailChangeRequest{Password: "Wrong-Password1", NewEmail: "new@example.com"}); errorType(err) != utils.PasswordIncorrect {
+			t.Fatalf("attempt %d after success: got %v, want password incorrect", i+1, err)
+		}
+	}
+	if _, err := s.RequestEmailChange(ctx, &RequestEmailChangeRequest{Password: "Correct-Password1", NewEmail: "new@example.com"}); errorType(err) != utils.TooManyRequests {
+		t.Fatalf("got %v, want too many requests", err)
+	}
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	s.configs.EmailChangeCodeExpiration = 10
+	s.configs.EmailChangeUndoExpiration = 7
+	code := requestEmailChange(t, s, repo, ctx, user.ID)
+
+	if _, err := s.ConfirmEmailChange(ctx, &ConfirmEmailChangeRequest{Code: "00000000"}); errorType(err) != utils.InvalidCode {
+		t.Fatalf("got %v, want invalid code", err)
+	}
+	if _, err := s.ConfirmEmailChange(ctx, &ConfirmEmailChangeRequest{Code: code}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.users[user.ID].Email != "new@example.com" || repo.limits[user.ID].NumOfLogin != 0 {
+		t.Fatalf("got email %s and %d guesses, want the new email and the guesses reset", repo.users[user.ID].Email, repo.limits[user.ID].NumOfLogin)
+	}
+	if mail := lastMail(t, s); mail.mtype != EmailChangeUndo || mail.to[0] != "known@example.com" {
+		t.Fatalf("got %v mail to %v, want the undo link mailed to the old email", mail.mtype, mail.to)
+	}
+}
+
+func TestConfirmEmailChangeGuessesAreLimited(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	s.configs.EmailChangeCodeExpiration = 10
+	code := requestEmailChange(t, s, repo, ctx, user.ID)
+
+	for i := 0; i < s.configs.LoginLimit; i++ {
+		if _, err := s.ConfirmEmailChange(ctx, &ConfirmEmailChangeRequest{Code: "00000000"}); errorType(err) != utils.InvalidCode {
+			t.Fatalf("guess %d: got %v, want invalid code", i+1, err)
+		}
+	}
+	if _, err := s.ConfirmEmailChange(ctx, &ConfirmEmailChangeRequest{Code: code}); errorType(err) != utils.TooManyRequests {
+		t.Fatalf("got %v, want too many requests", err)
+	}
+	if repo.users[user.ID].Email != "known@example.com" {
+		t.Fatal("email was changed past the guess limit")
+	}
+}
@@ -0,0 +1,235 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// loginCodeLength is the number of digits of a mailed login code
+const loginCodeLength = 6
+
+var errInvalidLoginToken = errors.New("invalid login token")
+
+// RequestLoginCode mails a one-time login code, and a signed link when a magic link URL is configured.
+func (s *userService) RequestLoginCode(ctx context.Context, request *EmailCodeLoginRequest) (string, error) {
+	successMsg := "successfully mailed login code. Please check your email."
+	user, err := s.repo.GetUserByEmail(ctx, request.Email)
+	if err != nil {
+		s.logger.Error("Email is not registered", "error", err)
+		if s.configs.EnumerationSafeMode {
+			return successMsg, nil
+		}
+		cusErr := utils.NewErrorResponse(utils.EmailNotRegistered)
+		return cusErr.Error(), cusErr
+	}
+	if user.Banned {
+		s.logger.Error("User is banned", "userID", user.ID)
+		if s.configs.EnumerationSafeMode {
+			return successMsg, nil
+		}
+		cusErr := utils.NewErrorResponse(utils.Forbidden)
+		return cusErr.Error(), cusErr
+	}
+	if err := s.mailLoginCode(ctx, user); err != nil {
+		if s.configs.EnumerationSafeMode {
+			// Failures only happen for registered emails, answering them would reveal the account
+			s.logger.Error("Cannot mail login code", "userID", user.ID, "error", err)
+			return successMsg, nil
+		}
+		return err.Error(), err
+	}
+	return successMsg, nil
+}
+
+// mailLoginCode stores a new login code of the user and mails it.
+func (s *userService) mailLoginCode(ctx context.Context, user *database.User) error {
+	if err := s.increaseSendMailCount(ctx, user.ID); err != nil {
+		return err
+	}
+
+	code, err := utils.GenerateSecureCode(loginCodeLength)
+	if err != nil {
+		s.logger.Error("unable to generate login code", "error", err)
+		return utils.NewErrorResponse(utils.InternalServerError)
+	}
+	verificationData := &database.VerificationData{
+		Email:     user.Email,
+		Code:      code,
+		Type:      database.LoginCode,
+		ExpiresAt: time.Now().Add(time.Minute * time.Duration(s.configs.LoginCodeExpiration)),
+	}
+	err = s.repo.StoreVerificationData(ctx, verificationData, false)
+	if err != nil {
+		s.logger.Error("unable to store login code", "error", err)
+		return utils.NewErrorResponse(utils.InternalServerError)
+	}
+
+	mailData := &MailData{
+		Username: user.Username,
+		Code:     code,
+		Link:     s.magicLink(verificationData),
+	}
+	if s.configs.EnumerationSafeMode {
+		// Sending in the background keeps the response time independent of the mail provider
+		go s.sendMail(user.Email, LoginCode, mailData)
+		return nil
+	}
+	if err := s.sendMail(user.Email, LoginCode, mailData); err != nil {
+		return utils.NewErrorResponse(utils.InternalServerError)
+	}
+	s.logger.Debug("successfully mailed login code", "userID", user.ID)
+	return nil
+}
+
+// VerifyLoginCode redeems a mailed login code or magic link token and signs the user in.
+func (s *userService) VerifyLoginCode(ctx context.Context, request *VerifyEmailCodeLoginRequest) (interface{}, error) {
+	email, code := request.Email, request.Code
+	if request.Token != "" {
+		var err error
+		email, code, err = s.parseLoginToken(request.Token)
+		if err != nil {
+			s.logger.Error("Invalid magic link", "error", err)
+			cusErr := utils.NewErrorResponse(utils.InvalidCode)
+			return cusErr.Error(), cusErr
+		}
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		s.logger.Error("Error getting user", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InvalidCode)
+		return cusErr.Error(), cusErr
+	}
+	// Code guesses count towards the login limit
+	limitData, err := s.increaseLoginCount(ctx, user.ID)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	actualVerificationData, err := s.repo.GetVerificationData(ctx, user.Email, database.LoginCode)
+	if err != nil {
+		s.logger.Error("unable to get login code", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InvalidCode)
+		return cusErr.Error(), cusErr
+	}
+	if actualVerificationData.ExpiresAt.Before(time.Now()) {
+		s.logger.Error("login code is expired", "userID", user.ID)
+		if err := s.repo.DeleteVerificationData(ctx, user.Email, database.LoginCode); err != nil {
+			s.logger.Error("unable to delete verification data from db", "error", err)
+		}
+		cusErr := utils.NewErrorResponse(utils.ExpiredCode)
+		return cusErr.Error(), cusErr
+	}
+	if subtle.ConstantTimeCompare([]byte(actualVerificationData.Code), []byte(code)) != 1 {
+		s.logger.Error("login code is invalid", "userID", user.ID)
+		cusErr := utils.NewErrorResponse(utils.InvalidCode)
+		return cusErr.Error(), cusErr
+	}
+	// The code is single use
+	err = s.repo.DeleteVerificationData(ctx, user.Email, database.LoginCode)
+	if err != nil {
+		s.logger.Error("unable to delete the verification data", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	if user.Banned {
+		s.logger.Error("User is banned", "userID", user.ID)
+		cusErr := utils.NewErrorResponse(utils.Forbidden)
+		return cusErr.Error(), cusErr
+	}
+	// Redeeming a mailed code proves the user owns the email
+	if !user.Verified {
+		if err := s.repo.UpdateUserVerificationStatus(ctx, user.Email, true); err != nil {
+			s.logger.Error("unable to set user verification status to true", "error", err)
+		} else {
+			user.Verified = true
+		}
+	}
+
+	accessToken, refreshToken, err := s.generateTokens(user)
+	if err != nil {
+		return err.Error(), err
+	}
+	// Reset limit data
+	limitData.NumOfLogin = 0
+	err = s.repo.InsertOrUpdateLimitData(ctx, limitData, false)
+	if err != nil {
+		s.logger.Error("Cannot reset number of login", "error", err)
+	}
+
+	s.logger.Debug("successfully signed in with login code", "userID", user.ID)
+	return LoginResponse{
+		Email:        user.Email,
+		Username:     user.Username,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		Verified:     user.Verified,
+	}, nil
+}
+
+// magicLink returns the signed login link for the code, or an empty string
+// when magic links are not configured.
+func (s *userService) magicLink(data *database.VerificationData) string {
+	if s.configs.MagicLinkURL == "" || s.configs.MagicLinkSecret == "" {
+		return ""
+	}
+	link, err := url.Parse(s.configs.MagicLinkURL)
+	if err != nil {
+		s.logger.Error("invalid magic link url", "error", err)
+		return ""
+	}
+	payload := strings.Join([]string{data.Email, data.Code, strconv.FormatInt(data.ExpiresAt.Unix(), 10)}, "|")
+	token := base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(s.signLoginPayload(payload))
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+// parseLoginToken checks the signature and expiry of a magic link token and returns its email and code
+func (s *userService) parseLoginToken(token string) (string, string, error) {
+	if s.configs.MagicLinkSecret == "" {
+		return "", "", errInvalidLoginToken
+	}
+	encodedPayload, encodedSignature, found := strings.Cut(token, ".")
+	if !found {
+		return "", "", errInvalidLoginToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return "", "", errInvalidLoginToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return "", "", errInvalidLoginToken
+	}
+	if !hmac.Equal(signature, s.signLoginPayload(string(payload))) {
+		return "", "", errInvalidLoginToken
+	}
+	parts := strings.Split(string(payload), "|")
+	if len(parts) != 3 {
+		return "", "", errInvalidLoginToken
+	}
+	expiresAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || time.Now().After(time.Unix(expiresAt, 0)) {
+		return "", "", errInvalidLoginToken
+	}
+	return parts[0], parts[1], nil
+}
+
+func (s *userService) signLoginPayload(payload string) []byte {
+	mac := hmac.New(sha256.New, []byte(s.configs.MagicLinkSecret))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
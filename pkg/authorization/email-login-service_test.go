@@ -0,0 +1,89 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"context"
+	"net/url"
+	"testing"
+)
+
+func TestRequestLoginCodeIsEnumerationSafe(t *testing.T) {
+	configs := testConfigs(t)
+	configs.EnumerationSafeMode = true
+	repo := newFakeRepo()
+	s := newTestService(t, configs, repo)
+	repo.addUser(t, s, "known@example.com", "")
+	ctx := context.Background()
+
+	unknown, err := s.RequestLoginCode(ctx, &EmailCodeLoginRequest{Email: "unknown@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i <= configs.SendMailLimit+1; i++ {
+		message, err := s.RequestLoginCode(ctx, &EmailCodeLoginRequest{Email: "known@example.com"})
+		if err != nil || message != unknown {
+			t.Fatalf("attempt %d: got %q (%v), want %q", i+1, message, err, unknown)
+		}
+	}
+}
+
+func TestRequestLoginCodeLimit(t *testing.T) {
+	configs := testConfigs(t)
+	repo := newFakeRepo()
+	s := newTestService(t, configs, repo)
+	repo.addUser(t, s, "known@example.com", "")
+	ctx := context.Background()
+
+	if _, err := s.RequestLoginCode(ctx, &EmailCodeLoginRequest{Email: "unknown@example.com"}); errorType(err) != utils.EmailNotRegistered {
+		t.Fatalf("got %v, want email not registered", err)
+	}
+	for i := 0; i < configs.SendMailLimit; i++ {
+		if _, err := s.RequestLoginCode(ctx, &EmailCodeLoginRequest{Email: "known@example.com"}); err != nil {
+			t.Fatalf("attempt %d: %v", i+1, err)
+		}
+	}
+	if _, err := s.RequestLoginCode(ctx, &EmailCodeLoginRequest{Email: "known@example.com"}); errorType(err) != utils.TooManyRequests {
+		t.Fatalf("got %v, want too many requests", err)
+	}
+}
+
+func TestVerifyLoginCode(t *testing.T) {
+	configs := testConfigs(t)
+	configs.MagicLinkURL = "https://app.example.com/login"
+	configs.MagicLinkSecret = "secret"
+	repo := newFakeRepo()
+	s := newTestService(t, configs, repo)
+	repo.addUser(t, s, "known@example.com", "")
+	ctx := context.Background()
+
+	if _, err := s.RequestLoginCode(ctx, &EmailCodeLoginRequest{Email: "known@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	mail := lastMail(t, s)
+	if _, err := s.VerifyLoginCode(ctx, &VerifyEmailCodeLoginRequest{Email: "known@example.com", Code: "000000x"}); errorType(err) != utils.InvalidCode {
+		t.Fatalf("got %v, want invalid code", err)
+	}
+	if _, err := s.VerifyLoginCode(ctx, &VerifyEmailCodeLoginRequest{Email: "known@example.com", Code: mail.data.Code}); err != nil {
+		t.Fatal(err)
+	}
+	// The code is single use
+	if _, err := s.VerifyLoginCode(ctx, &VerifyEmailCodeLoginRequest{Email: "known@example.com", Code: mail.data.Code}); errorType(err) != utils.InvalidCode {
+		t.Fatalf("got %v, want invalid code", err)
+	}
+
+	// The magic link carries the same code
+	if _, err := s.RequestLoginCode(ctx, &EmailCodeLoginRequest{Email: "known@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := url.Parse(lastMail(t, s).data.Link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := link.Query().Get("token")
+	if _, err := s.VerifyLoginCode(ctx, &VerifyEmailCodeLoginRequest{Token: token + "x"}); errorType(err) != utils.InvalidCode {
+		t.Fatalf("tampered token: got %v, want invalid code", err)
+	}
+	if _, err := s.VerifyLoginCode(ctx, &VerifyEmailCodeLoginRequest{Token: token}); err != nil {
+		t.Fatal(err)
+	}
+}
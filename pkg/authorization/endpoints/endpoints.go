@@ -12,24 +12,90 @@ import (
 	"github.com/juju/ratelimit"
 	"net/http"
 	"strings"
+	"time"
 )
 
 type Set struct {
-	HealthCheckEndpoint           endpoint.Endpoint
-	RegisterEndpoint              endpoint.Endpoint
-	VerifyMailEndpoint            endpoint.Endpoint
-	LoginEndpoint                 endpoint.Endpoint
-	LogoutEndpoint                endpoint.Endpoint
-	GetUserEndpoint               endpoint.Endpoint
-	GetProfileEndpoint            endpoint.Endpoint
-	UpdateProfileEndpoint         endpoint.Endpoint
-	UpdatePasswordEndpoint        endpoint.Endpoint
-	GetForgetPasswordCodeEndpoint endpoint.Endpoint
-	ResetPasswordEndpoint         endpoint.Endpoint
-	GetMultiRatioDataEndpoint     endpoint.Endpoint
-	InsertEarnScoreEndpoint       endpoint.Endpoint
-	GetEarnScoreEndpoint          endpoint.Endpoint
-	GenerateAccessTokenEndpoint   endpoint.Endpoint
+	HealthCheckEndpoint               endpoint.Endpoint
+	RegisterEndpoint                  endpoint.Endpoint
+	VerifyMailEndpoint                endpoint.Endpoint
+	LoginEndpoint                     endpoint.Endpoint
+	LogoutEndpoint                    endpoint.Endpoint
+	GetUserEndpoint                   endpoint.Endpoint
+	GetProfileEndpoint                endpoint.Endpoint
+	UpdateProfileEndpoint             endpoint.Endpoint
+	UpdatePasswordEndpoint            endpoint.Endpoint
+	GetForgetPasswordCodeEndpoint     endpoint.Endpoint
+	ResetPasswordEndpoint             endpoint.Endpoint
+	GetMultiRatioDataEndpoint         endpoint.Endpoint
+	InsertEarnScoreEndpoint           endpoint.Endpoint
+	GetEarnScoreEndpoint              endpoint.Endpoint
+	GenerateAccessTokenEndpoint       endpoint.Endpoint
+	ReauthenticateEndpoint            endpoint.Endpoint
+	RequestEmailChangeEndpoint        endpoint.Endpoint
+	ConfirmEmailChangeEndpoint        endpoint.Endpoint
+	UndoEmailChangeEndpoint           endpoint.Endpoint
+	SetUsernameEndpoint               endpoint.Endpoint
+	GetPublicProfileEndpoint          endpoint.Endpoint
+	SocialLoginEndpoint               endpoint.Endpoint
+	RequestLoginCodeEndpoint          endpoint.Endpoint
+	VerifyLoginCodeEndpoint           endpoint.Endpoint
+	GuestSignUpEndpoint               endpoint.Endpoint
+	GuestLoginEndpoint                endpoint.Endpoint
+	UpgradeGuestEndpoint              endpoint.Endpoint
+	GetSignInMethodsEndpoint          endpoint.Endpoint
+	LinkIdentityEndpoint              endpoint.Endpoint
+	LinkPasswordEndpoint              endpoint.Endpoint
+	UnlinkSignInMethodEndpoint        endpoint.Endpoint
+	CreatePersonalAccessTokenEndpoint endpoint.Endpoint
+	GetPersonalAccessTokensEndpoint   endpoint.Endpoint
+	RevokePersonalAccessTokenEndpoint endpoint.Endpoint
+	InternalGetUserEndpoint           endpoint.Endpoint
+	InternalGetEarnScoreEndpoint      endpoint.Endpoint
+	GetOAuthClientEndpoint            endpoint.Endpoint
+	AuthorizeOAuthClientEndpoint      endpoint.Endpoint
+	OAuthTokenEndpoint                endpoint.Endpoint
+	RequestDeviceCodeEndpoint         endpoint.Endpoint
+	VerifyDeviceCodeEndpoint          endpoint.Endpoint
+	GetOAuthConsentsEndpoint          endpoint.Endpoint
+	RevokeOAuthConsentEndpoint        endpoint.Endpoint
+	CreateOAuthClientEndpoint         endpoint.Endpoint
+	SendPhoneCodeEndpoint             endpoint.Endpoint
+	VerifyPhoneEndpoint               endpoint.Endpoint
+	GetForgetPasswordSMSEndpoint      endpoint.Endpoint
+	GetFocusStatsEndpoint             endpoint.Endpoint
+	GetAchievementsEndpoint           endpoint.Endpoint
+	GetLeaderboardEndpoint            endpoint.Endpoint
+	GetPrivacySettingsEndpoint        endpoint.Endpoint
+	UpdatePrivacySettingsEndpoint     endpoint.Endpoint
+	AddFriendEndpoint                 endpoint.Endpoint
+	RemoveFriendEndpoint              endpoint.Endpoint
+	GetFriendsEndpoint                endpoint.Endpoint
+	GetPlantSpeciesEndpoint           endpoint.Endpoint
+	GetGardenEndpoint                 endpoint.Endpoint
+	PlantSeedEndpoint                 endpoint.Endpoint
+	WaterPlantEndpoint                endpoint.Endpoint
+	GrowPlantEndpoint                 endpoint.Endpoint
+	GetShopEndpoint                   endpoint.Endpoint
+	PurchaseItemEndpoint              endpoint.Endpoint
+	GetInventoryEndpoint              endpoint.Endpoint
+	InternalGetShopItemsEndpoint      endpoint.Endpoint
+	InternalCreateShopItemEndpoint    endpoint.Endpoint
+	InternalUpdateShopItemEndpoint    endpoint.Endpoint
+	InternalDeleteShopItemEndpoint    endpoint.Endpoint
+	InternalGetMultiRatiosEndpoint    endpoint.Endpoint
+	InternalPublishMultiRatioEndpoint endpoint.Endpoint
+	SetGoalEndpoint                   endpoint.Endpoint
+	GetGoalProgressEndpoint           endpoint.Endpoint
+	GetGoalHistoryEndpoint            endpoint.Endpoint
+	CreateTagEndpoint                 endpoint.Endpoint
+	UpdateTagEndpoint                 endpoint.Endpoint
+	GetTagsEndpoint                   endpoint.Endpoint
+	CreateTaskEndpoint                endpoint.Endpoint
+	UpdateTaskEndpoint                endpoint.Endpoint
+	DeleteTaskEndpoint                endpoint.Endpoint
+	GetTasksEndpoint                  endpoint.Endpoint
+	ReorderTasksEndpoint              endpoint.Endpoint
 }
 
 func NewEndpointSet(svc authorization.Service,
@@ -37,7 +103,12 @@ func NewEndpointSet(svc authorization.Service,
 	r database.UserRepository,
 	logger hclog.Logger,
 	validator *database.Validation,
-	tb *ratelimit.Bucket) Set {
+	tb *ratelimit.Bucket,
+	configs *utils.Configurations) Set {
+	reauthMaxAge := time.Minute * time.Duration(configs.ReauthMaxAge)
+	// apiKeyLimiter applies the per key rate limits of internal services
+	apiKeyLimiter := middleware.NewAPIKeyLimiter()
+
 	healthCheckEndpoint := MakeHealthCheckEndpoint(svc)
 	healthCheckEndpoint = middleware.RateLimitRequest(tb, logger)(healthCheckEndpoint)
 
@@ -61,21 +132,24 @@ func NewEndpointSet(svc authorization.Service,
 	getUserEndpoint := MakeGetUserEndpoint(svc)
 	getUserEndpoint = middleware.RateLimitRequest(tb, logger)(getUserEndpoint)
 	getUserEndpoint = middleware.ValidateParamRequest(validator, logger)(getUserEndpoint)
-	getUserEndpoint = middleware.ValidateAccessToken(auth, r, logger)(getUserEndpoint)
+	getUserEndpoint = middleware.ValidateAccessToken(auth, r, logger, database.ScopeProfileRead)(getUserEndpoint)
 
 	getProfileEndpoint := MakeGetProfileEndpoint(svc)
 	getProfileEndpoint = middleware.RateLimitRequest(tb, logger)(getProfileEndpoint)
 	getProfileEndpoint = middleware.ValidateParamRequest(validator, logger)(getProfileEndpoint)
-	getProfileEndpoint = middleware.ValidateAccessToken(auth, r, logger)(getProfileEndpoint)
+	getProfileEndpoint = middleware.ValidateAccessToken(auth, r, logger, database.ScopeProfileRead)(getProfileEndpoint)
 
 	updateProfileEndpoint := MakeUpdateProfileEndpoint(svc)
 	updateProfileEndpoint = middleware.RateLimitRequest(tb, logger)(updateProfileEndpoint)
 	updateProfileEndpoint = middleware.ValidateParamRequest(validator, logger)(updateProfileEndpoint)
+	updateProfileEndpoint = middleware.RejectGuest(logger)(updateProfileEndpoint)
 	updateProfileEndpoint = middleware.ValidateAccessToken(auth, r, logger)(updateProfileEndpoint)
 
 	updatePasswordEndpoint := MakeUpdatePasswordEndpoint(svc)
 	updatePasswordEndpoint = middleware.RateLimitRequest(tb, logger)(updatePasswordEndpoint)
 	updatePasswordEndpoint = middleware.ValidateParamRequest(validator, logger)(updatePasswordEndpoint)
+	updatePasswordEndpoint = middleware.RequireRecentAuthentication(auth, reauthMaxAge, logger)(updatePasswordEndpoint)
+	updatePasswordEndpoint = middleware.RejectGuest(logger)(updatePasswordEndpoint)
 	updatePasswordEndpoint = middleware.ValidateAccessToken(auth, r, logger)(updatePasswordEndpoint)
 
 	getForgetPasswordCodeEndpoint := MakeGetForgetPasswordCodeEndpoint(svc)
@@ -92,34 +166,430 @@ func NewEndpointSet(svc authorization.Service,
 	insertEarnScoreEndpoint := MakeInsertEarnScoreEndpoint(svc)
 	insertEarnScoreEndpoint = middleware.RateLimitRequest(tb, logger)(insertEarnScoreEndpoint)
 	insertEarnScoreEndpoint = middleware.ValidateParamRequest(validator, logger)(insertEarnScoreEndpoint)
-	insertEarnScoreEndpoint = middleware.ValidateAccessToken(auth, r, logger)(insertEarnScoreEndpoint)
+	insertEarnScoreEndpoint = middleware.ValidateAccessToken(auth, r, logger, database.ScopeSessionsWrite)(insertEarnScoreEndpoint)
 
 	getEarnScoreEndpoint := MakeGetEarnScoreEndpoint(svc)
 	getEarnScoreEndpoint = middleware.RateLimitRequest(tb, logger)(getEarnScoreEndpoint)
 	getEarnScoreEndpoint = middleware.ValidateParamRequest(validator, logger)(getEarnScoreEndpoint)
-	getEarnScoreEndpoint = middleware.ValidateAccessToken(auth, r, logger)(getEarnScoreEndpoint)
+	getEarnScoreEndpoint = middleware.ValidateAccessToken(auth, r, logger, database.ScopeScoresRead)(getEarnScoreEndpoint)
 
 	generateAccessTokenEndpoint := MakeGenerateAccessTokenEndpoint(svc)
 	generateAccessTokenEndpoint = middleware.RateLimitRequest(tb, logger)(generateAccessTokenEndpoint)
 	generateAccessTokenEndpoint = middleware.ValidateParamRequest(validator, logger)(generateAccessTokenEndpoint)
 	generateAccessTokenEndpoint = middleware.ValidateRefreshToken(auth, r, logger)(generateAccessTokenEndpoint)
 
+	reauthenticateEndpoint := MakeReauthenticateEndpoint(svc)
+	reauthenticateEndpoint = middleware.RateLimitRequest(tb, logger)(reauthenticateEndpoint)
+	reauthenticateEndpoint = middleware.ValidateParamRequest(validator, logger)(reauthenticateEndpoint)
+	reauthenticateEndpoint = middleware.RejectGuest(logger)(reauthenticateEndpoint)
+	reauthenticateEndpoint = middleware.ValidateAccessToken(auth, r, logger)(reauthenticateEndpoint)
+
+	requestEmailChangeEndpoint := MakeRequestEmailChangeEndpoint(svc)
+	requestEmailChangeEndpoint = middleware.RateLimitRequest(tb, logger)(requestEmailChangeEndpoint)
+	requestEmailChangeEndpoint = middleware.ValidateParamRequest(validator, logger)(requestEmailChangeEndpoint)
+	requestEmailChangeEndpoint = middleware.RequireRecentAuthentication(auth, reauthMaxAge, logger)(requestEmailChangeEndpoint)
+	requestEmailChangeEndpoint = middleware.RejectGuest(logger)(requestEmailChangeEndpoint)
+	requestEmailChangeEndpoint = middleware.ValidateAccessToken(auth, r, logger)(requestEmailChangeEndpoint)
+
+	confirmEmailChangeEndpoint := MakeConfirmEmailChangeEndpoint(svc)
+	confirmEmailChangeEndpoint = middleware.RateLimitRequest(tb, logger)(confirmEmailChangeEndpoint)
+	confirmEmailChangeEndpoint = middleware.ValidateParamRequest(validator, logger)(confirmEmailChangeEndpoint)
+	confirmEmailChangeEndpoint = middleware.RequireRecentAuthentication(auth, reauthMaxAge, logger)(confirmEmailChangeEndpoint)
+	confirmEmailChangeEndpoint = middleware.RejectGuest(logger)(confirmEmailChangeEndpoint)
+	confirmEmailChangeEndpoint = middleware.ValidateAccessToken(auth, r, logger)(confirmEmailChangeEndpoint)
+
+	undoEmailChangeEndpoint := MakeUndoEmailChangeEndpoint(svc)
+	undoEmailChangeEndpoint = middleware.RateLimitRequest(tb, logger)(undoEmailChangeEndpoint)
+	undoEmailChangeEndpoint = middleware.ValidateParamRequest(validator, logger)(undoEmailChangeEndpoint)
+
+	setUsernameEndpoint := MakeSetUsernameEndpoint(svc)
+	setUsernameEndpoint = middleware.RateLimitRequest(tb, logger)(setUsernameEndpoint)
+	setUsernameEndpoint = middleware.ValidateParamRequest(validator, logger)(setUsernameEndpoint)
+	setUsernameEndpoint = middleware.RejectGuest(logger)(setUsernameEndpoint)
+	setUsernameEndpoint = middleware.ValidateAccessToken(auth, r, logger)(setUsernameEndpoint)
+
+	getPublicProfileEndpoint := MakeGetPublicProfileEndpoint(svc)
+	getPublicProfileEndpoint = middleware.RateLimitRequest(tb, logger)(getPublicProfileEndpoint)
+	getPublicProfileEndpoint = middleware.ValidateParamRequest(validator, logger)(getPublicProfileEndpoint)
+
+	socialLoginEndpoint := MakeSocialLoginEndpoint(svc)
+	socialLoginEndpoint = middleware.RateLimitRequest(tb, logger)(socialLoginEndpoint)
+	socialLoginEndpoint = middleware.ValidateParamRequest(validator, logger)(socialLoginEndpoint)
+
+	requestLoginCodeEndpoint := MakeRequestLoginCodeEndpoint(svc)
+	requestLoginCodeEndpoint = middleware.RateLimitRequest(tb, logger)(requestLoginCodeEndpoint)
+	requestLoginCodeEndpoint = middleware.ValidateParamRequest(validator, logger)(requestLoginCodeEndpoint)
+
+	verifyLoginCodeEndpoint := MakeVerifyLoginCodeEndpoint(svc)
+	verifyLoginCodeEndpoint = middleware.RateLimitRequest(tb, logger)(verifyLoginCodeEndpoint)
+	verifyLoginCodeEndpoint = middleware.ValidateParamRequest(validator, logger)(verifyLoginCodeEndpoint)
+
+	guestSignUpEndpoint := MakeGuestSignUpEndpoint(svc)
+	guestSignUpEndpoint = middleware.RateLimitRequest(tb, logger)(guestSignUpEndpoint)
+
+	guestLoginEndpoint := MakeGuestLoginEndpoint(svc)
+	guestLoginEndpoint = middleware.RateLimitRequest(tb, logger)(guestLoginEndpoint)
+	guestLoginEndpoint = middleware.ValidateParamRequest(validator, logger)(guestLoginEndpoint)
+
+	upgradeGuestEndpoint := MakeUpgradeGuestEndpoint(svc)
+	upgradeGuestEndpoint = middleware.RateLimitRequest(tb, logger)(upgradeGuestEndpoint)
+	upgradeGuestEndpoint = middleware.ValidateParamRequest(validator, logger)(upgradeGuestEndpoint)
+	upgradeGuestEndpoint = middleware.ValidateAccessToken(auth, r, logger)(upgradeGuestEndpoint)
+
+	getSignInMethodsEndpoint := MakeGetSignInMethodsEndpoint(svc)
+	getSignInMethodsEndpoint = middleware.RateLimitRequest(tb, logger)(getSignInMethodsEndpoint)
+	getSignInMethodsEndpoint = middleware.ValidateParamRequest(validator, logger)(getSignInMethodsEndpoint)
+	getSignInMethodsEndpoint = middleware.RejectGuest(logger)(getSignInMethodsEndpoint)
+	getSignInMethodsEndpoint = middleware.ValidateAccessToken(auth, r, logger)(getSignInMethodsEndpoint)
+
+	linkIdentityEndpoint := MakeLinkIdentityEndpoint(svc)
+	linkIdentityEndpoint = middleware.RateLimitRequest(tb, logger)(linkIdentityEndpoint)
+	linkIdentityEndpoint = middleware.ValidateParamRequest(validator, logger)(linkIdentityEndpoint)
+	linkIdentityEndpoint = middleware.RequireRecentAuthentication(auth, reauthMaxAge, logger)(linkIdentityEndpoint)
+	linkIdentityEndpoint = middleware.RejectGuest(logger)(linkIdentityEndpoint)
+	linkIdentityEndpoint = middleware.ValidateAccessToken(auth, r, logger)(linkIdentityEndpoint)
+
+	linkPasswordEndpoint := MakeLinkPasswordEndpoint(svc)
+	linkPasswordEndpoint = middleware.RateLimitRequest(tb, logger)(linkPasswordEndpoint)
+	linkPasswordEndpoint = middleware.ValidateParamRequest(validator, logger)(linkPasswordEndpoint)
+	linkPasswordEndpoint = middleware.RequireRecentAuthentication(auth, reauthMaxAge, logger)(linkPasswordEndpoint)
+	linkPasswordEndpoint = middleware.RejectGuest(logger)(linkPasswordEndpoint)
+	linkPasswordEndpoint = middleware.ValidateAccessToken(auth, r, logger)(linkPasswordEndpoint)
+
+	unlinkSignInMethodEndpoint := MakeUnlinkSignInMethodEndpoint(svc)
+	unlinkSignInMethodEndpoint = middleware.RateLimitRequest(tb, logger)(unlinkSignInMethodEndpoint)
+	unlinkSignInMethodEndpoint = middleware.ValidateParamRequest(validator, logger)(unlinkSignInMethodEndpoint)
+	unlinkSignInMethodEndpoint = middleware.RequireRecentAuthentication(auth, reauthMaxAge, logger)(unlinkSignInMethodEndpoint)
+	unlinkSignInMethodEndpoint = middleware.RejectGuest(logger)(unlinkSignInMethodEndpoint)
+	unlinkSignInMethodEndpoint = middleware.ValidateAccessToken(auth, r, logger)(unlinkSignInMethodEndpoint)
+
+	createPersonalAccessTokenEndpoint := MakeCreatePersonalAccessTokenEndpoint(svc)
+	createPersonalAccessTokenEndpoint = middleware.RateLimitRequest(tb, logger)(createPersonalAccessTokenEndpoint)
+	createPersonalAccessTokenEndpoint = middleware.ValidateParamRequest(validator, logger)(createPersonalAccessTokenEndpoint)
+	createPersonalAccessTokenEndpoint = middleware.RequireRecentAuthentication(auth, reauthMaxAge, logger)(createPersonalAccessTokenEndpoint)
+	createPersonalAccessTokenEndpoint = middleware.RejectGuest(logger)(createPersonalAccessTokenEndpoint)
+	createPersonalAccessTokenEndpoint = middleware.ValidateAccessToken(auth, r, logger)(createPersonalAccessTokenEndpoint)
+
+	getPersonalAccessTokensEndpoint := MakeGetPersonalAccessTokensEndpoint(svc)
+	getPersonalAccessTokensEndpoint = middleware.RateLimitRequest(tb, logger)(getPersonalAccessTokensEndpoint)
+	getPersonalAccessTokensEndpoint = middleware.ValidateParamRequest(validator, logger)(getPersonalAccessTokensEndpoint)
+	getPersonalAccessTokensEndpoint = middleware.RejectGuest(logger)(getPersonalAccessTokensEndpoint)
+	getPersonalAccessTokensEndpoint = middleware.ValidateAccessToken(auth, r, logger)(getPersonalAccessTokensEndpoint)
+
+	revokePersonalAccessTokenEndpoint := MakeRevokePersonalAccessTokenEndpoint(svc)
+	revokePersonalAccessTokenEndpoint = middleware.RateLimitRequest(tb, logger)(revokePersonalAccessTokenEndpoint)
+	revokePersonalAccessTokenEndpoint = middleware.ValidateParamRequest(validator, logger)(revokePersonalAccessTokenEndpoint)
+	revokePersonalAccessTokenEndpoint = middleware.RejectGuest(logger)(revokePersonalAccessTokenEndpoint)
+	revokePersonalAccessTokenEndpoint = middleware.ValidateAccessToken(auth, r, logger)(revokePersonalAccessTokenEndpoint)
+
+	internalGetUserEndpoint := MakeInternalGetUserEndpoint(svc)
+	internalGetUserEndpoint = middleware.ValidateParamRequest(validator, logger)(internalGetUserEndpoint)
+	internalGetUserEndpoint = middleware.ValidateAPIKey(r, apiKeyLimiter, logger, database.ScopeUsersRead)(internalGetUserEndpoint)
+
+	internalGetEarnScoreEndpoint := MakeInternalGetEarnScoreEndpoint(svc)
+	internalGetEarnScoreEndpoint = middleware.ValidateParamRequest(validator, logger)(internalGetEarnScoreEndpoint)
+	internalGetEarnScoreEndpoint = middleware.ValidateAPIKey(r, apiKeyLimiter, logger, database.ScopeScoresRead)(internalGetEarnScoreEndpoint)
+
+	getOAuthClientEndpoint := MakeGetOAuthClientEndpoint(svc)
+	getOAuthClientEndpoint = middleware.RateLimitRequest(tb, logger)(getOAuthClientEndpoint)
+	getOAuthClientEndpoint = middleware.ValidateParamRequest(validator, logger)(getOAuthClientEndpoint)
+	getOAuthClientEndpoint = middleware.RejectGuest(logger)(getOAuthClientEndpoint)
+	getOAuthClientEndpoint = middleware.ValidateAccessToken(auth, r, logger)(getOAuthClientEndpoint)
+
+	authorizeOAuthClientEndpoint := MakeAuthorizeOAuthClientEndpoint(svc)
+	authorizeOAuthClientEndpoint = middleware.RateLimitRequest(tb, logger)(authorizeOAuthClientEndpoint)
+	authorizeOAuthClientEndpoint = middleware.ValidateParamRequest(validator, logger)(authorizeOAuthClientEndpoint)
+	authorizeOAuthClientEndpoint = middleware.RejectGuest(logger)(authorizeOAuthClientEndpoint)
+	authorizeOAuthClientEndpoint = middleware.ValidateAccessToken(auth, r, logger)(authorizeOAuthClientEndpoint)
+
+	oauthTokenEndpoint := MakeOAuthTokenEndpoint(svc)
+	oauthTokenEndpoint = middleware.RateLimitRequest(tb, logger)(oauthTokenEndpoint)
+
+	requestDeviceCodeEndpoint := MakeRequestDeviceCodeEndpoint(svc)
+	requestDeviceCodeEndpoint = middleware.RateLimitRequest(tb, logger)(requestDeviceCodeEndpoint)
+
+	verifyDeviceCodeEndpoint := MakeVerifyDeviceCodeEndpoint(svc)
+	verifyDeviceCodeEndpoint = middleware.RateLimitRequest(tb, logger)(verifyDeviceCodeEndpoint)
+	verifyDeviceCodeEndpoint = middleware.ValidateParamRequest(validator, logger)(verifyDeviceCodeEndpoint)
+	verifyDeviceCodeEndpoint = middleware.RejectGuest(logger)(verifyDeviceCodeEndpoint)
+	verifyDeviceCodeEndpoint = middleware.ValidateAccessToken(auth, r, logger)(verifyDeviceCodeEndpoint)
+
+	getOAuthConsentsEndpoint := MakeGetOAuthConsentsEndpoint(svc)
+	getOAuthConsentsEndpoint = middleware.RateLimitRequest(tb, logger)(getOAuthConsentsEndpoint)
+	getOAuthConsentsEndpoint = middleware.ValidateParamRequest(validator, logger)(getOAuthConsentsEndpoint)
+	getOAuthConsentsEndpoint = middleware.RejectGuest(logger)(getOAuthConsentsEndpoint)
+	getOAuthConsentsEndpoint = middleware.ValidateAccessToken(auth, r, logger)(getOAuthConsentsEndpoint)
+
+	revokeOAuthConsentEndpoint := MakeRevokeOAuthConsentEndpoint(svc)
+	revokeOAuthConsentEndpoint = middleware.RateLimitRequest(tb, logger)(revokeOAuthConsentEndpoint)
+	revokeOAuthConsentEndpoint = middleware.ValidateParamRequest(validator, logger)(revokeOAuthConsentEndpoint)
+	revokeOAuthConsentEndpoint = middleware.RejectGuest(logger)(revokeOAuthConsentEndpoint)
+	revokeOAuthConsentEndpoint = middleware.ValidateAccessToken(auth, r, logger)(revokeOAuthConsentEndpoint)
+
+	createOAuthClientEndpoint := MakeCreateOAuthClientEndpoint(svc)
+	createOAuthClientEndpoint = middleware.ValidateParamRequest(validator, logger)(createOAuthClientEndpoint)
+	createOAuthClientEndpoint = middleware.ValidateAPIKey(r, apiKeyLimiter, logger, database.ScopeClientsWrite)(createOAuthClientEndpoint)
+
+	sendPhoneCodeEndpoint := MakeSendPhoneCodeEndpoint(svc)
+	sendPhoneCodeEndpoint = middleware.RateLimitRequest(tb, logger)(sendPhoneCodeEndpoint)
+	sendPhoneCodeEndpoint = middleware.ValidateParamRequest(validator, logger)(sendPhoneCodeEndpoint)
+	sendPhoneCodeEndpoint = middleware.RejectGuest(logger)(sendPhoneCodeEndpoint)
+	sendPhoneCodeEndpoint = middleware.ValidateAccessToken(auth, r, logger)(sendPhoneCodeEndpoint)
+
+	verifyPhoneEndpoint := MakeVerifyPhoneEndpoint(svc)
+	verifyPhoneEndpoint = middleware.RateLimitRequest(tb, logger)(verifyPhoneEndpoint)
+	verifyPhoneEndpoint = middleware.ValidateParamRequest(validator, logger)(verifyPhoneEndpoint)
+	verifyPhoneEndpoint = middleware.RejectGuest(logger)(verifyPhoneEndpoint)
+	verifyPhoneEndpoint = middleware.ValidateAccessToken(auth, r, logger)(verifyPhoneEndpoint)
+
+	getForgetPasswordSMSEndpoint := MakeGetForgetPasswordSMSEndpoint(svc)
+	getForgetPasswordSMSEndpoint = middleware.RateLimitRequest(tb, logger)(getForgetPasswordSMSEndpoint)
+	getForgetPasswordSMSEndpoint = middleware.ValidateParamRequest(validator, logger)(getForgetPasswordSMSEndpoint)
+
+	getFocusStatsEndpoint := MakeGetFocusStatsEndpoint(svc)
+	getFocusStatsEndpoint = middleware.RateLimitRequest(tb, logger)(getFocusStatsEndpoint)
+	getFocusStatsEndpoint = middleware.ValidateParamRequest(validator, logger)(getFocusStatsEndpoint)
+	getFocusStatsEndpoint = middleware.ValidateAccessToken(auth, r, logger, database.ScopeScoresRead)(getFocusStatsEndpoint)
+
+	getAchievementsEndpoint := MakeGetAchievementsEndpoint(svc)
+	getAchievementsEndpoint = middleware.RateLimitRequest(tb, logger)(getAchievementsEndpoint)
+	getAchievementsEndpoint = middleware.ValidateParamRequest(validator, logger)(getAchievementsEndpoint)
+	getAchievementsEndpoint = middleware.ValidateAccessToken(auth, r, logger, database.ScopeScoresRead)(getAchievementsEndpoint)
+
+	getLeaderboardEndpoint := MakeGetLeaderboardEndpoint(svc)
+	getLeaderboardEndpoint = middleware.RateLimitRequest(tb, logger)(getLeaderboardEndpoint)
+	getLeaderboardEndpoint = middleware.ValidateParamRequest(validator, logger)(getLeaderboardEndpoint)
+	getLeaderboardEndpoint = middleware.ValidateAccessToken(auth, r, logger, database.ScopeScoresRead)(getLeaderboardEndpoint)
+
+	getPrivacySettingsEndpoint := MakeGetPrivacySettingsEndpoint(svc)
+	getPrivacySettingsEndpoint = middleware.RateLimitRequest(tb, logger)(getPrivacySettingsEndpoint)
+	getPrivacySettingsEndpoint = middleware.ValidateParamRequest(validator, logger)(getPrivacySettingsEndpoint)
+	getPrivacySettingsEndpoint = middleware.RejectGuest(logger)(getPrivacySettingsEndpoint)
+	getPrivacySettingsEndpoint = middleware.ValidateAccessToken(auth, r, logger)(getPrivacySettingsEndpoint)
+
+	updatePrivacySettingsEndpoint := MakeUpdatePrivacySettingsEndpoint(svc)
+	updatePrivacySettingsEndpoint = middleware.RateLimitRequest(tb, logger)(updatePrivacySettingsEndpoint)
+	updatePrivacySettingsEndpoint = middleware.ValidateParamRequest(validator, logger)(updatePrivacySettingsEndpoint)
+	updatePrivacySettingsEndpoint = middleware.RejectGuest(logger)(updatePrivacySettingsEndpoint)
+	updatePrivacySettingsEndpoint = middleware.ValidateAccessToken(auth, r, logger)(updatePrivacySettingsEndpoint)
+
+	addFriendEndpoint := MakeAddFriendEndpoint(svc)
+	addFriendEndpoint = middleware.RateLimitRequest(tb, logger)(addFriendEndpoint)
+	addFriendEndpoint = middleware.ValidateParamRequest(validator, logger)(addFriendEndpoint)
+	addFriendEndpoint = middleware.RejectGuest(logger)(addFriendEndpoint)
+	addFriendEndpoint = middleware.ValidateAccessToken(auth, r, logger)(addFriendEndpoint)
+
+	removeFriendEndpoint := MakeRemoveFriendEndpoint(svc)
+	removeFriendEndpoint = middleware.RateLimitRequest(tb, logger)(removeFriendEndpoint)
+	removeFriendEndpoint = middleware.ValidateParamRequest(validator, logger)(removeFriendEndpoint)
+	removeFriendEndpoint = middleware.RejectGuest(logger)(removeFriendEndpoint)
+	removeFriendEndpoint = middleware.ValidateAccessToken(auth, r, logger)(removeFriendEndpoint)
+
+	getFriendsEndpoint := MakeGetFriendsEndpoint(svc)
+	getFriendsEndpoint = middleware.RateLimitRequest(tb, logger)(getFriendsEndpoint)
+	getFriendsEndpoint = middleware.ValidateParamRequest(validator, logger)(getFriendsEndpoint)
+	getFriendsEndpoint = middleware.RejectGuest(logger)(getFriendsEndpoint)
+	getFriendsEndpoint = middleware.ValidateAccessToken(auth, r, logger)(getFriendsEndpoint)
+
+	getPlantSpeciesEndpoint := MakeGetPlantSpeciesEndpoint(svc)
+	getPlantSpeciesEndpoint = middleware.RateLimitRequest(tb, logger)(getPlantSpeciesEndpoint)
+	getPlantSpeciesEndpoint = middleware.ValidateParamRequest(validator, logger)(getPlantSpeciesEndpoint)
+	getPlantSpeciesEndpoint = middleware.ValidateAccessToken(auth, r, logger, database.ScopeScoresRead)(getPlantSpeciesEndpoint)
+
+	getGardenEndpoint := MakeGetGardenEndpoint(svc)
+	getGardenEndpoint = middleware.RateLimitRequest(tb, logger)(getGardenEndpoint)
+	getGardenEndpoint = middleware.ValidateParamRequest(validator, logger)(getGardenEndpoint)
+	getGardenEndpoint = middleware.ValidateAccessToken(auth, r, logger, database.ScopeScoresRead)(getGardenEndpoint)
+
+	plantSeedEndpoint := MakePlantSeedEndpoint(svc)
+	plantSeedEndpoint = middleware.RateLimitRequest(tb, logger)(plantSeedEndpoint)
+	plantSeedEndpoint = middleware.ValidateParamRequest(validator, logger)(plantSeedEndpoint)
+	plantSeedEndpoint = middleware.ValidateAccessToken(auth, r, logger)(plantSeedEndpoint)
+
+	waterPlantEndpoint := MakeWaterPlantEndpoint(svc)
+	waterPlantEndpoint = middleware.RateLimitRequest(tb, logger)(waterPlantEndpoint)
+	waterPlantEndpoint = middleware.ValidateParamRequest(validator, logger)(waterPlantEndpoint)
+	waterPlantEndpoint = middleware.ValidateAccessToken(auth, r, logger)(waterPlantEndpoint)
+
+	growPlantEndpoint := MakeGrowPlantEndpoint(svc)
+	growPlantEndpoint = middleware.RateLimitRequest(tb, logger)(growPlantEndpoint)
+	growPlantEndpoint = middleware.ValidateParamRequest(validator, logger)(growPlantEndpoint)
+	growPlantEndpoint = middleware.ValidateAccessToken(auth, r, logger)(growPlantEndpoint)
+
+	getShopEndpoint := MakeGetShopEndpoint(svc)
+	getShopEndpoint = middleware.RateLimitRequest(tb, logger)(getShopEndpoint)
+	getShopEndpoint = middleware.ValidateParamRequest(validator, logger)(getShopEndpoint)
+	getShopEndpoint = middleware.ValidateAccessToken(auth, r, logger, database.ScopeScoresRead)(getShopEndpoint)
+
+	purchaseItemEndpoint := MakePurchaseItemEndpoint(svc)
+	purchaseItemEndpoint = middleware.RateLimitRequest(tb, logger)(purchaseItemEndpoint)
+	purchaseItemEndpoint = middleware.ValidateParamRequest(validator, logger)(purchaseItemEndpoint)
+	purchaseItemEndpoint = middleware.ValidateAccessToken(auth, r, logger)(purchaseItemEndpoint)
+
+	getInventoryEndpoint := MakeGetInventoryEndpoint(svc)
+	getInventoryEndpoint = middleware.RateLimitRequest(tb, logger)(getInventoryEndpoint)
+	getInventoryEndpoint = middleware.ValidateParamRequest(validator, logger)(getInventoryEndpoint)
+	getInventoryEndpoint = middleware.ValidateAccessToken(auth, r, logger, database.ScopeScoresRead)(getInventoryEndpoint)
+
+	internalGetShopItemsEndpoint := MakeInternalGetShopItemsEndpoint(svc)
+	internalGetShopItemsEndpoint = middleware.ValidateParamRequest(validator, logger)(internalGetShopItemsEndpoint)
+	internalGetShopItemsEndpoint = middleware.ValidateAPIKey(r, apiKeyLimiter, logger, database.ScopeShopWrite)(internalGetShopItemsEndpoint)
+
+	internalCreateShopItemEndpoint := MakeInternalCreateShopItemEndpoint(svc)
+	internalCreateShopItemEndpoint = middleware.ValidateParamRequest(validator, logger)(internalCreateShopItemEndpoint)
+	internalCreateShopItemEndpoint = middleware.ValidateAPIKey(r, apiKeyLimiter, logger, database.ScopeShopWrite)(internalCreateShopItemEndpoint)
+
+	internalUpdateShopItemEndpoint := MakeInternalUpdateShopItemEndpoint(svc)
+	internalUpdateShopItemEndpoint = middleware.ValidateParamRequest(validator, logger)(internalUpdateShopItemEndpoint)
+	internalUpdateShopItemEndpoint = middleware.ValidateAPIKey(r, apiKeyLimiter, logger, database.ScopeShopWrite)(internalUpdateShopItemEndpoint)
+
+	internalDeleteShopItemEndpoint := MakeInternalDeleteShopItemEndpoint(svc)
+	internalDeleteShopItemEndpoint = middleware.ValidateParamRequest(validator, logger)(internalDeleteShopItemEndpoint)
+	internalDeleteShopItemEndpoint = middleware.ValidateAPIKey(r, apiKeyLimiter, logger, database.ScopeShopWrite)(internalDeleteShopItemEndpoint)
+
+	internalGetMultiRatiosEndpoint := MakeInternalGetMultiRatiosEndpoint(svc)
+	internalGetMultiRatiosEndpoint = middleware.ValidateAPIKey(r, apiKeyLimiter, logger, database.ScopeRatiosWrite)(internalGetMultiRatiosEndpoint)
+
+	internalPublishMultiRatioEndpoint := MakeInternalPublishMultiRatioEndpoint(svc)
+	internalPublishMultiRatioEndpoint = middleware.ValidateParamRequest(validator, logger)(internalPublishMultiRatioEndpoint)
+	internalPublishMultiRatioEndpoint = middleware.ValidateAPIKey(r, apiKeyLimiter, logger, database.ScopeRatiosWrite)(internalPublishMultiRatioEndpoint)
+
+	setGoalEndpoint := MakeSetGoalEndpoint(svc)
+	setGoalEndpoint = middleware.RateLimitRequest(tb, logger)(setGoalEndpoint)
+	setGoalEndpoint = middleware.ValidateParamRequest(validator, logger)(setGoalEndpoint)
+	setGoalEndpoint = middleware.ValidateAccessToken(auth, r, logger)(setGoalEndpoint)
+
+	getGoalProgressEndpoint := MakeGetGoalProgressEndpoint(svc)
+	getGoalProgressEndpoint = middleware.RateLimitRequest(tb, logger)(getGoalProgressEndpoint)
+	getGoalProgressEndpoint = middleware.ValidateParamRequest(validator, logger)(getGoalProgressEndpoint)
+	getGoalProgressEndpoint = middleware.ValidateAccessToken(auth, r, logger, database.ScopeScoresRead)(getGoalProgressEndpoint)
+
+	getGoalHistoryEndpoint := MakeGetGoalHistoryEndpoint(svc)
+	getGoalHistoryEndpoint = middleware.RateLimitRequest(tb, logger)(getGoalHistoryEndpoint)
+	getGoalHistoryEndpoint = middleware.ValidateParamRequest(validator, logger)(getGoalHistoryEndpoint)
+	getGoalHistoryEndpoint = middleware.ValidateAccessToken(auth, r, logger, database.ScopeScoresRead)(getGoalHistoryEndpoint)
+
+	createTagEndpoint := MakeCreateTagEndpoint(svc)
+	createTagEndpoint = middleware.RateLimitRequest(tb, logger)(createTagEndpoint)
+	createTagEndpoint = middleware.ValidateParamRequest(validator, logger)(createTagEndpoint)
+	createTagEndpoint = middleware.ValidateAccessToken(auth, r, logger)(createTagEndpoint)
+
+	updateTagEndpoint := MakeUpdateTagEndpoint(svc)
+	updateTagEndpoint = middleware.RateLimitRequest(tb, logger)(updateTagEndpoint)
+	updateTagEndpoint = middleware.ValidateParamRequest(validator, logger)(updateTagEndpoint)
+	updateTagEndpoint = middleware.ValidateAccessToken(auth, r, logger)(updateTagEndpoint)
+
+	getTagsEndpoint := MakeGetTagsEndpoint(svc)
+	getTagsEndpoint = middleware.RateLimitRequest(tb, logger)(getTagsEndpoint)
+	getTagsEndpoint = middleware.ValidateParamRequest(validator, logger)(getTagsEndpoint)
+	getTagsEndpoint = middleware.ValidateAccessToken(auth, r, logger, database.ScopeScoresRead)(getTagsEndpoint)
+
+	createTaskEndpoint := MakeCreateTaskEndpoint(svc)
+	createTaskEndpoint = middleware.RateLimitRequest(tb, logger)(createTaskEndpoint)
+	createTaskEndpoint = middleware.ValidateParamRequest(validator, logger)(createTaskEndpoint)
+	createTaskEndpoint = middleware.ValidateAccessToken(auth, r, logger)(createTaskEndpoint)
+
+	updateTaskEndpoint := MakeUpdateTaskEndpoint(svc)
+	updateTaskEndpoint = middleware.RateLimitRequest(tb, logger)(updateTaskEndpoint)
+	updateTaskEndpoint = middleware.ValidateParamRequest(validator, logger)(updateTaskEndpoint)
+	updateTaskEndpoint = middleware.ValidateAccessToken(auth, r, logger)(updateTaskEndpoint)
+
+	deleteTaskEndpoint := MakeDeleteTaskEndpoint(svc)
+	deleteTaskEndpoint = middleware.RateLimitRequest(tb, logger)(deleteTaskEndpoint)
+	deleteTaskEndpoint = middleware.ValidateParamRequest(validator, logger)(deleteTaskEndpoint)
+	deleteTaskEndpoint = middleware.ValidateAccessToken(auth, r, logger)(deleteTaskEndpoint)
+
+	getTasksEndpoint := MakeGetTasksEndpoint(svc)
+	getTasksEndpoint = middleware.RateLimitRequest(tb, logger)(getTasksEndpoint)
+	getTasksEndpoint = middleware.ValidateParamRequest(validator, logger)(getTasksEndpoint)
+	getTasksEndpoint = middleware.ValidateAccessToken(auth, r, logger)(getTasksEndpoint)
+
+	reorderTasksEndpoint := MakeReorderTasksEndpoint(svc)
+	reorderTasksEndpoint = middleware.RateLimitRequest(tb, logger)(reorderTasksEndpoint)
+	reorderTasksEndpoint = middleware.ValidateParamRequest(validator, logger)(reorderTasksEndpoint)
+	reorderTasksEndpoint = middleware.ValidateAccessToken(auth, r, logger)(reorderTasksEndpoint)
+
 	return Set{
-		HealthCheckEndpoint:           healthCheckEndpoint,
-		RegisterEndpoint:              registerEndpoint,
-		VerifyMailEndpoint:            verifyMailEndpoint,
-		LoginEndpoint:                 loginEndpoint,
-		LogoutEndpoint:                logoutEndpoint,
-		GetUserEndpoint:               getUserEndpoint,
-		GetProfileEndpoint:            getProfileEndpoint,
-		UpdateProfileEndpoint:         updateProfileEndpoint,
-		UpdatePasswordEndpoint:        updatePasswordEndpoint,
-		GetForgetPasswordCodeEndpoint: getForgetPasswordCodeEndpoint,
-		ResetPasswordEndpoint:         resetPasswordEndpoint,
-		GetMultiRatioDataEndpoint:     getMultiRatioDataEndpoint,
-		InsertEarnScoreEndpoint:       insertEarnScoreEndpoint,
-		GetEarnScoreEndpoint:          getEarnScoreEndpoint,
-		GenerateAccessTokenEndpoint:   generateAccessTokenEndpoint,
+		HealthCheckEndpoint:               healthCheckEndpoint,
+		RegisterEndpoint:                  registerEndpoint,
+		VerifyMailEndpoint:                verifyMailEndpoint,
+		LoginEndpoint:                     loginEndpoint,
+		LogoutEndpoint:                    logoutEndpoint,
+		GetUserEndpoint:                   getUserEndpoint,
+		GetProfileEndpoint:                getProfileEndpoint,
+		UpdateProfileEndpoint:             updateProfileEndpoint,
+		UpdatePasswordEndpoint:            updatePasswordEndpoint,
+		GetForgetPasswordCodeEndpoint:     getForgetPasswordCodeEndpoint,
+		ResetPasswordEndpoint:             resetPasswordEndpoint,
+		GetMultiRatioDataEndpoint:         getMultiRatioDataEndpoint,
+		InsertEarnScoreEndpoint:           insertEarnScoreEndpoint,
+		GetEarnScoreEndpoint:              getEarnScoreEndpoint,
+		GenerateAccessTokenEndpoint:       generateAccessTokenEndpoint,
+		ReauthenticateEndpoint:            reauthenticateEndpoint,
+		RequestEmailChangeEndpoint:        requestEmailChangeEndpoint,
+		ConfirmEmailChangeEndpoint:        confirmEmailChangeEndpoint,
+		UndoEmailChangeEndpoint:           undoEmailChangeEndpoint,
+		SetUsernameEndpoint:               setUsernameEndpoint,
+		GetPublicProfileEndpoint:          getPublicProfileEndpoint,
+		SocialLoginEndpoint:               socialLoginEndpoint,
+		RequestLoginCodeEndpoint:          requestLoginCodeEndpoint,
+		VerifyLoginCodeEndpoint:           verifyLoginCodeEndpoint,
+		GuestSignUpEndpoint:               guestSignUpEndpoint,
+		GuestLoginEndpoint:                guestLoginEndpoint,
+		UpgradeGuestEndpoint:              upgradeGuestEndpoint,
+		GetSignInMethodsEndpoint:          getSignInMethodsEndpoint,
+		LinkIdentityEndpoint:              linkIdentityEndpoint,
+		LinkPasswordEndpoint:              linkPasswordEndpoint,
+		UnlinkSignInMethodEndpoint:        unlinkSignInMethodEndpoint,
+		CreatePersonalAccessTokenEndpoint: createPersonalAccessTokenEndpoint,
+		GetPersonalAccessTokensEndpoint:   getPersonalAccessTokensEndpoint,
+		RevokePersonalAccessTokenEndpoint: revokePersonalAccessTokenEndpoint,
+		InternalGetUserEndpoint:           internalGetUserEndpoint,
+		InternalGetEarnScoreEndpoint:      internalGetEarnScoreEndpoint,
+		GetOAuthClientEndpoint:            getOAuthClientEndpoint,
+		AuthorizeOAuthClientEndpoint:      authorizeOAuthClientEndpoint,
+		OAuthTokenEndpoint:                oauthTokenEndpoint,
+		RequestDeviceCodeEndpoint:         requestDeviceCodeEndpoint,
+		VerifyDeviceCodeEndpoint:          verifyDeviceCodeEndpoint,
+		GetOAuthConsentsEndpoint:          getOAuthConsentsEndpoint,
+		RevokeOAuthConsentEndpoint:        revokeOAuthConsentEndpoint,
+		CreateOAuthClientEndpoint:         createOAuthClientEndpoint,
+		SendPhoneCodeEndpoint:             sendPhoneCodeEndpoint,
+		VerifyPhoneEndpoint:               verifyPhoneEndpoint,
+		GetForgetPasswordSMSEndpoint:      getForgetPasswordSMSEndpoint,
+		GetFocusStatsEndpoint:             getFocusStatsEndpoint,
+		GetAchievementsEndpoint:           getAchievementsEndpoint,
+		GetLeaderboardEndpoint:            getLeaderboardEndpoint,
+		GetPrivacySettingsEndpoint:        getPrivacySettingsEndpoint,
+		UpdatePrivacySettingsEndpoint:     updatePrivacySettingsEndpoint,
+		AddFriendEndpoint:                 addFriendEndpoint,
+		RemoveFriendEndpoint:              removeFriendEndpoint,
+		GetFriendsEndpoint:                getFriendsEndpoint,
+		GetPlantSpeciesEndpoint:           getPlantSpeciesEndpoint,
+		GetGardenEndpoint:                 getGardenEndpoint,
+		PlantSeedEndpoint:                 plantSeedEndpoint,
+		WaterPlantEndpoint:                waterPlantEndpoint,
+		GrowPlantEndpoint:                 growPlantEndpoint,
+		GetShopEndpoint:                   getShopEndpoint,
+		PurchaseItemEndpoint:              purchaseItemEndpoint,
+		GetInventoryEndpoint:              getInventoryEndpoint,
+		InternalGetShopItemsEndpoint:      internalGetShopItemsEndpoint,
+		InternalCreateShopItemEndpoint:    internalCreateShopItemEndpoint,
+		InternalUpdateShopItemEndpoint:    internalUpdateShopItemEndpoint,
+		InternalDeleteShopItemEndpoint:    internalDeleteShopItemEndpoint,
+		InternalGetMultiRatiosEndpoint:    internalGetMultiRatiosEndpoint,
+		InternalPublishMultiRatioEndpoint: internalPublishMultiRatioEndpoint,
+		SetGoalEndpoint:                   setGoalEndpoint,
+		GetGoalProgressEndpoint:           getGoalProgressEndpoint,
+		GetGoalHistoryEndpoint:            getGoalHistoryEndpoint,
+		CreateTagEndpoint:                 createTagEndpoint,
+		UpdateTagEndpoint:                 updateTagEndpoint,
+		GetTagsEndpoint:                   getTagsEndpoint,
+		CreateTaskEndpoint:                createTaskEndpoint,
+		UpdateTaskEndpoint:                updateTaskEndpoint,
+		DeleteTaskEndpoint:                deleteTaskEndpoint,
+		GetTasksEndpoint:                  getTasksEndpoint,
+		ReorderTasksEndpoint:              reorderTasksEndpoint,
 	}
 }
 
@@ -135,6 +605,14 @@ func MakeRegisterEndpoint(svc authorization.Service) endpoint.Endpoint {
 		message, err := svc.SignUp(ctx, &req)
 
 		if err != nil {
+			if cusErr, ok := passwordPolicyError(err); ok {
+				return nil, cusErr
+			}
+			if errors.Is(err, utils.NewErrorResponse(utils.PasswordNotMatch)) ||
+				errors.Is(err, utils.NewErrorResponse(utils.DisposableEmail)) {
+				cusErr := utils.NewErrorWrapper(http.StatusBadRequest, err, err.Error())
+				return nil, cusErr
+			}
 			if strings.Contains(err.Error(), utils.PgDuplicateKeyMsg) {
 				cusErr := utils.NewErrorWrapper(http.StatusConflict, err, "Tài khoản đã tồn tại. Vui lòng thử lại.")
 				return nil, cusErr
@@ -176,6 +654,10 @@ func MakeLoginEndpoint(svc authorization.Service) endpoint.Endpoint {
 		user, err := svc.Login(ctx, &req)
 
 		if err != nil {
+			if errors.Is(err, utils.NewErrorResponse(utils.InvalidCredentials)) {
+				cusErr := utils.NewErrorWrapper(http.StatusUnauthorized, err, err.Error())
+				return nil, cusErr
+			}
 			if strings.Contains(err.Error(), utils.PgDuplicateKeyMsg) {
 				cusErr := utils.NewErrorWrapper(http.StatusConflict, err, "Account already exists. Please try again.")
 				return nil, cusErr
@@ -263,6 +745,10 @@ func MakeUpdateProfileEndpoint(svc authorization.Service) endpoint.Endpoint {
 		}
 		profile, err := svc.UpdateProfile(ctx, &req)
 		if err != nil {
+			var errResp utils.ErrorResponse
+			if errors.As(err, &errResp) {
+				return nil, serviceError(err)
+			}
 			cusErr := utils.NewErrorWrapper(http.StatusInternalServerError, err, "Can't update profile. Please try again later.")
 			return nil, cusErr
 		}
@@ -281,6 +767,9 @@ func MakeUpdatePasswordEndpoint(svc authorization.Service) endpoint.Endpoint {
 		}
 		message, err := svc.UpdatePassword(ctx, &req)
 		if err != nil {
+			if cusErr, ok := passwordPolicyError(err); ok {
+				return nil, cusErr
+			}
 			cusErr := utils.NewErrorWrapper(http.StatusInternalServerError, err, message)
 			return nil, cusErr
 		}
@@ -288,6 +777,9 @@ func MakeUpdatePasswordEndpoint(svc authorization.Service) endpoint.Endpoint {
 	}
 }
 
+// forgetPasswordCodeMsg is answered to every forget password request that did not fail
+const forgetPasswordCodeMsg = "successfully mailed password reset code. Please check your email."
+
 // MakeGetForgetPasswordCodeEndpoint returns an endpoint that invokes GetForgetPasswordCode on the service.
 func MakeGetForgetPasswordCodeEndpoint(svc authorization.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
@@ -304,7 +796,7 @@ func MakeGetForgetPasswordCodeEndpoint(svc authorization.Service) endpoint.Endpo
 			}
 			return nil, err
 		}
-		return "successfully mailed password reset code. Please check your email.", nil
+		return forgetPasswordCodeMsg, nil
 	}
 }
 
@@ -319,6 +811,9 @@ func MakeCreateNewPasswordWithCodeEndpoint(svc authorization.Service) endpoint.E
 		}
 		err := svc.ResetPassword(ctx, &req)
 		if err != nil {
+			if cusErr, ok := passwordPolicyError(err); ok {
+				return nil, cusErr
+			}
 			return nil, err
 		}
 		return "successfully updated password.", nil
@@ -347,7 +842,7 @@ func MakeInsertEarnScoreEndpoint(svc authorization.Service) endpoint.Endpoint {
 		}
 		err := svc.InsertEarnScore(ctx, &req)
 		if err != nil {
-			return nil, err
+			return nil, serviceError(err)
 		}
 		return "successfully inserted earn score.", nil
 	}
@@ -385,3 +880,1079 @@ func MakeGenerateAccessTokenEndpoint(svc authorization.Service) endpoint.Endpoin
 		return token, nil
 	}
 }
+
+// MakeReauthenticateEndpoint returns an endpoint that invokes Reauthenticate on the service.
+func MakeReauthenticateEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.ReauthenticateRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.Reauthenticate(ctx, &req)
+		if err != nil {
+			if errors.Is(err, utils.NewErrorResponse(utils.PasswordIncorrect)) {
+				cusErr := utils.NewErrorWrapper(http.StatusUnauthorized, err, err.Error())
+				return nil, cusErr
+			}
+			return nil, err
+		}
+		return response, nil
+	}
+}
+
+// MakeRequestEmailChangeEndpoint returns an endpoint that invokes RequestEmailChange on the service.
+func MakeRequestEmailChangeEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.RequestEmailChangeRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		message, err := svc.RequestEmailChange(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return message, nil
+	}
+}
+
+// MakeConfirmEmailChangeEndpoint returns an endpoint that invokes ConfirmEmailChange on the service.
+func MakeConfirmEmailChangeEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.ConfirmEmailChangeRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		message, err := svc.ConfirmEmailChange(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return message, nil
+	}
+}
+
+// MakeUndoEmailChangeEndpoint returns an endpoint that invokes UndoEmailChange on the service.
+func MakeUndoEmailChangeEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.UndoEmailChangeRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		message, err := svc.UndoEmailChange(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return message, nil
+	}
+}
+
+// MakeSetUsernameEndpoint returns an endpoint that invokes SetUsername on the service.
+func MakeSetUsernameEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.SetUsernameRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.SetUsername(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeGetPublicProfileEndpoint returns an endpoint that invokes GetPublicProfile on the service.
+func MakeGetPublicProfileEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.GetPublicProfileRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.GetPublicProfile(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeSocialLoginEndpoint returns an endpoint that invokes SocialLogin on the service.
+func MakeSocialLoginEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.SocialLoginRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.SocialLogin(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeRequestLoginCodeEndpoint returns an endpoint that invokes RequestLoginCode on the service.
+func MakeRequestLoginCodeEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.EmailCodeLoginRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		message, err := svc.RequestLoginCode(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return message, nil
+	}
+}
+
+// MakeVerifyLoginCodeEndpoint returns an endpoint that invokes VerifyLoginCode on the service.
+func MakeVerifyLoginCodeEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.VerifyEmailCodeLoginRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.VerifyLoginCode(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeGuestSignUpEndpoint returns an endpoint that invokes GuestSignUp on the service.
+func MakeGuestSignUpEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		response, err := svc.GuestSignUp(ctx)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeGuestLoginEndpoint returns an endpoint that invokes GuestLogin on the service.
+func MakeGuestLoginEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.GuestLoginRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.GuestLogin(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeUpgradeGuestEndpoint returns an endpoint that invokes UpgradeGuest on the service.
+func MakeUpgradeGuestEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.UpgradeGuestRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.UpgradeGuest(ctx, &req)
+		if err != nil {
+			if cusErr, ok := passwordPolicyError(err); ok {
+				return nil, cusErr
+			}
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeGetSignInMethodsEndpoint returns an endpoint that invokes GetSignInMethods on the service.
+func MakeGetSignInMethodsEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		response, err := svc.GetSignInMethods(ctx)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeLinkIdentityEndpoint returns an endpoint that invokes LinkIdentity on the service.
+func MakeLinkIdentityEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.LinkIdentityRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.LinkIdentity(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeLinkPasswordEndpoint returns an endpoint that invokes LinkPassword on the service.
+func MakeLinkPasswordEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.LinkPasswordRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.LinkPassword(ctx, &req)
+		if err != nil {
+			if cusErr, ok := passwordPolicyError(err); ok {
+				return nil, cusErr
+			}
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeUnlinkSignInMethodEndpoint returns an endpoint that invokes UnlinkSignInMethod on the service.
+func MakeUnlinkSignInMethodEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.UnlinkSignInMethodRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.UnlinkSignInMethod(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeCreatePersonalAccessTokenEndpoint returns an endpoint that invokes CreatePersonalAccessToken on the service.
+func MakeCreatePersonalAccessTokenEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.CreatePersonalAccessTokenRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.CreatePersonalAccessToken(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeGetPersonalAccessTokensEndpoint returns an endpoint that invokes GetPersonalAccessTokens on the service.
+func MakeGetPersonalAccessTokensEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		response, err := svc.GetPersonalAccessTokens(ctx)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeRevokePersonalAccessTokenEndpoint returns an endpoint that invokes RevokePersonalAccessToken on the service.
+func MakeRevokePersonalAccessTokenEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.RevokePersonalAccessTokenRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		message, err := svc.RevokePersonalAccessToken(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return message, nil
+	}
+}
+
+// MakeInternalGetUserEndpoint returns an endpoint that invokes InternalGetUser on the service.
+func MakeInternalGetUserEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.InternalGetUserRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.InternalGetUser(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeInternalGetEarnScoreEndpoint returns an endpoint that invokes InternalGetEarnScore on the service.
+func MakeInternalGetEarnScoreEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.InternalGetEarnScoreRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.InternalGetEarnScore(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeGetOAuthClientEndpoint returns an endpoint that invokes GetOAuthClient on the service.
+func MakeGetOAuthClientEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.GetOAuthClientRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.GetOAuthClient(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeAuthorizeOAuthClientEndpoint returns an endpoint that invokes AuthorizeOAuthClient on the service.
+func MakeAuthorizeOAuthClientEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.OAuthAuthorizeRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.AuthorizeOAuthClient(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeOAuthTokenEndpoint returns an endpoint that invokes OAuthToken on the service.
+func MakeOAuthTokenEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.OAuthTokenRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		// errors are already in the OAuth format
+		return svc.OAuthToken(ctx, &req)
+	}
+}
+
+// MakeRequestDeviceCodeEndpoint returns an endpoint that invokes RequestDeviceCode on the service.
+func MakeRequestDeviceCodeEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.OAuthDeviceCodeRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		// errors are already in the OAuth format
+		return svc.RequestDeviceCode(ctx, &req)
+	}
+}
+
+// MakeVerifyDeviceCodeEndpoint returns an endpoint that invokes VerifyDeviceCode on the service.
+func MakeVerifyDeviceCodeEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.VerifyDeviceCodeRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		message, err := svc.VerifyDeviceCode(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return message, nil
+	}
+}
+
+// MakeGetOAuthConsentsEndpoint returns an endpoint that invokes GetOAuthConsents on the service.
+func MakeGetOAuthConsentsEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		response, err := svc.GetOAuthConsents(ctx)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeRevokeOAuthConsentEndpoint returns an endpoint that invokes RevokeOAuthConsent on the service.
+func MakeRevokeOAuthConsentEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.RevokeOAuthConsentRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		message, err := svc.RevokeOAuthConsent(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return message, nil
+	}
+}
+
+// MakeCreateOAuthClientEndpoint returns an endpoint that invokes CreateOAuthClient on the service.
+func MakeCreateOAuthClientEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.CreateOAuthClientRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.CreateOAuthClient(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeSendPhoneCodeEndpoint returns an endpoint that invokes SendPhoneCode on the service.
+func MakeSendPhoneCodeEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.SendPhoneCodeRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		message, err := svc.SendPhoneCode(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return message, nil
+	}
+}
+
+// MakeVerifyPhoneEndpoint returns an endpoint that invokes VerifyPhone on the service.
+func MakeVerifyPhoneEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.VerifyPhoneRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		message, err := svc.VerifyPhone(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return message, nil
+	}
+}
+
+// MakeGetForgetPasswordSMSEndpoint returns an endpoint that invokes GetForgetPasswordCodeBySMS on the service.
+func MakeGetForgetPasswordSMSEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.GetForgetPasswordCodeBySMSRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		message, err := svc.GetForgetPasswordCodeBySMS(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return message, nil
+	}
+}
+
+// MakeGetFocusStatsEndpoint returns an endpoint that invokes GetFocusStats on the service.
+func MakeGetFocusStatsEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.GetFocusStatsRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.GetFocusStats(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeGetAchievementsEndpoint returns an endpoint that invokes GetAchievements on the service.
+func MakeGetAchievementsEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		_, ok := request.(authorization.GetAchievementsRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.GetAchievements(ctx)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeGetLeaderboardEndpoint returns an endpoint that invokes GetLeaderboard on the service.
+func MakeGetLeaderboardEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.GetLeaderboardRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.GetLeaderboard(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeGetPrivacySettingsEndpoint returns an endpoint that invokes GetPrivacySettings on the service.
+func MakeGetPrivacySettingsEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		_, ok := request.(authorization.GetPrivacySettingsRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.GetPrivacySettings(ctx)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeUpdatePrivacySettingsEndpoint returns an endpoint that invokes UpdatePrivacySettings on the service.
+func MakeUpdatePrivacySettingsEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.UpdatePrivacySettingsRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.UpdatePrivacySettings(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeAddFriendEndpoint returns an endpoint that invokes AddFriend on the service.
+func MakeAddFriendEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.FriendRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		message, err := svc.AddFriend(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return message, nil
+	}
+}
+
+// MakeRemoveFriendEndpoint returns an endpoint that invokes RemoveFriend on the service.
+func MakeRemoveFriendEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.FriendRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		message, err := svc.RemoveFriend(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return message, nil
+	}
+}
+
+// MakeGetFriendsEndpoint returns an endpoint that invokes GetFriends on the service.
+func MakeGetFriendsEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		_, ok := request.(authorization.GetFriendsRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.GetFriends(ctx)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeGetPlantSpeciesEndpoint returns an endpoint that invokes GetPlantSpecies on the service.
+func MakeGetPlantSpeciesEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		_, ok := request.(authorization.GetGardenRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.GetPlantSpecies(ctx)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeGetGardenEndpoint returns an endpoint that invokes GetGarden on the service.
+func MakeGetGardenEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		_, ok := request.(authorization.GetGardenRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.GetGarden(ctx)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakePlantSeedEndpoint returns an endpoint that invokes PlantSeed on the service.
+func MakePlantSeedEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.PlantSeedRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.PlantSeed(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeWaterPlantEndpoint returns an endpoint that invokes WaterPlant on the service.
+func MakeWaterPlantEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.WaterPlantRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.WaterPlant(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeGrowPlantEndpoint returns an endpoint that invokes GrowPlant on the service.
+func MakeGrowPlantEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.GrowPlantRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.GrowPlant(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeGetShopEndpoint returns an endpoint that invokes GetShop on the service.
+func MakeGetShopEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		_, ok := request.(authorization.GetShopRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.GetShop(ctx)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakePurchaseItemEndpoint returns an endpoint that invokes PurchaseItem on the service.
+func MakePurchaseItemEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.PurchaseItemRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.PurchaseItem(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeGetInventoryEndpoint returns an endpoint that invokes GetInventory on the service.
+func MakeGetInventoryEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		_, ok := request.(authorization.GetShopRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.GetInventory(ctx)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeInternalGetShopItemsEndpoint returns an endpoint that invokes InternalGetShopItems on the service.
+func MakeInternalGetShopItemsEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.InternalGetShopItemsRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.InternalGetShopItems(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeInternalCreateShopItemEndpoint returns an endpoint that invokes InternalCreateShopItem on the service.
+func MakeInternalCreateShopItemEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.ShopItemRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.InternalCreateShopItem(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeInternalUpdateShopItemEndpoint returns an endpoint that invokes InternalUpdateShopItem on the service.
+func MakeInternalUpdateShopItemEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.ShopItemRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.InternalUpdateShopItem(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeInternalDeleteShopItemEndpoint returns an endpoint that invokes InternalDeleteShopItem on the service.
+func MakeInternalDeleteShopItemEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.InternalDeleteShopItemRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		message, err := svc.InternalDeleteShopItem(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return message, nil
+	}
+}
+
+// MakeInternalGetMultiRatiosEndpoint returns an endpoint that invokes InternalGetMultiRatios on the service.
+func MakeInternalGetMultiRatiosEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		_, ok := request.(authorization.InternalGetMultiRatiosRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.InternalGetMultiRatios(ctx)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeInternalPublishMultiRatioEndpoint returns an endpoint that invokes InternalPublishMultiRatio on the service.
+func MakeInternalPublishMultiRatioEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.PublishMultiRatioRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.InternalPublishMultiRatio(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeSetGoalEndpoint returns an endpoint that invokes SetGoal on the service.
+func MakeSetGoalEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.SetGoalRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.SetGoal(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeGetGoalProgressEndpoint returns an endpoint that invokes GetGoalProgress on the service.
+func MakeGetGoalProgressEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.GetGoalProgressRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.GetGoalProgress(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeGetGoalHistoryEndpoint returns an endpoint that invokes GetGoalHistory on the service.
+func MakeGetGoalHistoryEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.GetGoalHistoryRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.GetGoalHistory(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeCreateTagEndpoint returns an endpoint that invokes CreateTag on the service.
+func MakeCreateTagEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.CreateTagRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.CreateTag(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeUpdateTagEndpoint returns an endpoint that invokes UpdateTag on the service.
+func MakeUpdateTagEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.UpdateTagRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.UpdateTag(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeGetTagsEndpoint returns an endpoint that invokes GetTags on the service.
+func MakeGetTagsEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.GetTagsRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.GetTags(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeCreateTaskEndpoint returns an endpoint that invokes CreateTask on the service.
+func MakeCreateTaskEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.CreateTaskRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.CreateTask(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeUpdateTaskEndpoint returns an endpoint that invokes UpdateTask on the service.
+func MakeUpdateTaskEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.UpdateTaskRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.UpdateTask(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeDeleteTaskEndpoint returns an endpoint that invokes DeleteTask on the service.
+func MakeDeleteTaskEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.TaskRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		message, err := svc.DeleteTask(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return message, nil
+	}
+}
+
+// MakeGetTasksEndpoint returns an endpoint that invokes GetTasks on the service.
+func MakeGetTasksEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.GetTasksRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.GetTasks(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// MakeReorderTasksEndpoint returns an endpoint that invokes ReorderTasks on the service.
+func MakeReorderTasksEndpoint(svc authorization.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(authorization.ReorderTasksRequest)
+		if !ok {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return nil, cusErr
+		}
+		response, err := svc.ReorderTasks(ctx, &req)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+		return response, nil
+	}
+}
+
+// serviceError converts an ErrorResponse returned by the service into an error
+// carrying the matching HTTP status code.
+func serviceError(err error) error {
+	var errResp utils.ErrorResponse
+	if !errors.As(err, &errResp) {
+		return err
+	}
+	var code int
+	switch errResp.ErrorType {
+	case utils.BadRequest, utils.InvalidCode, utils.ExpiredCode, utils.CodeInvalid, utils.SameEmail,
+		utils.UsernameRequired, utils.InvalidUsername, utils.UsernameNotAllowed, utils.DisposableEmail,
+		utils.MailRequired, utils.IDTokenRequired, utils.UnknownProvider, utils.CodeRequired, utils.EmailNotRegistered,
+		utils.PasswordRequired, utils.PasswordNotMatch, utils.NotGuest, utils.DeviceSecretRequired,
+		utils.InvalidScope, utils.InvalidClient, utils.InvalidRedirectURI, utils.InvalidUserCode, utils.InvalidPhone,
+		utils.PhoneRequired, utils.InvalidTimezone, utils.InvalidStatsRange, utils.UnknownSpecies,
+		utils.CurrencyNotAccepted, utils.InvalidShopItem, utils.InvalidFocusTime, utils.InvalidMultiRatio,
+		utils.InvalidTag, utils.InvalidTask, utils.InvalidGoal:
+		code = http.StatusBadRequest
+	case utils.Unauthorized, utils.PasswordIncorrect, utils.InvalidCredentials, utils.ReauthenticationRequired,
+		utils.InvalidIDToken:
+		code = http.StatusUnauthorized
+	case utils.Forbidden, utils.GuestNotAllowed, utils.InsufficientScope:
+		code = http.StatusForbidden
+	case utils.NotFound:
+		code = http.StatusNotFound
+	case utils.Conflict, utils.ExistUser, utils.ExistUserName, utils.LastSignInMethod, utils.IdentityAlreadyLinked,
+		utils.IdentityLinkRequired, utils.PasswordAlreadySet, utils.PhoneInUse, utils.AlreadyFriends,
+		utils.InsufficientScore, utils.PlantNotReady, utils.PlantFullyGrown, utils.ItemNotAvailable,
+		utils.RatioVersionMismatch, utils.TagExists:
+		code = http.StatusConflict
+	case utils.TooManyRequests, utils.QuicklyRequest, utils.UsernameChangeCooldown, utils.TokenLimitReached,
+		utils.FriendLimitReached, utils.PurchaseLimitReached, utils.TagLimitReached:
+		code = http.StatusTooManyRequests
+	case utils.RatioNotConfigured:
+		code = http.StatusServiceUnavailable
+	default:
+		code = http.StatusInternalServerError
+	}
+	return utils.NewErrorWrapper(code, err, errResp.Error())
+}
+
+// passwordPolicyError converts a password policy error into a bad request listing every violation.
+func passwordPolicyError(err error) (utils.CustomErrorWrapper, bool) {
+	var policyErr utils.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return utils.CustomErrorWrapper{}, false
+	}
+	cusErr := utils.NewErrorWrapper(http.StatusBadRequest, err, utils.NewErrorResponse(utils.PasswordPolicyViolated).Error())
+	cusErr.Details = policyErr.Violations
+	return cusErr, true
+}
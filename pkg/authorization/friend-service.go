@@ -0,0 +1,126 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"LoveLetterProject/pkg/authorization/middleware"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// AddFriend sends a friend request to the user with the given username, or accepts the
+// request that user already sent.
+func (s *userService) AddFriend(ctx context.Context, request *FriendRequest) (string, error) {
+	user, friend, err := s.friendOf(ctx, request.Username)
+	if err != nil {
+		return err.Error(), err
+	}
+	if _, err := s.repo.GetFriendship(ctx, user.ID, friend.ID); err == nil {
+		cusErr := utils.NewErrorResponse(utils.AlreadyFriends)
+		return cusErr.Error(), cusErr
+	}
+	if count, err := s.repo.CountFriendships(ctx, user.ID); err != nil {
+		s.logger.Error("Cannot count friendships", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	} else if count >= s.configs.FriendLimit {
+		s.logger.Error("Friend limit reached", "userID", user.ID)
+		cusErr := utils.NewErrorResponse(utils.FriendLimitReached)
+		return cusErr.Error(), cusErr
+	}
+
+	// A pending request from the other user is accepted instead of sending one back
+	if incoming, err := s.repo.GetFriendship(ctx, friend.ID, user.ID); err == nil && incoming.Status == database.FriendshipPending {
+		if err := s.repo.AcceptFriendship(ctx, user.ID, friend.ID); err != nil {
+			s.logger.Error("Cannot accept friendship", "error", err)
+			cusErr := utils.NewErrorResponse(utils.InternalServerError)
+			return cusErr.Error(), cusErr
+		}
+		s.logger.Info("Friend request accepted", "userID", user.ID, "friendID", friend.ID)
+		return "friend request accepted.", nil
+	}
+	err = s.repo.RequestFriendship(ctx, &database.Friendship{UserID: user.ID, FriendID: friend.ID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			cusErr := utils.NewErrorResponse(utils.AlreadyFriends)
+			return cusErr.Error(), cusErr
+		}
+		s.logger.Error("Cannot request friendship", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("Friend request sent", "userID", user.ID, "friendID", friend.ID)
+	return "friend request sent.", nil
+}
+
+// RemoveFriend removes a friend, or cancels or declines a friend request.
+func (s *userService) RemoveFriend(ctx context.Context, request *FriendRequest) (string, error) {
+	user, friend, err := s.friendOf(ctx, request.Username)
+	if err != nil {
+		return err.Error(), err
+	}
+	if err := s.repo.DeleteFriendship(ctx, user.ID, friend.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			cusErr := utils.NewErrorResponse(utils.NotFound)
+			return cusErr.Error(), cusErr
+		}
+		s.logger.Error("Cannot delete friendship", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("Friend removed", "userID", user.ID, "friendID", friend.ID)
+	return "friend removed.", nil
+}
+
+// GetFriends lists the friends of the user with the friend requests sent and received.
+func (s *userService) GetFriends(ctx context.Context) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	friends, err := s.repo.GetFriends(ctx, user.ID)
+	if err != nil {
+		s.logger.Error("Cannot get friends", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	response := make([]FriendResponse, 0, len(friends))
+	for _, friend := range friends {
+		response = append(response, FriendResponse{
+			Username:  friend.Username,
+			Status:    friend.Status,
+			Incoming:  friend.Incoming,
+			CreatedAt: friend.CreatedAt,
+		})
+	}
+	return response, nil
+}
+
+// friendOf returns the caller and the user with the given username, who must be someone else
+func (s *userService) friendOf(ctx context.Context, username string) (*database.User, *database.User, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		return nil, nil, utils.NewErrorResponse(utils.InternalServerError)
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	friend, err := s.repo.GetUserByUsername(ctx, utils.NormalizeUsername(username))
+	if err != nil || friend.Banned {
+		s.logger.Debug("Friend not found", "username", username)
+		return nil, nil, utils.NewErrorResponse(utils.NotFound)
+	}
+	if friend.ID == user.ID {
+		return nil, nil, utils.NewErrorResponse(utils.BadRequest)
+	}
+	return user, friend, nil
+}
@@ -0,0 +1,222 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"LoveLetterProject/pkg/authorization/middleware"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// PlantStage is a growth stage of a plant species with the water that must be given and
+// the light that is spent to grow the plant into it
+type PlantStage struct {
+	Name  string `json:"name"`
+	Water int    `json:"water"`
+	Light int    `json:"light"`
+}
+
+// PlantSpecies is an entry of the plant catalog. The ID is stored with every plant and
+// stages are referenced by index, so neither may change or be reordered once released.
+// The first stage is the planted seed and has no requirements.
+type PlantSpecies struct {
+	ID       string       `json:"id"`
+	Name     string       `json:"name"`
+	SeedCost int          `json:"seed_cost"`
+	Stages   []PlantStage `json:"stages"`
+}
+
+// PlantCatalog is the plant species catalog in the order it is listed to users
+var PlantCatalog = []PlantSpecies{
+	{ID: "sunflower", Name: "Sunflower", SeedCost: 1, Stages: []PlantStage{
+		{Name: "Seed"}, {Name: "Sprout", Water: 2, Light: 1}, {Name: "Bloom", Water: 4, Light: 2}}},
+	{ID: "oak", Name: "Oak", SeedCost: 3, Stages: []PlantStage{
+		{Name: "Acorn"}, {Name: "Sapling", Water: 3, Light: 1}, {Name: "Young Tree", Water: 6, Light: 3},
+		{Name: "Oak Tree", Water: 10, Light: 5}}},
+	{ID: "cherry_blossom", Name: "Cherry Blossom", SeedCost: 5, Stages: []PlantStage{
+		{Name: "Seed"}, {Name: "Sapling", Water: 4, Light: 2}, {Name: "Young Tree", Water: 8, Light: 4},
+		{Name: "Cherry Tree", Water: 12, Light: 6}, {Name: "Blossoming Tree", Water: 16, Light: 8}}},
+}
+
+// plantSpeciesByID returns the catalog entry of the species
+func plantSpeciesByID(id string) (PlantSpecies, bool) {
+	for _, species := range PlantCatalog {
+		if species.ID == id {
+			return species, true
+		}
+	}
+	return PlantSpecies{}, false
+}
+
+// GetPlantSpecies lists the plant catalog.
+func (s *userService) GetPlantSpecies(ctx context.Context) (interface{}, error) {
+	return PlantCatalog, nil
+}
+
+// GetGarden lists the plants of the user with their stage.
+func (s *userService) GetGarden(ctx context.Context) (interface{}, error) {
+	user, err := s.contextUserOf(ctx)
+	if err != nil {
+		return err.Error(), err
+	}
+	plants, err := s.repo.GetPlants(ctx, user.ID)
+	if err != nil {
+		s.logger.Error("Cannot get plants", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	response := make([]PlantResponse, 0, len(plants))
+	for _, plant := range plants {
+		species, ok := plantSpeciesByID(plant.SpeciesID)
+		if !ok {
+			s.logger.Error("Plant of unknown species", "plantID", plant.ID, "species", plant.SpeciesID)
+			continue
+		}
+		response = append(response, newPlantResponse(plant, species))
+	}
+	return response, nil
+}
+
+// PlantSeed spends the seed cost of the species to plant it.
+func (s *userService) PlantSeed(ctx context.Context, request *PlantSeedRequest) (interface{}, error) {
+	user, err := s.contextUserOf(ctx)
+	if err != nil {
+		return err.Error(), err
+	}
+	species, ok := plantSpeciesByID(request.SpeciesID)
+	if !ok {
+		cusErr := utils.NewErrorResponse(utils.UnknownSpecies)
+		return cusErr.Error(), cusErr
+	}
+	plant := &database.Plant{UserID: user.ID, SpeciesID: species.ID}
+	if err := s.repo.PlantSeed(ctx, plant, &database.EarnScore{SeedScore: species.SeedCost}); err != nil {
+		cusErr := s.gardenError(err, "Cannot plant seed")
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("Seed planted", "userID", user.ID, "plantID", plant.ID, "species", species.ID)
+	if _, err := s.evaluateAchievements(ctx, user.ID); err != nil {
+		s.logger.Error("Cannot evaluate achievements", "error", err, "userID", user.ID)
+	}
+	return newPlantResponse(*plant, species), nil
+}
+
+// WaterPlant spends water on the plant. Only the water the next stage still needs is spent,
+// so watering more than required is not lost.
+func (s *userService) WaterPlant(ctx context.Context, request *WaterPlantRequest) (interface{}, error) {
+	user, err := s.contextUserOf(ctx)
+	if err != nil {
+		return err.Error(), err
+	}
+	plant, species, err := s.plantOf(ctx, user.ID, request.PlantID)
+	if err != nil {
+		return err.Error(), err
+	}
+	if plant.Stage >= len(species.Stages)-1 {
+		cusErr := utils.NewErrorResponse(utils.PlantFullyGrown)
+		return cusErr.Error(), cusErr
+	}
+	water := species.Stages[plant.Stage+1].Water - plant.Water
+	if water > request.Water {
+		water = request.Water
+	}
+	if water > 0 {
+		if err := s.repo.WaterPlant(ctx, plant, water); err != nil {
+			cusErr := s.gardenError(err, "Cannot water plant")
+			return cusErr.Error(), cusErr
+		}
+		s.logger.Debug("Plant watered", "userID", user.ID, "plantID", plant.ID, "water", water)
+	}
+	return newPlantResponse(*plant, species), nil
+}
+
+// GrowPlant spends the light of the next stage to grow a plant that has been given
+// all the water the stage requires.
+func (s *userService) GrowPlant(ctx context.Context, request *GrowPlantRequest) (interface{}, error) {
+	user, err := s.contextUserOf(ctx)
+	if err != nil {
+		return err.Error(), err
+	}
+	plant, species, err := s.plantOf(ctx, user.ID, request.PlantID)
+	if err != nil {
+		return err.Error(), err
+	}
+	if plant.Stage >= len(species.Stages)-1 {
+		cusErr := utils.NewErrorResponse(utils.PlantFullyGrown)
+		return cusErr.Error(), cusErr
+	}
+	next := species.Stages[plant.Stage+1]
+	if plant.Water < next.Water {
+		cusErr := utils.NewErrorResponse(utils.PlantNotReady)
+		return cusErr.Error(), cusErr
+	}
+	if err := s.repo.GrowPlant(ctx, plant, &database.EarnScore{LightScore: next.Light}); err != nil {
+		cusErr := s.gardenError(err, "Cannot grow plant")
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("Plant grown", "userID", user.ID, "plantID", plant.ID, "stage", plant.Stage)
+	if _, err := s.evaluateAchievements(ctx, user.ID); err != nil {
+		s.logger.Error("Cannot evaluate achievements", "error", err, "userID", user.ID)
+	}
+	return newPlantResponse(*plant, species), nil
+}
+
+// contextUserOf returns the user of the request, who must not be banned
+func (s *userService) contextUserOf(ctx context.Context) (*database.User, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		return nil, utils.NewErrorResponse(utils.InternalServerError)
+	}
+	return s.commonCheckUserStatusByUserId(ctx, userID)
+}
+
+// plantOf returns a plant of the user with its species
+func (s *userService) plantOf(ctx context.Context, userID string, plantID string) (*database.Plant, PlantSpecies, error) {
+	plant, err := s.repo.GetPlant(ctx, userID, plantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, PlantSpecies{}, utils.NewErrorResponse(utils.NotFound)
+		}
+		s.logger.Error("Cannot get plant", "error", err)
+		return nil, PlantSpecies{}, utils.NewErrorResponse(utils.InternalServerError)
+	}
+	species, ok := plantSpeciesByID(plant.SpeciesID)
+	if !ok {
+		s.logger.Error("Plant of unknown species", "plantID", plant.ID, "species", plant.SpeciesID)
+		return nil, PlantSpecies{}, utils.NewErrorResponse(utils.InternalServerError)
+	}
+	return plant, species, nil
+}
+
+// gardenError converts an error of spending on the garden into an error response
+func (s *userService) gardenError(err error, msg string) error {
+	switch {
+	case errors.Is(err, database.ErrInsufficientScore):
+		return utils.NewErrorResponse(utils.InsufficientScore)
+	case errors.Is(err, sql.ErrNoRows):
+		// The plant was watered or grown by a concurrent request
+		return utils.NewErrorResponse(utils.Conflict)
+	default:
+		s.logger.Error(msg, "error", err)
+		return utils.NewErrorResponse(utils.InternalServerError)
+	}
+}
+
+func newPlantResponse(plant database.Plant, species PlantSpecies) PlantResponse {
+	response := PlantResponse{
+		ID:          plant.ID,
+		SpeciesID:   species.ID,
+		SpeciesName: species.Name,
+		Stage:       plant.Stage,
+		StageName:   species.Stages[plant.Stage].Name,
+		Water:       plant.Water,
+		FullyGrown:  plant.Stage >= len(species.Stages)-1,
+		PlantedAt:   plant.CreatedAt,
+	}
+	if !response.FullyGrown {
+		next := species.Stages[plant.Stage+1]
+		response.NextStage = &next
+	}
+	return response
+}
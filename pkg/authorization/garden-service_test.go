@@ -0,0 +1,219 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"context"
+	"database/sql"
+	"github.com/satori/go.uuid"
+	"sort"
+	"testing"
+	"time"
+)
+
+func (repo *fakeRepo) GetPlants(ctx context.Context, userID string) ([]database.Plant, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	plants := []database.Plant{}
+	for _, plant := range repo.plants {
+		if plant.UserID == userID {
+			plants = append(plants, *plant)
+		}
+	}
+	sort.Slice(plants, func(i, j int) bool { return plants[i].CreatedAt.Before(plants[j].CreatedAt) })
+	return plants, nil
+}
+
+func (repo *fakeRepo) GetPlant(ctx context.Context, userID string, plantID string) (*database.Plant, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	plant, ok := repo.plants[plantID]
+	if !ok || plant.UserID != userID {
+		return &database.Plant{}, sql.ErrNoRows
+	}
+	copied := *plant
+	return &copied, nil
+}
+
+func (repo *fakeRepo) PlantSeed(ctx context.Context, plant *database.Plant, cost *database.EarnScore) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if err := repo.spendScore(plant.UserID, cost); err != nil {
+		return err
+	}
+	plant.ID = uuid.NewV4().String()
+	plant.CreatedAt = time.Now()
+	plant.UpdatedAt = plant.CreatedAt
+	copied := *plant
+	repo.plants[plant.ID] = &copied
+	return nil
+}
+
+// storedPlant returns the plant if it is still as read, it expects the lock to be held.
+func (repo *fakeRepo) storedPlant(plant *database.Plant) (*database.Plant, error) {
+	stored, ok := repo.plants[plant.ID]
+	if !ok || stored.UserID != plant.UserID || stored.Stage != plant.Stage || stored.Water != plant.Water {
+		return nil, sql.ErrNoRows
+	}
+	return stored, nil
+}
+
+func (repo *fakeRepo) WaterPlant(ctx context.Context, plant *database.Plant, water int) error {
+	if repo.beforeWrite != nil {
+		repo.beforeWrite()
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	stored, err := repo.storedPlant(plant)
+	if err != nil {
+		return err
+	}
+	if err := repo.spendScore(plant.UserID, &database.EarnScore{WaterScore: water}); err != nil {
+		return err
+	}
+	stored.Water += water
+	plant.Water = stored.Water
+	return nil
+}
+
+func (repo *fakeRepo) GrowPlant(ctx context.Context, plant *database.Plant, cost *database.EarnScore) error {
+	if repo.beforeWrite != nil {
+		repo.beforeWrite()
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	stored, err := repo.storedPlant(plant)
+	if err != nil {
+		return err
+	}
+	if err := repo.spendScore(plant.UserID, cost); err != nil {
+		return err
+	}
+	stored.Stage++
+	stored.Water = 0
+	plant.Stage, plant.Water = stored.Stage, stored.Water
+	return nil
+}
+
+func TestGardenUnlocksAchievements(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	repo.scores[user.ID] = &database.EarnScore{UserID: user.ID, WaterScore: 100, LightScore: 100, SeedScore: 5}
+
+	unlocked := func(id string) bool {
+		for _, achievement := range repo.achievements[user.ID] {
+			if achievement.AchievementID == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	plantIDs := []string{}
+	for i := 0; i < 5; i++ {
+		response, err := s.PlantSeed(ctx, &PlantSeedRequest{SpeciesID: "sunflower"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		plantIDs = append(plantIDs, response.(PlantResponse).ID)
+		if !unlocked("first_plant") {
+			t.Fatal("planting did not unlock first_plant")
+		}
+	}
+	for i, plantID := range plantIDs {
+		for repo.plants[plantID].Stage < len(PlantCatalog[0].Stages)-1 {
+			if _, err := s.WaterPlant(ctx, &WaterPlantRequest{PlantID: plantID, Water: 10}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.GrowPlant(ctx, &GrowPlantRequest{PlantID: plantID}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := unlocked("plants_grown_5"); got != (i == len(plantIDs)-1) {
+			t.Fatalf("%d plants grown: got plants_grown_5 unlocked %v", i+1, got)
+		}
+	}
+}
+
+func TestGardenSpending(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	repo.scores[user.ID] = &database.EarnScore{UserID: user.ID, WaterScore: 3, LightScore: 0, SeedScore: 2}
+
+	if _, err := s.PlantSeed(ctx, &PlantSeedRequest{SpeciesID: "oak"}); errorType(err) != utils.InsufficientScore {
+		t.Fatalf("got %v, want insufficient score", err)
+	}
+	if _, err := s.PlantSeed(ctx, &PlantSeedRequest{SpeciesID: "baobab"}); errorType(err) != utils.UnknownSpecies {
+		t.Fatalf("got %v, want unknown species", err)
+	}
+	response, err := s.PlantSeed(ctx, &PlantSeedRequest{SpeciesID: "sunflower"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plantID := response.(PlantResponse).ID
+	if score := repo.scores[user.ID]; score.SeedScore != 1 {
+		t.Fatalf("got %d seeds, want the seed cost debited", score.SeedScore)
+	}
+	if _, err := s.GrowPlant(ctx, &GrowPlantRequest{PlantID: plantID}); errorType(err) != utils.PlantNotReady {
+		t.Fatalf("got %v, want plant not ready", err)
+	}
+	// Only the water the next stage needs is spent
+	water := repo.scores[user.ID].WaterScore
+	response, err = s.WaterPlant(ctx, &WaterPlantRequest{PlantID: plantID, Water: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plant := response.(PlantResponse); plant.Water != 2 || repo.scores[user.ID].WaterScore != water-2 {
+		t.Fatalf("got plant water %d and %d water left, want 2 and %d", plant.Water, repo.scores[user.ID].WaterScore, water-2)
+	}
+	if _, err := s.GrowPlant(ctx, &GrowPlantRequest{PlantID: plantID}); errorType(err) != utils.InsufficientScore {
+		t.Fatalf("got %v, want insufficient score", err)
+	}
+	if plant := repo.plants[plantID]; plant.Stage != 0 || plant.Water != 2 {
+		t.Fatalf("got %+v, want the plant unchanged", plant)
+	}
+	if _, err := s.GrowPlant(ctx, &GrowPlantRequest{PlantID: "unknown"}); errorType(err) != utils.NotFound {
+		t.Fatalf("got %v, want not found", err)
+	}
+}
+
+func TestGardenConcurrentWrites(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	repo.scores[user.ID] = &database.EarnScore{UserID: user.ID, WaterScore: 10, LightScore: 10, SeedScore: 1}
+
+	response, err := s.PlantSeed(ctx, &PlantSeedRequest{SpeciesID: "sunflower"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plantID := response.(PlantResponse).ID
+	score := *repo.scores[user.ID]
+
+	// Another request waters the plant between the read and the write of this one
+	repo.beforeWrite = func() {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		repo.plants[plantID].Water++
+	}
+	if _, err := s.WaterPlant(ctx, &WaterPlantRequest{PlantID: plantID, Water: 2}); errorType(err) != utils.Conflict {
+		t.Fatalf("water: got %v, want conflict", err)
+	}
+	if *repo.scores[user.ID] != score {
+		t.Fatalf("got %+v, want nothing debited", *repo.scores[user.ID])
+	}
+	repo.beforeWrite = nil
+	if _, err := s.WaterPlant(ctx, &WaterPlantRequest{PlantID: plantID, Water: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	score = *repo.scores[user.ID]
+	repo.beforeWrite = func() {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		repo.plants[plantID].Stage++
+		repo.plants[plantID].Water = 0
+	}
+	if _, err := s.GrowPlant(ctx, &GrowPlantRequest{PlantID: plantID}); errorType(err) != utils.Conflict {
+		t.Fatalf("grow: got %v, want conflict", err)
+	}
+	if *repo.scores[user.ID] != score {
+		t.Fatalf("got %+v, want nothing debited", *repo.scores[user.ID])
+	}
+}
@@ -0,0 +1,263 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"LoveLetterProject/pkg/authorization/middleware"
+	"context"
+	"time"
+)
+
+// goalPeriods are the periods a focus goal can be set for
+var goalPeriods = []string{database.StatsPeriodDay, database.StatsPeriodWeek}
+
+// SetGoal sets the daily or weekly focus goal of the user from now on, a target of 0 clears it.
+// Past periods keep being measured against the goal in effect at the time. A minutes goal
+// must be at least the configured minimum.
+func (s *userService) SetGoal(ctx context.Context, request *SetGoalRequest) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	if request.Metric == database.GoalMetricMinutes && request.Target != 0 && request.Target < s.configs.GoalMinMinutes {
+		cusErr := utils.NewErrorResponse(utils.InvalidGoal)
+		return cusErr.Error(), cusErr
+	}
+	goal := &database.Goal{
+		UserID: user.ID,
+		Period: request.Period,
+		Metric: request.Metric,
+		Target: request.Target,
+	}
+	if err := s.repo.SetGoal(ctx, goal); err != nil {
+		s.logger.Error("Cannot set goal", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("Goal set", "userID", user.ID, "period", goal.Period, "metric", goal.Metric, "target", goal.Target)
+	// The new goal may already be met by the focus of the period
+	response, err := s.goalProgressOf(ctx, user.ID, s.goalLocationOf(ctx, user.ID))
+	if err != nil {
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	return response, nil
+}
+
+// GetGoalProgress returns the progress of today and of this week towards the goals of the user.
+func (s *userService) GetGoalProgress(ctx context.Context, request *GetGoalProgressRequest) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	location, err := s.userLocation(ctx, user.ID, request.Timezone)
+	if err != nil {
+		s.logger.Error("Invalid timezone", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InvalidTimezone)
+		return cusErr.Error(), cusErr
+	}
+	response, err := s.goalProgressOf(ctx, user.ID, location)
+	if err != nil {
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	return response, nil
+}
+
+// GetGoalHistory returns the progress of every day or week of a range towards the goal in
+// effect at the time.
+func (s *userService) GetGoalHistory(ctx context.Context, request *GetGoalHistoryRequest) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	location, err := s.userLocation(ctx, user.ID, request.Timezone)
+	if err != nil {
+		s.logger.Error("Invalid timezone", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InvalidTimezone)
+		return cusErr.Error(), cusErr
+	}
+	from, errFrom := time.ParseInLocation(statsDateLayout, request.From, location)
+	to, errTo := time.ParseInLocation(statsDateLayout, request.To, location)
+	if errFrom != nil || errTo != nil || to.Before(from) {
+		s.logger.Error("Invalid goal history range", "from", request.From, "to", request.To)
+		cusErr := utils.NewErrorResponse(utils.InvalidStatsRange)
+		return cusErr.Error(), cusErr
+	}
+	starts := []time.Time{}
+	start, end := bucketStart(from, request.Period), nextBucket(bucketStart(to, request.Period), request.Period)
+	for bucket := start; bucket.Before(end); bucket = nextBucket(bucket, request.Period) {
+		if len(starts) == maxFocusStatsBuckets {
+			s.logger.Error("Goal history range is too long", "from", request.From, "to", request.To)
+			cusErr := utils.NewErrorResponse(utils.InvalidStatsRange)
+			return cusErr.Error(), cusErr
+		}
+		starts = append(starts, bucket)
+	}
+
+	goals, err := s.repo.GetGoals(ctx, user.ID)
+	if err != nil {
+		s.logger.Error("Cannot get goals", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	stats, err := s.repo.GetFocusStats(ctx, user.ID, request.Period, location.String(), start, end)
+	if err != nil {
+		s.logger.Error("Cannot get focus stats", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	bonuses, err := s.repo.GetGoalBonuses(ctx, user.ID, streakDay(start), streakDay(end))
+	if err != nil {
+		s.logger.Error("Cannot get goal bonuses", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	statsByStart := make(map[string]database.FocusStats, len(stats))
+	for _, stat := range stats {
+		statsByStart[stat.Bucket.Format(statsDateLayout)] = stat
+	}
+
+	response := GoalHistoryResponse{
+		Period:   request.Period,
+		Timezone: location.String(),
+		Entries:  make([]GoalProgress, 0, len(starts)),
+	}
+	for _, bucket := range starts {
+		progress := GoalProgress{Period: request.Period, Start: bucket.Format(statsDateLayout)}
+		if goal := goalAt(goals, request.Period, nextBucket(bucket, request.Period)); goal != nil {
+			progress.Metric = goal.Metric
+			progress.Target = goal.Target
+		}
+		measureGoal(&progress, statsByStart[progress.Start])
+		progress.BonusAwarded = goalBonusAwarded(bonuses, request.Period, bucket)
+		response.Entries = append(response.Entries, progress)
+	}
+	return response, nil
+}
+
+// goalProgressOf measures the goals of the current day and week of the user and credits the
+// bonus of the goals met for the first time in their period.
+func (s *userService) goalProgressOf(ctx context.Context, userID string, location *time.Location) (GoalProgressResponse, error) {
+	response := GoalProgressResponse{Timezone: location.String()}
+	goals, err := s.repo.GetGoals(ctx, userID)
+	if err != nil {
+		s.logger.Error("Cannot get goals", "error", err)
+		return response, err
+	}
+	now := time.Now().In(location)
+	weekStart, dayStart := bucketStart(now, database.StatsPeriodWeek), bucketStart(now, database.StatsPeriodDay)
+	bonuses, err := s.repo.GetGoalBonuses(ctx, userID, streakDay(weekStart), streakDay(dayStart))
+	if err != nil {
+		s.logger.Error("Cannot get goal bonuses", "error", err)
+		return response, err
+	}
+
+	for _, period := range goalPeriods {
+		start := bucketStart(now, period)
+		end := nextBucket(start, period)
+		goal := goalAt(goals, period, end)
+		if goal == nil || goal.Target == 0 {
+			continue
+		}
+		stats, err := s.repo.GetFocusStats(ctx, userID, period, location.String(), start, end)
+		if err != nil {
+			s.logger.Error("Cannot get focus stats", "error", err)
+			return response, err
+		}
+		progress := &GoalProgress{Period: period, Start: start.Format(statsDateLayout), Metric: goal.Metric, Target: goal.Target}
+		stat := database.FocusStats{}
+		if len(stats) > 0 {
+			stat = stats[0]
+		}
+		measureGoal(progress, stat)
+		progress.BonusAwarded = goalBonusAwarded(bonuses, period, start)
+		if progress.Met && !progress.BonusAwarded {
+			bonus := &database.GoalBonus{UserID: userID, Period: period, PeriodStart: streakDay(start), WaterScore: s.goalBonusOf(period)}
+			awarded, err := s.repo.AwardGoalBonus(ctx, bonus)
+			if err != nil {
+				s.logger.Error("Cannot award goal bonus", "error", err, "userID", userID)
+			} else {
+				progress.BonusAwarded = true
+				if awarded {
+					s.logger.Info("Goal bonus awarded", "userID", userID, "period", period, "water", bonus.WaterScore)
+				}
+			}
+		}
+		if period == database.StatsPeriodDay {
+			response.Daily = progress
+		} else {
+			response.Weekly = progress
+		}
+	}
+	return response, nil
+}
+
+// goalLocationOf returns the profile timezone of the user, UTC when it is unknown to the server
+func (s *userService) goalLocationOf(ctx context.Context, userID string) *time.Location {
+	location, err := s.userLocation(ctx, userID, "")
+	if err != nil {
+		s.logger.Error("Invalid profile timezone", "error", err, "userID", userID)
+		return time.UTC
+	}
+	return location
+}
+
+// goalBonusOf returns the water credited when a goal of the period is met
+func (s *userService) goalBonusOf(period string) int {
+	if period == database.StatsPeriodWeek {
+		return s.configs.GoalWeeklyBonusWater
+	}
+	return s.configs.GoalDailyBonusWater
+}
+
+// goalAt returns the latest goal of the period set before the given time, goals are oldest first
+func goalAt(goals []database.Goal, period string, before time.Time) *database.Goal {
+	var goal *database.Goal
+	for i := range goals {
+		if goals[i].Period == period && goals[i].EffectiveFrom.Before(before) {
+			goal = &goals[i]
+		}
+	}
+	return goal
+}
+
+// measureGoal sets the progress of the goal from the focus stats of its period
+func measureGoal(progress *GoalProgress, stat database.FocusStats) {
+	switch progress.Metric {
+	case database.GoalMetricSessions:
+		progress.Progress = stat.Sessions
+	default:
+		progress.Progress = stat.Minutes
+	}
+	progress.Met = progress.Target > 0 && progress.Progress >= progress.Target
+}
+
+// goalBonusAwarded reports whether the bonus of the period starting at start was credited
+func goalBonusAwarded(bonuses []database.GoalBonus, period string, start time.Time) bool {
+	day := streakDay(start)
+	for _, bonus := range bonuses {
+		if bonus.Period == period && bonus.PeriodStart.Equal(day) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"context"
+	"github.com/satori/go.uuid"
+	"sort"
+	"testing"
+	"time"
+)
+
+func (repo *fakeRepo) SetGoal(ctx context.Context, goal *database.Goal) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	goal.ID = uuid.NewV4().String()
+	goal.EffectiveFrom = time.Now()
+	repo.goals = append(repo.goals, *goal)
+	return nil
+}
+
+func (repo *fakeRepo) AwardGoalBonus(ctx context.Context, bonus *database.GoalBonus) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, awarded := range repo.goalBonuses {
+		if awarded.UserID == bonus.UserID && awarded.Period == bonus.Period && awarded.PeriodStart.Equal(bonus.PeriodStart) {
+			return false, nil
+		}
+	}
+	bonus.CreatedAt = time.Now()
+	repo.goalBonuses = append(repo.goalBonuses, *bonus)
+	repo.addScore(bonus.UserID, bonus.WaterScore, 0, 0)
+	return true, nil
+}
+
+// GetFocusStats buckets the sessions like date_trunc over the session times in the timezone,
+// buckets are wall clock times returned in UTC as postgres returns them.
+func (repo *fakeRepo) GetFocusStats(ctx context.Context, userID string, period string, timezone string, from time.Time, to time.Time) ([]database.FocusStats, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	buckets := map[time.Time]*database.FocusStats{}
+	for _, session := range repo.sessions {
+		if session.UserID != userID || session.CreatedAt.Before(from) || !session.CreatedAt.Before(to) {
+			continue
+		}
+		start := bucketStart(session.CreatedAt.In(location), period)
+		bucket := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+		stat, ok := buckets[bucket]
+		if !ok {
+			stat = &database.FocusStats{Bucket: bucket}
+			buckets[bucket] = stat
+		}
+		stat.Sessions++
+		stat.Minutes += session.Minutes
+		stat.WaterScore += session.WaterScore
+		stat.LightScore += session.LightScore
+		stat.SeedScore += session.SeedScore
+	}
+	stats := []database.FocusStats{}
+	for _, stat := range buckets {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Bucket.Before(stats[j].Bucket) })
+	return stats, nil
+}
+
+func TestSetGoalMinimum(t *testing.T) {
+	s, repo, _, ctx := newUserTest(t)
+
+	tests := []struct {
+		metric string
+		target int
+		valid  bool
+	}{
+		{database.GoalMetricMinutes, 1, false},
+		{database.GoalMetricMinutes, 14, false},
+		{database.GoalMetricMinutes, 15, true},
+		{database.GoalMetricMinutes, 0, true},
+		{database.GoalMetricSessions, 1, true},
+	}
+	for _, test := range tests {
+		_, err := s.SetGoal(ctx, &SetGoalRequest{Period: database.StatsPeriodDay, Metric: test.metric, Target: test.target})
+		if test.valid && err != nil || !test.valid && errorType(err) != utils.InvalidGoal {
+			t.Fatalf("%s goal of %d: got %v, want valid %v", test.metric, test.target, err, test.valid)
+		}
+	}
+	if len(repo.goals) != 3 {
+		t.Fatalf("got %d goals, want 3", len(repo.goals))
+	}
+}
+
+func TestInsertEarnScoreRejectsEmptySessions(t *testing.T) {
+	s, repo, _, ctx := newUserTest(t)
+	repo.ratios = []database.MultiRatioData{{Version: 1, WaterRatio: 5, LightRatio: 10, SeedRatio: 20, EffectiveFrom: time.Now().Add(-time.Hour)}}
+	if _, err := s.SetGoal(ctx, &SetGoalRequest{Period: database.StatsPeriodDay, Metric: database.GoalMetricSessions, Target: 1}); err != nil {
+		t.Fatal(err)
+	}
+	for _, water := range []int{0, -1} {
+		if err := s.InsertEarnScore(ctx, &InsertEarnScoreRequest{WaterScore: water}); err == nil {
+			t.Fatalf("water %d: session was accepted", water)
+		}
+	}
+	if len(repo.sessions) != 0 || len(repo.goalBonuses) != 0 {
+		t.Fatalf("got %d sessions and %d bonuses, want none", len(repo.sessions), len(repo.goalBonuses))
+	}
+}
+
+func TestGoalBonusOncePerPeriod(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	repo.ratios = []database.MultiRatioData{{Version: 1, WaterRatio: 5, LightRatio: 10, SeedRatio: 20, EffectiveFrom: time.Now().Add(-time.Hour)}}
+	if _, err := s.SetGoal(ctx, &SetGoalRequest{Period: database.StatsPeriodDay, Metric: database.GoalMetricMinutes, Target: 30}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SetGoal(ctx, &SetGoalRequest{Period: database.StatsPeriodWeek, Metric: database.GoalMetricSessions, Target: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each session is 20 minutes worth 4 water, 2 light and 1 seed, the first one unlocks an
+	// achievement of 1 water
+	wantWater := []int{4 + 1, 4 + 1 + 4 + 2 + 5, 4 + 1 + 4 + 2 + 5 + 4}
+	for i, want := range wantWater {
+		if err := s.InsertEarnScore(ctx, &InsertEarnScoreRequest{WaterScore: 4, LightScore: 2, SeedScore: 1}); err != nil {
+			t.Fatal(err)
+		}
+		if got := repo.scores[user.ID].WaterScore; got != want {
+			t.Fatalf("session %d: got %d water, want %d", i+1, got, want)
+		}
+	}
+	if len(repo.goalBonuses) != 2 {
+		t.Fatalf("got %d bonuses, want the daily and weekly bonus once", len(repo.goalBonuses))
+	}
+
+	response, err := s.GetGoalProgress(ctx, &GetGoalProgressRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	progress := response.(GoalProgressResponse)
+	if progress.Daily == nil || progress.Daily.Progress != 60 || !progress.Daily.Met || !progress.Daily.BonusAwarded {
+		t.Fatalf("got daily %+v, want 60 minutes met and awarded", progress.Daily)
+	}
+	if progress.Weekly == nil || progress.Weekly.Progress != 3 || !progress.Weekly.Met || !progress.Weekly.BonusAwarded {
+		t.Fatalf("got weekly %+v, want 3 sessions met and awarded", progress.Weekly)
+	}
+}
+
+func TestGoalHistory(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	repo.timezones[user.ID] = "Asia/Ho_Chi_Minh"
+	location, err := time.LoadLocation("Asia/Ho_Chi_Minh")
+	if err != nil {
+		t.Skip(err)
+	}
+	// The goal was set a week ago and raised today
+	repo.goals = []database.Goal{
+		{UserID: user.ID, Period: database.StatsPeriodDay, Metric: database.GoalMetricMinutes, Target: 30, EffectiveFrom: time.Now().AddDate(0, 0, -7)},
+		{UserID: user.ID, Period: database.StatsPeriodDay, Metric: database.GoalMetricMinutes, Target: 60, EffectiveFrom: time.Now()},
+	}
+	today := bucketStart(time.Now().In(location), database.StatsPeriodDay)
+	yesterday := today.AddDate(0, 0, -1)
+	// The session of 23:30 yesterday counts for yesterday in the profile timezone
+	repo.addSession(user.ID, 40, yesterday.Add(23*time.Hour+30*time.Minute))
+	repo.addSession(user.ID, 40, today.Add(time.Hour))
+	repo.goalBonuses = []database.GoalBonus{{UserID: user.ID, Period: database.StatsPeriodDay, PeriodStart: streakDay(yesterday), WaterScore: 2}}
+
+	response, err := s.GetGoalHistory(ctx, &GetGoalHistoryRequest{Period: database.StatsPeriodDay,
+		From: yesterday.Format(statsDateLayout), To: today.Format(statsDateLayout)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := response.(GoalHistoryResponse)
+	if history.Timezone != "Asia/Ho_Chi_Minh" || len(history.Entries) != 2 {
+		t.Fatalf("got %+v, want two days in the profile timezone", history)
+	}
+	want := []GoalProgress{
+		{Period: database.StatsPeriodDay, Start: yesterday.Format(statsDateLayout), Metric: database.GoalMetricMinutes, Target: 30, Progress: 40, Met: true, BonusAwarded: true},
+		{Period: database.StatsPeriodDay, Start: today.Format(statsDateLayout), Metric: database.GoalMetricMinutes, Target: 60, Progress: 40},
+	}
+	for i := range want {
+		if history.Entries[i] != want[i] {
+			t.Fatalf("entry %d: got %+v, want %+v", i, history.Entries[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"LoveLetterProject/pkg/authorization/middleware"
+	"context"
+	"crypto/subtle"
+	"strings"
+)
+
+// deviceSecretLength is the number of random bytes of a guest device secret
+const deviceSecretLength = 32
+
+// GuestSignUp creates a guest user bound to a new device secret and signs it in.
+func (s *userService) GuestSignUp(ctx context.Context) (interface{}, error) {
+	deviceSecret, err := utils.GenerateSecureToken(deviceSecretLength)
+	if err != nil {
+		s.logger.Error("unable to generate device secret", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user := &database.User{
+		TokenHash:        utils.GenerateRandomString(15),
+		DeviceSecretHash: utils.HashToken(deviceSecret),
+	}
+	if err := s.repo.CreateGuestUser(ctx, user); err != nil {
+		s.logger.Error("Error creating guest user", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+
+	accessToken, refreshToken, err := s.generateTokens(user)
+	if err != nil {
+		return err.Error(), err
+	}
+	s.logger.Debug("guest user created", "userID", user.ID)
+	return GuestResponse{
+		UserID:       user.ID,
+		DeviceSecret: deviceSecret,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// GuestLogin signs a guest user in again with its device secret.
+func (s *userService) GuestLogin(ctx context.Context, request *GuestLoginRequest) (interface{}, error) {
+	user, err := s.repo.GetUserByID(ctx, request.UserID)
+	if err != nil || user.Role != database.RoleGuest {
+		s.logger.Error("Guest user not found", "userID", request.UserID, "error", err)
+		cusErr := utils.NewErrorResponse(utils.InvalidCredentials)
+		return cusErr.Error(), cusErr
+	}
+	limitData, err := s.increaseLoginCount(ctx, user.ID)
+	if err != nil {
+		return err.Error(), err
+	}
+	secretHash := utils.HashToken(request.DeviceSecret)
+	if subtle.ConstantTimeCompare([]byte(user.DeviceSecretHash), []byte(secretHash)) != 1 {
+		s.logger.Error("Device secret is incorrect", "userID", user.ID)
+		cusErr := utils.NewErrorResponse(utils.InvalidCredentials)
+		return cusErr.Error(), cusErr
+	}
+	if user.Banned {
+		s.logger.Error("User is banned", "userID", user.ID)
+		cusErr := utils.NewErrorResponse(utils.Forbidden)
+		return cusErr.Error(), cusErr
+	}
+
+	accessToken, refreshToken, err := s.generateTokens(user)
+	if err != nil {
+		return err.Error(), err
+	}
+	// Reset limit data
+	limitData.NumOfLogin = 0
+	err = s.repo.InsertOrUpdateLimitData(ctx, limitData, false)
+	if err != nil {
+		s.logger.Error("Cannot reset number of login", "error", err)
+	}
+	return GuestResponse{
+		UserID:       user.ID,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// UpgradeGuest attaches an email and password, or a social login identity, to the guest
+// user. The user keeps its id, so scores and sessions earned as a guest are kept.
+func (s *userService) UpgradeGuest(ctx context.Context, request *UpgradeGuestRequest) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	if user.Role != database.RoleGuest {
+		cusErr := utils.NewErrorResponse(utils.NotGuest)
+		return cusErr.Error(), cusErr
+	}
+
+	var identity *database.Identity
+	if request.IDToken != "" {
+		claims, err := s.oidcVerifier.Verify(ctx, request.Provider, request.IDToken)
+		if err != nil {
+			s.logger.Error("Cannot verify id token", "provider", request.Provider, "error", err)
+			cusErr := utils.NewErrorResponse(utils.InvalidIDToken)
+			return cusErr.Error(), cusErr
+		}
+		if _, err := s.repo.GetIdentity(ctx, request.Provider, claims.Subject); err == nil {
+			s.logger.Error("Identity is already linked to another user", "provider", request.Provider)
+			cusErr := utils.NewErrorResponse(utils.IdentityAlreadyLinked)
+			return cusErr.Error(), cusErr
+		}
+		user.Email = utils.NormalizeEmail(claims.Email)
+		if user.Email == "" {
+			cusErr := utils.NewErrorResponse(utils.MailRequired)
+			return cusErr.Error(), cusErr
+		}
+		user.Verified = bool(claims.EmailVerified)
+		identity = &database.Identity{
+			Provider: request.Provider,
+			Subject:  claims.Subject,
+			Email:    user.Email,
+		}
+	} else {
+		if s.disposableDomains.IsDisposable(request.Email) {
+			s.logger.Error("Disposable email rejected", "userID", userID)
+			cusErr := utils.NewErrorResponse(utils.DisposableEmail)
+			return cusErr.Error(), cusErr
+		}
+		if request.Password != request.RePassword {
+			cusErr := utils.NewErrorResponse(utils.PasswordNotMatch)
+			return cusErr.Error(), cusErr
+		}
+		if err := s.policy.Validate(request.Password, request.Email); err != nil {
+			s.logger.Error("Password does not satisfy the policy", "error", err)
+			return "Password does not satisfy the password policy", err
+		}
+		hashedPassword, err := s.hasher.Hash(request.Password)
+		if err != nil {
+			s.logger.Error("Error hashing password", "error", err)
+			cusErr := utils.NewErrorResponse(utils.InternalServerError)
+			return cusErr.Error(), cusErr
+		}
+		user.Email = request.Email
+		user.Password = hashedPassword
+		user.Verified = false
+	}
+
+	if _, err := s.repo.GetUserByEmail(ctx, user.Email); err == nil {
+		s.logger.Error("Email is already registered", "userID", userID)
+		cusErr := utils.NewErrorResponse(utils.ExistUser)
+		return cusErr.Error(), cusErr
+	}
+	if err := s.repo.UpgradeGuest(ctx, user, identity); err != nil {
+		s.logger.Error("Error upgrading guest user", "error", err)
+		if strings.Contains(err.Error(), utils.PgDuplicateKeyMsg) {
+			cusErr := utils.NewErrorResponse(utils.ExistUser)
+			return cusErr.Error(), cusErr
+		}
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+
+	accessToken, refreshToken, err := s.generateTokens(user)
+	if err != nil {
+		return err.Error(), err
+	}
+	s.logger.Info("Guest user upgraded", "userID", user.ID)
+	return LoginResponse{
+		Email:        user.Email,
+		Username:     user.Username,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		Verified:     user.Verified,
+	}, nil
+}
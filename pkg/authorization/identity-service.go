@@ -0,0 +1,220 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"LoveLetterProject/pkg/authorization/middleware"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// Types of sign-in methods
+const (
+	SignInMethodPassword = "password"
+	SignInMethodIdentity = "identity"
+)
+
+// GetSignInMethods lists the password and the provider identities the user can sign in with.
+func (s *userService) GetSignInMethods(ctx context.Context) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	methods, err := s.signInMethods(ctx, user)
+	if err != nil {
+		return err.Error(), err
+	}
+	return SignInMethodsResponse{Methods: methods}, nil
+}
+
+// LinkIdentity links the social login account of the ID token to the user.
+func (s *userService) LinkIdentity(ctx context.Context, request *LinkIdentityRequest) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	claims, err := s.oidcVerifier.Verify(ctx, request.Provider, request.IDToken)
+	if err != nil {
+		s.logger.Error("Cannot verify id token", "provider", request.Provider, "error", err)
+		cusErr := utils.NewErrorResponse(utils.InvalidIDToken)
+		return cusErr.Error(), cusErr
+	}
+
+	identity, err := s.repo.GetIdentity(ctx, request.Provider, claims.Subject)
+	if err == nil {
+		if identity.UserID != user.ID {
+			s.logger.Error("Identity is already linked to another user", "provider", request.Provider, "userID", user.ID)
+			cusErr := utils.NewErrorResponse(utils.IdentityAlreadyLinked)
+			return cusErr.Error(), cusErr
+		}
+	} else {
+		identity = &database.Identity{
+			UserID:   user.ID,
+			Provider: request.Provider,
+			Subject:  claims.Subject,
+			Email:    utils.NormalizeEmail(claims.Email),
+		}
+		if err := s.repo.CreateIdentity(ctx, identity); err != nil {
+			s.logger.Error("Error linking identity", "error", err)
+			if strings.Contains(err.Error(), utils.PgDuplicateKeyMsg) {
+				cusErr := utils.NewErrorResponse(utils.IdentityAlreadyLinked)
+				return cusErr.Error(), cusErr
+			}
+			cusErr := utils.NewErrorResponse(utils.InternalServerError)
+			return cusErr.Error(), cusErr
+		}
+		s.logger.Info("Identity linked", "userID", user.ID, "provider", request.Provider)
+	}
+
+	methods, err := s.signInMethods(ctx, user)
+	if err != nil {
+		return err.Error(), err
+	}
+	return SignInMethodsResponse{Methods: methods}, nil
+}
+
+// LinkPassword sets a password on an account that only signs in with social login.
+func (s *userService) LinkPassword(ctx context.Context, request *LinkPasswordRequest) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	if user.Password != "" {
+		cusErr := utils.NewErrorResponse(utils.PasswordAlreadySet)
+		return cusErr.Error(), cusErr
+	}
+	if request.Password != request.RePassword {
+		cusErr := utils.NewErrorResponse(utils.PasswordNotMatch)
+		return cusErr.Error(), cusErr
+	}
+	if err := s.policy.Validate(request.Password, user.Email); err != nil {
+		s.logger.Error("Password does not satisfy the policy", "error", err)
+		return "Password does not satisfy the password policy", err
+	}
+	hashedPassword, err := s.hasher.Hash(request.Password)
+	if err != nil {
+		s.logger.Error("Error hashing password", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	if err := s.repo.UpdatePasswordHash(ctx, user.ID, hashedPassword); err != nil {
+		s.logger.Error("Cannot set password", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	// Keep the password history complete for the reuse check
+	err = s.repo.InsertListOfPasswords(ctx, &database.PassworUsers{UserID: user.ID, Password: hashedPassword})
+	if err != nil {
+		s.logger.Error("Cannot update password into list of passwords", "error", err)
+	}
+	user.Password = hashedPassword
+	s.logger.Info("Password linked", "userID", user.ID)
+
+	methods, err := s.signInMethods(ctx, user)
+	if err != nil {
+		return err.Error(), err
+	}
+	return SignInMethodsResponse{Methods: methods}, nil
+}
+
+// UnlinkSignInMethod removes the password or a linked identity, refusing to remove the last one.
+func (s *userService) UnlinkSignInMethod(ctx context.Context, request *UnlinkSignInMethodRequest) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	methods, err := s.signInMethods(ctx, user)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	matches := func(method SignInMethod) bool {
+		return method.Type == request.Type && (request.Type == SignInMethodPassword || method.ID == request.IdentityID)
+	}
+	found := false
+	for _, method := range methods {
+		found = found || matches(method)
+	}
+	if !found {
+		cusErr := utils.NewErrorResponse(utils.NotFound)
+		return cusErr.Error(), cusErr
+	}
+	if len(methods) == 1 {
+		cusErr := utils.NewErrorResponse(utils.LastSignInMethod)
+		return cusErr.Error(), cusErr
+	}
+
+	if request.Type == SignInMethodPassword {
+		err = s.repo.RemovePassword(ctx, user.ID)
+	} else {
+		err = s.repo.DeleteIdentity(ctx, user.ID, request.IdentityID)
+	}
+	if err != nil {
+		s.logger.Error("Cannot unlink sign-in method", "userID", user.ID, "error", err)
+		// the guarded delete matched nothing, another request removed a method meanwhile
+		if errors.Is(err, sql.ErrNoRows) {
+			cusErr := utils.NewErrorResponse(utils.LastSignInMethod)
+			return cusErr.Error(), cusErr
+		}
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("Sign-in method unlinked", "userID", user.ID, "type", request.Type)
+
+	remaining := []SignInMethod{}
+	for _, method := range methods {
+		if !matches(method) {
+			remaining = append(remaining, method)
+		}
+	}
+	return SignInMethodsResponse{Methods: remaining}, nil
+}
+
+// signInMethods returns the password, when set, followed by the linked identities
+func (s *userService) signInMethods(ctx context.Context, user *database.User) ([]SignInMethod, error) {
+	identities, err := s.repo.GetIdentitiesByUserID(ctx, user.ID)
+	if err != nil {
+		s.logger.Error("Cannot get identities", "userID", user.ID, "error", err)
+		return nil, utils.NewErrorResponse(utils.InternalServerError)
+	}
+	methods := []SignInMethod{}
+	if user.Password != "" {
+		methods = append(methods, SignInMethod{Type: SignInMethodPassword, Email: user.Email})
+	}
+	for _, identity := range identities {
+		methods = append(methods, SignInMethod{
+			Type:      SignInMethodIdentity,
+			ID:        identity.ID,
+			Provider:  identity.Provider,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+	return methods, nil
+}
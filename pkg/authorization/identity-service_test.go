@@ -0,0 +1,126 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"context"
+	"database/sql"
+	"testing"
+)
+
+// signInMethodCount counts the password and identities of the user, it expects the lock to be held.
+func (repo *fakeRepo) signInMethodCount(userID string) int {
+	count := 0
+	if repo.users[userID].Password != "" {
+		count++
+	}
+	for _, identity := range repo.identities {
+		if identity.UserID == userID {
+			count++
+		}
+	}
+	return count
+}
+
+func (repo *fakeRepo) DeleteIdentity(ctx context.Context, userID string, identityID string) error {
+	if repo.beforeWrite != nil {
+		repo.beforeWrite()
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	identity, ok := repo.identities[identityID]
+	if !ok || identity.UserID != userID || repo.signInMethodCount(userID) < 2 {
+		return sql.ErrNoRows
+	}
+	delete(repo.identities, identityID)
+	return nil
+}
+
+func (repo *fakeRepo) RemovePassword(ctx context.Context, userID string) error {
+	if repo.beforeWrite != nil {
+		repo.beforeWrite()
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if repo.users[userID].Password == "" || repo.signInMethodCount(userID) < 2 {
+		return sql.ErrNoRows
+	}
+	repo.users[userID].Password = ""
+	return nil
+}
+
+func TestUnlinkSignInMethod(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	identity := &database.Identity{UserID: user.ID, Provider: "test", Subject: "subject-1", Email: user.Email}
+	if err := repo.CreateIdentity(ctx, identity); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.UnlinkSignInMethod(ctx, &UnlinkSignInMethodRequest{Type: SignInMethodIdentity, IdentityID: "unknown"}); errorType(err) != utils.NotFound {
+		t.Fatalf("unknown identity: got %v, want not found", err)
+	}
+	response, err := s.UnlinkSignInMethod(ctx, &UnlinkSignInMethodRequest{Type: SignInMethodPassword})
+	if err != nil {
+		t.Fatal(err)
+	}
+	methods := response.(SignInMethodsResponse).Methods
+	if len(methods) != 1 || methods[0].ID != identity.ID || repo.users[user.ID].Password != "" {
+		t.Fatalf("got %+v, want the identity left", methods)
+	}
+	if _, err := s.UnlinkSignInMethod(ctx, &UnlinkSignInMethodRequest{Type: SignInMethodIdentity, IdentityID: identity.ID}); errorType(err) != utils.LastSignInMethod {
+		t.Fatalf("last method: got %v, want last sign-in method", err)
+	}
+	if _, ok := repo.identities[identity.ID]; !ok {
+		t.Fatal("last sign-in method was removed")
+	}
+}
+
+func TestUnlinkSignInMethodConcurrently(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	identity := &database.Identity{UserID: user.ID, Provider: "test", Subject: "subject-1", Email: user.Email}
+	if err := repo.CreateIdentity(ctx, identity); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another request removes the password after this one listed both methods
+	repo.beforeWrite = func() {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		repo.users[user.ID].Password = ""
+	}
+	if _, err := s.UnlinkSignInMethod(ctx, &UnlinkSignInMethodRequest{Type: SignInMethodIdentity, IdentityID: identity.ID}); errorType(err) != utils.LastSignInMethod {
+		t.Fatalf("got %v, want last sign-in method", err)
+	}
+	if _, ok := repo.identities[identity.ID]; !ok {
+		t.Fatal("last sign-in method was removed")
+	}
+}
+
+func TestLinkPassword(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	identity := &database.Identity{UserID: user.ID, Provider: "test", Subject: "subject-1", Email: user.Email}
+	if err := repo.CreateIdentity(ctx, identity); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.LinkPassword(ctx, &LinkPasswordRequest{Password: "New-Password1", RePassword: "New-Password1"}); errorType(err) != utils.PasswordAlreadySet {
+		t.Fatalf("got %v, want password already set", err)
+	}
+	repo.users[user.ID].Password = ""
+	if _, err := s.LinkPassword(ctx, &LinkPasswordRequest{Password: "New-Password1", RePassword: "New-Password2"}); errorType(err) != utils.PasswordNotMatch {
+		t.Fatalf("got %v, want passwords not matching", err)
+	}
+	if _, err := s.LinkPassword(ctx, &LinkPasswordRequest{Password: "short", RePassword: "short"}); err == nil {
+		t.Fatal("password against the policy was linked")
+	}
+	response, err := s.LinkPassword(ctx, &LinkPasswordRequest{Password: "New-Password1", RePassword: "New-Password1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if methods := response.(SignInMethodsResponse).Methods; len(methods) != 2 || methods[0].Type != SignInMethodPassword {
+		t.Fatalf("got %+v, want the password and the identity", methods)
+	}
+	if !s.auth.ComparePassword(repo.users[user.ID].Password, "New-Password1") || len(repo.passwords[user.ID]) != 1 {
+		t.Fatal("password was not stored with its history")
+	}
+}
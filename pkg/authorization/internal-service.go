@@ -0,0 +1,115 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"LoveLetterProject/pkg/authorization/middleware"
+	"context"
+	"strings"
+	"time"
+)
+
+// InternalGetUser looks a user up by id or email for internal services authenticated with an API key.
+func (s *userService) InternalGetUser(ctx context.Context, request *InternalGetUserRequest) (interface{}, error) {
+	var user *database.User
+	var err error
+	if request.UserID != "" {
+		user, err = s.repo.GetUserByID(ctx, request.UserID)
+	} else {
+		user, err = s.repo.GetUserByEmail(ctx, request.Email)
+	}
+	if err != nil {
+		s.logger.Error("Cannot get user", "error", err)
+		if strings.Contains(err.Error(), utils.PgNoRowsMsg) {
+			cusErr := utils.NewErrorResponse(utils.NotFound)
+			return cusErr.Error(), cusErr
+		}
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	return InternalUserResponse{
+		ID:        user.ID,
+		Email:     user.Email,
+		Username:  user.Username,
+		Verified:  user.Verified,
+		Banned:    user.Banned,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+	}, nil
+}
+
+// InternalGetMultiRatios lists every multi ratio version for internal services.
+func (s *userService) InternalGetMultiRatios(ctx context.Context) (interface{}, error) {
+	ratios, err := s.repo.GetMultiRatios(ctx)
+	if err != nil {
+		s.logger.Error("Cannot get multi ratio versions", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	return ratios, nil
+}
+
+// InternalPublishMultiRatio publishes the ratios as a new version for internal services.
+// Versions cannot take effect in the past or before the latest version, so the scores
+// already validated keep the version they were validated against.
+func (s *userService) InternalPublishMultiRatio(ctx context.Context, request *PublishMultiRatioRequest) (interface{}, error) {
+	now := time.Now()
+	ratio := &database.MultiRatioData{
+		WaterRatio:    request.WaterRatio,
+		LightRatio:    request.LightRatio,
+		SeedRatio:     request.SeedRatio,
+		EffectiveFrom: now,
+	}
+	if request.EffectiveFrom != nil {
+		if request.EffectiveFrom.Before(now.Add(-focusClockSkew)) {
+			cusErr := utils.NewErrorResponse(utils.InvalidMultiRatio)
+			return cusErr.Error(), cusErr
+		}
+		ratio.EffectiveFrom = *request.EffectiveFrom
+	}
+	ratios, err := s.repo.GetMultiRatios(ctx)
+	if err != nil {
+		s.logger.Error("Cannot get multi ratio versions", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	for _, published := range ratios {
+		if ratio.EffectiveFrom.Before(published.EffectiveFrom) {
+			s.logger.Error("Multi ratio takes effect before a published version", "version", published.Version)
+			cusErr := utils.NewErrorResponse(utils.InvalidMultiRatio)
+			return cusErr.Error(), cusErr
+		}
+	}
+	if err := s.repo.PublishMultiRatio(ctx, ratio); err != nil {
+		if strings.Contains(err.Error(), utils.PgDuplicateKeyMsg) {
+			cusErr := utils.NewErrorResponse(utils.Conflict)
+			return cusErr.Error(), cusErr
+		}
+		s.logger.Error("Cannot publish multi ratio", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("Multi ratio published", "version", ratio.Version, "effectiveFrom", ratio.EffectiveFrom,
+		"apiKeyID", ctx.Value(middleware.APIKeyIDKey{}))
+	return ratio, nil
+}
+
+// InternalGetEarnScore returns the earn score of any user for internal services authenticated with an API key.
+func (s *userService) InternalGetEarnScore(ctx context.Context, request *InternalGetEarnScoreRequest) (interface{}, error) {
+	user, err := s.repo.GetUserByID(ctx, request.UserID)
+	if err != nil {
+		s.logger.Error("Cannot get user", "error", err)
+		if strings.Contains(err.Error(), utils.PgNoRowsMsg) {
+			cusErr := utils.NewErrorResponse(utils.NotFound)
+			return cusErr.Error(), cusErr
+		}
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	response, err := s.earnScoreOf(ctx, user.ID)
+	if err != nil {
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	return response, nil
+}
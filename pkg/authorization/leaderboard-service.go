@@ -0,0 +1,157 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"LoveLetterProject/pkg/authorization/middleware"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Leaderboard scopes
+const (
+	LeaderboardScopeGlobal  = "global"
+	LeaderboardScopeFriends = "friends"
+)
+
+// GetLeaderboard returns the top of a materialized leaderboard and the caller's own rank,
+// also when it is outside the top. The friends scope ranks the caller among their friends.
+func (s *userService) GetLeaderboard(ctx context.Context, request *GetLeaderboardRequest) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	limit := request.Limit
+	if limit <= 0 || limit > s.configs.LeaderboardSize {
+		limit = s.configs.LeaderboardSize
+	}
+	scope := request.Scope
+	if scope == "" {
+		scope = LeaderboardScopeGlobal
+	}
+	board := database.LeaderboardName(request.Metric, request.Period)
+
+	var entries []database.LeaderboardEntry
+	var you *database.LeaderboardEntry
+	if scope == LeaderboardScopeFriends {
+		entries, err = s.repo.GetFriendsLeaderboard(ctx, board, user.ID)
+		for i := range entries {
+			if entries[i].UserID == user.ID {
+				you = &entries[i]
+			}
+		}
+		if len(entries) > limit {
+			entries = entries[:limit]
+		}
+	} else {
+		entries, err = s.repo.GetLeaderboard(ctx, board, limit)
+		if err == nil {
+			you, err = s.repo.GetLeaderboardEntry(ctx, board, user.ID)
+			if errors.Is(err, sql.ErrNoRows) {
+				you, err = nil, nil
+			}
+		}
+	}
+	if err != nil {
+		s.logger.Error("Cannot get leaderboard", "error", err, "board", board)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+
+	response := LeaderboardResponse{
+		Metric:  request.Metric,
+		Period:  request.Period,
+		Scope:   scope,
+		Entries: make([]LeaderboardEntryResponse, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, newLeaderboardEntryResponse(entry, user.ID))
+	}
+	if len(entries) > 0 {
+		response.ComputedAt = &entries[0].ComputedAt
+	}
+	if you != nil {
+		yourEntry := newLeaderboardEntryResponse(*you, user.ID)
+		response.You = &yourEntry
+		response.ComputedAt = &you.ComputedAt
+	}
+	// Tell hidden users why they are not ranked
+	if settings, err := s.repo.GetPrivacySettings(ctx, user.ID); err == nil {
+		response.HiddenFromLeaderboards = settings.HideFromLeaderboards
+	}
+	return response, nil
+}
+
+// GetPrivacySettings returns the privacy settings of the user, the defaults when never changed.
+func (s *userService) GetPrivacySettings(ctx context.Context) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	settings, err := s.repo.GetPrivacySettings(ctx, user.ID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			s.logger.Error("Cannot get privacy settings", "error", err)
+			cusErr := utils.NewErrorResponse(utils.InternalServerError)
+			return cusErr.Error(), cusErr
+		}
+		settings = &database.PrivacySettings{UserID: user.ID}
+	}
+	return PrivacySettingsResponse{HideFromLeaderboards: settings.HideFromLeaderboards}, nil
+}
+
+// UpdatePrivacySettings changes the given privacy settings and keeps the others.
+func (s *userService) UpdatePrivacySettings(ctx context.Context, request *UpdatePrivacySettingsRequest) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	settings, err := s.repo.GetPrivacySettings(ctx, user.ID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			s.logger.Error("Cannot get privacy settings", "error", err)
+			cusErr := utils.NewErrorResponse(utils.InternalServerError)
+			return cusErr.Error(), cusErr
+		}
+		settings = &database.PrivacySettings{UserID: user.ID}
+	}
+	if request.HideFromLeaderboards != nil {
+		settings.HideFromLeaderboards = *request.HideFromLeaderboards
+	}
+	if err := s.repo.UpdatePrivacySettings(ctx, settings); err != nil {
+		s.logger.Error("Cannot update privacy settings", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("Privacy settings updated", "userID", user.ID)
+	return PrivacySettingsResponse{HideFromLeaderboards: settings.HideFromLeaderboards}, nil
+}
+
+// newLeaderboardEntryResponse returns the entry without the user id, marking the caller's own entry
+func newLeaderboardEntryResponse(entry database.LeaderboardEntry, userID string) LeaderboardEntryResponse {
+	return LeaderboardEntryResponse{
+		Rank:     entry.Rank,
+		Username: entry.Username,
+		Score:    entry.Score,
+		IsYou:    entry.UserID == userID,
+	}
+}
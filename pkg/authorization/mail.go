@@ -19,12 +19,19 @@ type MailType int
 const (
 	MailConfirmation MailType = iota + 1
 	PassReset
+	AccountExists
+	EmailChangeCode
+	EmailChangeNotice
+	EmailChangeUndo
+	LoginCode
 )
 
 // MailData represents the data to be sent to the template of the mail.
 type MailData struct {
 	Username string
 	Code     string
+	Email    string
+	Link     string
 }
 
 // Mail represents a email request
@@ -55,10 +62,21 @@ func (ms *SGMailService) CreateMail(mailReq *Mail) []byte {
 	from := mail.NewEmail("Admin", mailReq.from)
 	m.SetFrom(from)
 
-	if mailReq.mtype == MailConfirmation {
+	switch mailReq.mtype {
+	case MailConfirmation:
 		m.SetTemplateID(ms.configs.MailVerifTemplateID)
-	} else if mailReq.mtype == PassReset {
+	case PassReset:
 		m.SetTemplateID(ms.configs.PassResetTemplateID)
+	case AccountExists:
+		m.SetTemplateID(ms.configs.AccountExistsTemplateID)
+	case EmailChangeCode:
+		m.SetTemplateID(ms.configs.EmailChangeCodeTemplateID)
+	case EmailChangeNotice:
+		m.SetTemplateID(ms.configs.EmailChangeNoticeTemplateID)
+	case EmailChangeUndo:
+		m.SetTemplateID(ms.configs.EmailChangeUndoTemplateID)
+	case LoginCode:
+		m.SetTemplateID(ms.configs.LoginCodeTemplateID)
 	}
 
 	p := mail.NewPersonalization()
@@ -72,6 +90,8 @@ func (ms *SGMailService) CreateMail(mailReq *Mail) []byte {
 
 	p.SetDynamicTemplateData("Username", mailReq.data.Username)
 	p.SetDynamicTemplateData("Code", mailReq.data.Code)
+	p.SetDynamicTemplateData("Email", mailReq.data.Email)
+	p.SetDynamicTemplateData("Link", mailReq.data.Link)
 
 	m.AddPersonalizations(p)
 	return mail.GetRequestBody(m)
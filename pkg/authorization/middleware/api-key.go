@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	utils "LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"context"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/hashicorp/go-hclog"
+	"github.com/juju/ratelimit"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// APIKeyHeader is the request header carrying the API key of an internal service
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyHeaderKey is used as a key for storing the X-API-Key header in context at transport
+type APIKeyHeaderKey struct{}
+
+// APIKeyIDKey is used as a key for storing the id of the authenticated API key in context at middleware
+type APIKeyIDKey struct{}
+
+// APIKeyLimiter keeps one token bucket per API key, sized by the rate limit of the key
+type APIKeyLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*apiKeyBucket
+}
+
+type apiKeyBucket struct {
+	rateLimit int
+	bucket    *ratelimit.Bucket
+}
+
+// NewAPIKeyLimiter returns an empty APIKeyLimiter
+func NewAPIKeyLimiter() *APIKeyLimiter {
+	return &APIKeyLimiter{buckets: map[string]*apiKeyBucket{}}
+}
+
+// Allow takes one request from the bucket of the key. The bucket is rebuilt when the
+// rate limit of the key changed.
+func (l *APIKeyLimiter) Allow(key *database.APIKey) bool {
+	if key.RateLimit <= 0 {
+		return false
+	}
+	l.mu.Lock()
+	b, ok := l.buckets[key.ID]
+	if !ok || b.rateLimit != key.RateLimit {
+		b = &apiKeyBucket{
+			rateLimit: key.RateLimit,
+			bucket:    ratelimit.NewBucketWithQuantum(time.Minute, int64(key.RateLimit), int64(key.RateLimit)),
+		}
+		l.buckets[key.ID] = b
+	}
+	l.mu.Unlock()
+	return b.bucket.TakeAvailable(1) == 1
+}
+
+// ExtractAPIKey stores the X-API-Key header in context, to be used as a transport ServerBefore
+func ExtractAPIKey(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, APIKeyHeaderKey{}, r.Header.Get(APIKeyHeader))
+}
+
+// ValidateAPIKey is a middleware for endpoints of internal services. It authenticates the
+// X-API-Key header instead of a user token, checks the key has every given scope and
+// applies the rate limit of the key.
+func ValidateAPIKey(r database.UserRepository, limiter *APIKeyLimiter, logger hclog.Logger, scopes ...string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			rawKey, _ := ctx.Value(APIKeyHeaderKey{}).(string)
+			if rawKey == "" {
+				logger.Debug("api key is missing")
+				cusErr := utils.NewErrorResponse(utils.InvalidAPIKey)
+				return nil, utils.NewErrorWrapper(http.StatusUnauthorized, cusErr, cusErr.Error())
+			}
+			key, err := r.GetAPIKeyByHash(ctx, utils.HashToken(rawKey))
+			if err != nil || !key.IsActive() {
+				logger.Error("api key validation failed", "error", err)
+				cusErr := utils.NewErrorResponse(utils.InvalidAPIKey)
+				return nil, utils.NewErrorWrapper(http.StatusUnauthorized, cusErr, cusErr.Error())
+			}
+			if !key.HasScopes(scopes...) {
+				logger.Debug("api key is missing a scope", "keyID", key.ID, "scopes", scopes)
+				cusErr := utils.NewErrorResponse(utils.InsufficientScope)
+				return nil, utils.NewErrorWrapper(http.StatusForbidden, cusErr, cusErr.Error())
+			}
+			if !limiter.Allow(key) {
+				logger.Error("api key rate limit exceeded", "keyID", key.ID)
+				cusErr := utils.NewErrorResponse(utils.QuicklyRequest)
+				return nil, utils.NewErrorWrapper(http.StatusTooManyRequests, cusErr, cusErr.Error())
+			}
+			if err := r.TouchAPIKey(ctx, key.ID); err != nil {
+				logger.Error("unable to record api key use", "error", err)
+			}
+			ctx = context.WithValue(ctx, APIKeyIDKey{}, key.ID)
+			return next(ctx, request)
+		}
+	}
+}
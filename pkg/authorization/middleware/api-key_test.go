@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	utils "LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"context"
+	"database/sql"
+	"github.com/hashicorp/go-hclog"
+	"testing"
+	"time"
+)
+
+func (repo *fakeRepo) GetAPIKeyByHash(ctx context.Context, keyHash string) (*database.APIKey, error) {
+	key, ok := repo.apiKeys[keyHash]
+	if !ok {
+		return &database.APIKey{}, sql.ErrNoRows
+	}
+	copied := *key
+	return &copied, nil
+}
+
+func (repo *fakeRepo) TouchAPIKey(ctx context.Context, id string) error {
+	for _, key := range repo.apiKeys {
+		if key.ID == id {
+			now := time.Now()
+			key.LastUsedAt = &now
+		}
+	}
+	return nil
+}
+
+// callWithAPIKey runs an endpoint behind ValidateAPIKey with the scopes and returns the id
+// of the key the endpoint saw.
+func callWithAPIKey(repo *fakeRepo, limiter *APIKeyLimiter, rawKey string, scopes ...string) (string, error) {
+	next := func(ctx context.Context, request interface{}) (interface{}, error) {
+		return ctx.Value(APIKeyIDKey{}).(string), nil
+	}
+	ctx := context.WithValue(context.Background(), APIKeyHeaderKey{}, rawKey)
+	response, err := ValidateAPIKey(repo, limiter, hclog.NewNullLogger(), scopes...)(next)(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	return response.(string), nil
+}
+
+func TestValidateAPIKey(t *testing.T) {
+	repo := newFakeRepo()
+	limiter := NewAPIKeyLimiter()
+	rawKey := database.APIKeyPrefix + "secret"
+	key := &database.APIKey{ID: "key-1", KeyHash: utils.HashToken(rawKey), RateLimit: 100,
+		Scopes: database.ScopeUsersRead + "," + database.ScopeScoresRead}
+	repo.apiKeys[key.KeyHash] = key
+
+	if keyID, err := callWithAPIKey(repo, limiter, rawKey, database.ScopeUsersRead); err != nil || keyID != key.ID {
+		t.Fatalf("got %q (%v), want the key", keyID, err)
+	}
+	if key.LastUsedAt == nil {
+		t.Fatal("use of the key was not recorded")
+	}
+	if _, err := callWithAPIKey(repo, limiter, rawKey, database.ScopeUsersRead, database.ScopeScoresRead); err != nil {
+		t.Fatalf("every scope granted: %v", err)
+	}
+	tests := []struct {
+		name   string
+		rawKey string
+		scopes []string
+		want   utils.ErrorType
+	}{
+		{"missing key", "", []string{database.ScopeUsersRead}, utils.InvalidAPIKey},
+		{"unknown key", database.APIKeyPrefix + "unknown", []string{database.ScopeUsersRead}, utils.InvalidAPIKey},
+		{"scope not granted", rawKey, []string{database.ScopeShopWrite}, utils.InsufficientScope},
+		{"one scope not granted", rawKey, []string{database.ScopeUsersRead, database.ScopeRatiosWrite}, utils.InsufficientScope},
+	}
+	for _, test := range tests {
+		if _, err := callWithAPIKey(repo, limiter, test.rawKey, test.scopes...); errorType(err) != test.want {
+			t.Fatalf("%s: got %v, want %v", test.name, err, utils.NewErrorResponse(test.want))
+		}
+	}
+
+	revokedAt := time.Now()
+	key.RevokedAt = &revokedAt
+	if _, err := callWithAPIKey(repo, limiter, rawKey, database.ScopeUsersRead); errorType(err) != utils.InvalidAPIKey {
+		t.Fatalf("revoked key: got %v, want invalid api key", err)
+	}
+}
+
+func TestValidateAPIKeyRateLimit(t *testing.T) {
+	repo := newFakeRepo()
+	limiter := NewAPIKeyLimiter()
+	rawKey := database.APIKeyPrefix + "secret"
+	key := &database.APIKey{ID: "key-1", KeyHash: utils.HashToken(rawKey), RateLimit: 3, Scopes: database.ScopeUsersRead}
+	repo.apiKeys[key.KeyHash] = key
+
+	for i := 0; i < key.RateLimit; i++ {
+		if _, err := callWithAPIKey(repo, limiter, rawKey, database.ScopeUsersRead); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+	if _, err := callWithAPIKey(repo, limiter, rawKey, database.ScopeUsersRead); errorType(err) != utils.QuicklyRequest {
+		t.Fatalf("got %v, want the rate limit", err)
+	}
+	// Raising the rate limit of the key takes effect at once
+	key.RateLimit = 10
+	if _, err := callWithAPIKey(repo, limiter, rawKey, database.ScopeUsersRead); err != nil {
+		t.Fatalf("raised rate limit: %v", err)
+	}
+	key.RateLimit = 0
+	if _, err := callWithAPIKey(repo, limiter, rawKey, database.ScopeUsersRead); errorType(err) != utils.QuicklyRequest {
+		t.Fatalf("key without rate limit: got %v, want it refused", err)
+	}
+}
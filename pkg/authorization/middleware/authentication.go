@@ -8,8 +8,8 @@ import (
 	"encoding/hex"
 	"github.com/golang-jwt/jwt"
 	"github.com/hashicorp/go-hclog"
-	"golang.org/x/crypto/bcrypt"
 	"io/ioutil"
+	"strings"
 	"time"
 )
 
@@ -17,9 +17,13 @@ import (
 type Authentication interface {
 	ComparePassword(userPassword string, requestPassword string) bool
 	GenerateAccessToken(user *database.User) (string, error)
+	GenerateAuthenticatedAccessToken(user *database.User) (string, error)
+	GenerateOAuthAccessToken(user *database.User, clientID string, scopes []string, consentGeneration int) (string, error)
 	GenerateRefreshToken(user *database.User) (string, error)
 	GenerateCustomKey(userID string, password string) string
 	ValidateAccessToken(token string) (string, string, error)
+	GetAccessTokenAuthTime(token string) (time.Time, error)
+	GetAccessTokenClient(token string) (string, []string, int, error)
 	ValidateRefreshToken(token string) (string, string, error)
 }
 
@@ -36,6 +40,15 @@ type AccessTokenCustomClaims struct {
 	UserID    string
 	KeyType   string
 	CustomKey string
+	// AuthTime is the unix time the user last proved their credentials,
+	// zero for tokens issued from a refresh token.
+	AuthTime int64 `json:"auth_time,omitempty"`
+	// ClientID and Scope are set on tokens issued to third-party OAuth clients,
+	// which may only call the endpoints covered by the space separated scopes.
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	// ConsentGeneration is the generation of the consent the OAuth token was issued under
+	ConsentGeneration int `json:"consent_gen,omitempty"`
 	jwt.StandardClaims
 }
 
@@ -43,20 +56,25 @@ type AccessTokenCustomClaims struct {
 type AuthService struct {
 	logger  hclog.Logger
 	configs *utils.Configurations
+	hasher  utils.PasswordHasher
 }
 
 // NewAuthService returns a new instance of the auth service
-func NewAuthService(logger hclog.Logger, configs *utils.Configurations) *AuthService {
-	return &AuthService{logger, configs}
+func NewAuthService(logger hclog.Logger, configs *utils.Configurations, hasher utils.PasswordHasher) *AuthService {
+	return &AuthService{logger, configs, hasher}
 }
 
 // ComparePassword check password same or not
 func (auth *AuthService) ComparePassword(userPassword string, requestPassword string) bool {
-	if err := bcrypt.CompareHashAndPassword([]byte(userPassword), []byte(requestPassword)); err != nil {
-		auth.logger.Debug("password hashes are not same")
+	same, err := auth.hasher.Verify(userPassword, requestPassword)
+	if err != nil {
+		auth.logger.Error("unable to verify password hash", "error", err)
 		return false
 	}
-	return true
+	if !same {
+		auth.logger.Debug("password hashes are not same")
+	}
+	return same
 }
 
 // GenerateRefreshToken generate a new refresh token for the given user
@@ -94,19 +112,47 @@ func (auth *AuthService) GenerateRefreshToken(user *database.User) (string, erro
 
 // GenerateAccessToken generates a new access token for the given user
 func (auth *AuthService) GenerateAccessToken(user *database.User) (string, error) {
+	return auth.generateAccessToken(user, 0)
+}
+
+// GenerateAuthenticatedAccessToken generates a new access token stamped with
+// the current time as auth_time. Use it only right after the user proved their credentials.
+func (auth *AuthService) GenerateAuthenticatedAccessToken(user *database.User) (string, error) {
+	return auth.generateAccessToken(user, time.Now().Unix())
+}
+
+// GenerateOAuthAccessToken generates a new access token for a third-party client,
+// limited to the scopes the user granted it in the given consent generation.
+func (auth *AuthService) GenerateOAuthAccessToken(user *database.User, clientID string, scopes []string, consentGeneration int) (string, error) {
+	claims := auth.accessTokenClaims(user, 0)
+	claims.ClientID = clientID
+	claims.Scope = strings.Join(scopes, " ")
+	claims.ConsentGeneration = consentGeneration
+	return auth.signAccessToken(claims)
+}
+
+func (auth *AuthService) generateAccessToken(user *database.User, authTime int64) (string, error) {
+	return auth.signAccessToken(auth.accessTokenClaims(user, authTime))
+}
+
+func (auth *AuthService) accessTokenClaims(user *database.User, authTime int64) AccessTokenCustomClaims {
 	userID := user.ID
 	tokenType := database.AccessType
 	cusKey := auth.GenerateCustomKey(user.ID, user.TokenHash)
 
-	claims := AccessTokenCustomClaims{
-		userID,
-		tokenType,
-		cusKey,
-		jwt.StandardClaims{
+	return AccessTokenCustomClaims{
+		UserID:    userID,
+		KeyType:   tokenType,
+		CustomKey: cusKey,
+		AuthTime:  authTime,
+		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: time.Now().Add(time.Minute * time.Duration(auth.configs.JwtExpiration)).Unix(),
 			Issuer:    auth.configs.Issuer,
 		},
 	}
+}
+
+func (auth *AuthService) signAccessToken(claims AccessTokenCustomClaims) (string, error) {
 
 	signBytes, err := ioutil.ReadFile(auth.configs.AccessTokenPrivateKeyPath)
 	if err != nil {
@@ -140,6 +186,38 @@ func (auth *AuthService) GenerateCustomKey(userID string, tokenHash string) stri
 // ValidateAccessToken parses and validates the given access token
 // returns the userId present in the token payload
 func (auth *AuthService) ValidateAccessToken(tokenString string) (string, string, error) {
+	claims, err := auth.parseAccessToken(tokenString)
+	if err != nil {
+		return "", "", err
+	}
+	return claims.UserID, claims.CustomKey, nil
+}
+
+// GetAccessTokenAuthTime returns the time the user last authenticated with
+// their credentials, the zero time if the token does not carry auth_time.
+func (auth *AuthService) GetAccessTokenAuthTime(tokenString string) (time.Time, error) {
+	claims, err := auth.parseAccessToken(tokenString)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if claims.AuthTime == 0 {
+		return time.Time{}, nil
+	}
+	return time.Unix(claims.AuthTime, 0), nil
+}
+
+// GetAccessTokenClient returns the OAuth client id, scopes and consent generation of the
+// access token, an empty client id for first-party tokens.
+func (auth *AuthService) GetAccessTokenClient(tokenString string) (string, []string, int, error) {
+	claims, err := auth.parseAccessToken(tokenString)
+	if err != nil {
+		return "", nil, 0, err
+	}
+	return claims.ClientID, strings.Fields(claims.Scope), claims.ConsentGeneration, nil
+}
+
+// parseAccessToken parses and validates the given access token and returns its claims
+func (auth *AuthService) parseAccessToken(tokenString string) (*AccessTokenCustomClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &AccessTokenCustomClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
 			auth.logger.Error("Unexpected signing method in auth token")
@@ -165,15 +243,15 @@ func (auth *AuthService) ValidateAccessToken(tokenString string) (string, string
 
 	if err != nil {
 		auth.logger.Error("unable to parse claims", "error", err)
-		return "", "", err
+		return nil, err
 	}
 
 	claims, ok := token.Claims.(*AccessTokenCustomClaims)
 	if !ok || !token.Valid || claims.UserID == "" || claims.KeyType != "access" {
 		cusErr := utils.NewErrorResponse(utils.InternalServerError)
-		return "", "", cusErr
+		return nil, cusErr
 	}
-	return claims.UserID, claims.CustomKey, nil
+	return claims, nil
 }
 
 // ValidateRefreshToken parses and validates the given refresh token
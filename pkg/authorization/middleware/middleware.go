@@ -9,13 +9,18 @@ import (
 	"github.com/go-kit/kit/endpoint"
 	"github.com/hashicorp/go-hclog"
 	"github.com/juju/ratelimit"
+	"net/http"
 	"regexp"
 	"strings"
+	"time"
 )
 
 // UserIDKey is used as a key for storing the UserID in context at middleware
 type UserIDKey struct{}
 
+// UserRoleKey is used as a key for storing the role of the user in context at middleware
+type UserRoleKey struct{}
+
 // ValidateRefreshToken is a middleware that validates the refresh token
 func ValidateRefreshToken(auth Authentication, r database.UserRepository, logger hclog.Logger) endpoint.Middleware {
 	return func(next endpoint.Endpoint) endpoint.Endpoint {
@@ -79,60 +84,168 @@ func extractValue(request interface{}, key string) (string, error) {
 	return strings.ReplaceAll(keyValMatch[1], "\"", ""), nil
 }
 
-// ValidateAccessToken is a middleware that validates the access token
-func ValidateAccessToken(auth Authentication, r database.UserRepository, logger hclog.Logger) endpoint.Middleware {
+// ValidateAccessToken is a middleware that validates the access token. Personal access
+// tokens and the access tokens of OAuth clients are only accepted when the endpoint lists
+// the scopes they need, and must have been granted all of them.
+func ValidateAccessToken(auth Authentication, r database.UserRepository, logger hclog.Logger, scopes ...string) endpoint.Middleware {
 	return func(next endpoint.Endpoint) endpoint.Endpoint {
 		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
-			userID, err := authorizedAccessToken(ctx, auth, r, logger, request)
+			user, grant, err := authorizedAccessToken(ctx, auth, r, logger, request, len(scopes) != 0)
 			if err != nil {
 				logger.Error("You're not authorized. Please try again latter.")
 				cusErr := utils.NewErrorResponse(utils.ValidationTokenFailure)
 				return nil, cusErr
 			}
-			ctx = context.WithValue(ctx, UserIDKey{}, userID)
+			if grant != nil && !database.ContainsScopes(grant.scopes, scopes) {
+				logger.Debug("scoped token is missing a scope", "token", grant.id, "scopes", scopes)
+				cusErr := utils.NewErrorResponse(utils.InsufficientScope)
+				return nil, utils.NewErrorWrapper(http.StatusForbidden, cusErr, cusErr.Error())
+			}
+			ctx = context.WithValue(ctx, UserIDKey{}, user.ID)
+			ctx = context.WithValue(ctx, UserRoleKey{}, user.Role)
 			return next(ctx, request)
 		}
 	}
 }
 
-func authorizedAccessToken(ctx context.Context, auth Authentication, r database.UserRepository, logger hclog.Logger, request interface{}) (string, error) {
+// scopedGrant is what a personal access token or the access token of an OAuth client was
+// granted. It is nil for first-party access tokens, which may call every endpoint.
+type scopedGrant struct {
+	id     string // for logs
+	scopes []string
+}
+
+// authorizedAccessToken returns the user of the access token, and the scopes granted to
+// the token when it is a personal access token or was issued to an OAuth client.
+func authorizedAccessToken(ctx context.Context, auth Authentication, r database.UserRepository, logger hclog.Logger, request interface{}, allowScopedToken bool) (*database.User, *scopedGrant, error) {
 	token, err := extractValue(request, "access_token")
 	if err != nil {
 		logger.Error("token validation failed", "err", err)
 		cusErr := utils.NewErrorResponse(utils.ValidationTokenFailure)
-		return "", cusErr
+		return nil, nil, cusErr
+	}
+
+	if strings.HasPrefix(token, database.PersonalAccessTokenPrefix) {
+		if !allowScopedToken {
+			logger.Debug("personal access token is not accepted by this endpoint")
+			cusErr := utils.NewErrorResponse(utils.ValidationTokenFailure)
+			return nil, nil, cusErr
+		}
+		return authorizedPersonalAccessToken(ctx, r, logger, token)
 	}
 
 	userID, customKey, err := auth.ValidateAccessToken(token)
 	if err != nil {
 		logger.Error("token validation failed", "error", err)
 		cusErr := utils.NewErrorResponse(utils.ValidationTokenFailure)
-		return "", cusErr
+		return nil, nil, cusErr
 	}
 
 	user, err := r.GetUserByID(ctx, userID)
 	if err != nil {
 		logger.Error("You're not authorized. Please try again latter.", err)
 		cusErr := utils.NewErrorResponse(utils.ValidationTokenFailure)
-		return "", cusErr
+		return nil, nil, cusErr
 	}
 
-	// Check role is guest, not authorized
-	//if user.Role == "guest" {
-	//	logger.Error("You're not authorized. Please try again latter.")
-	//	cusErr := utils.NewErrorResponse(utils.ValidationTokenFailure)
-	//	return "", cusErr
-	//}
-
 	actualCustomKey := auth.GenerateCustomKey(user.ID, user.TokenHash)
 	if customKey != actualCustomKey {
 		logger.Debug("wrong token: authentication failed")
 		cusErr := utils.NewErrorResponse(utils.Unauthorized)
-		return "", cusErr
+		return nil, nil, cusErr
+	}
+
+	clientID, scopes, consentGeneration, err := auth.GetAccessTokenClient(token)
+	if err != nil {
+		logger.Error("token validation failed", "error", err)
+		cusErr := utils.NewErrorResponse(utils.ValidationTokenFailure)
+		return nil, nil, cusErr
+	}
+	if clientID != "" {
+		if !allowScopedToken {
+			logger.Debug("oauth access token is not accepted by this endpoint", "clientID", clientID)
+			cusErr := utils.NewErrorResponse(utils.ValidationTokenFailure)
+			return nil, nil, cusErr
+		}
+		// Revoking the consent cuts off access tokens already issued to the client,
+		// also once the client is approved again and the consent is in a new generation
+		consent, err := r.GetOAuthConsent(ctx, user.ID, clientID)
+		if err != nil || !consent.IsActive() || consent.Generation != consentGeneration {
+			logger.Debug("oauth consent is revoked", "clientID", clientID, "error", err)
+			cusErr := utils.NewErrorResponse(utils.ValidationTokenFailure)
+			return nil, nil, cusErr
+		}
+		logger.Debug("oauth access token validated", "userID", userID, "clientID", clientID)
+		return user, &scopedGrant{id: "oauth client " + clientID, scopes: scopes}, nil
 	}
 
 	logger.Debug("access token validated", userID)
-	return userID, nil
+	return user, nil, nil
+}
+
+// authorizedPersonalAccessToken looks the personal access token up by its hash and records its use.
+func authorizedPersonalAccessToken(ctx context.Context, r database.UserRepository, logger hclog.Logger, token string) (*database.User, *scopedGrant, error) {
+	pat, err := r.GetPersonalAccessTokenByHash(ctx, utils.HashToken(token))
+	if err != nil || !pat.IsActive() {
+		logger.Error("personal access token validation failed", "error", err)
+		cusErr := utils.NewErrorResponse(utils.ValidationTokenFailure)
+		return nil, nil, cusErr
+	}
+	user, err := r.GetUserByID(ctx, pat.UserID)
+	if err != nil {
+		logger.Error("You're not authorized. Please try again latter.", err)
+		cusErr := utils.NewErrorResponse(utils.ValidationTokenFailure)
+		return nil, nil, cusErr
+	}
+	if err := r.TouchPersonalAccessToken(ctx, pat.ID); err != nil {
+		logger.Error("unable to record personal access token use", "error", err)
+	}
+	logger.Debug("personal access token validated", "tokenID", pat.ID)
+	return user, &scopedGrant{id: "personal access token " + pat.ID, scopes: pat.ScopeList()}, nil
+}
+
+// RejectGuest is a middleware for endpoints that need a full account. It must be
+// wrapped by ValidateAccessToken, which stores the role of the user in context.
+func RejectGuest(logger hclog.Logger) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			role, _ := ctx.Value(UserRoleKey{}).(string)
+			if role == database.RoleGuest {
+				logger.Debug("guest is not allowed", "userID", ctx.Value(UserIDKey{}))
+				cusErr := utils.NewErrorResponse(utils.GuestNotAllowed)
+				return nil, utils.NewErrorWrapper(http.StatusForbidden, cusErr, cusErr.Error())
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// RequireRecentAuthentication is a middleware for sensitive endpoints. It rejects
+// access tokens whose auth_time is missing or older than maxAge, the client then
+// has to call /reauthenticate to get a fresh access token.
+func RequireRecentAuthentication(auth Authentication, maxAge time.Duration, logger hclog.Logger) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			token, err := extractValue(request, "access_token")
+			if err != nil {
+				logger.Error("extract value token failed", "err", err)
+				cusErr := utils.NewErrorResponse(utils.ValidationTokenFailure)
+				return nil, cusErr
+			}
+			authTime, err := auth.GetAccessTokenAuthTime(token)
+			if err != nil {
+				logger.Error("token validation failed", "error", err)
+				cusErr := utils.NewErrorResponse(utils.ValidationTokenFailure)
+				return nil, cusErr
+			}
+			if authTime.IsZero() || time.Since(authTime) > maxAge {
+				logger.Debug("recent authentication required", "authTime", authTime)
+				cusErr := utils.NewErrorResponse(utils.ReauthenticationRequired)
+				return nil, utils.NewErrorWrapper(http.StatusUnauthorized, cusErr, cusErr.Error())
+			}
+			return next(ctx, request)
+		}
+	}
 }
 
 // ValidateParamRequest validates the user in the request
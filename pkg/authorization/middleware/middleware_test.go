@@ -0,0 +1,242 @@
+package middleware
+
+import (
+	utils "LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"database/sql"
+	"encoding/pem"
+	"errors"
+	"github.com/hashicorp/go-hclog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeRepo keeps the records the middlewares read in memory. It embeds the repository
+// interface, so calling a method a test does not fake panics.
+type fakeRepo struct {
+	database.UserRepository
+	users    map[string]*database.User
+	consents map[string]*database.OAuthConsent
+	pats     map[string]*database.PersonalAccessToken // by token hash
+	apiKeys  map[string]*database.APIKey              // by key hash
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{
+		users:    map[string]*database.User{},
+		consents: map[string]*database.OAuthConsent{},
+		pats:     map[string]*database.PersonalAccessToken{},
+		apiKeys:  map[string]*database.APIKey{},
+	}
+}
+
+func (repo *fakeRepo) GetUserByID(ctx context.Context, id string) (*database.User, error) {
+	user, ok := repo.users[id]
+	if !ok {
+		return &database.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (repo *fakeRepo) GetOAuthConsent(ctx context.Context, userID string, clientID string) (*database.OAuthConsent, error) {
+	consent, ok := repo.consents[userID+"/"+clientID]
+	if !ok {
+		return &database.OAuthConsent{}, sql.ErrNoRows
+	}
+	copied := *consent
+	return &copied, nil
+}
+
+func (repo *fakeRepo) GetPersonalAccessTokenByHash(ctx context.Context, tokenHash string) (*database.PersonalAccessToken, error) {
+	pat, ok := repo.pats[tokenHash]
+	if !ok {
+		return &database.PersonalAccessToken{}, sql.ErrNoRows
+	}
+	copied := *pat
+	return &copied, nil
+}
+
+func (repo *fakeRepo) TouchPersonalAccessToken(ctx context.Context, id string) error {
+	for _, pat := range repo.pats {
+		if pat.ID == id {
+			now := time.Now()
+			pat.LastUsedAt = &now
+		}
+	}
+	return nil
+}
+
+// newTestAuth returns an auth service signing with a temporary key.
+func newTestAuth(t *testing.T) *AuthService {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	privatePath := filepath.Join(dir, "private.pem")
+	publicPath := filepath.Join(dir, "public.pem")
+	if err := os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicBytes}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	configs := &utils.Configurations{
+		JwtExpiration:              10,
+		AccessTokenPrivateKeyPath:  privatePath,
+		AccessTokenPublicKeyPath:   publicPath,
+		RefreshTokenPrivateKeyPath: privatePath,
+		RefreshTokenPublicKeyPath:  publicPath,
+		PasswordHashAlgorithm:      utils.HashAlgorithmBcrypt,
+		BcryptCost:                 4,
+	}
+	hasher, err := utils.NewPasswordHasher(configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewAuthService(hclog.NewNullLogger(), configs, hasher)
+}
+
+type tokenRequest struct {
+	AccessToken string `json:"access_token"`
+}
+
+// callWithToken runs an endpoint behind ValidateAccessToken with the scopes and returns
+// the user id the endpoint saw.
+func callWithToken(repo *fakeRepo, auth Authentication, token string, scopes ...string) (string, error) {
+	next := func(ctx context.Context, request interface{}) (interface{}, error) {
+		return ctx.Value(UserIDKey{}).(string), nil
+	}
+	response, err := ValidateAccessToken(auth, repo, hclog.NewNullLogger(), scopes...)(next)(context.Background(), tokenRequest{AccessToken: token})
+	if err != nil {
+		return "", err
+	}
+	return response.(string), nil
+}
+
+// errorType returns the type of an error response, also when it is wrapped, -1 for any other error.
+func errorType(err error) utils.ErrorType {
+	var cusErr utils.ErrorResponse
+	if errors.As(err, &cusErr) {
+		return cusErr.ErrorType
+	}
+	return -1
+}
+
+func TestValidateAccessTokenFirstParty(t *testing.T) {
+	auth := newTestAuth(t)
+	repo := newFakeRepo()
+	user := &database.User{ID: "user-1", TokenHash: "hash", Role: database.RoleUser}
+	repo.users[user.ID] = user
+
+	token, err := auth.GenerateAccessToken(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, scopes := range [][]string{nil, {database.ScopeScoresRead}} {
+		if userID, err := callWithToken(repo, auth, token, scopes...); err != nil || userID != user.ID {
+			t.Fatalf("scopes %v: got %q (%v), want the user", scopes, userID, err)
+		}
+	}
+	// Changing the token hash signs every token out
+	user.TokenHash = "rotated"
+	if _, err := callWithToken(repo, auth, token); err == nil {
+		t.Fatal("token of a rotated token hash was accepted")
+	}
+}
+
+func TestValidateAccessTokenOAuth(t *testing.T) {
+	auth := newTestAuth(t)
+	repo := newFakeRepo()
+	user := &database.User{ID: "user-1", TokenHash: "hash", Role: database.RoleUser}
+	repo.users[user.ID] = user
+	consent := &database.OAuthConsent{UserID: user.ID, ClientID: "client-1", Scopes: database.ScopeScoresRead, Generation: 1}
+	repo.consents[user.ID+"/client-1"] = consent
+
+	token, err := auth.GenerateOAuthAccessToken(user, "client-1", []string{database.ScopeScoresRead}, consent.Generation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userID, err := callWithToken(repo, auth, token, database.ScopeScoresRead); err != nil || userID != user.ID {
+		t.Fatalf("got %q (%v), want the user", userID, err)
+	}
+	if _, err := callWithToken(repo, auth, token); errorType(err) != utils.ValidationTokenFailure {
+		t.Fatalf("endpoint without scopes: got %v, want a token failure", err)
+	}
+	if _, err := callWithToken(repo, auth, token, database.ScopeSessionsWrite); errorType(err) != utils.InsufficientScope {
+		t.Fatalf("scope not granted: got %v, want insufficient scope", err)
+	}
+
+	// Revoking cuts the token off, and approving the client again does not bring it back
+	revokedAt := time.Now()
+	consent.RevokedAt = &revokedAt
+	consent.Generation++
+	if _, err := callWithToken(repo, auth, token, database.ScopeScoresRead); errorType(err) != utils.ValidationTokenFailure {
+		t.Fatalf("revoked consent: got %v, want a token failure", err)
+	}
+	consent.RevokedAt = nil
+	if _, err := callWithToken(repo, auth, token, database.ScopeScoresRead); errorType(err) != utils.ValidationTokenFailure {
+		t.Fatalf("token of an earlier grant: got %v, want a token failure", err)
+	}
+	token, err = auth.GenerateOAuthAccessToken(user, "client-1", []string{database.ScopeScoresRead}, consent.Generation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := callWithToken(repo, auth, token, database.ScopeScoresRead); err != nil {
+		t.Fatalf("token of the new grant: %v", err)
+	}
+}
+
+func TestValidateAccessTokenPersonalAccessToken(t *testing.T) {
+	auth := newTestAuth(t)
+	repo := newFakeRepo()
+	user := &database.User{ID: "user-1", TokenHash: "hash", Role: database.RoleUser}
+	repo.users[user.ID] = user
+	token := database.PersonalAccessTokenPrefix + "secret"
+	pat := &database.PersonalAccessToken{ID: "pat-1", UserID: user.ID, TokenHash: utils.HashToken(token),
+		Scopes: database.ScopeScoresRead + "," + database.ScopeSessionsWrite}
+	repo.pats[pat.TokenHash] = pat
+
+	if userID, err := callWithToken(repo, auth, token, database.ScopeScoresRead); err != nil || userID != user.ID {
+		t.Fatalf("got %q (%v), want the user", userID, err)
+	}
+	if pat.LastUsedAt == nil {
+		t.Fatal("use of the token was not recorded")
+	}
+	if _, err := callWithToken(repo, auth, token, database.ScopeScoresRead, database.ScopeSessionsWrite); err != nil {
+		t.Fatalf("every scope granted: %v", err)
+	}
+	// Endpoints without scopes are for first-party tokens only
+	if _, err := callWithToken(repo, auth, token); errorType(err) != utils.ValidationTokenFailure {
+		t.Fatalf("endpoint without scopes: got %v, want a token failure", err)
+	}
+	if _, err := callWithToken(repo, auth, token, database.ScopeProfileRead); errorType(err) != utils.InsufficientScope {
+		t.Fatalf("scope not granted: got %v, want insufficient scope", err)
+	}
+	if _, err := callWithToken(repo, auth, database.PersonalAccessTokenPrefix+"unknown", database.ScopeScoresRead); errorType(err) != utils.ValidationTokenFailure {
+		t.Fatalf("unknown token: got %v, want a token failure", err)
+	}
+
+	expiredAt := time.Now().Add(-time.Minute)
+	pat.ExpiresAt = &expiredAt
+	if _, err := callWithToken(repo, auth, token, database.ScopeScoresRead); errorType(err) != utils.ValidationTokenFailure {
+		t.Fatalf("expired token: got %v, want a token failure", err)
+	}
+	pat.ExpiresAt = nil
+	revokedAt := time.Now()
+	pat.RevokedAt = &revokedAt
+	if _, err := callWithToken(repo, auth, token, database.ScopeScoresRead); errorType(err) != utils.ValidationTokenFailure {
+		t.Fatalf("revoked token: got %v, want a token failure", err)
+	}
+}
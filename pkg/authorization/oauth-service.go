@@ -0,0 +1,644 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"LoveLetterProject/pkg/authorization/middleware"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// oauthSecretLength is the number of random bytes of client secrets, codes and refresh tokens
+const oauthSecretLength = 32
+
+// userCodeAlphabet has no vowels or look-alike characters, user codes are typed by hand
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+// userCodeLength is the number of characters of a device user code, shown as XXXX-XXXX
+const userCodeLength = 8
+
+// Grant types of the token endpoint
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+// OAuthError is an error of the token and device code endpoints, in the format of RFC 6749
+// so that standard OAuth client libraries understand it.
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	Status      int    `json:"-"`
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+func newOAuthError(status int, code string, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description, Status: status}
+}
+
+// GetOAuthClient returns what a consent screen shows: the client, the scopes it requests
+// and the scopes the user already granted it. The client is given by id, or by the user
+// code a device displays.
+func (s *userService) GetOAuthClient(ctx context.Context, request *GetOAuthClientRequest) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	clientID, scope := request.ClientID, request.Scope
+	if request.UserCode != "" {
+		deviceCode, err := s.pendingDeviceCode(ctx, request.UserCode)
+		if err != nil {
+			return err.Error(), err
+		}
+		clientID, scope = deviceCode.ClientID, strings.Join(database.SplitScopes(deviceCode.Scopes), " ")
+	}
+	client, err := s.activeOAuthClient(ctx, clientID)
+	if err != nil {
+		return err.Error(), err
+	}
+	scopes, ok := requestedScopes(client, scope)
+	if !ok {
+		cusErr := utils.NewErrorResponse(utils.InvalidScope)
+		return cusErr.Error(), cusErr
+	}
+	consented := []string{}
+	consent, err := s.repo.GetOAuthConsent(ctx, user.ID, client.ID)
+	if err == nil && consent.IsActive() {
+		consented = database.SplitScopes(consent.Scopes)
+	}
+	return OAuthClientResponse{
+		ClientID:        client.ID,
+		Name:            client.Name,
+		RequestedScopes: scopes,
+		ConsentedScopes: consented,
+	}, nil
+}
+
+// AuthorizeOAuthClient records the decision of the user on the consent screen of the
+// authorization code flow and returns the redirect uri to send the browser back to the
+// client with a code, or with an error.
+func (s *userService) AuthorizeOAuthClient(ctx context.Context, request *OAuthAuthorizeRequest) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	client, err := s.activeOAuthClient(ctx, request.ClientID)
+	if err != nil {
+		return err.Error(), err
+	}
+	// Never redirect to an unregistered uri, the error goes to the user instead
+	if !client.AllowsRedirectURI(request.RedirectURI) {
+		s.logger.Error("Unregistered redirect uri", "clientID", client.ID, "redirectURI", request.RedirectURI)
+		cusErr := utils.NewErrorResponse(utils.InvalidRedirectURI)
+		return cusErr.Error(), cusErr
+	}
+
+	// From here on errors are returned to the client through the redirect uri
+	redirect := func(params map[string]string) (interface{}, error) {
+		params["state"] = request.State
+		return OAuthAuthorizeResponse{RedirectURI: redirectURIWith(request.RedirectURI, params)}, nil
+	}
+	if request.ResponseType != "code" {
+		return redirect(map[string]string{"error": "unsupported_response_type"})
+	}
+	if request.CodeChallenge == "" || request.CodeChallengeMethod != "S256" {
+		return redirect(map[string]string{"error": "invalid_request", "error_description": "PKCE with the S256 method is required"})
+	}
+	scopes, ok := requestedScopes(client, request.Scope)
+	if !ok {
+		return redirect(map[string]string{"error": "invalid_scope"})
+	}
+	if !request.Approve {
+		s.logger.Info("OAuth consent denied", "userID", user.ID, "clientID", client.ID)
+		return redirect(map[string]string{"error": "access_denied"})
+	}
+
+	if err := s.grantOAuthConsent(ctx, user.ID, client.ID, scopes); err != nil {
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	code, err := utils.GenerateSecureToken(oauthSecretLength)
+	if err != nil {
+		s.logger.Error("unable to generate authorization code", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	err = s.repo.CreateOAuthAuthorizationCode(ctx, &database.OAuthAuthorizationCode{
+		CodeHash:      utils.HashToken(code),
+		ClientID:      client.ID,
+		UserID:        user.ID,
+		RedirectURI:   request.RedirectURI,
+		Scopes:        strings.Join(scopes, ","),
+		CodeChallenge: request.CodeChallenge,
+		ExpiresAt:     time.Now().Add(time.Minute * time.Duration(s.configs.OAuthCodeExpiration)),
+	})
+	if err != nil {
+		s.logger.Error("Cannot store authorization code", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("OAuth client authorized", "userID", user.ID, "clientID", client.ID, "scopes", scopes)
+	return redirect(map[string]string{"code": code})
+}
+
+// OAuthToken is the token endpoint of the authorization code, refresh token and device code grants.
+// Errors are *OAuthError.
+func (s *userService) OAuthToken(ctx context.Context, request *OAuthTokenRequest) (interface{}, error) {
+	client, err := s.authenticateOAuthClient(ctx, request.ClientID, request.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	switch request.GrantType {
+	case GrantTypeAuthorizationCode:
+		return s.exchangeAuthorizationCode(ctx, client, request)
+	case GrantTypeRefreshToken:
+		return s.exchangeOAuthRefreshToken(ctx, client, request)
+	case GrantTypeDeviceCode:
+		return s.exchangeDeviceCode(ctx, client, request)
+	default:
+		return nil, newOAuthError(http.StatusBadRequest, "unsupported_grant_type", "")
+	}
+}
+
+func (s *userService) exchangeAuthorizationCode(ctx context.Context, client *database.OAuthClient, request *OAuthTokenRequest) (interface{}, error) {
+	if request.Code == "" || request.CodeVerifier == "" {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_request", "code and code_verifier are required")
+	}
+	code, err := s.repo.RedeemOAuthAuthorizationCode(ctx, utils.HashToken(request.Code))
+	if err != nil {
+		s.logger.Error("Cannot redeem authorization code", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "authorization code is invalid or already used")
+		}
+		return nil, newOAuthError(http.StatusInternalServerError, "server_error", "")
+	}
+	if code.ClientID != client.ID || code.RedirectURI != request.RedirectURI {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "authorization code was issued to another client or redirect uri")
+	}
+	if code.ExpiresAt.Before(time.Now()) {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "authorization code is expired")
+	}
+	challenge := sha256.Sum256([]byte(request.CodeVerifier))
+	if subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(challenge[:])), []byte(code.CodeChallenge)) != 1 {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "code_verifier does not match the code challenge")
+	}
+	return s.issueOAuthTokens(ctx, client.ID, code.UserID, database.SplitScopes(code.Scopes), "")
+}
+
+func (s *userService) exchangeOAuthRefreshToken(ctx context.Context, client *database.OAuthClient, request *OAuthTokenRequest) (interface{}, error) {
+	if request.RefreshToken == "" {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_request", "refresh_token is required")
+	}
+	token, err := s.repo.GetOAuthRefreshTokenByHash(ctx, utils.HashToken(request.RefreshToken))
+	if err != nil || !token.IsActive() || token.ClientID != client.ID {
+		s.logger.Error("Invalid oauth refresh token", "clientID", client.ID, "error", err)
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "refresh token is invalid, expired or revoked")
+	}
+	return s.issueOAuthTokens(ctx, client.ID, token.UserID, database.SplitScopes(token.Scopes), token.TokenHash)
+}
+
+func (s *userService) exchangeDeviceCode(ctx context.Context, client *database.OAuthClient, request *OAuthTokenRequest) (interface{}, error) {
+	if request.DeviceCode == "" {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_request", "device_code is required")
+	}
+	deviceCodeHash := utils.HashToken(request.DeviceCode)
+	deviceCode, err := s.repo.GetOAuthDeviceCodeByHash(ctx, deviceCodeHash)
+	if err != nil || deviceCode.ClientID != client.ID {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "device code is invalid")
+	}
+	if deviceCode.ExpiresAt.Before(time.Now()) {
+		return nil, newOAuthError(http.StatusBadRequest, "expired_token", "")
+	}
+	tooSoon := deviceCode.LastPolledAt != nil &&
+		time.Since(*deviceCode.LastPolledAt) < time.Second*time.Duration(deviceCode.PollInterval)
+	if err := s.repo.TouchOAuthDeviceCode(ctx, deviceCodeHash); err != nil {
+		s.logger.Error("Cannot record device code poll", "error", err)
+	}
+	switch deviceCode.Status {
+	case database.DeviceCodePending:
+		if tooSoon {
+			return nil, newOAuthError(http.StatusBadRequest, "slow_down", "")
+		}
+		return nil, newOAuthError(http.StatusBadRequest, "authorization_pending", "")
+	case database.DeviceCodeDenied:
+		return nil, newOAuthError(http.StatusBadRequest, "access_denied", "")
+	}
+	// The device code is single use
+	deviceCode, err = s.repo.RedeemOAuthDeviceCode(ctx, deviceCodeHash)
+	if err != nil {
+		s.logger.Error("Cannot redeem device code", "error", err)
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "device code was already used")
+	}
+	return s.issueOAuthTokens(ctx, client.ID, deviceCode.UserID, database.SplitScopes(deviceCode.Scopes), "")
+}
+
+// issueOAuthTokens returns a scoped access token and a refresh token tied to the client.
+// The refresh token replaces the one with the given hash, if any.
+func (s *userService) issueOAuthTokens(ctx context.Context, clientID string, userID string, scopes []string, replacedTokenHash string) (interface{}, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil || user.Banned {
+		s.logger.Error("Cannot issue oauth tokens to user", "userID", userID, "error", err)
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "user is not available")
+	}
+	consent, err := s.repo.GetOAuthConsent(ctx, user.ID, clientID)
+	if err != nil || !consent.IsActive() {
+		s.logger.Error("Cannot issue oauth tokens without consent", "userID", userID, "clientID", clientID, "error", err)
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "consent was revoked")
+	}
+	accessToken, err := s.auth.GenerateOAuthAccessToken(user, clientID, scopes, consent.Generation)
+	if err != nil {
+		s.logger.Error("unable to generate oauth access token", "error", err)
+		return nil, newOAuthError(http.StatusInternalServerError, "server_error", "")
+	}
+	secret, err := utils.GenerateSecureToken(oauthSecretLength)
+	if err != nil {
+		s.logger.Error("unable to generate oauth refresh token", "error", err)
+		return nil, newOAuthError(http.StatusInternalServerError, "server_error", "")
+	}
+	refreshToken := database.OAuthRefreshTokenPrefix + secret
+	token := &database.OAuthRefreshToken{
+		TokenHash: utils.HashToken(refreshToken),
+		ClientID:  clientID,
+		UserID:    user.ID,
+		Scopes:    strings.Join(scopes, ","),
+		ExpiresAt: time.Now().AddDate(0, 0, s.configs.OAuthRefreshTokenExpiration),
+	}
+	if replacedTokenHash == "" {
+		err = s.repo.CreateOAuthRefreshToken(ctx, token)
+	} else {
+		err = s.repo.RotateOAuthRefreshToken(ctx, replacedTokenHash, token)
+	}
+	if err != nil {
+		s.logger.Error("Cannot store oauth refresh token", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "refresh token was already used")
+		}
+		return nil, newOAuthError(http.StatusInternalServerError, "server_error", "")
+	}
+	s.logger.Debug("oauth tokens issued", "userID", user.ID, "clientID", clientID)
+	return OAuthTokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    s.configs.JwtExpiration * 60,
+		RefreshToken: refreshToken,
+		Scope:        strings.Join(scopes, " "),
+	}, nil
+}
+
+// RequestDeviceCode starts the device code flow for clients without a browser, such as a
+// smartwatch. Errors are *OAuthError.
+func (s *userService) RequestDeviceCode(ctx context.Context, request *OAuthDeviceCodeRequest) (interface{}, error) {
+	client, err := s.authenticateOAuthClient(ctx, request.ClientID, request.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	scopes, ok := requestedScopes(client, request.Scope)
+	if !ok {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_scope", "")
+	}
+	deviceCode, err := utils.GenerateSecureToken(oauthSecretLength)
+	if err != nil {
+		s.logger.Error("unable to generate device code", "error", err)
+		return nil, newOAuthError(http.StatusInternalServerError, "server_error", "")
+	}
+	userCode, err := generateUserCode()
+	if err != nil {
+		s.logger.Error("unable to generate user code", "error", err)
+		return nil, newOAuthError(http.StatusInternalServerError, "server_error", "")
+	}
+	expiresIn := time.Minute * time.Duration(s.configs.OAuthDeviceCodeExpiration)
+	err = s.repo.CreateOAuthDeviceCode(ctx, &database.OAuthDeviceCode{
+		DeviceCodeHash: utils.HashToken(deviceCode),
+		UserCode:       userCode,
+		ClientID:       client.ID,
+		Scopes:         strings.Join(scopes, ","),
+		PollInterval:   s.configs.OAuthDevicePollInterval,
+		ExpiresAt:      time.Now().Add(expiresIn),
+	})
+	if err != nil {
+		s.logger.Error("Cannot store device code", "error", err)
+		return nil, newOAuthError(http.StatusInternalServerError, "server_error", "")
+	}
+	return OAuthDeviceCodeResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                userCode,
+		VerificationURI:         s.configs.OAuthDeviceVerificationURL,
+		VerificationURIComplete: redirectURIWith(s.configs.OAuthDeviceVerificationURL, map[string]string{"user_code": userCode}),
+		ExpiresIn:               int(expiresIn.Seconds()),
+		Interval:                s.configs.OAuthDevicePollInterval,
+	}, nil
+}
+
+// VerifyDeviceCode approves or denies the device showing the user code.
+func (s *userService) VerifyDeviceCode(ctx context.Context, request *VerifyDeviceCodeRequest) (string, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	deviceCode, err := s.pendingDeviceCode(ctx, request.UserCode)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	status := database.DeviceCodeDenied
+	if request.Approve {
+		status = database.DeviceCodeApproved
+		if err := s.grantOAuthConsent(ctx, user.ID, deviceCode.ClientID, database.SplitScopes(deviceCode.Scopes)); err != nil {
+			cusErr := utils.NewErrorResponse(utils.InternalServerError)
+			return cusErr.Error(), cusErr
+		}
+	}
+	err = s.repo.DecideOAuthDeviceCode(ctx, deviceCode.DeviceCodeHash, user.ID, status)
+	if err != nil {
+		s.logger.Error("Cannot update device code", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			cusErr := utils.NewErrorResponse(utils.InvalidUserCode)
+			return cusErr.Error(), cusErr
+		}
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("Device code decided", "userID", user.ID, "clientID", deviceCode.ClientID, "status", status)
+	if !request.Approve {
+		return "Device denied", nil
+	}
+	return "Device approved. You can return to your device.", nil
+}
+
+// GetOAuthConsents lists the clients the user granted access to.
+func (s *userService) GetOAuthConsents(ctx context.Context) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	consents, err := s.repo.GetOAuthConsentsByUserID(ctx, user.ID)
+	if err != nil {
+		s.logger.Error("Cannot get oauth consents", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	response := make([]OAuthConsentResponse, 0, len(consents))
+	for _, consent := range consents {
+		client, err := s.repo.GetOAuthClient(ctx, consent.ClientID)
+		if err != nil {
+			s.logger.Error("Cannot get oauth client", "error", err)
+			cusErr := utils.NewErrorResponse(utils.InternalServerError)
+			return cusErr.Error(), cusErr
+		}
+		response = append(response, OAuthConsentResponse{
+			ClientID:   client.ID,
+			ClientName: client.Name,
+			Scopes:     database.SplitScopes(consent.Scopes),
+			CreatedAt:  consent.CreatedAt,
+			UpdatedAt:  consent.UpdatedAt,
+		})
+	}
+	return response, nil
+}
+
+// RevokeOAuthConsent withdraws the access of a client, its refresh tokens and access tokens stop working.
+func (s *userService) RevokeOAuthConsent(ctx context.Context, request *RevokeOAuthConsentRequest) (string, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	err = s.repo.RevokeOAuthConsent(ctx, user.ID, request.ClientID)
+	if err != nil {
+		s.logger.Error("Cannot revoke oauth consent", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			cusErr := utils.NewErrorResponse(utils.NotFound)
+			return cusErr.Error(), cusErr
+		}
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("OAuth consent revoked", "userID", user.ID, "clientID", request.ClientID)
+	return "Access revoked", nil
+}
+
+// CreateOAuthClient registers a third-party client, for internal services with an API key.
+// The secret of a confidential client is only returned by this call.
+func (s *userService) CreateOAuthClient(ctx context.Context, request *CreateOAuthClientRequest) (interface{}, error) {
+	scopes, err := validateScopes(request.Scopes, database.OAuthScopes)
+	if err != nil {
+		return err.Error(), err
+	}
+	for _, uri := range request.RedirectURIs {
+		if !validRedirectURI(uri) {
+			cusErr := utils.NewErrorResponse(utils.InvalidRedirectURI)
+			return cusErr.Error(), cusErr
+		}
+	}
+	client := &database.OAuthClient{
+		Name:         strings.TrimSpace(request.Name),
+		RedirectURIs: strings.Join(request.RedirectURIs, " "),
+		Scopes:       strings.Join(scopes, ","),
+	}
+	var secret string
+	if request.Confidential {
+		secret, err = utils.GenerateSecureToken(oauthSecretLength)
+		if err != nil {
+			s.logger.Error("unable to generate client secret", "error", err)
+			cusErr := utils.NewErrorResponse(utils.InternalServerError)
+			return cusErr.Error(), cusErr
+		}
+		client.SecretHash = utils.HashToken(secret)
+	}
+	if err := s.repo.CreateOAuthClient(ctx, client); err != nil {
+		s.logger.Error("Cannot create oauth client", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("OAuth client registered", "clientID", client.ID, "apiKeyID", ctx.Value(middleware.APIKeyIDKey{}))
+	return OAuthClientCreatedResponse{
+		ClientID:     client.ID,
+		ClientSecret: secret,
+		Name:         client.Name,
+		RedirectURIs: request.RedirectURIs,
+		Scopes:       scopes,
+	}, nil
+}
+
+// activeOAuthClient returns the client unless it is unknown or revoked
+func (s *userService) activeOAuthClient(ctx context.Context, clientID string) (*database.OAuthClient, error) {
+	client, err := s.repo.GetOAuthClient(ctx, clientID)
+	if err != nil || client.RevokedAt != nil {
+		s.logger.Error("Invalid oauth client", "clientID", clientID, "error", err)
+		return nil, utils.NewErrorResponse(utils.InvalidClient)
+	}
+	return client, nil
+}
+
+// authenticateOAuthClient returns the client of the token endpoints, checking the secret of
+// confidential clients. Public clients are identified by their id alone.
+func (s *userService) authenticateOAuthClient(ctx context.Context, clientID string, clientSecret string) (*database.OAuthClient, error) {
+	client, err := s.repo.GetOAuthClient(ctx, clientID)
+	if err != nil || client.RevokedAt != nil {
+		s.logger.Error("Invalid oauth client", "clientID", clientID, "error", err)
+		return nil, newOAuthError(http.StatusUnauthorized, "invalid_client", "")
+	}
+	if !client.IsPublic() && subtle.ConstantTimeCompare([]byte(utils.HashToken(clientSecret)), []byte(client.SecretHash)) != 1 {
+		s.logger.Error("Invalid oauth client secret", "clientID", clientID)
+		return nil, newOAuthError(http.StatusUnauthorized, "invalid_client", "")
+	}
+	return client, nil
+}
+
+// pendingDeviceCode returns the pending and unexpired device code with the user code
+func (s *userService) pendingDeviceCode(ctx context.Context, userCode string) (*database.OAuthDeviceCode, error) {
+	deviceCode, err := s.repo.GetOAuthDeviceCodeByUserCode(ctx, normalizeUserCode(userCode))
+	if err != nil || deviceCode.Status != database.DeviceCodePending || deviceCode.ExpiresAt.Before(time.Now()) {
+		s.logger.Error("Invalid user code", "error", err)
+		return nil, utils.NewErrorResponse(utils.InvalidUserCode)
+	}
+	return deviceCode, nil
+}
+
+// grantOAuthConsent adds the scopes to the active consent of the user for the client
+func (s *userService) grantOAuthConsent(ctx context.Context, userID string, clientID string, scopes []string) error {
+	granted := scopes
+	consent, err := s.repo.GetOAuthConsent(ctx, userID, clientID)
+	if err == nil && consent.IsActive() {
+		granted = database.SplitScopes(consent.Scopes)
+		for _, scope := range scopes {
+			if !database.ContainsScopes(granted, []string{scope}) {
+				granted = append(granted, scope)
+			}
+		}
+	}
+	err = s.repo.UpsertOAuthConsent(ctx, &database.OAuthConsent{
+		UserID:   userID,
+		ClientID: clientID,
+		Scopes:   strings.Join(granted, ","),
+	})
+	if err != nil {
+		s.logger.Error("Cannot store oauth consent", "error", err)
+	}
+	return err
+}
+
+// requestedScopes returns the space separated requested scopes, all the scopes of the client
+// when none are requested. It reports false when the client may not request one of them.
+func requestedScopes(client *database.OAuthClient, scope string) ([]string, bool) {
+	allowed := database.SplitScopes(client.Scopes)
+	requested := strings.Fields(scope)
+	if len(requested) == 0 {
+		return allowed, true
+	}
+	scopes, err := validateScopes(requested, allowed)
+	if err != nil {
+		return nil, false
+	}
+	return scopes, true
+}
+
+// redirectURIWith returns the uri with the non-empty params added to its query
+func redirectURIWith(uri string, params map[string]string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	query := u.Query()
+	for key, value := range params {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// validRedirectURI accepts absolute uris without fragment. Plain http is only allowed for
+// loopback addresses, custom schemes are allowed for native apps.
+func validRedirectURI(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme == "" || u.Fragment != "" {
+		return false
+	}
+	switch u.Scheme {
+	case "http":
+		host := u.Hostname()
+		return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	case "https":
+		return u.Host != ""
+	case "javascript", "data", "file":
+		return false
+	default:
+		return true
+	}
+}
+
+// generateUserCode returns a random user code formatted as XXXX-XXXX
+func generateUserCode() (string, error) {
+	code := make([]byte, userCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(userCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = userCodeAlphabet[n.Int64()]
+	}
+	return string(code[:userCodeLength/2]) + "-" + string(code[userCodeLength/2:]), nil
+}
+
+// normalizeUserCode formats a user code typed by hand as XXXX-XXXX
+func normalizeUserCode(userCode string) string {
+	code := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(userCode))
+	if len(code) != userCodeLength {
+		return code
+	}
+	return code[:userCodeLength/2] + "-" + code[userCodeLength/2:]
+}
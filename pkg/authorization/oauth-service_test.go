@@ -0,0 +1,279 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func (repo *fakeRepo) GetOAuthClient(ctx context.Context, id string) (*database.OAuthClient, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	client, ok := repo.oauthClients[id]
+	if !ok {
+		return &database.OAuthClient{}, sql.ErrNoRows
+	}
+	copied := *client
+	return &copied, nil
+}
+
+func (repo *fakeRepo) UpsertOAuthConsent(ctx context.Context, consent *database.OAuthConsent) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	stored, ok := repo.oauthConsents[consent.UserID+"/"+consent.ClientID]
+	if !ok {
+		copied := *consent
+		copied.Generation = 1
+		repo.oauthConsents[consent.UserID+"/"+consent.ClientID] = &copied
+		return nil
+	}
+	stored.Scopes = consent.Scopes
+	stored.RevokedAt = nil
+	return nil
+}
+
+func (repo *fakeRepo) GetOAuthConsent(ctx context.Context, userID string, clientID string) (*database.OAuthConsent, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	consent, ok := repo.oauthConsents[userID+"/"+clientID]
+	if !ok {
+		return &database.OAuthConsent{}, sql.ErrNoRows
+	}
+	copied := *consent
+	return &copied, nil
+}
+
+func (repo *fakeRepo) RevokeOAuthConsent(ctx context.Context, userID string, clientID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	consent, ok := repo.oauthConsents[userID+"/"+clientID]
+	if !ok || consent.RevokedAt != nil {
+		return sql.ErrNoRows
+	}
+	now := time.Now()
+	consent.RevokedAt = &now
+	consent.Generation++
+	for _, token := range repo.oauthTokens {
+		if token.UserID == userID && token.ClientID == clientID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (repo *fakeRepo) CreateOAuthAuthorizationCode(ctx context.Context, code *database.OAuthAuthorizationCode) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	copied := *code
+	repo.oauthCodes[code.CodeHash] = &copied
+	return nil
+}
+
+func (repo *fakeRepo) RedeemOAuthAuthorizationCode(ctx context.Context, codeHash string) (*database.OAuthAuthorizationCode, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	code, ok := repo.oauthCodes[codeHash]
+	if !ok || code.UsedAt != nil {
+		return &database.OAuthAuthorizationCode{}, sql.ErrNoRows
+	}
+	now := time.Now()
+	code.UsedAt = &now
+	copied := *code
+	return &copied, nil
+}
+
+func (repo *fakeRepo) CreateOAuthRefreshToken(ctx context.Context, token *database.OAuthRefreshToken) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	copied := *token
+	repo.oauthTokens[token.TokenHash] = &copied
+	return nil
+}
+
+func (repo *fakeRepo) GetOAuthRefreshTokenByHash(ctx context.Context, tokenHash string) (*database.OAuthRefreshToken, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	token, ok := repo.oauthTokens[tokenHash]
+	if !ok {
+		return &database.OAuthRefreshToken{}, sql.ErrNoRows
+	}
+	copied := *token
+	return &copied, nil
+}
+
+func (repo *fakeRepo) RotateOAuthRefreshToken(ctx context.Context, oldTokenHash string, token *database.OAuthRefreshToken) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	old, ok := repo.oauthTokens[oldTokenHash]
+	if !ok || old.RevokedAt != nil {
+		return sql.ErrNoRows
+	}
+	now := time.Now()
+	old.RevokedAt = &now
+	copied := *token
+	repo.oauthTokens[token.TokenHash] = &copied
+	return nil
+}
+
+const testRedirectURI = "https://client.example.com/callback"
+
+// authorize approves the client with the PKCE challenge of the verifier and returns the
+// parameters of the redirect.
+func authorize(t *testing.T, s *userService, ctx context.Context, verifier string, scope string) url.Values {
+	t.Helper()
+	challenge := sha256.Sum256([]byte(verifier))
+	response, err := s.AuthorizeOAuthClient(ctx, &OAuthAuthorizeRequest{ResponseType: "code", ClientID: "client-1",
+		RedirectURI: testRedirectURI, Scope: scope, State: "state-1", Approve: true,
+		CodeChallenge: base64.RawURLEncoding.EncodeToString(challenge[:]), CodeChallengeMethod: "S256"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	redirect, err := url.Parse(response.(OAuthAuthorizeResponse).RedirectURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redirect.Query().Get("state") != "state-1" {
+		t.Fatalf("got redirect %s, want the state", redirect)
+	}
+	return redirect.Query()
+}
+
+// oauthErrorCode returns the code of an *OAuthError, "" for any other error.
+func oauthErrorCode(err error) string {
+	var oauthErr *OAuthError
+	if errors.As(err, &oauthErr) {
+		return oauthErr.Code
+	}
+	return ""
+}
+
+func TestOAuthAuthorizationCodePKCE(t *testing.T) {
+	s, repo, _, ctx := newUserTest(t)
+	repo.oauthClients["client-1"] = &database.OAuthClient{ID: "client-1", Name: "Client", SecretHash: utils.HashToken("secret"),
+		RedirectURIs: testRedirectURI, Scopes: database.ScopeScoresRead + "," + database.ScopeSessionsWrite}
+	exchange := func(code string, verifier string) (interface{}, error) {
+		return s.OAuthToken(context.Background(), &OAuthTokenRequest{GrantType: GrantTypeAuthorizationCode, ClientID: "client-1",
+			ClientSecret: "secret", Code: code, RedirectURI: testRedirectURI, CodeVerifier: verifier})
+	}
+
+	code := authorize(t, s, ctx, "verifier-1", database.ScopeScoresRead).Get("code")
+	if _, err := exchange(code, "verifier-2"); oauthErrorCode(err) != "invalid_grant" {
+		t.Fatalf("mismatched verifier: got %v, want invalid grant", err)
+	}
+	// The code was redeemed by the failed attempt, it cannot be retried
+	if _, err := exchange(code, "verifier-1"); oauthErrorCode(err) != "invalid_grant" {
+		t.Fatalf("code used twice: got %v, want invalid grant", err)
+	}
+
+	code = authorize(t, s, ctx, "verifier-1", database.ScopeScoresRead).Get("code")
+	response, err := exchange(code, "verifier-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := response.(OAuthTokenResponse)
+	if tokens.Scope != database.ScopeScoresRead || tokens.RefreshToken == "" {
+		t.Fatalf("got %+v, want tokens scoped to reading scores", tokens)
+	}
+	clientID, scopes, generation, err := s.auth.GetAccessTokenClient(tokens.AccessToken)
+	if err != nil || clientID != "client-1" || len(scopes) != 1 || scopes[0] != database.ScopeScoresRead || generation != 1 {
+		t.Fatalf("got access token of %q with scopes %v in generation %d (%v)", clientID, scopes, generation, err)
+	}
+	if _, err := exchange(code, "verifier-1"); oauthErrorCode(err) != "invalid_grant" {
+		t.Fatalf("code replayed: got %v, want invalid grant", err)
+	}
+}
+
+func TestOAuthAuthorizeErrors(t *testing.T) {
+	s, repo, _, ctx := newUserTest(t)
+	repo.oauthClients["client-1"] = &database.OAuthClient{ID: "client-1", Name: "Client", SecretHash: utils.HashToken("secret"),
+		RedirectURIs: testRedirectURI, Scopes: database.ScopeScoresRead + "," + database.ScopeSessionsWrite}
+	challenge := sha256.Sum256([]byte("verifier-1"))
+	valid := OAuthAuthorizeRequest{ResponseType: "code", ClientID: "client-1", RedirectURI: testRedirectURI, Approve: true,
+		CodeChallenge: base64.RawURLEncoding.EncodeToString(challenge[:]), CodeChallengeMethod: "S256"}
+
+	tests := []struct {
+		name   string
+		change func(request *OAuthAuthorizeRequest)
+		want   string
+	}{
+		{"no pkce", func(request *OAuthAuthorizeRequest) { request.CodeChallenge = "" }, "invalid_request"},
+		{"plain pkce", func(request *OAuthAuthorizeRequest) { request.CodeChallengeMethod = "plain" }, "invalid_request"},
+		{"implicit flow", func(request *OAuthAuthorizeRequest) { request.ResponseType = "token" }, "unsupported_response_type"},
+		{"scope not allowed", func(request *OAuthAuthorizeRequest) { request.Scope = database.ScopeProfileRead }, "invalid_scope"},
+		{"denied", func(request *OAuthAuthorizeRequest) { request.Approve = false }, "access_denied"},
+	}
+	for _, test := range tests {
+		request := valid
+		test.change(&request)
+		response, err := s.AuthorizeOAuthClient(ctx, &request)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		redirect, _ := url.Parse(response.(OAuthAuthorizeResponse).RedirectURI)
+		if got := redirect.Query().Get("error"); got != test.want || redirect.Query().Get("code") != "" {
+			t.Fatalf("%s: got redirect %s, want error %s", test.name, redirect, test.want)
+		}
+	}
+	// An unregistered redirect uri is never redirected to
+	request := valid
+	request.RedirectURI = "https://attacker.example.com/callback"
+	if _, err := s.AuthorizeOAuthClient(ctx, &request); errorType(err) != utils.InvalidRedirectURI {
+		t.Fatalf("got %v, want invalid redirect uri", err)
+	}
+}
+
+func TestOAuthRefreshTokenRotation(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	repo.oauthClients["client-1"] = &database.OAuthClient{ID: "client-1", Name: "Client", SecretHash: utils.HashToken("secret"),
+		RedirectURIs: testRedirectURI, Scopes: database.ScopeScoresRead + "," + database.ScopeSessionsWrite}
+	repo.oauthClients["client-2"] = &database.OAuthClient{ID: "client-2", Name: "Other", RedirectURIs: testRedirectURI,
+		Scopes: database.ScopeScoresRead}
+	code := authorize(t, s, ctx, "verifier-1", "").Get("code")
+	response, err := s.OAuthToken(context.Background(), &OAuthTokenRequest{GrantType: GrantTypeAuthorizationCode, ClientID: "client-1",
+		ClientSecret: "secret", Code: code, RedirectURI: testRedirectURI, CodeVerifier: "verifier-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := response.(OAuthTokenResponse).RefreshToken
+	refresh := func(clientID string, secret string, refreshToken string) (interface{}, error) {
+		return s.OAuthToken(context.Background(), &OAuthTokenRequest{GrantType: GrantTypeRefreshToken, ClientID: clientID,
+			ClientSecret: secret, RefreshToken: refreshToken})
+	}
+
+	if _, err := refresh("client-1", "wrong", first); oauthErrorCode(err) != "invalid_client" {
+		t.Fatalf("wrong secret: got %v, want invalid client", err)
+	}
+	if _, err := refresh("client-2", "", first); oauthErrorCode(err) != "invalid_grant" {
+		t.Fatalf("other client: got %v, want invalid grant", err)
+	}
+	response, err = refresh("client-1", "secret", first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := response.(OAuthTokenResponse).RefreshToken
+	if second == first || response.(OAuthTokenResponse).Scope != database.ScopeScoresRead+" "+database.ScopeSessionsWrite {
+		t.Fatalf("got %+v, want a new refresh token with the granted scopes", response)
+	}
+	// A rotated refresh token cannot be used again
+	if _, err := refresh("client-1", "secret", first); oauthErrorCode(err) != "invalid_grant" {
+		t.Fatalf("reused refresh token: got %v, want invalid grant", err)
+	}
+
+	// Revoking the consent revokes the refresh tokens of the client
+	if _, err := s.RevokeOAuthConsent(ctx, &RevokeOAuthConsentRequest{ClientID: "client-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := refresh("client-1", "secret", second); oauthErrorCode(err) != "invalid_grant" {
+		t.Fatalf("refresh token of a revoked consent: got %v, want invalid grant", err)
+	}
+	if consent := repo.oauthConsents[user.ID+"/client-1"]; consent.Generation != 2 {
+		t.Fatalf("got generation %d, want the revocation counted", consent.Generation)
+	}
+}
@@ -0,0 +1,159 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"LoveLetterProject/pkg/authorization/middleware"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// personalAccessTokenLength is the number of random bytes of a personal access token
+const personalAccessTokenLength = 32
+
+// CreatePersonalAccessToken creates a token with the requested scopes. The token is
+// only returned by this call, it is stored hashed.
+func (s *userService) CreatePersonalAccessToken(ctx context.Context, request *CreatePersonalAccessTokenRequest) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	scopes, err := validateScopes(request.Scopes, database.PersonalAccessTokenScopes)
+	if err != nil {
+		return err.Error(), err
+	}
+	count, err := s.repo.CountActivePersonalAccessTokens(ctx, user.ID)
+	if err != nil {
+		s.logger.Error("Cannot count personal access tokens", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	if count >= s.configs.PersonalAccessTokenLimit {
+		cusErr := utils.NewErrorResponse(utils.TokenLimitReached)
+		return cusErr.Error(), cusErr
+	}
+
+	secret, err := utils.GenerateSecureToken(personalAccessTokenLength)
+	if err != nil {
+		s.logger.Error("unable to generate personal access token", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	token := database.PersonalAccessTokenPrefix + secret
+	pat := &database.PersonalAccessToken{
+		UserID:    user.ID,
+		Name:      strings.TrimSpace(request.Name),
+		TokenHash: utils.HashToken(token),
+		// enough to recognise the token in a list, far too short to guess it
+		Prefix: token[:len(database.PersonalAccessTokenPrefix)+6],
+		Scopes: strings.Join(scopes, ","),
+	}
+	if request.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, request.ExpiresInDays)
+		pat.ExpiresAt = &expiresAt
+	}
+	if err := s.repo.CreatePersonalAccessToken(ctx, pat); err != nil {
+		s.logger.Error("Cannot create personal access token", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+
+	s.logger.Info("Personal access token created", "userID", user.ID, "tokenID", pat.ID)
+	response := newPersonalAccessTokenResponse(pat)
+	response.Token = token
+	return response, nil
+}
+
+// GetPersonalAccessTokens lists the personal access tokens of the user without their secret.
+func (s *userService) GetPersonalAccessTokens(ctx context.Context) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	tokens, err := s.repo.GetPersonalAccessTokensByUserID(ctx, user.ID)
+	if err != nil {
+		s.logger.Error("Cannot get personal access tokens", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	response := make([]PersonalAccessTokenResponse, 0, len(tokens))
+	for i := range tokens {
+		response = append(response, newPersonalAccessTokenResponse(&tokens[i]))
+	}
+	return response, nil
+}
+
+// RevokePersonalAccessToken revokes one personal access token of the user.
+func (s *userService) RevokePersonalAccessToken(ctx context.Context, request *RevokePersonalAccessTokenRequest) (string, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	err = s.repo.RevokePersonalAccessToken(ctx, user.ID, request.ID)
+	if err != nil {
+		s.logger.Error("Cannot revoke personal access token", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			cusErr := utils.NewErrorResponse(utils.NotFound)
+			return cusErr.Error(), cusErr
+		}
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("Personal access token revoked", "userID", user.ID, "tokenID", request.ID)
+	return "Personal access token revoked", nil
+}
+
+// validateScopes returns the requested scopes without duplicates, or InvalidScope for one
+// that is not allowed
+func validateScopes(requested []string, allowed []string) ([]string, error) {
+	seen := map[string]bool{}
+	var scopes []string
+	for _, scope := range requested {
+		valid := false
+		for _, known := range allowed {
+			valid = valid || scope == known
+		}
+		if !valid {
+			return nil, utils.NewErrorResponse(utils.InvalidScope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+func newPersonalAccessTokenResponse(pat *database.PersonalAccessToken) PersonalAccessTokenResponse {
+	return PersonalAccessTokenResponse{
+		ID:         pat.ID,
+		Name:       pat.Name,
+		Prefix:     pat.Prefix,
+		Scopes:     pat.ScopeList(),
+		ExpiresAt:  pat.ExpiresAt,
+		LastUsedAt: pat.LastUsedAt,
+		RevokedAt:  pat.RevokedAt,
+		CreatedAt:  pat.CreatedAt,
+	}
+}
@@ -0,0 +1,236 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"LoveLetterProject/pkg/authorization/middleware"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"time"
+)
+
+// phoneCodeLength is the number of digits of a phone verification code
+const phoneCodeLength = 6
+
+// smsPassResetCodeLength is the number of digits of a password reset code sent by sms.
+// Reset codes outlive verification codes, so they are longer as well.
+const smsPassResetCodeLength = 8
+
+// SendPhoneCode normalizes the phone number and sends a verification code to it.
+func (s *userService) SendPhoneCode(ctx context.Context, request *SendPhoneCodeRequest) (string, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	phone, err := utils.NormalizePhone(request.Phone, s.configs.PhoneDefaultCountryCode)
+	if err != nil {
+		s.logger.Error("Invalid phone number", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InvalidPhone)
+		return cusErr.Error(), cusErr
+	}
+	if profile, err := s.repo.GetProfileByVerifiedPhone(ctx, phone); err == nil {
+		if profile.UserID == user.ID {
+			return "phone number is already verified.", nil
+		}
+		s.logger.Error("Phone number is verified by another user", "userID", user.ID)
+		cusErr := utils.NewErrorResponse(utils.PhoneInUse)
+		return cusErr.Error(), cusErr
+	}
+	if err := s.increaseSendMailCount(ctx, user.ID); err != nil {
+		return err.Error(), err
+	}
+
+	code, err := utils.GenerateSecureCode(phoneCodeLength)
+	if err != nil {
+		s.logger.Error("unable to generate phone code", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	expiration := time.Minute * time.Duration(s.configs.PhoneCodeExpiration)
+	verificationData := &database.VerificationData{
+		Email:     user.Email,
+		Code:      code,
+		Type:      database.PhoneVerification,
+		Target:    phone,
+		ExpiresAt: time.Now().Add(expiration),
+	}
+	err = s.repo.StoreVerificationData(ctx, verificationData, false)
+	if err != nil {
+		s.logger.Error("unable to store phone code", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	body := fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", code, s.configs.PhoneCodeExpiration)
+	if err := s.sendSMS(phone, body); err != nil {
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Debug("successfully sent phone code", "userID", user.ID)
+	return "successfully sent verification code. Please check your phone.", nil
+}
+
+// VerifyPhone redeems the code sent to the phone number and marks the number verified.
+func (s *userService) VerifyPhone(ctx context.Context, request *VerifyPhoneRequest) (string, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	// Code guesses count towards the login limit
+	limitData, err := s.increaseLoginCount(ctx, user.ID)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	actualVerificationData, err := s.repo.GetVerificationData(ctx, user.Email, database.PhoneVerification)
+	if err != nil {
+		s.logger.Error("unable to get phone code", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InvalidCode)
+		return cusErr.Error(), cusErr
+	}
+	if actualVerificationData.ExpiresAt.Before(time.Now()) {
+		s.logger.Error("phone code is expired", "userID", user.ID)
+		if err := s.repo.DeleteVerificationData(ctx, user.Email, database.PhoneVerification); err != nil {
+			s.logger.Error("unable to delete verification data from db", "error", err)
+		}
+		cusErr := utils.NewErrorResponse(utils.ExpiredCode)
+		return cusErr.Error(), cusErr
+	}
+	if subtle.ConstantTimeCompare([]byte(actualVerificationData.Code), []byte(request.Code)) != 1 {
+		s.logger.Error("phone code is invalid", "userID", user.ID)
+		cusErr := utils.NewErrorResponse(utils.InvalidCode)
+		return cusErr.Error(), cusErr
+	}
+	// The code is single use
+	err = s.repo.DeleteVerificationData(ctx, user.Email, database.PhoneVerification)
+	if err != nil {
+		s.logger.Error("unable to delete the verification data", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	phone := actualVerificationData.Target
+	// Another account may have verified the number since the code was sent
+	if profile, err := s.repo.GetProfileByVerifiedPhone(ctx, phone); err == nil && profile.UserID != user.ID {
+		s.logger.Error("Phone number is verified by another user", "userID", user.ID)
+		cusErr := utils.NewErrorResponse(utils.PhoneInUse)
+		return cusErr.Error(), cusErr
+	}
+	if err := s.repo.VerifyPhone(ctx, user.ID, user.Email, phone); err != nil {
+		s.logger.Error("unable to verify phone", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	// Reset limit data
+	limitData.NumOfLogin = 0
+	err = s.repo.InsertOrUpdateLimitData(ctx, limitData, false)
+	if err != nil {
+		s.logger.Error("Cannot reset number of login", "error", err)
+	}
+
+	s.logger.Debug("successfully verified phone", "userID", user.ID)
+	return "successfully verified phone number.", nil
+}
+
+// GetForgetPasswordCodeBySMS sends a password reset code to a verified phone number.
+// The code is redeemed on the reset password endpoint with either the phone or the email.
+func (s *userService) GetForgetPasswordCodeBySMS(ctx context.Context, request *GetForgetPasswordCodeBySMSRequest) (string, error) {
+	successMsg := "successfully sent password reset code. Please check your phone."
+	phone, err := utils.NormalizePhone(request.Phone, s.configs.PhoneDefaultCountryCode)
+	if err != nil {
+		s.logger.Error("Invalid phone number", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InvalidPhone)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.userByVerifiedPhone(ctx, phone)
+	if err != nil {
+		s.logger.Error("Phone number is not verified", "error", err)
+		if s.configs.EnumerationSafeMode {
+			return successMsg, nil
+		}
+		cusErr := utils.NewErrorResponse(utils.NotFound)
+		return cusErr.Error(), cusErr
+	}
+	if user.Banned {
+		s.logger.Error("User is banned", "userID", user.ID)
+		if s.configs.EnumerationSafeMode {
+			return successMsg, nil
+		}
+		cusErr := utils.NewErrorResponse(utils.Forbidden)
+		return cusErr.Error(), cusErr
+	}
+	if err := s.smsPasswordResetCode(ctx, user, phone); err != nil {
+		if s.configs.EnumerationSafeMode {
+			// Failures only happen for verified numbers, answering them would reveal the account
+			s.logger.Error("Cannot send password reset code", "userID", user.ID, "error", err)
+			return successMsg, nil
+		}
+		return err.Error(), err
+	}
+	return successMsg, nil
+}
+
+// smsPasswordResetCode stores a new password reset code of the user and sends it to the phone number.
+func (s *userService) smsPasswordResetCode(ctx context.Context, user *database.User, phone string) error {
+	if err := s.increaseSendMailCount(ctx, user.ID); err != nil {
+		return err
+	}
+
+	code, err := utils.GenerateSecureCode(smsPassResetCodeLength)
+	if err != nil {
+		s.logger.Error("unable to generate password reset code", "error", err)
+		return utils.NewErrorResponse(utils.InternalServerError)
+	}
+	verificationData := &database.VerificationData{
+		Email:     user.Email,
+		Code:      code,
+		Type:      database.PassReset,
+		Target:    phone,
+		ExpiresAt: time.Now().Add(time.Minute * time.Duration(s.configs.PassResetCodeExpiration)),
+	}
+	err = s.repo.StoreVerificationData(ctx, verificationData, false)
+	if err != nil {
+		s.logger.Error("unable to store password reset verification data", "error", err)
+		return utils.NewErrorResponse(utils.InternalServerError)
+	}
+	body := fmt.Sprintf("Your password reset code is %s. It expires in %d minutes.", code, s.configs.PassResetCodeExpiration)
+	if s.configs.EnumerationSafeMode {
+		// Sending in the background keeps the response time independent of the sms provider
+		go s.sendSMS(phone, body)
+		return nil
+	}
+	if err := s.sendSMS(phone, body); err != nil {
+		return utils.NewErrorResponse(utils.InternalServerError)
+	}
+	s.logger.Debug("successfully sent password reset code by sms", "userID", user.ID)
+	return nil
+}
+
+// userByVerifiedPhone returns the user that verified the normalized phone number
+func (s *userService) userByVerifiedPhone(ctx context.Context, phone string) (*database.User, error) {
+	profile, err := s.repo.GetProfileByVerifiedPhone(ctx, phone)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetUserByID(ctx, profile.UserID)
+}
+
+// sendSMS sends a text message to a single phone number.
+func (s *userService) sendSMS(phone string, body string) error {
+	if err := s.smsService.SendSMS(phone, body); err != nil {
+		s.logger.Error("unable to send sms", "error", err)
+		return err
+	}
+	return nil
+}
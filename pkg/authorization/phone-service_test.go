@@ -0,0 +1,156 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"LoveLetterProject/pkg/authorization/middleware"
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+)
+
+func (repo *fakeRepo) VerifyPhone(ctx context.Context, userID string, email string, phone string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.phones[phone] = userID
+	return nil
+}
+
+func (repo *fakeRepo) GetProfileByVerifiedPhone(ctx context.Context, phone string) (*database.ProfileData, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	userID, ok := repo.phones[phone]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &database.ProfileData{UserID: userID, Phone: phone, PhoneVerified: true}, nil
+}
+
+var smsCodePattern = regexp.MustCompile(`\d{6,}`)
+
+// lastSMSCode returns the code of the latest sms sent to the phone number.
+func lastSMSCode(t *testing.T, s *userService, phone string) string {
+	t.Helper()
+	sms, ok := s.smsService.(*FakeSMSService).LastMessage(phone)
+	if !ok {
+		t.Fatalf("no sms was sent to %s", phone)
+	}
+	return smsCodePattern.FindString(sms.Body)
+}
+
+func TestVerifyPhone(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	s.configs.PhoneDefaultCountryCode = "84"
+
+	if _, err := s.SendPhoneCode(ctx, &SendPhoneCodeRequest{Phone: "12"}); errorType(err) != utils.InvalidPhone {
+		t.Fatalf("got %v, want invalid phone", err)
+	}
+	if _, err := s.SendPhoneCode(ctx, &SendPhoneCodeRequest{Phone: "0912 345 678"}); err != nil {
+		t.Fatal(err)
+	}
+	code := lastSMSCode(t, s, "+84912345678")
+	if len(code) != phoneCodeLength {
+		t.Fatalf("got code %q, want %d digits", code, phoneCodeLength)
+	}
+	if _, err := s.VerifyPhone(ctx, &VerifyPhoneRequest{Code: "x" + code}); errorType(err) != utils.InvalidCode {
+		t.Fatalf("got %v, want invalid code", err)
+	}
+	if _, err := s.VerifyPhone(ctx, &VerifyPhoneRequest{Code: code}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.phones["+84912345678"] != user.ID {
+		t.Fatal("phone was not verified")
+	}
+	// The code is single use
+	if _, err := s.VerifyPhone(ctx, &VerifyPhoneRequest{Code: code}); errorType(err) != utils.InvalidCode {
+		t.Fatalf("got %v, want invalid code", err)
+	}
+}
+
+func TestSendPhoneCodePhoneInUse(t *testing.T) {
+	configs := testConfigs(t)
+	repo := newFakeRepo()
+	s := newTestService(t, configs, repo)
+	owner := repo.addUser(t, s, "owner@example.com", "Correct-Password1")
+	other := repo.addUser(t, s, "other@example.com", "Correct-Password1")
+	repo.phones["+84912345678"] = owner.ID
+
+	ownerCtx := context.WithValue(context.Background(), middleware.UserIDKey{}, owner.ID)
+	if message, err := s.SendPhoneCode(ownerCtx, &SendPhoneCodeRequest{Phone: "+84912345678"}); err != nil || message != "phone number is already verified." {
+		t.Fatalf("got %q (%v), want already verified", message, err)
+	}
+	otherCtx := context.WithValue(context.Background(), middleware.UserIDKey{}, other.ID)
+	if _, err := s.SendPhoneCode(otherCtx, &SendPhoneCodeRequest{Phone: "+84 912-345-678"}); errorType(err) != utils.PhoneInUse {
+		t.Fatalf("got %v, want phone in use", err)
+	}
+	if len(s.smsService.(*FakeSMSService).Messages()) != 0 {
+		t.Fatal("an sms was sent")
+	}
+}
+
+func TestResetPasswordBySMS(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	repo.phones["+84912345678"] = user.ID
+
+	if _, err := s.GetForgetPasswordCodeBySMS(ctx, &GetForgetPasswordCodeBySMSRequest{Phone: "+84912345678"}); err != nil {
+		t.Fatal(err)
+	}
+	code := lastSMSCode(t, s, "+84912345678")
+	if len(code) != smsPassResetCodeLength {
+		t.Fatalf("got code %q, want %d digits", code, smsPassResetCodeLength)
+	}
+	err := s.ResetPassword(ctx, &CreateNewPasswordWithCodeRequest{Phone: "+84912345678", Code: code, NewPassword: "New-Password1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.auth.ComparePassword(repo.users[user.ID].Password, "New-Password1") {
+		t.Fatal("password was not changed")
+	}
+	// The code is single use
+	err = s.ResetPassword(ctx, &CreateNewPasswordWithCodeRequest{Phone: "+84912345678", Code: code, NewPassword: "Other-Password1"})
+	if err == nil {
+		t.Fatal("code was accepted twice")
+	}
+}
+
+func TestResetPasswordGuessesAreLimited(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	repo.phones["+84912345678"] = user.ID
+
+	if _, err := s.GetForgetPasswordCodeBySMS(ctx, &GetForgetPasswordCodeBySMSRequest{Phone: "+84912345678"}); err != nil {
+		t.Fatal(err)
+	}
+	code := lastSMSCode(t, s, "+84912345678")
+	for i := 0; i < s.configs.LoginLimit; i++ {
+		err := s.ResetPassword(ctx, &CreateNewPasswordWithCodeRequest{Phone: "+84912345678", Code: "00000000", NewPassword: "New-Password1"})
+		if err == nil || err.Error() != "invalid code" {
+			t.Fatalf("guess %d: got %v, want invalid code", i+1, err)
+		}
+	}
+	// Once the limit is reached the code is gone, even the right one fails
+	err := s.ResetPassword(ctx, &CreateNewPasswordWithCodeRequest{Phone: "+84912345678", Code: code, NewPassword: "New-Password1"})
+	if err == nil {
+		t.Fatal("code was accepted after the guess limit")
+	}
+	if _, err := repo.GetVerificationData(ctx, user.Email, database.PassReset); err == nil {
+		t.Fatal("code was kept after the guess limit")
+	}
+}
+
+func TestGetForgetPasswordCodeBySMSIsEnumerationSafe(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	s.configs.EnumerationSafeMode = true
+	repo.phones["+84912345678"] = user.ID
+
+	unverified, err := s.GetForgetPasswordCodeBySMS(ctx, &GetForgetPasswordCodeBySMSRequest{Phone: "+84987654321"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i <= s.configs.SendMailLimit+1; i++ {
+		message, err := s.GetForgetPasswordCodeBySMS(ctx, &GetForgetPasswordCodeBySMSRequest{Phone: "+84912345678"})
+		if err != nil || message != unverified {
+			t.Fatalf("attempt %d: got %q (%v), want %q", i+1, message, err, unverified)
+		}
+	}
+}
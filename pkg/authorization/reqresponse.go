@@ -1,5 +1,7 @@
 package authorization
 
+import "time"
+
 // RegisterRequest is used for registering a new account/user.
 type RegisterRequest struct {
 	Email      string `json:"email" validate:"required,email"`
@@ -28,6 +30,50 @@ type LoginResponse struct {
 	Verified     bool   `json:"verified"`
 }
 
+// SocialLoginRequest is the request for login with an OpenID Connect provider
+type SocialLoginRequest struct {
+	Provider string `json:"provider" validate:"required"`
+	IDToken  string `json:"id_token" validate:"required"`
+}
+
+// EmailCodeLoginRequest is used to get a login code or magic link by email
+type EmailCodeLoginRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// VerifyEmailCodeLoginRequest is used to sign in with a mailed code or magic link token
+type VerifyEmailCodeLoginRequest struct {
+	Email string `json:"email"`
+	Code  string `json:"code"`
+	Token string `json:"token"`
+}
+
+// GuestLoginRequest is used to sign a guest user in again from its device
+type GuestLoginRequest struct {
+	UserID       string `json:"user_id" validate:"required"`
+	DeviceSecret string `json:"device_secret" validate:"required"`
+}
+
+// GuestResponse is the response for guest signup and login. The device secret is
+// only returned at signup and must be kept on the device.
+type GuestResponse struct {
+	UserID       string `json:"user_id"`
+	DeviceSecret string `json:"device_secret,omitempty"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// UpgradeGuestRequest is used to turn a guest into a full account, either with
+// email and password or with an ID token of a social login provider
+type UpgradeGuestRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	Email       string `json:"email" validate:"omitempty,email"`
+	Password    string `json:"password"`
+	RePassword  string `json:"re-password"`
+	Provider    string `json:"provider"`
+	IDToken     string `json:"id_token"`
+}
+
 // LogoutRequest is the request for logout
 type LogoutRequest struct {
 	Email        string `json:"email" validate:"required,email"`
@@ -56,6 +102,8 @@ type UpdateProfileRequest struct {
 	State       string `json:"state"`
 	ZipCode     string `json:"zip_code"`
 	Country     string `json:"country"`
+	// Timezone is an IANA timezone such as Asia/Ho_Chi_Minh
+	Timezone string `json:"timezone"`
 }
 
 // GetUserResponse is the response for get user info
@@ -63,6 +111,7 @@ type GetUserResponse struct {
 	Email    string `json:"email"`
 	Username string `json:"username,omitempty"`
 	Verified bool   `json:"verified"`
+	Role     string `json:"role"`
 }
 
 // GetProfileResponse is the response for get user profile
@@ -72,11 +121,14 @@ type GetProfileResponse struct {
 	LastName  string `json:"last_name,omitempty"`
 	AvatarURL string `json:"avatar_url,omitempty"`
 	Phone     string `json:"phone,omitempty"`
-	Street    string `json:"street,omitempty"`
-	City      string `json:"city,omitempty"`
-	State     string `json:"state,omitempty"`
-	ZipCode   string `json:"zip_code,omitempty"`
-	Country   string `json:"country,omitempty"`
+	// PhoneVerified is true once the user confirmed a code sent to Phone
+	PhoneVerified bool   `json:"phone_verified"`
+	Street        string `json:"street,omitempty"`
+	City          string `json:"city,omitempty"`
+	State         string `json:"state,omitempty"`
+	ZipCode       string `json:"zip_code,omitempty"`
+	Country       string `json:"country,omitempty"`
+	Timezone      string `json:"timezone,omitempty"`
 }
 
 // UpdatePasswordRequest is used to change password
@@ -95,9 +147,10 @@ type GenericResponse struct {
 }
 
 type GenericErrorResponse struct {
-	Status    bool   `json:"status"`
-	ErrorCode int    `json:"error_code"`
-	Message   string `json:"message"`
+	Status    bool     `json:"status"`
+	ErrorCode int      `json:"error_code"`
+	Message   string   `json:"message"`
+	Details   []string `json:"details,omitempty"`
 }
 
 type AuthResponse struct {
@@ -116,17 +169,55 @@ type GetForgetPasswordCodeRequest struct {
 }
 
 type CreateNewPasswordWithCodeRequest struct {
-	Code        string `json:"code" validate:"required"`
-	Email       string `json:"email" validate:"required,email"`
+	Code  string `json:"code" validate:"required"`
+	Email string `json:"email" validate:"omitempty,email"`
+	// Phone replaces the email when the code was sent by sms
+	Phone       string `json:"phone"`
 	NewPassword string `json:"new_password" validate:"required"`
 }
 
+// GetForgetPasswordCodeBySMSRequest is used to send a password reset code to a verified phone number
+type GetForgetPasswordCodeBySMSRequest struct {
+	Phone string `json:"phone" validate:"required"`
+}
+
+// SendPhoneCodeRequest is used to send a verification code to a phone number
+type SendPhoneCodeRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	Phone       string `json:"phone" validate:"required"`
+}
+
+// VerifyPhoneRequest is used to confirm the phone number with the sent code
+type VerifyPhoneRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	Code        string `json:"code" validate:"required"`
+}
+
 // InsertEarnScoreRequest is used to insert earn score
 type InsertEarnScoreRequest struct {
 	AccessToken string `json:"access_token" validate:"required"`
 	WaterScore  int    `json:"water_score"`
 	LightScore  int    `json:"light_score"`
 	SeedScore   int    `json:"seed_score"`
+	// FocusedAt is when the focus happened, now when omitted
+	FocusedAt *time.Time `json:"focused_at"`
+	// RatioVersion is the multi ratio version the scores were computed with, not checked when omitted
+	RatioVersion int `json:"ratio_version" validate:"gte=0"`
+	// TagIDs are the tags of the focus session
+	TagIDs []string `json:"tag_ids" validate:"max=5"`
+	// TaskID is the open task the focus session was spent on, if any
+	TaskID string `json:"task_id"`
+}
+
+// MultiRatioResponse is a multi ratio version. The version in effect is returned with the
+// next scheduled version, if any.
+type MultiRatioResponse struct {
+	Version       int                 `json:"version"`
+	WaterRatio    int                 `json:"water_ratio"`
+	LightRatio    int                 `json:"light_ratio"`
+	SeedRatio     int                 `json:"seed_ratio"`
+	EffectiveFrom time.Time           `json:"effective_from"`
+	Next          *MultiRatioResponse `json:"next,omitempty"`
 }
 
 // GetEarnScoreRequest is used to get earn score
@@ -145,3 +236,656 @@ type GenerateAccessResponse struct {
 	AccessToken  string `json:"access_token,omitempty"`
 	Username     string `json:"username,omitempty"`
 }
+
+// ReauthenticateRequest is used to confirm the password before a sensitive operation
+type ReauthenticateRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	Password    string `json:"password" validate:"required"`
+}
+
+// ReauthenticateResponse carries an access token with a fresh auth_time
+type ReauthenticateResponse struct {
+	AccessToken string `json:"access_token"`
+	AuthTime    int64  `json:"auth_time"`
+}
+
+// RequestEmailChangeRequest is used to start changing the account email
+type RequestEmailChangeRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	Password    string `json:"password" validate:"required"`
+	NewEmail    string `json:"new_email" validate:"required,email"`
+}
+
+// ConfirmEmailChangeRequest is used to confirm the new email with the mailed code
+type ConfirmEmailChangeRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	Code        string `json:"code" validate:"required"`
+}
+
+// UndoEmailChangeRequest is used to revert an email change from the link sent to the old email
+type UndoEmailChangeRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// SetUsernameRequest is used to claim or change the username
+type SetUsernameRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	Username    string `json:"username" validate:"required"`
+}
+
+// SetUsernameResponse is the response for set username
+type SetUsernameResponse struct {
+	Username string `json:"username"`
+}
+
+// GetPublicProfileRequest is used to look up a public profile by username
+type GetPublicProfileRequest struct {
+	Username string `json:"username" validate:"required"`
+}
+
+// PublicProfileResponse is the part of a profile anyone can see
+type PublicProfileResponse struct {
+	Username   string `json:"username"`
+	AvatarURL  string `json:"avatar_url,omitempty"`
+	WaterScore int    `json:"water_score"`
+	LightScore int    `json:"light_score"`
+	SeedScore  int    `json:"seed_score"`
+}
+
+// SignInMethod is a way the user can sign in, the password or a linked provider identity
+type SignInMethod struct {
+	Type      string    `json:"type"`
+	ID        string    `json:"id,omitempty"`
+	Provider  string    `json:"provider,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// SignInMethodsResponse lists the sign-in methods of the user
+type SignInMethodsResponse struct {
+	Methods []SignInMethod `json:"methods"`
+}
+
+// GetSignInMethodsRequest is used to list the sign-in methods of the user
+type GetSignInMethodsRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+}
+
+// LinkIdentityRequest is used to link a social login account to the user
+type LinkIdentityRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	Provider    string `json:"provider" validate:"required"`
+	IDToken     string `json:"id_token" validate:"required"`
+}
+
+// LinkPasswordRequest is used to add a password to an account without one
+type LinkPasswordRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	Password    string `json:"password" validate:"required"`
+	RePassword  string `json:"re-password" validate:"required"`
+}
+
+// UnlinkSignInMethodRequest is used to remove the password or a linked identity
+type UnlinkSignInMethodRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	Type        string `json:"type" validate:"required,oneof=password identity"`
+	IdentityID  string `json:"identity_id"`
+}
+
+// CreatePersonalAccessTokenRequest is used to create a personal access token for scripts
+type CreatePersonalAccessTokenRequest struct {
+	AccessToken   string   `json:"access_token" validate:"required"`
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"min=0,max=3650"` // 0 never expires
+}
+
+// GetPersonalAccessTokensRequest is used to list the personal access tokens
+type GetPersonalAccessTokensRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+}
+
+// RevokePersonalAccessTokenRequest is used to revoke a personal access token
+type RevokePersonalAccessTokenRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	ID          string `json:"id" validate:"required"`
+}
+
+// PersonalAccessTokenResponse describes a personal access token. Token is only set on creation.
+type PersonalAccessTokenResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Token      string     `json:"token,omitempty"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// InternalGetUserRequest is used by internal services to look a user up by id or email
+type InternalGetUserRequest struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email" validate:"omitempty,email"`
+}
+
+// GetFocusStatsRequest is used to get focus statistics bucketed by day, week or month.
+// From and To are dates (YYYY-MM-DD) in the timezone, which defaults to the profile timezone.
+type GetFocusStatsRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	Period      string `json:"period" validate:"required,oneof=day week month"`
+	From        string `json:"from" validate:"required"`
+	To          string `json:"to" validate:"required"`
+	Timezone    string `json:"timezone"`
+	// ByTag adds the totals of the range broken down by tag
+	ByTag bool `json:"by_tag"`
+}
+
+// TagFocusStatsResponse is the focus statistics of one tag. A session with several tags
+// counts towards each of them, sessions without tags are reported as untagged.
+type TagFocusStatsResponse struct {
+	TagID    string `json:"tag_id,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Color    string `json:"color,omitempty"`
+	Archived bool   `json:"archived,omitempty"`
+	Untagged bool   `json:"untagged,omitempty"`
+	Minutes  int    `json:"minutes"`
+	Sessions int    `json:"sessions"`
+}
+
+// FocusStatsBucket is the focus statistics of one day, week or month
+type FocusStatsBucket struct {
+	Start      string `json:"start,omitempty"`
+	Minutes    int    `json:"minutes"`
+	Sessions   int    `json:"sessions"`
+	WaterScore int    `json:"water_score"`
+	LightScore int    `json:"light_score"`
+	SeedScore  int    `json:"seed_score"`
+}
+
+// FocusStatsResponse is the response for get focus statistics
+type FocusStatsResponse struct {
+	Period   string             `json:"period"`
+	Timezone string             `json:"timezone"`
+	Buckets  []FocusStatsBucket `json:"buckets"`
+	Total    FocusStatsBucket   `json:"total"`
+	// Tags is the breakdown of the totals by tag, only when asked for
+	Tags []TagFocusStatsResponse `json:"tags,omitempty"`
+}
+
+// GetAchievementsRequest is used to list the achievements with the user's progress
+type GetAchievementsRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+}
+
+// AchievementResponse is an achievement with the user's progress towards its goal
+type AchievementResponse struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Goal        int               `json:"goal"`
+	Progress    int               `json:"progress"`
+	Reward      AchievementReward `json:"reward"`
+	Unlocked    bool              `json:"unlocked"`
+	UnlockedAt  *time.Time        `json:"unlocked_at,omitempty"`
+}
+
+// GetLeaderboardRequest is used to get a leaderboard
+type GetLeaderboardRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	Metric      string `json:"metric" validate:"required,oneof=water minutes"`
+	Period      string `json:"period" validate:"required,oneof=all week month"`
+	// Scope is global, the default, or friends
+	Scope string `json:"scope" validate:"omitempty,oneof=global friends"`
+	Limit int    `json:"limit" validate:"gte=0"`
+}
+
+// LeaderboardEntryResponse is a ranked user on a leaderboard
+type LeaderboardEntryResponse struct {
+	Rank     int    `json:"rank"`
+	Username string `json:"username"`
+	Score    int    `json:"score"`
+	IsYou    bool   `json:"is_you,omitempty"`
+}
+
+// LeaderboardResponse is the response for get leaderboard
+type LeaderboardResponse struct {
+	Metric  string                     `json:"metric"`
+	Period  string                     `json:"period"`
+	Scope   string                     `json:"scope"`
+	Entries []LeaderboardEntryResponse `json:"entries"`
+	// You is the caller's own entry, null when the caller is not ranked
+	You                    *LeaderboardEntryResponse `json:"you"`
+	HiddenFromLeaderboards bool                      `json:"hidden_from_leaderboards"`
+	// ComputedAt is when the leaderboard was last materialized
+	ComputedAt *time.Time `json:"computed_at,omitempty"`
+}
+
+// GetPrivacySettingsRequest is used to get the privacy settings
+type GetPrivacySettingsRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+}
+
+// UpdatePrivacySettingsRequest is used to change privacy settings, omitted settings are kept
+type UpdatePrivacySettingsRequest struct {
+	AccessToken          string `json:"access_token" validate:"required"`
+	HideFromLeaderboards *bool  `json:"hide_from_leaderboards"`
+}
+
+// PrivacySettingsResponse is the response for get and update privacy settings
+type PrivacySettingsResponse struct {
+	HideFromLeaderboards bool `json:"hide_from_leaderboards"`
+}
+
+// FriendRequest is used to add or remove a friend by username
+type FriendRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	Username    string `json:"username" validate:"required"`
+}
+
+// GetFriendsRequest is used to list friends and friend requests
+type GetFriendsRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+}
+
+// FriendResponse is a friend or a pending friend request
+type FriendResponse struct {
+	Username  string    `json:"username"`
+	Status    string    `json:"status"`
+	Incoming  bool      `json:"incoming"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GetGardenRequest is used to list the plants of the garden or the plant catalog
+type GetGardenRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+}
+
+// PlantSeedRequest is used to plant a seed of a species
+type PlantSeedRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	SpeciesID   string `json:"species_id" validate:"required"`
+}
+
+// WaterPlantRequest is used to give water to a plant
+type WaterPlantRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	PlantID     string `json:"plant_id" validate:"required"`
+	Water       int    `json:"water" validate:"required,gte=1"`
+}
+
+// GrowPlantRequest is used to grow a plant to its next stage
+type GrowPlantRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	PlantID     string `json:"plant_id" validate:"required"`
+}
+
+// PlantResponse is a plant of the garden
+type PlantResponse struct {
+	ID          string `json:"id"`
+	SpeciesID   string `json:"species_id"`
+	SpeciesName string `json:"species_name"`
+	Stage       int    `json:"stage"`
+	StageName   string `json:"stage_name"`
+	// Water is the water given towards the next stage
+	Water int `json:"water"`
+	// NextStage is the stage the plant grows into, null when fully grown
+	NextStage  *PlantStage `json:"next_stage"`
+	FullyGrown bool        `json:"fully_grown"`
+	PlantedAt  time.Time   `json:"planted_at"`
+}
+
+// GetShopRequest is used to list the shop items or the inventory
+type GetShopRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+}
+
+// ShopItemResponse is an item of the shop. Prices of 0 mean the item cannot be bought
+// with that currency.
+type ShopItemResponse struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	Description    string     `json:"description"`
+	Kind           string     `json:"kind"`
+	WaterPrice     int        `json:"water_price"`
+	LightPrice     int        `json:"light_price"`
+	SeedPrice      int        `json:"seed_price"`
+	AvailableUntil *time.Time `json:"available_until,omitempty"`
+	PerUserLimit   int        `json:"per_user_limit"`
+}
+
+// PurchaseItemRequest is used to buy a shop item. The idempotency key is chosen by the client
+// and reused when the request is retried.
+type PurchaseItemRequest struct {
+	AccessToken    string `json:"access_token" validate:"required"`
+	ItemID         string `json:"item_id" validate:"required"`
+	Currency       string `json:"currency" validate:"required,oneof=water light seed"`
+	Quantity       int    `json:"quantity" validate:"gte=0,lte=100"`
+	IdempotencyKey string `json:"idempotency_key" validate:"required,max=64"`
+}
+
+// PurchaseResponse is a recorded purchase, Replayed is set when it was recorded by an
+// earlier request with the same idempotency key
+type PurchaseResponse struct {
+	ID        string    `json:"id"`
+	ItemID    string    `json:"item_id"`
+	Quantity  int       `json:"quantity"`
+	Currency  string    `json:"currency"`
+	Price     int       `json:"price"`
+	Replayed  bool      `json:"replayed"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// InventoryItemResponse is an item owned by the user
+type InventoryItemResponse struct {
+	ItemID   string `json:"item_id"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Quantity int    `json:"quantity"`
+}
+
+// InternalGetShopItemsRequest is used by internal services to list the shop catalog
+type InternalGetShopItemsRequest struct {
+	IncludeDeleted bool `json:"include_deleted"`
+}
+
+// ShopItemRequest is used by internal services to create or replace a shop item
+type ShopItemRequest struct {
+	ID             string     `json:"id" validate:"required,max=50"`
+	Name           string     `json:"name" validate:"required,max=100"`
+	Description    string     `json:"description" validate:"max=500"`
+	Kind           string     `json:"kind" validate:"required,oneof=pot theme streak_freeze"`
+	WaterPrice     int        `json:"water_price" validate:"gte=0"`
+	LightPrice     int        `json:"light_price" validate:"gte=0"`
+	SeedPrice      int        `json:"seed_price" validate:"gte=0"`
+	AvailableFrom  *time.Time `json:"available_from"`
+	AvailableUntil *time.Time `json:"available_until"`
+	PerUserLimit   int        `json:"per_user_limit" validate:"gte=0"`
+}
+
+// InternalDeleteShopItemRequest is used by internal services to delete a shop item
+type InternalDeleteShopItemRequest struct {
+	ID string `json:"id" validate:"required"`
+}
+
+// InternalGetMultiRatiosRequest is used by internal services to list the multi ratio versions
+type InternalGetMultiRatiosRequest struct{}
+
+// PublishMultiRatioRequest is used by internal services to publish a multi ratio version
+type PublishMultiRatioRequest struct {
+	WaterRatio int `json:"water_ratio" validate:"required,gte=1"`
+	LightRatio int `json:"light_ratio" validate:"required,gte=1"`
+	SeedRatio  int `json:"seed_ratio" validate:"required,gte=1"`
+	// EffectiveFrom is when the version takes effect, now when omitted
+	EffectiveFrom *time.Time `json:"effective_from"`
+}
+
+// SetGoalRequest is used to set the daily or weekly focus goal, a target of 0 clears it
+type SetGoalRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	Period      string `json:"period" validate:"required,oneof=day week"`
+	Metric      string `json:"metric" validate:"required,oneof=minutes sessions"`
+	Target      int    `json:"target" validate:"gte=0,lte=10080"`
+}
+
+// GetGoalProgressRequest is used to get the progress towards the focus goals
+type GetGoalProgressRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	// Timezone is an IANA timezone, the profile timezone when empty
+	Timezone string `json:"timezone"`
+}
+
+// GetGoalHistoryRequest is used to get the progress towards the focus goals of past days or weeks.
+// From and To are dates (YYYY-MM-DD), both included.
+type GetGoalHistoryRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	Period      string `json:"period" validate:"required,oneof=day week"`
+	From        string `json:"from" validate:"required"`
+	To          string `json:"to" validate:"required"`
+	Timezone    string `json:"timezone"`
+}
+
+// GoalProgress is the progress of a day or week towards the goal in effect at the time.
+// Target is 0 when no goal was set.
+type GoalProgress struct {
+	Period string `json:"period"`
+	// Start is the first day of the period
+	Start        string `json:"start"`
+	Metric       string `json:"metric"`
+	Target       int    `json:"target"`
+	Progress     int    `json:"progress"`
+	Met          bool   `json:"met"`
+	BonusAwarded bool   `json:"bonus_awarded"`
+}
+
+// GoalProgressResponse is the progress of today and of this week, null when no goal is set
+type GoalProgressResponse struct {
+	Timezone string        `json:"timezone"`
+	Daily    *GoalProgress `json:"daily"`
+	Weekly   *GoalProgress `json:"weekly"`
+}
+
+// GoalHistoryResponse is the response for get goal history
+type GoalHistoryResponse struct {
+	Period   string         `json:"period"`
+	Timezone string         `json:"timezone"`
+	Entries  []GoalProgress `json:"entries"`
+}
+
+// CreateTagRequest is used to create a tag for focus sessions
+type CreateTagRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	Name        string `json:"name" validate:"required,max=30"`
+	Color       string `json:"color" validate:"omitempty,hexcolor"`
+}
+
+// UpdateTagRequest is used to change a tag, omitted fields are kept
+type UpdateTagRequest struct {
+	AccessToken string  `json:"access_token" validate:"required"`
+	TagID       string  `json:"tag_id" validate:"required"`
+	Name        *string `json:"name" validate:"omitempty,max=30"`
+	Color       *string `json:"color" validate:"omitempty,hexcolor"`
+	Archived    *bool   `json:"archived"`
+}
+
+// GetTagsRequest is used to list the tags
+type GetTagsRequest struct {
+	AccessToken     string `json:"access_token" validate:"required"`
+	IncludeArchived bool   `json:"include_archived"`
+}
+
+// TagResponse is a tag of the user
+type TagResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	Archived  bool      `json:"archived"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateTaskRequest is used to add a task, DueDate is a date (YYYY-MM-DD)
+type CreateTaskRequest struct {
+	AccessToken        string `json:"access_token" validate:"required"`
+	Title              string `json:"title" validate:"required,max=200"`
+	Notes              string `json:"notes" validate:"max=2000"`
+	EstimatedPomodoros int    `json:"estimated_pomodoros" validate:"gte=0,lte=100"`
+	DueDate            string `json:"due_date"`
+}
+
+// UpdateTaskRequest is used to change a task, omitted fields are kept and an empty due date clears it
+type UpdateTaskRequest struct {
+	AccessToken        string  `json:"access_token" validate:"required"`
+	TaskID             string  `json:"task_id" validate:"required"`
+	Title              *string `json:"title" validate:"omitempty,max=200"`
+	Notes              *string `json:"notes" validate:"omitempty,max=2000"`
+	EstimatedPomodoros *int    `json:"estimated_pomodoros" validate:"omitempty,gte=0,lte=100"`
+	DueDate            *string `json:"due_date"`
+	Completed          *bool   `json:"completed"`
+}
+
+// TaskRequest is used to delete a task
+type TaskRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	TaskID      string `json:"task_id" validate:"required"`
+}
+
+// GetTasksRequest is used to list the tasks
+type GetTasksRequest struct {
+	AccessToken      string `json:"access_token" validate:"required"`
+	IncludeCompleted bool   `json:"include_completed"`
+}
+
+// ReorderTasksRequest is used to order the tasks, the given tasks are moved to the top in order
+type ReorderTasksRequest struct {
+	AccessToken string   `json:"access_token" validate:"required"`
+	TaskIDs     []string `json:"task_ids" validate:"required,min=1,max=500"`
+}
+
+// TaskResponse is a task of the user. ActualPomodoros counts the focus sessions spent on it.
+type TaskResponse struct {
+	ID                 string     `json:"id"`
+	Title              string     `json:"title"`
+	Notes              string     `json:"notes"`
+	EstimatedPomodoros int        `json:"estimated_pomodoros"`
+	ActualPomodoros    int        `json:"actual_pomodoros"`
+	DueDate            string     `json:"due_date,omitempty"`
+	Completed          bool       `json:"completed"`
+	CompletedAt        *time.Time `json:"completed_at,omitempty"`
+	Position           int        `json:"position"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// InternalGetEarnScoreRequest is used by internal services to get the earn score of a user
+type InternalGetEarnScoreRequest struct {
+	UserID string `json:"user_id" validate:"required"`
+}
+
+// InternalUserResponse is the user as seen by internal services
+type InternalUserResponse struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Username  string    `json:"username"`
+	Verified  bool      `json:"verified"`
+	Banned    bool      `json:"banned"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GetOAuthClientRequest is used by the consent screen to describe a client, given by
+// client id and scope or by the user code shown on a device
+type GetOAuthClientRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	ClientID    string `json:"client_id"`
+	Scope       string `json:"scope"` // space separated
+	UserCode    string `json:"user_code"`
+}
+
+// OAuthClientResponse describes a client on the consent screen
+type OAuthClientResponse struct {
+	ClientID        string   `json:"client_id"`
+	Name            string   `json:"name"`
+	RequestedScopes []string `json:"requested_scopes"`
+	ConsentedScopes []string `json:"consented_scopes"`
+}
+
+// OAuthAuthorizeRequest carries the authorization request of a client and the decision of the user
+type OAuthAuthorizeRequest struct {
+	AccessToken         string `json:"access_token" validate:"required"`
+	ResponseType        string `json:"response_type"`
+	ClientID            string `json:"client_id" validate:"required"`
+	RedirectURI         string `json:"redirect_uri" validate:"required"`
+	Scope               string `json:"scope"` // space separated
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+	Approve             bool   `json:"approve"`
+}
+
+// OAuthAuthorizeResponse is the uri to send the browser back to the client
+type OAuthAuthorizeResponse struct {
+	RedirectURI string `json:"redirect_uri"`
+}
+
+// OAuthTokenRequest is the form posted by clients to the token endpoint
+type OAuthTokenRequest struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	DeviceCode   string
+}
+
+// OAuthTokenResponse is the token response of RFC 6749
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+// OAuthDeviceCodeRequest is the form posted by devices to start the device code flow
+type OAuthDeviceCodeRequest struct {
+	ClientID     string
+	ClientSecret string
+	Scope        string
+}
+
+// OAuthDeviceCodeResponse is the device authorization response of RFC 8628
+type OAuthDeviceCodeResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// VerifyDeviceCodeRequest is used to approve or deny the device showing the user code
+type VerifyDeviceCodeRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	UserCode    string `json:"user_code" validate:"required"`
+	Approve     bool   `json:"approve"`
+}
+
+// GetOAuthConsentsRequest is used to list the clients the user granted access to
+type GetOAuthConsentsRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+}
+
+// OAuthConsentResponse describes the access the user granted a client
+type OAuthConsentResponse struct {
+	ClientID   string    `json:"client_id"`
+	ClientName string    `json:"client_name"`
+	Scopes     []string  `json:"scopes"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// RevokeOAuthConsentRequest is used to withdraw the access of a client
+type RevokeOAuthConsentRequest struct {
+	AccessToken string `json:"access_token" validate:"required"`
+	ClientID    string `json:"client_id" validate:"required"`
+}
+
+// CreateOAuthClientRequest is used by internal services to register a third-party client.
+// Confidential clients get a secret, public clients such as browser extensions rely on PKCE.
+type CreateOAuthClientRequest struct {
+	Name         string   `json:"name" validate:"required,max=100"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes" validate:"required,min=1"`
+	Confidential bool     `json:"confidential"`
+}
+
+// OAuthClientCreatedResponse describes a new client. ClientSecret is only set on creation.
+type OAuthClientCreatedResponse struct {
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+}
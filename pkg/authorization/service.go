@@ -20,4 +20,134 @@ type Service interface {
 	// GetEarnScore Get earn score by user id
 	GetEarnScore(ctx context.Context) (interface{}, error)
 	GenerateAccessToken(ctx context.Context) (interface{}, error)
+	// Reauthenticate confirms the password and returns a fresh access token for sensitive operations
+	Reauthenticate(ctx context.Context, request *ReauthenticateRequest) (interface{}, error)
+	// RequestEmailChange Mail a code to the new email and a notice to the current one
+	RequestEmailChange(ctx context.Context, request *RequestEmailChangeRequest) (string, error)
+	// ConfirmEmailChange Switch the account to the new email with the mailed code
+	ConfirmEmailChange(ctx context.Context, request *ConfirmEmailChangeRequest) (string, error)
+	// UndoEmailChange Restore the old email from the undo link
+	UndoEmailChange(ctx context.Context, request *UndoEmailChangeRequest) (string, error)
+	// SetUsername Claim or change the username
+	SetUsername(ctx context.Context, request *SetUsernameRequest) (interface{}, error)
+	// GetPublicProfile Get the public profile by username
+	GetPublicProfile(ctx context.Context, request *GetPublicProfileRequest) (interface{}, error)
+	// SocialLogin Sign in with an ID token from an OpenID Connect provider
+	SocialLogin(ctx context.Context, request *SocialLoginRequest) (interface{}, error)
+	// RequestLoginCode Mail a one-time login code and magic link
+	RequestLoginCode(ctx context.Context, request *EmailCodeLoginRequest) (string, error)
+	// VerifyLoginCode Sign in with a mailed login code or magic link token
+	VerifyLoginCode(ctx context.Context, request *VerifyEmailCodeLoginRequest) (interface{}, error)
+	// GuestSignUp Create a guest user bound to a device secret
+	GuestSignUp(ctx context.Context) (interface{}, error)
+	// GuestLogin Sign a guest user in with its device secret
+	GuestLogin(ctx context.Context, request *GuestLoginRequest) (interface{}, error)
+	// UpgradeGuest Turn the guest user into a full account
+	UpgradeGuest(ctx context.Context, request *UpgradeGuestRequest) (interface{}, error)
+	// GetSignInMethods List the password and provider identities of the user
+	GetSignInMethods(ctx context.Context) (interface{}, error)
+	// LinkIdentity Link a social login account to the user
+	LinkIdentity(ctx context.Context, request *LinkIdentityRequest) (interface{}, error)
+	// LinkPassword Add a password to an account that has none
+	LinkPassword(ctx context.Context, request *LinkPasswordRequest) (interface{}, error)
+	// UnlinkSignInMethod Remove the password or a linked identity, keeping at least one
+	UnlinkSignInMethod(ctx context.Context, request *UnlinkSignInMethodRequest) (interface{}, error)
+	// CreatePersonalAccessToken Create a scoped token for scripts
+	CreatePersonalAccessToken(ctx context.Context, request *CreatePersonalAccessTokenRequest) (interface{}, error)
+	// GetPersonalAccessTokens List the personal access tokens of the user
+	GetPersonalAccessTokens(ctx context.Context) (interface{}, error)
+	// RevokePersonalAccessToken Revoke a personal access token
+	RevokePersonalAccessToken(ctx context.Context, request *RevokePersonalAccessTokenRequest) (string, error)
+	// InternalGetUser Look a user up by id or email, for internal services
+	InternalGetUser(ctx context.Context, request *InternalGetUserRequest) (interface{}, error)
+	// InternalGetEarnScore Get the earn score of a user, for internal services
+	InternalGetEarnScore(ctx context.Context, request *InternalGetEarnScoreRequest) (interface{}, error)
+	// GetOAuthClient Describe an OAuth client for the consent screen
+	GetOAuthClient(ctx context.Context, request *GetOAuthClientRequest) (interface{}, error)
+	// AuthorizeOAuthClient Record the consent decision and return the redirect uri with a code
+	AuthorizeOAuthClient(ctx context.Context, request *OAuthAuthorizeRequest) (interface{}, error)
+	// OAuthToken Exchange a code, refresh token or device code for tokens
+	OAuthToken(ctx context.Context, request *OAuthTokenRequest) (interface{}, error)
+	// RequestDeviceCode Start the device code flow
+	RequestDeviceCode(ctx context.Context, request *OAuthDeviceCodeRequest) (interface{}, error)
+	// VerifyDeviceCode Approve or deny a device with its user code
+	VerifyDeviceCode(ctx context.Context, request *VerifyDeviceCodeRequest) (string, error)
+	// GetOAuthConsents List the clients the user granted access to
+	GetOAuthConsents(ctx context.Context) (interface{}, error)
+	// RevokeOAuthConsent Withdraw the access of a client
+	RevokeOAuthConsent(ctx context.Context, request *RevokeOAuthConsentRequest) (string, error)
+	// CreateOAuthClient Register a third-party client, for internal services
+	CreateOAuthClient(ctx context.Context, request *CreateOAuthClientRequest) (interface{}, error)
+	// SendPhoneCode Send a verification code to a phone number
+	SendPhoneCode(ctx context.Context, request *SendPhoneCodeRequest) (string, error)
+	// VerifyPhone Confirm the phone number with the sent code
+	VerifyPhone(ctx context.Context, request *VerifyPhoneRequest) (string, error)
+	// GetForgetPasswordCodeBySMS Send a password reset code to a verified phone number
+	GetForgetPasswordCodeBySMS(ctx context.Context, request *GetForgetPasswordCodeBySMSRequest) (string, error)
+	// GetFocusStats Get focus statistics bucketed by day, week or month
+	GetFocusStats(ctx context.Context, request *GetFocusStatsRequest) (interface{}, error)
+	// GetAchievements List the achievements with the progress of the user
+	GetAchievements(ctx context.Context) (interface{}, error)
+	// GetLeaderboard Get a global or friends leaderboard with the rank of the user
+	GetLeaderboard(ctx context.Context, request *GetLeaderboardRequest) (interface{}, error)
+	// GetPrivacySettings Get the privacy settings of the user
+	GetPrivacySettings(ctx context.Context) (interface{}, error)
+	// UpdatePrivacySettings Change the privacy settings of the user
+	UpdatePrivacySettings(ctx context.Context, request *UpdatePrivacySettingsRequest) (interface{}, error)
+	// AddFriend Send or accept a friend request
+	AddFriend(ctx context.Context, request *FriendRequest) (string, error)
+	// RemoveFriend Remove a friend or a friend request
+	RemoveFriend(ctx context.Context, request *FriendRequest) (string, error)
+	// GetFriends List the friends and friend requests of the user
+	GetFriends(ctx context.Context) (interface{}, error)
+	// GetPlantSpecies List the plant catalog
+	GetPlantSpecies(ctx context.Context) (interface{}, error)
+	// GetGarden List the plants of the user
+	GetGarden(ctx context.Context) (interface{}, error)
+	// PlantSeed Spend seeds to plant a species
+	PlantSeed(ctx context.Context, request *PlantSeedRequest) (interface{}, error)
+	// WaterPlant Spend water on a plant
+	WaterPlant(ctx context.Context, request *WaterPlantRequest) (interface{}, error)
+	// GrowPlant Spend light to grow a plant to its next stage
+	GrowPlant(ctx context.Context, request *GrowPlantRequest) (interface{}, error)
+	// GetShop List the shop items that can be bought now
+	GetShop(ctx context.Context) (interface{}, error)
+	// PurchaseItem Buy a shop item, idempotently
+	PurchaseItem(ctx context.Context, request *PurchaseItemRequest) (interface{}, error)
+	// GetInventory List the items the user owns
+	GetInventory(ctx context.Context) (interface{}, error)
+	// InternalGetShopItems List the shop catalog, for internal services
+	InternalGetShopItems(ctx context.Context, request *InternalGetShopItemsRequest) (interface{}, error)
+	// InternalCreateShopItem Add a shop item, for internal services
+	InternalCreateShopItem(ctx context.Context, request *ShopItemRequest) (interface{}, error)
+	// InternalUpdateShopItem Replace a shop item, for internal services
+	InternalUpdateShopItem(ctx context.Context, request *ShopItemRequest) (interface{}, error)
+	// InternalDeleteShopItem Delete a shop item, for internal services
+	InternalDeleteShopItem(ctx context.Context, request *InternalDeleteShopItemRequest) (string, error)
+	// InternalGetMultiRatios List the multi ratio versions, for internal services
+	InternalGetMultiRatios(ctx context.Context) (interface{}, error)
+	// InternalPublishMultiRatio Publish a multi ratio version, for internal services
+	InternalPublishMultiRatio(ctx context.Context, request *PublishMultiRatioRequest) (interface{}, error)
+	// SetGoal Set the daily or weekly focus goal
+	SetGoal(ctx context.Context, request *SetGoalRequest) (interface{}, error)
+	// GetGoalProgress Get the progress of today and this week towards the focus goals
+	GetGoalProgress(ctx context.Context, request *GetGoalProgressRequest) (interface{}, error)
+	// GetGoalHistory Get the progress of past days or weeks towards the focus goals
+	GetGoalHistory(ctx context.Context, request *GetGoalHistoryRequest) (interface{}, error)
+	// CreateTag Create a tag for focus sessions
+	CreateTag(ctx context.Context, request *CreateTagRequest) (interface{}, error)
+	// UpdateTag Rename, recolor, archive or restore a tag
+	UpdateTag(ctx context.Context, request *UpdateTagRequest) (interface{}, error)
+	// GetTags List the tags of the user
+	GetTags(ctx context.Context, request *GetTagsRequest) (interface{}, error)
+	// CreateTask Add a task to the task list
+	CreateTask(ctx context.Context, request *CreateTaskRequest) (interface{}, error)
+	// UpdateTask Change or complete a task
+	UpdateTask(ctx context.Context, request *UpdateTaskRequest) (interface{}, error)
+	// DeleteTask Delete a task
+	DeleteTask(ctx context.Context, request *TaskRequest) (string, error)
+	// GetTasks List the tasks of the user
+	GetTasks(ctx context.Context, request *GetTasksRequest) (interface{}, error)
+	// ReorderTasks Order the tasks of the user
+	ReorderTasks(ctx context.Context, request *ReorderTasksRequest) (interface{}, error)
 }
@@ -0,0 +1,442 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"LoveLetterProject/pkg/authorization/middleware"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"database/sql"
+	"encoding/pem"
+	"errors"
+	"github.com/hashicorp/go-hclog"
+	"github.com/satori/go.uuid"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testConfigs returns configurations with cheap password hashing and a temporary token signing key.
+func testConfigs(t *testing.T) *utils.Configurations {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	privatePath := filepath.Join(dir, "private.pem")
+	publicPath := filepath.Join(dir, "public.pem")
+	private := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	publicBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicBytes})
+	if err := os.WriteFile(privatePath, private, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(publicPath, public, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return &utils.Configurations{
+		JwtExpiration:               10,
+		AccessTokenPrivateKeyPath:   privatePath,
+		AccessTokenPublicKeyPath:    publicPath,
+		RefreshTokenPrivateKeyPath:  privatePath,
+		RefreshTokenPublicKeyPath:   publicPath,
+		PassResetCodeExpiration:     10,
+		LoginLimit:                  3,
+		SendMailLimit:               3,
+		ChangePasswordLimit:         3,
+		PasswordHashAlgorithm:       utils.HashAlgorithmBcrypt,
+		BcryptCost:                  4,
+		PasswordMinLength:           8,
+		LoginCodeExpiration:         10,
+		PhoneCodeExpiration:         10,
+		FocusBackdateLimit:          24,
+		GoalDailyBonusWater:         2,
+		GoalWeeklyBonusWater:        5,
+		GoalMinMinutes:              15,
+		StreakMaxFreezes:            2,
+		OAuthCodeExpiration:         10,
+		OAuthRefreshTokenExpiration: 30,
+		TagLimit:                    30,
+	}
+}
+
+// newTestService returns a user service over the repository, mails and sms are recorded and
+// throwaway.example is a disposable email domain.
+func newTestService(t *testing.T, configs *utils.Configurations, repo database.UserRepository) *userService {
+	t.Helper()
+	logger := hclog.NewNullLogger()
+	hasher, err := utils.NewPasswordHasher(configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := middleware.NewAuthService(logger, configs, hasher)
+	blocklist := filepath.Join(t.TempDir(), "disposable.txt")
+	if err := os.WriteFile(blocklist, []byte("throwaway.example\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	disposableDomains, err := utils.NewDisposableDomains(blocklist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewUserService(logger, configs, repo, &fakeMailService{}, NewFakeSMSService(logger), auth,
+		hasher, utils.NewPasswordPolicy(configs), nil, disposableDomains, nil)
+}
+
+// newUserTest returns a service over a fake repository, a user with a password signed in to it
+// and the context of the user. Tests change s.configs and seed the repository themselves.
+func newUserTest(t *testing.T) (*userService, *fakeRepo, *database.User, context.Context) {
+	t.Helper()
+	repo := newFakeRepo()
+	s := newTestService(t, testConfigs(t), repo)
+	user := repo.addUser(t, s, "known@example.com", "Correct-Password1")
+	return s, repo, user, context.WithValue(context.Background(), middleware.UserIDKey{}, user.ID)
+}
+
+// fakeMailService records mails instead of sending them.
+type fakeMailService struct {
+	mu    sync.Mutex
+	mails []*Mail
+}
+
+func (ms *fakeMailService) CreateMail(mailReq *Mail) []byte {
+	return nil
+}
+
+func (ms *fakeMailService) SendMail(mailReq *Mail) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.mails = append(ms.mails, mailReq)
+	return nil
+}
+
+func (ms *fakeMailService) NewMail(from string, to []string, subject string, mailType MailType, data *MailData) *Mail {
+	return &Mail{from: from, to: to, subject: subject, mtype: mailType, data: data}
+}
+
+// fakeRepo keeps users, limits and verification codes in memory. It embeds the repository
+// interface, so calling a method a test does not fake panics.
+type fakeRepo struct {
+	database.UserRepository
+	mu            sync.Mutex
+	users         map[string]*database.User
+	limits        map[string]*database.LimitData
+	verifications map[string]*database.VerificationData
+	passwords     map[string][]string
+	identities    map[string]*database.Identity
+	phones        map[string]string // verified phone to user id
+	scores        map[string]*database.EarnScore
+	totals        map[string]*database.FocusTotals
+	streaks       map[string]*database.Streak
+	achievements  map[string][]database.UserAchievement
+	plants        map[string]*database.Plant
+	timezones     map[string]string
+	sessions      []database.FocusSession
+	ratios        []database.MultiRatioData
+	goals         []database.Goal
+	goalBonuses   []database.GoalBonus
+	shopItems     map[string]*database.ShopItem
+	purchases     []database.Purchase
+	inventory     map[string]int // user id/item id to quantity
+	oauthClients  map[string]*database.OAuthClient
+	oauthConsents map[string]*database.OAuthConsent // user id/client id
+	oauthCodes    map[string]*database.OAuthAuthorizationCode
+	oauthTokens   map[string]*database.OAuthRefreshToken
+	emailChanges  map[string]*database.EmailChange // pending by user id
+	// beforeWrite runs ahead of the optimistic writes, a test sets it to change a row meanwhile
+	beforeWrite func()
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{
+		users:         map[string]*database.User{},
+		limits:        map[string]*database.LimitData{},
+		verifications: map[string]*database.VerificationData{},
+		passwords:     map[string][]string{},
+		identities:    map[string]*database.Identity{},
+		phones:        map[string]string{},
+		scores:        map[string]*database.EarnScore{},
+		totals:        map[string]*database.FocusTotals{},
+		streaks:       map[string]*database.Streak{},
+		achievements:  map[string][]database.UserAchievement{},
+		plants:        map[string]*database.Plant{},
+		timezones:     map[string]string{},
+		shopItems:     map[string]*database.ShopItem{},
+		inventory:     map[string]int{},
+		oauthClients:  map[string]*database.OAuthClient{},
+		oauthConsents: map[string]*database.OAuthConsent{},
+		oauthCodes:    map[string]*database.OAuthAuthorizationCode{},
+		oauthTokens:   map[string]*database.OAuthRefreshToken{},
+		emailChanges:  map[string]*database.EmailChange{},
+	}
+}
+
+// addUser stores a verified user with the password hashed by the service.
+func (repo *fakeRepo) addUser(t *testing.T, s *userService, email string, password string) *database.User {
+	t.Helper()
+	user := &database.User{
+		ID:        uuid.NewV4().String(),
+		Email:     email,
+		TokenHash: utils.GenerateRandomString(15),
+		Verified:  true,
+		Role:      database.RoleUser,
+	}
+	if password != "" {
+		hashed, err := s.hasher.Hash(password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		user.Password = hashed
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.users[user.ID] = user
+	return user
+}
+
+func (repo *fakeRepo) GetUserByEmail(ctx context.Context, email string) (*database.User, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, user := range repo.users {
+		if strings.EqualFold(user.Email, email) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return &database.User{}, sql.ErrNoRows
+}
+
+func (repo *fakeRepo) GetUserByID(ctx context.Context, id string) (*database.User, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	user, ok := repo.users[id]
+	if !ok {
+		return &database.User{}, sql.ErrNoRows
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (repo *fakeRepo) UpdatePassword(ctx context.Context, userID string, password string, tokenHash string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.users[userID].Password = password
+	repo.users[userID].TokenHash = tokenHash
+	return nil
+}
+
+func (repo *fakeRepo) UpdatePasswordHash(ctx context.Context, userID string, password string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.users[userID].Password = password
+	return nil
+}
+
+func (repo *fakeRepo) GetListOfPasswords(ctx context.Context, userID string) ([]string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	return repo.passwords[userID], nil
+}
+
+func (repo *fakeRepo) InsertListOfPasswords(ctx context.Context, passwordUsers *database.PassworUsers) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.passwords[passwordUsers.UserID] = append(repo.passwords[passwordUsers.UserID], passwordUsers.Password)
+	return nil
+}
+
+func (repo *fakeRepo) GetLimitData(ctx context.Context, userID string) (*database.LimitData, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	limitData, ok := repo.limits[userID]
+	if !ok {
+		return &database.LimitData{}, sql.ErrNoRows
+	}
+	copied := *limitData
+	return &copied, nil
+}
+
+func (repo *fakeRepo) InsertOrUpdateLimitData(ctx context.Context, limitData *database.LimitData, isInsert bool) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	copied := *limitData
+	repo.limits[limitData.UserID] = &copied
+	return nil
+}
+
+func verificationKey(email string, verificationDataType database.VerificationDataType) string {
+	return strings.ToLower(email) + "/" + string(rune('0'+verificationDataType))
+}
+
+func (repo *fakeRepo) StoreVerificationData(ctx context.Context, verificationData *database.VerificationData, isInsert bool) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	copied := *verificationData
+	repo.verifications[verificationKey(verificationData.Email, verificationData.Type)] = &copied
+	return nil
+}
+
+func (repo *fakeRepo) GetVerificationData(ctx context.Context, email string, verificationDataType database.VerificationDataType) (*database.VerificationData, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	verificationData, ok := repo.verifications[verificationKey(email, verificationDataType)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *verificationData
+	return &copied, nil
+}
+
+func (repo *fakeRepo) DeleteVerificationData(ctx context.Context, email string, verificationDataType database.VerificationDataType) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	delete(repo.verifications, verificationKey(email, verificationDataType))
+	return nil
+}
+
+func (repo *fakeRepo) GetEarnScore(ctx context.Context, userID string) (*database.EarnScore, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	score, ok := repo.scores[userID]
+	if !ok {
+		return &database.EarnScore{}, sql.ErrNoRows
+	}
+	copied := *score
+	return &copied, nil
+}
+
+// addScore credits the scores of the user, it expects the lock to be held.
+func (repo *fakeRepo) addScore(userID string, water int, light int, seed int) {
+	score, ok := repo.scores[userID]
+	if !ok {
+		score = &database.EarnScore{UserID: userID}
+		repo.scores[userID] = score
+	}
+	score.WaterScore += water
+	score.LightScore += light
+	score.SeedScore += seed
+}
+
+// spendScore debits the cost like the repository does, it expects the lock to be held.
+func (repo *fakeRepo) spendScore(userID string, cost *database.EarnScore) error {
+	score, ok := repo.scores[userID]
+	if !ok || score.WaterScore < cost.WaterScore || score.LightScore < cost.LightScore || score.SeedScore < cost.SeedScore {
+		return database.ErrInsufficientScore
+	}
+	repo.addScore(userID, -cost.WaterScore, -cost.LightScore, -cost.SeedScore)
+	return nil
+}
+
+func (repo *fakeRepo) GetProfileByID(ctx context.Context, userID string) (*database.ProfileData, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, ok := repo.users[userID]; !ok {
+		return &database.ProfileData{}, sql.ErrNoRows
+	}
+	return &database.ProfileData{UserID: userID, Timezone: repo.timezones[userID]}, nil
+}
+
+func (repo *fakeRepo) GetFocusMinutes(ctx context.Context, userID string, from time.Time, to time.Time) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	minutes := 0
+	for _, session := range repo.sessions {
+		if session.UserID == userID && !session.CreatedAt.Before(from) && session.CreatedAt.Before(to) {
+			minutes += session.Minutes
+		}
+	}
+	return minutes, nil
+}
+
+func (repo *fakeRepo) GetMultiRatioData(ctx context.Context, at time.Time) (*database.MultiRatioData, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	var ratio *database.MultiRatioData
+	for i := range repo.ratios {
+		if !repo.ratios[i].EffectiveFrom.After(at) {
+			ratio = &repo.ratios[i]
+		}
+	}
+	if ratio == nil {
+		return &database.MultiRatioData{}, sql.ErrNoRows
+	}
+	copied := *ratio
+	return &copied, nil
+}
+
+func (repo *fakeRepo) RecordFocusSession(ctx context.Context, session *database.FocusSession, tagIDs []string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	session.ID = uuid.NewV4().String()
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = time.Now()
+	}
+	repo.sessions = append(repo.sessions, *session)
+	repo.addScore(session.UserID, session.WaterScore, session.LightScore, session.SeedScore)
+	return nil
+}
+
+func (repo *fakeRepo) GetGoals(ctx context.Context, userID string) ([]database.Goal, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	goals := []database.Goal{}
+	for _, goal := range repo.goals {
+		if goal.UserID == userID {
+			goals = append(goals, goal)
+		}
+	}
+	return goals, nil
+}
+
+func (repo *fakeRepo) GetGoalBonuses(ctx context.Context, userID string, from time.Time, to time.Time) ([]database.GoalBonus, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	bonuses := []database.GoalBonus{}
+	for _, bonus := range repo.goalBonuses {
+		if bonus.UserID == userID && !bonus.PeriodStart.Before(from) && !bonus.PeriodStart.After(to) {
+			bonuses = append(bonuses, bonus)
+		}
+	}
+	return bonuses, nil
+}
+
+// addSession stores a focus session of the user without crediting it.
+func (repo *fakeRepo) addSession(userID string, minutes int, createdAt time.Time) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.sessions = append(repo.sessions, database.FocusSession{
+		ID:        uuid.NewV4().String(),
+		UserID:    userID,
+		Minutes:   minutes,
+		CreatedAt: createdAt,
+	})
+}
+
+// errorType returns the type of an error response, -1 for any other error.
+func errorType(err error) utils.ErrorType {
+	var cusErr utils.ErrorResponse
+	if errors.As(err, &cusErr) {
+		return cusErr.ErrorType
+	}
+	return -1
+}
+
+// lastMail returns the latest mail recorded by the service.
+func lastMail(t *testing.T, s *userService) *Mail {
+	t.Helper()
+	ms := s.mailService.(*fakeMailService)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if len(ms.mails) == 0 {
+		t.Fatal("no mail was sent")
+	}
+	return ms.mails[len(ms.mails)-1]
+}
@@ -0,0 +1,252 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"LoveLetterProject/pkg/authorization/middleware"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// GetShop lists the shop items that can be bought now.
+func (s *userService) GetShop(ctx context.Context) (interface{}, error) {
+	if _, err := s.contextUserOf(ctx); err != nil {
+		return err.Error(), err
+	}
+	items, err := s.repo.GetShopItems(ctx, false)
+	if err != nil {
+		s.logger.Error("Cannot get shop items", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	now := time.Now()
+	response := []ShopItemResponse{}
+	for _, item := range items {
+		if item.IsAvailable(now) {
+			response = append(response, newShopItemResponse(item))
+		}
+	}
+	return response, nil
+}
+
+// PurchaseItem buys an item with the chosen currency. A purchase retried with the same
+// idempotency key returns the recorded purchase instead of buying the item again.
+func (s *userService) PurchaseItem(ctx context.Context, request *PurchaseItemRequest) (interface{}, error) {
+	user, err := s.contextUserOf(ctx)
+	if err != nil {
+		return err.Error(), err
+	}
+	if request.Quantity == 0 {
+		request.Quantity = 1
+	}
+	if previous, err := s.repo.GetPurchaseByKey(ctx, user.ID, request.IdempotencyKey); err == nil {
+		return s.replayedPurchase(previous, request)
+	}
+
+	item, err := s.repo.GetShopItem(ctx, request.ItemID)
+	if err != nil || !item.IsAvailable(time.Now()) {
+		s.logger.Debug("Shop item is not available", "itemID", request.ItemID, "error", err)
+		cusErr := utils.NewErrorResponse(utils.ItemNotAvailable)
+		return cusErr.Error(), cusErr
+	}
+	price := item.PriceIn(request.Currency)
+	if price <= 0 {
+		cusErr := utils.NewErrorResponse(utils.CurrencyNotAccepted)
+		return cusErr.Error(), cusErr
+	}
+	purchase := &database.Purchase{
+		UserID:         user.ID,
+		ItemID:         item.ID,
+		Quantity:       request.Quantity,
+		Currency:       request.Currency,
+		Price:          price * request.Quantity,
+		IdempotencyKey: request.IdempotencyKey,
+	}
+	err = s.repo.Purchase(ctx, purchase, item, s.configs.StreakMaxFreezes)
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		// A concurrent request with the same key was recorded first
+		previous, err := s.repo.GetPurchaseByKey(ctx, user.ID, request.IdempotencyKey)
+		if err != nil {
+			s.logger.Error("Cannot get purchase", "error", err)
+			cusErr := utils.NewErrorResponse(utils.InternalServerError)
+			return cusErr.Error(), cusErr
+		}
+		return s.replayedPurchase(previous, request)
+	case errors.Is(err, database.ErrInsufficientScore):
+		cusErr := utils.NewErrorResponse(utils.InsufficientScore)
+		return cusErr.Error(), cusErr
+	case errors.Is(err, database.ErrPurchaseLimitReached):
+		cusErr := utils.NewErrorResponse(utils.PurchaseLimitReached)
+		return cusErr.Error(), cusErr
+	default:
+		s.logger.Error("Cannot purchase item", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("Item purchased", "userID", user.ID, "itemID", item.ID, "quantity", purchase.Quantity)
+	return newPurchaseResponse(purchase, false), nil
+}
+
+// GetInventory lists the items the user owns.
+func (s *userService) GetInventory(ctx context.Context) (interface{}, error) {
+	user, err := s.contextUserOf(ctx)
+	if err != nil {
+		return err.Error(), err
+	}
+	inventory, err := s.repo.GetInventory(ctx, user.ID)
+	if err != nil {
+		s.logger.Error("Cannot get inventory", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	response := make([]InventoryItemResponse, 0, len(inventory))
+	for _, owned := range inventory {
+		item, err := s.repo.GetShopItem(ctx, owned.ItemID)
+		if err != nil {
+			s.logger.Error("Cannot get shop item", "error", err, "itemID", owned.ItemID)
+			cusErr := utils.NewErrorResponse(utils.InternalServerError)
+			return cusErr.Error(), cusErr
+		}
+		response = append(response, InventoryItemResponse{
+			ItemID:   item.ID,
+			Name:     item.Name,
+			Kind:     item.Kind,
+			Quantity: owned.Quantity,
+		})
+	}
+	return response, nil
+}
+
+// InternalGetShopItems lists the shop catalog for internal services, including items
+// outside their availability window.
+func (s *userService) InternalGetShopItems(ctx context.Context, request *InternalGetShopItemsRequest) (interface{}, error) {
+	items, err := s.repo.GetShopItems(ctx, request.IncludeDeleted)
+	if err != nil {
+		s.logger.Error("Cannot get shop items", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	return items, nil
+}
+
+// InternalCreateShopItem adds an item to the shop catalog.
+func (s *userService) InternalCreateShopItem(ctx context.Context, request *ShopItemRequest) (interface{}, error) {
+	item, err := shopItemOf(request)
+	if err != nil {
+		return err.Error(), err
+	}
+	if err := s.repo.CreateShopItem(ctx, item); err != nil {
+		if strings.Contains(err.Error(), utils.PgDuplicateKeyMsg) {
+			cusErr := utils.NewErrorResponse(utils.Conflict)
+			return cusErr.Error(), cusErr
+		}
+		s.logger.Error("Cannot create shop item", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("Shop item created", "itemID", item.ID, "apiKeyID", ctx.Value(middleware.APIKeyIDKey{}))
+	return item, nil
+}
+
+// InternalUpdateShopItem replaces an item of the shop catalog. Past purchases keep the price
+// they were made at.
+func (s *userService) InternalUpdateShopItem(ctx context.Context, request *ShopItemRequest) (interface{}, error) {
+	item, err := shopItemOf(request)
+	if err != nil {
+		return err.Error(), err
+	}
+	if err := s.repo.UpdateShopItem(ctx, item); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			cusErr := utils.NewErrorResponse(utils.NotFound)
+			return cusErr.Error(), cusErr
+		}
+		s.logger.Error("Cannot update shop item", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("Shop item updated", "itemID", item.ID, "apiKeyID", ctx.Value(middleware.APIKeyIDKey{}))
+	updated, err := s.repo.GetShopItem(ctx, item.ID)
+	if err != nil {
+		s.logger.Error("Cannot get shop item", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	return updated, nil
+}
+
+// InternalDeleteShopItem removes an item from the shop. Owned items stay in the inventories.
+func (s *userService) InternalDeleteShopItem(ctx context.Context, request *InternalDeleteShopItemRequest) (string, error) {
+	if err := s.repo.DeleteShopItem(ctx, request.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			cusErr := utils.NewErrorResponse(utils.NotFound)
+			return cusErr.Error(), cusErr
+		}
+		s.logger.Error("Cannot delete shop item", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("Shop item deleted", "itemID", request.ID, "apiKeyID", ctx.Value(middleware.APIKeyIDKey{}))
+	return "shop item deleted.", nil
+}
+
+// replayedPurchase returns the purchase recorded with the idempotency key of the request,
+// the key must not be reused for a different purchase
+func (s *userService) replayedPurchase(purchase *database.Purchase, request *PurchaseItemRequest) (interface{}, error) {
+	if purchase.ItemID != request.ItemID || purchase.Currency != request.Currency || purchase.Quantity != request.Quantity {
+		s.logger.Error("Idempotency key reused for another purchase", "userID", purchase.UserID)
+		cusErr := utils.NewErrorResponse(utils.Conflict)
+		return cusErr.Error(), cusErr
+	}
+	return newPurchaseResponse(purchase, true), nil
+}
+
+// shopItemOf validates the shop item of the request
+func shopItemOf(request *ShopItemRequest) (*database.ShopItem, error) {
+	if request.WaterPrice+request.LightPrice+request.SeedPrice == 0 ||
+		(request.AvailableFrom != nil && request.AvailableUntil != nil && !request.AvailableUntil.After(*request.AvailableFrom)) {
+		return nil, utils.NewErrorResponse(utils.InvalidShopItem)
+	}
+	return &database.ShopItem{
+		ID:             request.ID,
+		Name:           strings.TrimSpace(request.Name),
+		Description:    request.Description,
+		Kind:           request.Kind,
+		WaterPrice:     request.WaterPrice,
+		LightPrice:     request.LightPrice,
+		SeedPrice:      request.SeedPrice,
+		AvailableFrom:  request.AvailableFrom,
+		AvailableUntil: request.AvailableUntil,
+		PerUserLimit:   request.PerUserLimit,
+	}, nil
+}
+
+func newShopItemResponse(item database.ShopItem) ShopItemResponse {
+	return ShopItemResponse{
+		ID:             item.ID,
+		Name:           item.Name,
+		Description:    item.Description,
+		Kind:           item.Kind,
+		WaterPrice:     item.WaterPrice,
+		LightPrice:     item.LightPrice,
+		SeedPrice:      item.SeedPrice,
+		AvailableUntil: item.AvailableUntil,
+		PerUserLimit:   item.PerUserLimit,
+	}
+}
+
+func newPurchaseResponse(purchase *database.Purchase, replayed bool) PurchaseResponse {
+	return PurchaseResponse{
+		ID:        purchase.ID,
+		ItemID:    purchase.ItemID,
+		Quantity:  purchase.Quantity,
+		Currency:  purchase.Currency,
+		Price:     purchase.Price,
+		Replayed:  replayed,
+		CreatedAt: purchase.CreatedAt,
+	}
+}
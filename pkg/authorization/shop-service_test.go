@@ -0,0 +1,197 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"context"
+	"database/sql"
+	"github.com/satori/go.uuid"
+	"testing"
+	"time"
+)
+
+func (repo *fakeRepo) GetShopItem(ctx context.Context, id string) (*database.ShopItem, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	item, ok := repo.shopItems[id]
+	if !ok {
+		return &database.ShopItem{}, sql.ErrNoRows
+	}
+	copied := *item
+	return &copied, nil
+}
+
+func (repo *fakeRepo) GetPurchaseByKey(ctx context.Context, userID string, key string) (*database.Purchase, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, purchase := range repo.purchases {
+		if purchase.UserID == userID && purchase.IdempotencyKey == key {
+			copied := purchase
+			return &copied, nil
+		}
+	}
+	return &database.Purchase{}, sql.ErrNoRows
+}
+
+func (repo *fakeRepo) Purchase(ctx context.Context, purchase *database.Purchase, item *database.ShopItem, maxFreezes int) error {
+	if repo.beforeWrite != nil {
+		repo.beforeWrite()
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	bought := purchase.Quantity
+	for _, recorded := range repo.purchases {
+		if recorded.UserID != purchase.UserID {
+			continue
+		}
+		if recorded.IdempotencyKey == purchase.IdempotencyKey {
+			return sql.ErrNoRows
+		}
+		if recorded.ItemID == purchase.ItemID {
+			bought += recorded.Quantity
+		}
+	}
+	if item.PerUserLimit > 0 && bought > item.PerUserLimit {
+		return database.ErrPurchaseLimitReached
+	}
+	if err := repo.spendScore(purchase.UserID, purchase.Cost()); err != nil {
+		return err
+	}
+	purchase.ID = uuid.NewV4().String()
+	purchase.CreatedAt = time.Now()
+	repo.purchases = append(repo.purchases, *purchase)
+	if item.Kind == database.ShopItemStreakFreeze {
+		streak, ok := repo.streaks[purchase.UserID]
+		if !ok {
+			streak = &database.Streak{UserID: purchase.UserID}
+		}
+		if streak.Freezes+purchase.Quantity > maxFreezes {
+			// The transaction rolls back the debit and the purchase
+			repo.addScore(purchase.UserID, purchase.Cost().WaterScore, purchase.Cost().LightScore, purchase.Cost().SeedScore)
+			repo.purchases = repo.purchases[:len(repo.purchases)-1]
+			return database.ErrPurchaseLimitReached
+		}
+		repo.streaks[purchase.UserID] = streak
+		streak.Freezes += purchase.Quantity
+	} else {
+		repo.inventory[purchase.UserID+"/"+purchase.ItemID] += purchase.Quantity
+	}
+	return nil
+}
+
+func TestPurchaseItemReplay(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	repo.scores[user.ID] = &database.EarnScore{UserID: user.ID, WaterScore: 10}
+	repo.shopItems["pot"] = &database.ShopItem{ID: "pot", Name: "Pot", Kind: database.ShopItemPot, WaterPrice: 3, PerUserLimit: 3}
+	repo.shopItems["freeze"] = &database.ShopItem{ID: "freeze", Name: "Freeze", Kind: database.ShopItemStreakFreeze, WaterPrice: 2}
+
+	request := &PurchaseItemRequest{ItemID: "pot", Currency: database.CurrencyWater, IdempotencyKey: "key-1"}
+	first, err := s.PurchaseItem(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purchase := first.(PurchaseResponse); purchase.Replayed || purchase.Quantity != 1 || purchase.Price != 3 {
+		t.Fatalf("got %+v, want a new purchase of one pot", purchase)
+	}
+	// Retrying returns the recorded purchase and debits nothing
+	replayed, err := s.PurchaseItem(ctx, &PurchaseItemRequest{ItemID: "pot", Currency: database.CurrencyWater, IdempotencyKey: "key-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purchase := replayed.(PurchaseResponse); !purchase.Replayed || purchase.ID != first.(PurchaseResponse).ID {
+		t.Fatalf("got %+v, want the first purchase replayed", purchase)
+	}
+	if repo.scores[user.ID].WaterScore != 7 || repo.inventory[user.ID+"/pot"] != 1 || len(repo.purchases) != 1 {
+		t.Fatalf("got %d water, %d pots and %d purchases, want the pot bought once",
+			repo.scores[user.ID].WaterScore, repo.inventory[user.ID+"/pot"], len(repo.purchases))
+	}
+	// The key cannot be reused for another purchase
+	for _, other := range []*PurchaseItemRequest{
+		{ItemID: "freeze", Currency: database.CurrencyWater, IdempotencyKey: "key-1"},
+		{ItemID: "pot", Currency: database.CurrencyWater, Quantity: 2, IdempotencyKey: "key-1"},
+		{ItemID: "pot", Currency: database.CurrencySeed, IdempotencyKey: "key-1"},
+	} {
+		if _, err := s.PurchaseItem(ctx, other); errorType(err) != utils.Conflict {
+			t.Fatalf("%+v: got %v, want conflict", other, err)
+		}
+	}
+}
+
+func TestPurchaseItemConcurrentReplay(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	repo.scores[user.ID] = &database.EarnScore{UserID: user.ID, WaterScore: 10}
+	repo.shopItems["pot"] = &database.ShopItem{ID: "pot", Name: "Pot", Kind: database.ShopItemPot, WaterPrice: 3, PerUserLimit: 3}
+
+	// A request with the same key is recorded between the lookup and the purchase of this one
+	repo.beforeWrite = func() {
+		repo.beforeWrite = nil
+		if _, err := s.PurchaseItem(ctx, &PurchaseItemRequest{ItemID: "pot", Currency: database.CurrencyWater, IdempotencyKey: "key-1"}); err != nil {
+			t.Error(err)
+		}
+	}
+	response, err := s.PurchaseItem(ctx, &PurchaseItemRequest{ItemID: "pot", Currency: database.CurrencyWater, IdempotencyKey: "key-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !response.(PurchaseResponse).Replayed || repo.scores[user.ID].WaterScore != 7 {
+		t.Fatalf("got %+v with %d water left, want the concurrent purchase replayed", response, repo.scores[user.ID].WaterScore)
+	}
+}
+
+func TestPurchaseItemErrors(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	repo.scores[user.ID] = &database.EarnScore{UserID: user.ID, WaterScore: 10}
+	repo.shopItems["pot"] = &database.ShopItem{ID: "pot", Name: "Pot", Kind: database.ShopItemPot, WaterPrice: 3, PerUserLimit: 3}
+	repo.shopItems["freeze"] = &database.ShopItem{ID: "freeze", Name: "Freeze", Kind: database.ShopItemStreakFreeze, WaterPrice: 2}
+	deletedAt := time.Now()
+	repo.shopItems["retired"] = &database.ShopItem{ID: "retired", Kind: database.ShopItemTheme, WaterPrice: 1, DeletedAt: &deletedAt}
+
+	tests := []struct {
+		name    string
+		request PurchaseItemRequest
+		want    utils.ErrorType
+	}{
+		{"unknown item", PurchaseItemRequest{ItemID: "unknown", Currency: database.CurrencyWater}, utils.ItemNotAvailable},
+		{"deleted item", PurchaseItemRequest{ItemID: "retired", Currency: database.CurrencyWater}, utils.ItemNotAvailable},
+		{"no price in currency", PurchaseItemRequest{ItemID: "pot", Currency: database.CurrencyLight}, utils.CurrencyNotAccepted},
+		{"insufficient score", PurchaseItemRequest{ItemID: "freeze", Currency: database.CurrencyWater, Quantity: 6}, utils.InsufficientScore},
+	}
+	for i, test := range tests {
+		test.request.IdempotencyKey = string(rune('a' + i))
+		if _, err := s.PurchaseItem(ctx, &test.request); errorType(err) != test.want {
+			t.Fatalf("%s: got %v, want %v", test.name, err, utils.NewErrorResponse(test.want))
+		}
+	}
+	if repo.scores[user.ID].WaterScore != 10 || len(repo.purchases) != 0 {
+		t.Fatalf("got %d water and %d purchases, want nothing bought", repo.scores[user.ID].WaterScore, len(repo.purchases))
+	}
+
+	repo.scores[user.ID].WaterScore = 100
+	if _, err := s.PurchaseItem(ctx, &PurchaseItemRequest{ItemID: "pot", Currency: database.CurrencyWater, Quantity: 3, IdempotencyKey: "limit-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.PurchaseItem(ctx, &PurchaseItemRequest{ItemID: "pot", Currency: database.CurrencyWater, IdempotencyKey: "limit-2"}); errorType(err) != utils.PurchaseLimitReached {
+		t.Fatalf("got %v, want purchase limit reached", err)
+	}
+}
+
+func TestPurchaseStreakFreeze(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	repo.scores[user.ID] = &database.EarnScore{UserID: user.ID, WaterScore: 10}
+	repo.shopItems["freeze"] = &database.ShopItem{ID: "freeze", Name: "Freeze", Kind: database.ShopItemStreakFreeze, WaterPrice: 2}
+
+	if _, err := s.PurchaseItem(ctx, &PurchaseItemRequest{ItemID: "freeze", Currency: database.CurrencyWater, Quantity: 2, IdempotencyKey: "key-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if streak := repo.streaks[user.ID]; streak == nil || streak.Freezes != 2 || repo.inventory[user.ID+"/freeze"] != 0 {
+		t.Fatalf("got streak %+v, want the freezes added to the streak", streak)
+	}
+	// Bought freezes share the cap of earned ones
+	if _, err := s.PurchaseItem(ctx, &PurchaseItemRequest{ItemID: "freeze", Currency: database.CurrencyWater, IdempotencyKey: "key-2"}); errorType(err) != utils.PurchaseLimitReached {
+		t.Fatalf("got %v, want purchase limit reached", err)
+	}
+	if repo.streaks[user.ID].Freezes != 2 || repo.scores[user.ID].WaterScore != 6 || len(repo.purchases) != 1 {
+		t.Fatalf("got %d freezes, %d water and %d purchases, want the purchase over the cap undone",
+			repo.streaks[user.ID].Freezes, repo.scores[user.ID].WaterScore, len(repo.purchases))
+	}
+}
@@ -0,0 +1,114 @@
+package authorization
+
+import (
+	utils "LoveLetterProject/internal"
+	"fmt"
+	"github.com/hashicorp/go-hclog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SMSService represents the interface for our sms service.
+type SMSService interface {
+	SendSMS(to string, body string) error
+}
+
+// NewSMSService returns the sms service of the configured provider.
+func NewSMSService(logger hclog.Logger, configs *utils.Configurations) SMSService {
+	switch configs.SMSProvider {
+	case "twilio":
+		return NewTwilioSMSService(logger, configs)
+	default:
+		logger.Warn("sms are not delivered, using the fake sms service", "provider", configs.SMSProvider)
+		return NewFakeSMSService(logger)
+	}
+}
+
+// TwilioSMSService is the twilio implementation of our SMSService.
+type TwilioSMSService struct {
+	logger  hclog.Logger
+	configs *utils.Configurations
+	client  *http.Client
+}
+
+// NewTwilioSMSService returns a new instance of TwilioSMSService
+func NewTwilioSMSService(logger hclog.Logger, configs *utils.Configurations) *TwilioSMSService {
+	return &TwilioSMSService{logger, configs, &http.Client{Timeout: 10 * time.Second}}
+}
+
+// SendSMS sends the message through the twilio messages api.
+func (ss *TwilioSMSService) SendSMS(to string, body string) error {
+	endpoint := fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json", ss.configs.TwilioAccountSID)
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", ss.configs.TwilioFromNumber)
+	form.Set("Body", body)
+	request, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	request.SetBasicAuth(ss.configs.TwilioAccountSID, ss.configs.TwilioAuthToken)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response, err := ss.client.Do(request)
+	if err != nil {
+		ss.logger.Error("unable to send sms", "error", err)
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		ss.logger.Error("unable to send sms", "status code", response.StatusCode)
+		return fmt.Errorf("sms provider returned status %d", response.StatusCode)
+	}
+	ss.logger.Info("sms sent successfully", "sent status code", response.StatusCode)
+	return nil
+}
+
+// SMS is a message recorded by the FakeSMSService.
+type SMS struct {
+	To     string
+	Body   string
+	SentAt time.Time
+}
+
+// FakeSMSService records messages instead of delivering them, for local development and tests.
+type FakeSMSService struct {
+	logger   hclog.Logger
+	mu       sync.Mutex
+	messages []SMS
+}
+
+// NewFakeSMSService returns a new instance of FakeSMSService
+func NewFakeSMSService(logger hclog.Logger) *FakeSMSService {
+	return &FakeSMSService{logger: logger}
+}
+
+// SendSMS records the message.
+func (ss *FakeSMSService) SendSMS(to string, body string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.messages = append(ss.messages, SMS{To: to, Body: body, SentAt: time.Now()})
+	ss.logger.Debug("sms recorded", "to", to, "body", body)
+	return nil
+}
+
+// Messages returns the recorded messages in the order they were sent.
+func (ss *FakeSMSService) Messages() []SMS {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return append([]SMS(nil), ss.messages...)
+}
+
+// LastMessage returns the latest message sent to the number.
+func (ss *FakeSMSService) LastMessage(to string) (SMS, bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	for i := len(ss.messages) - 1; i >= 0; i-- {
+		if ss.messages[i].To == to {
+			return ss.messages[i], true
+		}
+	}
+	return SMS{}, false
+}
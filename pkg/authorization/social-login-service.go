@@ -0,0 +1,125 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"LoveLetterProject/pkg/oidc"
+	"context"
+	"errors"
+	"strings"
+)
+
+// SocialLogin signs in with an ID token from a configured OpenID Connect provider.
+// The provider account is linked to the passwordless user with the same verified
+// email, or a new user without a password is created for a verified email.
+func (s *userService) SocialLogin(ctx context.Context, request *SocialLoginRequest) (interface{}, error) {
+	claims, err := s.oidcVerifier.Verify(ctx, request.Provider, request.IDToken)
+	if err != nil {
+		s.logger.Error("Cannot verify id token", "provider", request.Provider, "error", err)
+		if errors.Is(err, oidc.ErrUnknownProvider) {
+			cusErr := utils.NewErrorResponse(utils.UnknownProvider)
+			return cusErr.Error(), cusErr
+		}
+		cusErr := utils.NewErrorResponse(utils.InvalidIDToken)
+		return cusErr.Error(), cusErr
+	}
+
+	user, err := s.userForIdentity(ctx, request.Provider, claims)
+	if err != nil {
+		return err.Error(), err
+	}
+	if user.Banned {
+		s.logger.Error("User is banned", "userID", user.ID)
+		cusErr := utils.NewErrorResponse(utils.Forbidden)
+		return cusErr.Error(), cusErr
+	}
+
+	accessToken, refreshToken, err := s.generateTokens(user)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	s.logger.Debug("successfully signed in with provider", "provider", request.Provider, "userID", user.ID)
+	return LoginResponse{
+		Email:        user.Email,
+		Username:     user.Username,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		Verified:     user.Verified,
+	}, nil
+}
+
+// userForIdentity returns the user linked to the provider subject, linking or creating one on first sign in
+func (s *userService) userForIdentity(ctx context.Context, provider string, claims *oidc.IDTokenClaims) (*database.User, error) {
+	identity, err := s.repo.GetIdentity(ctx, provider, claims.Subject)
+	if err == nil {
+		user, err := s.repo.GetUserByID(ctx, identity.UserID)
+		if err != nil {
+			s.logger.Error("Error getting user of identity", "error", err)
+			return nil, utils.NewErrorResponse(utils.InternalServerError)
+		}
+		return user, nil
+	}
+	if !strings.Contains(err.Error(), utils.PgNoRowsMsg) {
+		s.logger.Error("Error getting identity", "error", err)
+		return nil, utils.NewErrorResponse(utils.InternalServerError)
+	}
+
+	email := utils.NormalizeEmail(claims.Email)
+	if email == "" {
+		s.logger.Error("Id token has no email", "provider", provider)
+		return nil, utils.NewErrorResponse(utils.MailRequired)
+	}
+	identity = &database.Identity{
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    email,
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err == nil {
+		// Never merge into a password account on email alone, whoever controls the
+		// provider account would take it over. The owner links it from the settings.
+		// Accounts without a password are merged only when both sides verified the email.
+		if user.Password != "" || !user.Verified || !bool(claims.EmailVerified) {
+			s.logger.Error("Provider email matches an existing user", "provider", provider, "userID", user.ID)
+			return nil, utils.NewErrorResponse(utils.IdentityLinkRequired)
+		}
+		identity.UserID = user.ID
+		if err := s.repo.CreateIdentity(ctx, identity); err != nil {
+			s.logger.Error("Error linking identity", "error", err)
+			return nil, utils.NewErrorResponse(utils.InternalServerError)
+		}
+		return user, nil
+	}
+	if !strings.Contains(err.Error(), utils.PgNoRowsMsg) {
+		s.logger.Error("Error getting user", "error", err)
+		return nil, utils.NewErrorResponse(utils.InternalServerError)
+	}
+
+	// An unverified email would squat the address, its owner could not sign up any more
+	if !bool(claims.EmailVerified) {
+		s.logger.Error("Provider email is not verified", "provider", provider)
+		return nil, utils.NewErrorResponse(utils.MailRequired)
+	}
+	if s.disposableDomains.IsDisposable(email) {
+		s.logger.Error("Disposable email rejected", "provider", provider)
+		return nil, utils.NewErrorResponse(utils.DisposableEmail)
+	}
+
+	// Social accounts have no password until the user sets one
+	user = &database.User{
+		Email:     email,
+		Password:  "",
+		TokenHash: utils.GenerateRandomString(15),
+		Verified:  true,
+	}
+	if err := s.repo.CreateUserWithIdentity(ctx, user, identity); err != nil {
+		s.logger.Error("Error creating user with identity", "error", err)
+		if strings.Contains(err.Error(), utils.PgDuplicateKeyMsg) {
+			return nil, utils.NewErrorResponse(utils.ExistUser)
+		}
+		return nil, utils.NewErrorResponse(utils.InternalServerError)
+	}
+	return user, nil
+}
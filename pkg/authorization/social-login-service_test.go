@@ -0,0 +1,206 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"LoveLetterProject/pkg/authorization/middleware"
+	"LoveLetterProject/pkg/oidc"
+	"LoveLetterProject/pkg/oidc/oidctest"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/satori/go.uuid"
+	"testing"
+)
+
+func (repo *fakeRepo) GetIdentity(ctx context.Context, provider string, subject string) (*database.Identity, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, identity := range repo.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			copied := *identity
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (repo *fakeRepo) CreateIdentity(ctx context.Context, identity *database.Identity) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, other := range repo.identities {
+		if other.Provider == identity.Provider && other.Subject == identity.Subject {
+			return errors.New(utils.PgDuplicateKeyMsg)
+		}
+	}
+	identity.ID = uuid.NewV4().String()
+	copied := *identity
+	repo.identities[identity.ID] = &copied
+	return nil
+}
+
+func (repo *fakeRepo) CreateUserWithIdentity(ctx context.Context, user *database.User, identity *database.Identity) error {
+	repo.mu.Lock()
+	user.ID = uuid.NewV4().String()
+	copied := *user
+	repo.users[user.ID] = &copied
+	repo.mu.Unlock()
+	identity.UserID = user.ID
+	return repo.CreateIdentity(ctx, identity)
+}
+
+func (repo *fakeRepo) GetIdentitiesByUserID(ctx context.Context, userID string) ([]database.Identity, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	identities := []database.Identity{}
+	for _, identity := range repo.identities {
+		if identity.UserID == userID {
+			identities = append(identities, *identity)
+		}
+	}
+	return identities, nil
+}
+
+// newSocialLoginTest returns a service trusting a local IdP as the provider "test".
+func newSocialLoginTest(t *testing.T) (*userService, *fakeRepo, *oidctest.IdP) {
+	t.Helper()
+	idp, server, err := oidctest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+	repo := newFakeRepo()
+	s := newTestService(t, testConfigs(t), repo)
+	s.oidcVerifier = oidc.NewVerifier([]oidc.Provider{idp.Provider("test", "our-client")}, server.Client())
+	return s, repo, idp
+}
+
+func mintIDToken(t *testing.T, idp *oidctest.IdP, subject string, email string, verified bool) string {
+	t.Helper()
+	token, err := idp.MintIDToken(oidctest.TokenOptions{
+		Subject:       subject,
+		Email:         email,
+		EmailVerified: verified,
+		Audience:      "our-client",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestSocialLoginCreatesUser(t *testing.T) {
+	s, repo, idp := newSocialLoginTest(t)
+	ctx := context.Background()
+
+	token := mintIDToken(t, idp, "subject-1", "New@Example.com", true)
+	response, err := s.SocialLogin(ctx, &SocialLoginRequest{Provider: "test", IDToken: token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if login := response.(LoginResponse); login.Email != "new@example.com" || !login.Verified {
+		t.Fatalf("got %+v, want a verified user with the normalized email", login)
+	}
+	// Signing in again uses the linked identity
+	if _, err := s.SocialLogin(ctx, &SocialLoginRequest{Provider: "test", IDToken: token}); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.users) != 1 || len(repo.identities) != 1 {
+		t.Fatalf("got %d users and %d identities, want one each", len(repo.users), len(repo.identities))
+	}
+}
+
+func TestSocialLoginRejectsNewAccounts(t *testing.T) {
+	s, repo, idp := newSocialLoginTest(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		email    string
+		verified bool
+		want     utils.ErrorType
+	}{
+		{"unverified email", "new@example.com", false, utils.MailRequired},
+		{"disposable email", "new@throwaway.example", true, utils.DisposableEmail},
+		{"disposable subdomain", "new@mail.throwaway.example", true, utils.DisposableEmail},
+	}
+	for i, test := range tests {
+		token := mintIDToken(t, idp, "subject-"+string(rune('a'+i)), test.email, test.verified)
+		if _, err := s.SocialLogin(ctx, &SocialLoginRequest{Provider: "test", IDToken: token}); errorType(err) != test.want {
+			t.Fatalf("%s: got %v, want %v", test.name, err, utils.NewErrorResponse(test.want))
+		}
+	}
+	if len(repo.users) != 0 || len(repo.identities) != 0 {
+		t.Fatalf("got %d users and %d identities, want none", len(repo.users), len(repo.identities))
+	}
+}
+
+func TestSocialLoginMerge(t *testing.T) {
+	tests := []struct {
+		name          string
+		password      string
+		userVerified  bool
+		emailVerified bool
+		merged        bool
+	}{
+		{"verified passwordless account", "", true, true, true},
+		{"password account", "Correct-Password1", true, true, false},
+		{"unverified account", "", false, true, false},
+		{"unverified provider email", "", true, false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, repo, idp := newSocialLoginTest(t)
+			user := repo.addUser(t, s, "owner@example.com", test.password)
+			user.Verified = test.userVerified
+
+			token := mintIDToken(t, idp, "subject-1", "owner@example.com", test.emailVerified)
+			response, err := s.SocialLogin(context.Background(), &SocialLoginRequest{Provider: "test", IDToken: token})
+			if !test.merged {
+				if errorType(err) != utils.IdentityLinkRequired {
+					t.Fatalf("got %v, want identity link required", err)
+				}
+				if len(repo.identities) != 0 {
+					t.Fatal("identity was linked")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.(LoginResponse).Email != user.Email || len(repo.identities) != 1 {
+				t.Fatalf("got %+v, want the existing user", response)
+			}
+		})
+	}
+}
+
+func TestLinkIdentity(t *testing.T) {
+	s, repo, idp := newSocialLoginTest(t)
+	owner := repo.addUser(t, s, "owner@example.com", "Correct-Password1")
+	other := repo.addUser(t, s, "other@example.com", "Correct-Password1")
+	token := mintIDToken(t, idp, "subject-1", "owner@example.com", true)
+
+	// The owner links the provider account from the settings
+	ownerCtx := context.WithValue(context.Background(), middleware.UserIDKey{}, owner.ID)
+	response, err := s.LinkIdentity(ownerCtx, &LinkIdentityRequest{Provider: "test", IDToken: token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if methods := response.(SignInMethodsResponse).Methods; len(methods) != 2 {
+		t.Fatalf("got %+v, want the password and the identity", methods)
+	}
+	login, err := s.SocialLogin(context.Background(), &SocialLoginRequest{Provider: "test", IDToken: token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if login.(LoginResponse).Email != owner.Email {
+		t.Fatalf("got %+v, want the owner", login)
+	}
+
+	// Nobody else can take the linked identity
+	otherCtx := context.WithValue(context.Background(), middleware.UserIDKey{}, other.ID)
+	if _, err := s.LinkIdentity(otherCtx, &LinkIdentityRequest{Provider: "test", IDToken: token}); errorType(err) != utils.IdentityAlreadyLinked {
+		t.Fatalf("got %v, want identity already linked", err)
+	}
+}
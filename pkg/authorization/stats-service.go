@@ -0,0 +1,149 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"LoveLetterProject/pkg/authorization/middleware"
+	"context"
+	"errors"
+	"time"
+)
+
+// maxFocusStatsBuckets limits the number of buckets of one statistics request
+const maxFocusStatsBuckets = 366
+
+// statsDateLayout is the layout of the dates of a statistics request and of the bucket starts
+const statsDateLayout = "2006-01-02"
+
+// GetFocusStats returns the focus minutes, sessions and earned scores of the user bucketed by
+// day, week or month in the user's timezone. Buckets without sessions are returned with zeros
+// so the range can be charted as is.
+func (s *userService) GetFocusStats(ctx context.Context, request *GetFocusStatsRequest) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+
+	location, err := s.userLocation(ctx, user.ID, request.Timezone)
+	if err != nil {
+		s.logger.Error("Invalid timezone", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InvalidTimezone)
+		return cusErr.Error(), cusErr
+	}
+
+	from, errFrom := time.ParseInLocation(statsDateLayout, request.From, location)
+	to, errTo := time.ParseInLocation(statsDateLayout, request.To, location)
+	if errFrom != nil || errTo != nil || to.Before(from) {
+		s.logger.Error("Invalid statistics range", "from", request.From, "to", request.To)
+		cusErr := utils.NewErrorResponse(utils.InvalidStatsRange)
+		return cusErr.Error(), cusErr
+	}
+	// The range is widened to whole buckets so the first and last bucket are complete
+	starts := []time.Time{}
+	start, end := bucketStart(from, request.Period), nextBucket(bucketStart(to, request.Period), request.Period)
+	for bucket := start; bucket.Before(end); bucket = nextBucket(bucket, request.Period) {
+		if len(starts) == maxFocusStatsBuckets {
+			s.logger.Error("Statistics range is too long", "from", request.From, "to", request.To)
+			cusErr := utils.NewErrorResponse(utils.InvalidStatsRange)
+			return cusErr.Error(), cusErr
+		}
+		starts = append(starts, bucket)
+	}
+
+	stats, err := s.repo.GetFocusStats(ctx, user.ID, request.Period, location.String(), start, end)
+	if err != nil {
+		s.logger.Error("Cannot get focus stats", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	statsByStart := make(map[string]database.FocusStats, len(stats))
+	for _, stat := range stats {
+		statsByStart[stat.Bucket.Format(statsDateLayout)] = stat
+	}
+
+	response := FocusStatsResponse{
+		Period:   request.Period,
+		Timezone: location.String(),
+		Buckets:  make([]FocusStatsBucket, 0, len(starts)),
+	}
+	for _, bucket := range starts {
+		key := bucket.Format(statsDateLayout)
+		stat := statsByStart[key]
+		response.Buckets = append(response.Buckets, FocusStatsBucket{
+			Start:      key,
+			Minutes:    stat.Minutes,
+			Sessions:   stat.Sessions,
+			WaterScore: stat.WaterScore,
+			LightScore: stat.LightScore,
+			SeedScore:  stat.SeedScore,
+		})
+		response.Total.Minutes += stat.Minutes
+		response.Total.Sessions += stat.Sessions
+		response.Total.WaterScore += stat.WaterScore
+		response.Total.LightScore += stat.LightScore
+		response.Total.SeedScore += stat.SeedScore
+	}
+	if request.ByTag {
+		response.Tags, err = s.tagStatsOf(ctx, user.ID, start, end)
+		if err != nil {
+			cusErr := utils.NewErrorResponse(utils.InternalServerError)
+			return cusErr.Error(), cusErr
+		}
+	}
+	return response, nil
+}
+
+// userLocation returns the given timezone, or the profile timezone of the user when empty.
+// Users without a profile timezone are reported in UTC.
+func (s *userService) userLocation(ctx context.Context, userID string, timezone string) (*time.Location, error) {
+	if timezone == "" {
+		if profile, err := s.repo.GetProfileByID(ctx, userID); err == nil {
+			timezone = profile.Timezone
+		}
+	}
+	if timezone == "" {
+		return time.UTC, nil
+	}
+	return loadTimezone(timezone)
+}
+
+// loadTimezone loads an IANA timezone. The server local timezone is not accepted
+// as it differs between deployments.
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, errors.New("unknown time zone " + name)
+	}
+	return time.LoadLocation(name)
+}
+
+// bucketStart returns the start of the day, week (starting on Monday, as date_trunc) or month of t
+func bucketStart(t time.Time, period string) time.Time {
+	year, month, day := t.Date()
+	switch period {
+	case database.StatsPeriodWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+	case database.StatsPeriodMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// nextBucket returns the start of the bucket following the one starting at t
+func nextBucket(t time.Time, period string) time.Time {
+	switch period {
+	case database.StatsPeriodWeek:
+		return t.AddDate(0, 0, 7)
+	case database.StatsPeriodMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,131 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skip(err)
+	}
+	return location
+}
+
+func TestBucketsAcrossDST(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	tests := []struct {
+		name   string
+		at     time.Time
+		period string
+		start  time.Time
+		next   time.Time
+	}{
+		// Clocks go forward on 8 March 2026, the day is 23 hours long
+		{"spring forward day", time.Date(2026, 3, 8, 15, 0, 0, 0, newYork), database.StatsPeriodDay,
+			time.Date(2026, 3, 8, 0, 0, 0, 0, newYork), time.Date(2026, 3, 9, 0, 0, 0, 0, newYork)},
+		// Clocks go back on 1 November 2026, the day is 25 hours long
+		{"fall back day", time.Date(2026, 11, 1, 23, 30, 0, 0, newYork), database.StatsPeriodDay,
+			time.Date(2026, 11, 1, 0, 0, 0, 0, newYork), time.Date(2026, 11, 2, 0, 0, 0, 0, newYork)},
+		{"week over spring forward", time.Date(2026, 3, 8, 12, 0, 0, 0, newYork), database.StatsPeriodWeek,
+			time.Date(2026, 3, 2, 0, 0, 0, 0, newYork), time.Date(2026, 3, 9, 0, 0, 0, 0, newYork)},
+		{"week starting on monday", time.Date(2026, 3, 9, 0, 0, 0, 0, newYork), database.StatsPeriodWeek,
+			time.Date(2026, 3, 9, 0, 0, 0, 0, newYork), time.Date(2026, 3, 16, 0, 0, 0, 0, newYork)},
+		{"month over spring forward", time.Date(2026, 3, 31, 23, 59, 0, 0, newYork), database.StatsPeriodMonth,
+			time.Date(2026, 3, 1, 0, 0, 0, 0, newYork), time.Date(2026, 4, 1, 0, 0, 0, 0, newYork)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start := bucketStart(test.at, test.period)
+			if !start.Equal(test.start) {
+				t.Fatalf("got start %v, want %v", start, test.start)
+			}
+			if next := nextBucket(start, test.period); !next.Equal(test.next) {
+				t.Fatalf("got next %v, want %v", next, test.next)
+			}
+		})
+	}
+}
+
+func TestGetFocusStats(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	newYork := mustLoadLocation(t, "America/New_York")
+	repo.timezones[user.ID] = "America/New_York"
+	for _, at := range []time.Time{
+		time.Date(2026, 3, 7, 23, 30, 0, 0, newYork), // 8 March in UTC
+		time.Date(2026, 3, 8, 1, 30, 0, 0, newYork),  // before the clocks go forward
+		time.Date(2026, 3, 8, 3, 30, 0, 0, newYork),  // after the clocks went forward
+		time.Date(2026, 3, 10, 0, 15, 0, 0, newYork), // 10 March 04:15 in UTC
+	} {
+		repo.addSession(user.ID, 30, at)
+	}
+
+	response, err := s.GetFocusStats(ctx, &GetFocusStatsRequest{Period: database.StatsPeriodDay, From: "2026-03-07", To: "2026-03-10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := response.(FocusStatsResponse)
+	want := []FocusStatsBucket{
+		{Start: "2026-03-07", Minutes: 30, Sessions: 1},
+		{Start: "2026-03-08", Minutes: 60, Sessions: 2},
+		{Start: "2026-03-09"},
+		{Start: "2026-03-10", Minutes: 30, Sessions: 1},
+	}
+	if stats.Timezone != "America/New_York" || len(stats.Buckets) != len(want) {
+		t.Fatalf("got %+v, want %d days in the profile timezone", stats, len(want))
+	}
+	for i := range want {
+		if stats.Buckets[i] != want[i] {
+			t.Fatalf("bucket %d: got %+v, want %+v", i, stats.Buckets[i], want[i])
+		}
+	}
+	if stats.Total.Minutes != 120 || stats.Total.Sessions != 4 {
+		t.Fatalf("got total %+v, want 120 minutes in 4 sessions", stats.Total)
+	}
+
+	// The same sessions in Tokyo fall on later days, and a week bucket covers the whole week
+	response, err = s.GetFocusStats(ctx, &GetFocusStatsRequest{Period: database.StatsPeriodDay, From: "2026-03-08", To: "2026-03-10", Timezone: "Asia/Tokyo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats = response.(FocusStatsResponse)
+	if got := []int{stats.Buckets[0].Sessions, stats.Buckets[1].Sessions, stats.Buckets[2].Sessions}; got[0] != 3 || got[1] != 0 || got[2] != 1 {
+		t.Fatalf("got sessions per day %v in Tokyo, want [3 0 1]", got)
+	}
+	response, err = s.GetFocusStats(ctx, &GetFocusStatsRequest{Period: database.StatsPeriodWeek, From: "2026-03-04", To: "2026-03-04"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats = response.(FocusStatsResponse)
+	if len(stats.Buckets) != 1 || stats.Buckets[0].Start != "2026-03-02" || stats.Buckets[0].Sessions != 3 {
+		t.Fatalf("got %+v, want the week from Monday 2 March with 3 sessions", stats.Buckets)
+	}
+}
+
+func TestGetFocusStatsErrors(t *testing.T) {
+	s, _, _, ctx := newUserTest(t)
+	tests := []struct {
+		name    string
+		request GetFocusStatsRequest
+		want    utils.ErrorType
+	}{
+		{"unknown timezone", GetFocusStatsRequest{Period: database.StatsPeriodDay, From: "2026-03-01", To: "2026-03-02", Timezone: "Mars/Olympus"}, utils.InvalidTimezone},
+		{"server timezone", GetFocusStatsRequest{Period: database.StatsPeriodDay, From: "2026-03-01", To: "2026-03-02", Timezone: "Local"}, utils.InvalidTimezone},
+		{"invalid date", GetFocusStatsRequest{Period: database.StatsPeriodDay, From: "2026-03-32", To: "2026-04-02"}, utils.InvalidStatsRange},
+		{"reversed range", GetFocusStatsRequest{Period: database.StatsPeriodDay, From: "2026-03-02", To: "2026-03-01"}, utils.InvalidStatsRange},
+		{"too many buckets", GetFocusStatsRequest{Period: database.StatsPeriodDay, From: "2025-01-01", To: "2026-01-02"}, utils.InvalidStatsRange},
+	}
+	for _, test := range tests {
+		if _, err := s.GetFocusStats(ctx, &test.request); errorType(err) != test.want {
+			t.Fatalf("%s: got %v, want %v", test.name, err, utils.NewErrorResponse(test.want))
+		}
+	}
+	// A year of months is far below the limit
+	if _, err := s.GetFocusStats(ctx, &GetFocusStatsRequest{Period: database.StatsPeriodMonth, From: "2025-01-01", To: "2026-01-01"}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,119 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal/database"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// creditStreak counts today towards the streak of the user once the focus minutes of today,
+// in the user's timezone, reach the daily threshold. Missed days since the last counted day
+// are covered by freeze days when there are enough of them, otherwise the streak restarts.
+func (s *userService) creditStreak(ctx context.Context, userID string) error {
+	location, err := s.userLocation(ctx, userID, "")
+	if err != nil {
+		// A timezone the server does not know falls back to UTC rather than losing the streak
+		s.logger.Error("Invalid profile timezone", "error", err, "userID", userID)
+		location = time.UTC
+	}
+	now := time.Now().In(location)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	today := streakDay(now)
+
+	streak, err := s.streakOf(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if streak.LastDay != nil && !streak.LastDay.Before(today) {
+		return nil
+	}
+	minutes, err := s.repo.GetFocusMinutes(ctx, userID, dayStart, dayStart.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	if minutes < s.configs.StreakDailyMinutes {
+		return nil
+	}
+
+	// Freezes may be bought meanwhile, so only the change of the freezes is saved
+	freezes := 0
+	missed := missedStreakDays(streak, today)
+	switch {
+	case streak.LastDay == nil || missed > streak.Freezes:
+		streak.CurrentStreak = 1
+	default:
+		freezes -= missed
+		streak.CurrentStreak++
+	}
+	if streak.CurrentStreak > streak.BestStreak {
+		streak.BestStreak = streak.CurrentStreak
+	}
+	if s.configs.StreakFreezeEvery > 0 && streak.CurrentStreak%s.configs.StreakFreezeEvery == 0 &&
+		streak.Freezes+freezes < s.configs.StreakMaxFreezes {
+		freezes++
+	}
+	streak.Freezes += freezes
+	streak.LastDay = &today
+	return s.repo.SaveStreak(ctx, streak, freezes)
+}
+
+// streakResponseOf returns the streak of the user as of today. The stored streak is only
+// updated when focus is credited, so a streak broken by missed days is reported as zero here.
+func (s *userService) streakResponseOf(ctx context.Context, userID string) (database.StreakResponse, error) {
+	response := database.StreakResponse{DailyMinutes: s.configs.StreakDailyMinutes}
+	streak, err := s.streakOf(ctx, userID)
+	if err != nil {
+		return response, err
+	}
+	location, err := s.userLocation(ctx, userID, "")
+	if err != nil {
+		location = time.UTC
+	}
+	today := streakDay(time.Now().In(location))
+
+	response.BestStreak = streak.BestStreak
+	response.Freezes = streak.Freezes
+	if streak.LastDay == nil {
+		return response, nil
+	}
+	response.ExtendedToday = !streak.LastDay.Before(today)
+	missed := missedStreakDays(streak, today)
+	if missed <= streak.Freezes {
+		response.CurrentStreak = streak.CurrentStreak
+		// The freezes covering the missed days are used when today is credited
+		response.Freezes -= missed
+	}
+	return response, nil
+}
+
+// streakOf returns the stored streak of the user, an empty streak when none was started yet
+func (s *userService) streakOf(ctx context.Context, userID string) (*database.Streak, error) {
+	streak, err := s.repo.GetStreak(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &database.Streak{UserID: userID, CreatedAt: time.Now()}, nil
+		}
+		s.logger.Error("Cannot get streak", "error", err)
+		return nil, err
+	}
+	return streak, nil
+}
+
+// missedStreakDays returns the number of days between the last counted day and today that did not count
+func missedStreakDays(streak *database.Streak, today time.Time) int {
+	if streak.LastDay == nil {
+		return 0
+	}
+	missed := int(today.Sub(*streak.LastDay).Hours()/24) - 1
+	if missed < 0 {
+		return 0
+	}
+	return missed
+}
+
+// streakDay returns the calendar day of t as midnight UTC, the form dates are stored in
+func streakDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,121 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal/database"
+	"context"
+	"testing"
+	"time"
+)
+
+func (repo *fakeRepo) SaveStreak(ctx context.Context, streak *database.Streak, freezes int) error {
+	if repo.beforeWrite != nil {
+		repo.beforeWrite()
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	stored, ok := repo.streaks[streak.UserID]
+	if !ok {
+		copied := *streak
+		repo.streaks[streak.UserID] = &copied
+		return nil
+	}
+	if stored.LastDay != nil && !stored.LastDay.Before(*streak.LastDay) {
+		return nil
+	}
+	stored.CurrentStreak = streak.CurrentStreak
+	stored.BestStreak = streak.BestStreak
+	stored.Freezes += freezes
+	stored.LastDay = streak.LastDay
+	return nil
+}
+
+func TestCreditStreak(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	s.configs.StreakDailyMinutes = 30
+	s.configs.StreakFreezeEvery = 3
+	s.configs.StreakMaxFreezes = 2
+	today := streakDay(time.Now())
+
+	repo.addSession(user.ID, 20, time.Now())
+	if err := s.creditStreak(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := repo.streaks[user.ID]; ok {
+		t.Fatal("streak was credited under the daily threshold")
+	}
+	repo.addSession(user.ID, 10, time.Now())
+	if err := s.creditStreak(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if streak := repo.streaks[user.ID]; streak.CurrentStreak != 1 || streak.BestStreak != 1 || !streak.LastDay.Equal(today) {
+		t.Fatalf("got %+v, want a streak of today", streak)
+	}
+	// Crediting the same day again does not extend the streak
+	if err := s.creditStreak(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if streak := repo.streaks[user.ID]; streak.CurrentStreak != 1 {
+		t.Fatalf("got %d, want the streak credited once a day", streak.CurrentStreak)
+	}
+}
+
+func TestCreditStreakFreezes(t *testing.T) {
+	tests := []struct {
+		name        string
+		lastDay     int // days ago
+		current     int
+		freezes     int
+		wantCurrent int
+		wantFreezes int
+	}{
+		{"yesterday", 1, 4, 0, 5, 0},
+		{"earns a freeze", 1, 2, 0, 3, 1},
+		{"earns no freeze over the max", 1, 5, 2, 6, 2},
+		{"missed day covered", 2, 4, 1, 5, 0},
+		{"missed days covered, earning one back", 3, 7, 2, 8, 0},
+		{"missed days not covered", 3, 4, 1, 1, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, repo, user, ctx := newUserTest(t)
+			s.configs.StreakDailyMinutes = 30
+			s.configs.StreakFreezeEvery = 3
+			s.configs.StreakMaxFreezes = 2
+			lastDay := streakDay(time.Now()).AddDate(0, 0, -test.lastDay)
+			repo.streaks[user.ID] = &database.Streak{UserID: user.ID, CurrentStreak: test.current, BestStreak: 10,
+				Freezes: test.freezes, LastDay: &lastDay}
+			repo.addSession(user.ID, 30, time.Now())
+
+			if err := s.creditStreak(ctx, user.ID); err != nil {
+				t.Fatal(err)
+			}
+			streak := repo.streaks[user.ID]
+			if streak.CurrentStreak != test.wantCurrent || streak.Freezes != test.wantFreezes {
+				t.Fatalf("got streak %d with %d freezes, want %d with %d", streak.CurrentStreak, streak.Freezes, test.wantCurrent, test.wantFreezes)
+			}
+		})
+	}
+}
+
+func TestCreditStreakKeepsFreezesBoughtMeanwhile(t *testing.T) {
+	s, repo, user, ctx := newUserTest(t)
+	s.configs.StreakDailyMinutes = 30
+	s.configs.StreakFreezeEvery = 3
+	s.configs.StreakMaxFreezes = 2
+	lastDay := streakDay(time.Now()).AddDate(0, 0, -2)
+	repo.streaks[user.ID] = &database.Streak{UserID: user.ID, CurrentStreak: 4, BestStreak: 4, Freezes: 1, LastDay: &lastDay}
+	repo.addSession(user.ID, 30, time.Now())
+
+	// A streak freeze is bought between the read and the save of the streak
+	repo.beforeWrite = func() {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		repo.streaks[user.ID].Freezes++
+	}
+	if err := s.creditStreak(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if streak := repo.streaks[user.ID]; streak.CurrentStreak != 5 || streak.Freezes != 1 {
+		t.Fatalf("got streak %d with %d freezes, want 5 with the bought freeze", streak.CurrentStreak, streak.Freezes)
+	}
+}
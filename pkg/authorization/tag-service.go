@@ -0,0 +1,221 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxSessionTags is the number of tags a focus session can have
+const maxSessionTags = 5
+
+// defaultTagColor is the color of tags created without one
+const defaultTagColor = "#9e9e9e"
+
+// CreateTag adds a tag the user can attach to focus sessions.
+func (s *userService) CreateTag(ctx context.Context, request *CreateTagRequest) (interface{}, error) {
+	user, err := s.contextUserOf(ctx)
+	if err != nil {
+		return err.Error(), err
+	}
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		cusErr := utils.NewErrorResponse(utils.BadRequest)
+		return cusErr.Error(), cusErr
+	}
+	if err := s.checkTagLimit(ctx, user.ID); err != nil {
+		return err.Error(), err
+	}
+	tag := &database.Tag{UserID: user.ID, Name: name, Color: defaultTagColor}
+	if request.Color != "" {
+		tag.Color = strings.ToLower(request.Color)
+	}
+	if err := s.repo.CreateTag(ctx, tag); err != nil {
+		cusErr := s.tagError(err, "Cannot create tag")
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("Tag created", "userID", user.ID, "tagID", tag.ID)
+	return newTagResponse(*tag), nil
+}
+
+// UpdateTag renames, recolors, archives or restores a tag. Omitted fields are kept.
+func (s *userService) UpdateTag(ctx context.Context, request *UpdateTagRequest) (interface{}, error) {
+	user, err := s.contextUserOf(ctx)
+	if err != nil {
+		return err.Error(), err
+	}
+	tags, err := s.repo.GetTags(ctx, user.ID)
+	if err != nil {
+		s.logger.Error("Cannot get tags", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	var tag *database.Tag
+	for i := range tags {
+		if tags[i].ID == request.TagID {
+			tag = &tags[i]
+		}
+	}
+	if tag == nil {
+		cusErr := utils.NewErrorResponse(utils.NotFound)
+		return cusErr.Error(), cusErr
+	}
+	if request.Name != nil {
+		tag.Name = strings.TrimSpace(*request.Name)
+		if tag.Name == "" {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return cusErr.Error(), cusErr
+		}
+	}
+	if request.Color != nil {
+		tag.Color = strings.ToLower(*request.Color)
+	}
+	if request.Archived != nil {
+		// Restored tags count towards the limit again
+		if tag.Archived && !*request.Archived {
+			if err := s.checkTagLimit(ctx, user.ID); err != nil {
+				return err.Error(), err
+			}
+		}
+		tag.Archived = *request.Archived
+	}
+	if err := s.repo.UpdateTag(ctx, tag); err != nil {
+		cusErr := s.tagError(err, "Cannot update tag")
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("Tag updated", "userID", user.ID, "tagID", tag.ID)
+	return newTagResponse(*tag), nil
+}
+
+// GetTags lists the tags of the user, archived tags only when asked for.
+func (s *userService) GetTags(ctx context.Context, request *GetTagsRequest) (interface{}, error) {
+	user, err := s.contextUserOf(ctx)
+	if err != nil {
+		return err.Error(), err
+	}
+	tags, err := s.repo.GetTags(ctx, user.ID)
+	if err != nil {
+		s.logger.Error("Cannot get tags", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	response := make([]TagResponse, 0, len(tags))
+	for _, tag := range tags {
+		if !tag.Archived || request.IncludeArchived {
+			response = append(response, newTagResponse(tag))
+		}
+	}
+	return response, nil
+}
+
+// sessionTagsOf returns the tags to attach to a new focus session without duplicates.
+// Every tag must belong to the user and not be archived.
+func (s *userService) sessionTagsOf(ctx context.Context, userID string, tagIDs []string) ([]string, error) {
+	if len(tagIDs) == 0 {
+		return nil, nil
+	}
+	tags, err := s.repo.GetTags(ctx, userID)
+	if err != nil {
+		s.logger.Error("Cannot get tags", "error", err)
+		return nil, utils.NewErrorResponse(utils.InternalServerError)
+	}
+	active := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if !tag.Archived {
+			active[tag.ID] = true
+		}
+	}
+	unique := []string{}
+	seen := make(map[string]bool, len(tagIDs))
+	for _, tagID := range tagIDs {
+		if !active[tagID] {
+			s.logger.Error("Invalid session tag", "userID", userID, "tagID", tagID)
+			return nil, utils.NewErrorResponse(utils.InvalidTag)
+		}
+		if !seen[tagID] {
+			seen[tagID] = true
+			unique = append(unique, tagID)
+		}
+	}
+	if len(unique) > maxSessionTags {
+		return nil, utils.NewErrorResponse(utils.InvalidTag)
+	}
+	return unique, nil
+}
+
+// tagStatsOf returns the focus of the user within [from, to) broken down by tag, the most
+// focused tag first. Sessions without tags are reported as one untagged entry.
+func (s *userService) tagStatsOf(ctx context.Context, userID string, from time.Time, to time.Time) ([]TagFocusStatsResponse, error) {
+	stats, err := s.repo.GetFocusStatsByTag(ctx, userID, from, to)
+	if err != nil {
+		s.logger.Error("Cannot get focus stats by tag", "error", err)
+		return nil, err
+	}
+	tags, err := s.repo.GetTags(ctx, userID)
+	if err != nil {
+		s.logger.Error("Cannot get tags", "error", err)
+		return nil, err
+	}
+	tagsByID := make(map[string]database.Tag, len(tags))
+	for _, tag := range tags {
+		tagsByID[tag.ID] = tag
+	}
+	response := make([]TagFocusStatsResponse, 0, len(stats))
+	for _, stat := range stats {
+		entry := TagFocusStatsResponse{Minutes: stat.Minutes, Sessions: stat.Sessions, Untagged: stat.TagID == nil}
+		if stat.TagID != nil {
+			tag := tagsByID[*stat.TagID]
+			entry.TagID = tag.ID
+			entry.Name = tag.Name
+			entry.Color = tag.Color
+			entry.Archived = tag.Archived
+		}
+		response = append(response, entry)
+	}
+	sort.SliceStable(response, func(i, j int) bool {
+		return response[i].Minutes > response[j].Minutes
+	})
+	return response, nil
+}
+
+// checkTagLimit returns an error when the user has as many tags as allowed that are not archived
+func (s *userService) checkTagLimit(ctx context.Context, userID string) error {
+	count, err := s.repo.CountActiveTags(ctx, userID)
+	if err != nil {
+		s.logger.Error("Cannot count tags", "error", err)
+		return utils.NewErrorResponse(utils.InternalServerError)
+	}
+	if count >= s.configs.TagLimit {
+		s.logger.Error("Tag limit reached", "userID", userID)
+		return utils.NewErrorResponse(utils.TagLimitReached)
+	}
+	return nil
+}
+
+// tagError converts an error of storing a tag into an error response
+func (s *userService) tagError(err error, msg string) error {
+	switch {
+	case strings.Contains(err.Error(), utils.PgDuplicateKeyMsg):
+		return utils.NewErrorResponse(utils.TagExists)
+	case errors.Is(err, sql.ErrNoRows):
+		return utils.NewErrorResponse(utils.NotFound)
+	default:
+		s.logger.Error(msg, "error", err)
+		return utils.NewErrorResponse(utils.InternalServerError)
+	}
+}
+
+func newTagResponse(tag database.Tag) TagResponse {
+	return TagResponse{
+		ID:        tag.ID,
+		Name:      tag.Name,
+		Color:     tag.Color,
+		Archived:  tag.Archived,
+		CreatedAt: tag.CreatedAt,
+	}
+}
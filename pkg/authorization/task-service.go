@@ -0,0 +1,207 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// CreateTask adds a task at the end of the task list of the user.
+func (s *userService) CreateTask(ctx context.Context, request *CreateTaskRequest) (interface{}, error) {
+	user, err := s.contextUserOf(ctx)
+	if err != nil {
+		return err.Error(), err
+	}
+	task := &database.Task{
+		UserID:             user.ID,
+		Title:              strings.TrimSpace(request.Title),
+		Notes:              request.Notes,
+		EstimatedPomodoros: request.EstimatedPomodoros,
+	}
+	if task.Title == "" {
+		cusErr := utils.NewErrorResponse(utils.BadRequest)
+		return cusErr.Error(), cusErr
+	}
+	if task.DueDate, err = parseDueDate(request.DueDate); err != nil {
+		cusErr := utils.NewErrorResponse(utils.BadRequest)
+		return cusErr.Error(), cusErr
+	}
+	if err := s.repo.CreateTask(ctx, task); err != nil {
+		s.logger.Error("Cannot create task", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Debug("Task created", "userID", user.ID, "taskID", task.ID)
+	return newTaskResponse(*task), nil
+}
+
+// UpdateTask changes a task, omitted fields are kept. An empty due date clears it.
+func (s *userService) UpdateTask(ctx context.Context, request *UpdateTaskRequest) (interface{}, error) {
+	user, err := s.contextUserOf(ctx)
+	if err != nil {
+		return err.Error(), err
+	}
+	task, err := s.repo.GetTask(ctx, user.ID, request.TaskID)
+	if err != nil {
+		cusErr := s.taskError(err, "Cannot get task")
+		return cusErr.Error(), cusErr
+	}
+	if request.Title != nil {
+		task.Title = strings.TrimSpace(*request.Title)
+		if task.Title == "" {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return cusErr.Error(), cusErr
+		}
+	}
+	if request.Notes != nil {
+		task.Notes = *request.Notes
+	}
+	if request.EstimatedPomodoros != nil {
+		task.EstimatedPomodoros = *request.EstimatedPomodoros
+	}
+	if request.DueDate != nil {
+		if task.DueDate, err = parseDueDate(*request.DueDate); err != nil {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return cusErr.Error(), cusErr
+		}
+	}
+	if request.Completed != nil && *request.Completed != task.Completed {
+		task.Completed = *request.Completed
+		task.CompletedAt = nil
+		if task.Completed {
+			now := time.Now()
+			task.CompletedAt = &now
+		}
+	}
+	if err := s.repo.UpdateTask(ctx, task); err != nil {
+		cusErr := s.taskError(err, "Cannot update task")
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Debug("Task updated", "userID", user.ID, "taskID", task.ID)
+	return newTaskResponse(*task), nil
+}
+
+// DeleteTask deletes a task. The focus sessions spent on it keep their scores.
+func (s *userService) DeleteTask(ctx context.Context, request *TaskRequest) (string, error) {
+	user, err := s.contextUserOf(ctx)
+	if err != nil {
+		return err.Error(), err
+	}
+	if err := s.repo.DeleteTask(ctx, user.ID, request.TaskID); err != nil {
+		cusErr := s.taskError(err, "Cannot delete task")
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Debug("Task deleted", "userID", user.ID, "taskID", request.TaskID)
+	return "task deleted.", nil
+}
+
+// GetTasks lists the open tasks of the user in order, followed by the completed tasks when asked for.
+func (s *userService) GetTasks(ctx context.Context, request *GetTasksRequest) (interface{}, error) {
+	user, err := s.contextUserOf(ctx)
+	if err != nil {
+		return err.Error(), err
+	}
+	tasks, err := s.repo.GetTasks(ctx, user.ID)
+	if err != nil {
+		s.logger.Error("Cannot get tasks", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	response := make([]TaskResponse, 0, len(tasks))
+	for _, task := range tasks {
+		if !task.Completed || request.IncludeCompleted {
+			response = append(response, newTaskResponse(task))
+		}
+	}
+	return response, nil
+}
+
+// ReorderTasks moves the given tasks to the top of the task list in the given order. The
+// tasks left out keep their relative order after them.
+func (s *userService) ReorderTasks(ctx context.Context, request *ReorderTasksRequest) (interface{}, error) {
+	user, err := s.contextUserOf(ctx)
+	if err != nil {
+		return err.Error(), err
+	}
+	tasks, err := s.repo.GetTasks(ctx, user.ID)
+	if err != nil {
+		s.logger.Error("Cannot get tasks", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	listed := make(map[string]bool, len(request.TaskIDs))
+	for _, taskID := range request.TaskIDs {
+		if listed[taskID] {
+			cusErr := utils.NewErrorResponse(utils.BadRequest)
+			return cusErr.Error(), cusErr
+		}
+		listed[taskID] = true
+	}
+	order := append([]string{}, request.TaskIDs...)
+	for _, task := range tasks {
+		if !listed[task.ID] {
+			order = append(order, task.ID)
+		}
+	}
+	if err := s.repo.ReorderTasks(ctx, user.ID, order); err != nil {
+		cusErr := s.taskError(err, "Cannot reorder tasks")
+		return cusErr.Error(), cusErr
+	}
+	return s.GetTasks(ctx, &GetTasksRequest{AccessToken: request.AccessToken})
+}
+
+// sessionTaskOf returns the task a new focus session is spent on, which must be an open task of the user
+func (s *userService) sessionTaskOf(ctx context.Context, userID string, taskID string) (*string, error) {
+	if taskID == "" {
+		return nil, nil
+	}
+	task, err := s.repo.GetTask(ctx, userID, taskID)
+	if err != nil || task.Completed {
+		s.logger.Error("Invalid session task", "userID", userID, "taskID", taskID, "error", err)
+		return nil, utils.NewErrorResponse(utils.InvalidTask)
+	}
+	return &task.ID, nil
+}
+
+// taskError converts an error of a task of the user into an error response
+func (s *userService) taskError(err error, msg string) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return utils.NewErrorResponse(utils.NotFound)
+	}
+	s.logger.Error(msg, "error", err)
+	return utils.NewErrorResponse(utils.InternalServerError)
+}
+
+// parseDueDate parses a due date (YYYY-MM-DD), an empty date is no due date
+func parseDueDate(date string) (*time.Time, error) {
+	if date == "" {
+		return nil, nil
+	}
+	dueDate, err := time.Parse(statsDateLayout, date)
+	if err != nil {
+		return nil, err
+	}
+	return &dueDate, nil
+}
+
+func newTaskResponse(task database.Task) TaskResponse {
+	response := TaskResponse{
+		ID:                 task.ID,
+		Title:              task.Title,
+		Notes:              task.Notes,
+		EstimatedPomodoros: task.EstimatedPomodoros,
+		ActualPomodoros:    task.ActualPomodoros,
+		Completed:          task.Completed,
+		CompletedAt:        task.CompletedAt,
+		Position:           task.Position,
+		CreatedAt:          task.CreatedAt,
+	}
+	if task.DueDate != nil {
+		response.DueDate = task.DueDate.Format(statsDateLayout)
+	}
+	return response
+}
@@ -4,17 +4,22 @@ import (
 	utils "LoveLetterProject/internal"
 	"LoveLetterProject/pkg/authorization"
 	"LoveLetterProject/pkg/authorization/endpoints"
+	"LoveLetterProject/pkg/authorization/middleware"
 	"context"
 	"encoding/json"
 	"errors"
 	httptransport "github.com/go-kit/kit/transport/http"
 	"net/http"
-	"strings"
 )
 
 func NewHTTPHandler(ep endpoints.Set) http.Handler {
 	options := []httptransport.ServerOption{
 		httptransport.ServerErrorEncoder(errEncoder),
+		httptransport.ServerBefore(middleware.ExtractAPIKey),
+	}
+	// The endpoints called by OAuth client libraries answer in the format of RFC 6749
+	oauthOptions := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(oauthErrEncoder),
 	}
 
 	m := http.NewServeMux()
@@ -116,281 +121,2029 @@ func NewHTTPHandler(ep endpoints.Set) http.Handler {
 		options...,
 	))
 
-	mux := http.NewServeMux()
-	mux.Handle("/api/v1/", http.StripPrefix("/api/v1", m))
-	return mux
+	m.Handle("/reauthenticate", httptransport.NewServer(
+		ep.ReauthenticateEndpoint,
+		decodeHTTPReauthenticateRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/request-email-change", httptransport.NewServer(
+		ep.RequestEmailChangeEndpoint,
+		decodeHTTPRequestEmailChangeRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/confirm-email-change", httptransport.NewServer(
+		ep.ConfirmEmailChangeEndpoint,
+		decodeHTTPConfirmEmailChangeRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/undo-email-change", httptransport.NewServer(
+		ep.UndoEmailChangeEndpoint,
+		decodeHTTPUndoEmailChangeRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/set-username", httptransport.NewServer(
+		ep.SetUsernameEndpoint,
+		decodeHTTPSetUsernameRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/get-public-profile", httptransport.NewServer(
+		ep.GetPublicProfileEndpoint,
+		decodeHTTPGetPublicProfileRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/login/oidc", httptransport.NewServer(
+		ep.SocialLoginEndpoint,
+		decodeHTTPSocialLoginRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/login/email-code", httptransport.NewServer(
+		ep.RequestLoginCodeEndpoint,
+		decodeHTTPEmailCodeLoginRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/login/email-code/verify", httptransport.NewServer(
+		ep.VerifyLoginCodeEndpoint,
+		decodeHTTPVerifyEmailCodeLoginRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/signup/guest", httptransport.NewServer(
+		ep.GuestSignUpEndpoint,
+		decodeHTTPGuestSignUpRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/login/guest", httptransport.NewServer(
+		ep.GuestLoginEndpoint,
+		decodeHTTPGuestLoginRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/upgrade-guest", httptransport.NewServer(
+		ep.UpgradeGuestEndpoint,
+		decodeHTTPUpgradeGuestRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/get-sign-in-methods", httptransport.NewServer(
+		ep.GetSignInMethodsEndpoint,
+		decodeHTTPGetSignInMethodsRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/link-identity", httptransport.NewServer(
+		ep.LinkIdentityEndpoint,
+		decodeHTTPLinkIdentityRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/link-password", httptransport.NewServer(
+		ep.LinkPasswordEndpoint,
+		decodeHTTPLinkPasswordRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/unlink-sign-in-method", httptransport.NewServer(
+		ep.UnlinkSignInMethodEndpoint,
+		decodeHTTPUnlinkSignInMethodRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/create-personal-access-token", httptransport.NewServer(
+		ep.CreatePersonalAccessTokenEndpoint,
+		decodeHTTPCreatePersonalAccessTokenRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/get-personal-access-tokens", httptransport.NewServer(
+		ep.GetPersonalAccessTokensEndpoint,
+		decodeHTTPGetPersonalAccessTokensRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/revoke-personal-access-token", httptransport.NewServer(
+		ep.RevokePersonalAccessTokenEndpoint,
+		decodeHTTPRevokePersonalAccessTokenRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/internal/get-user", httptransport.NewServer(
+		ep.InternalGetUserEndpoint,
+		decodeHTTPInternalGetUserRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/internal/get-earn-score", httptransport.NewServer(
+		ep.InternalGetEarnScoreEndpoint,
+		decodeHTTPInternalGetEarnScoreRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/oauth/get-client", httptransport.NewServer(
+		ep.GetOAuthClientEndpoint,
+		decodeHTTPGetOAuthClientRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/oauth/authorize", httptransport.NewServer(
+		ep.AuthorizeOAuthClientEndpoint,
+		decodeHTTPOAuthAuthorizeRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/oauth/token", httptransport.NewServer(
+		ep.OAuthTokenEndpoint,
+		decodeHTTPOAuthTokenRequest,
+		encodeOAuthResponse,
+		oauthOptions...,
+	))
+
+	m.Handle("/oauth/device/code", httptransport.NewServer(
+		ep.RequestDeviceCodeEndpoint,
+		decodeHTTPOAuthDeviceCodeRequest,
+		encodeOAuthResponse,
+		oauthOptions...,
+	))
+
+	m.Handle("/oauth/device/verify", httptransport.NewServer(
+		ep.VerifyDeviceCodeEndpoint,
+		decodeHTTPVerifyDeviceCodeRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/oauth/get-consents", httptransport.NewServer(
+		ep.GetOAuthConsentsEndpoint,
+		decodeHTTPGetOAuthConsentsRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/oauth/revoke-consent", httptransport.NewServer(
+		ep.RevokeOAuthConsentEndpoint,
+		decodeHTTPRevokeOAuthConsentRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/internal/create-oauth-client", httptransport.NewServer(
+		ep.CreateOAuthClientEndpoint,
+		decodeHTTPCreateOAuthClientRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/send-phone-code", httptransport.NewServer(
+		ep.SendPhoneCodeEndpoint,
+		decodeHTTPSendPhoneCodeRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/verify-phone", httptransport.NewServer(
+		ep.VerifyPhoneEndpoint,
+		decodeHTTPVerifyPhoneRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/get-forget-password-code-sms", httptransport.NewServer(
+		ep.GetForgetPasswordSMSEndpoint,
+		decodeHTTPGetForgetPasswordCodeBySMSRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/get-focus-stats", httptransport.NewServer(
+		ep.GetFocusStatsEndpoint,
+		decodeHTTPGetFocusStatsRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/get-achievements", httptransport.NewServer(
+		ep.GetAchievementsEndpoint,
+		decodeHTTPGetAchievementsRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/get-leaderboard", httptransport.NewServer(
+		ep.GetLeaderboardEndpoint,
+		decodeHTTPGetLeaderboardRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/get-privacy-settings", httptransport.NewServer(
+		ep.GetPrivacySettingsEndpoint,
+		decodeHTTPGetPrivacySettingsRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/update-privacy-settings", httptransport.NewServer(
+		ep.UpdatePrivacySettingsEndpoint,
+		decodeHTTPUpdatePrivacySettingsRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/add-friend", httptransport.NewServer(
+		ep.AddFriendEndpoint,
+		decodeHTTPAddFriendRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/remove-friend", httptransport.NewServer(
+		ep.RemoveFriendEndpoint,
+		decodeHTTPRemoveFriendRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/get-friends", httptransport.NewServer(
+		ep.GetFriendsEndpoint,
+		decodeHTTPGetFriendsRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/get-plant-species", httptransport.NewServer(
+		ep.GetPlantSpeciesEndpoint,
+		decodeHTTPGetPlantSpeciesRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/get-garden", httptransport.NewServer(
+		ep.GetGardenEndpoint,
+		decodeHTTPGetGardenRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/plant-seed", httptransport.NewServer(
+		ep.PlantSeedEndpoint,
+		decodeHTTPPlantSeedRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/water-plant", httptransport.NewServer(
+		ep.WaterPlantEndpoint,
+		decodeHTTPWaterPlantRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/grow-plant", httptransport.NewServer(
+		ep.GrowPlantEndpoint,
+		decodeHTTPGrowPlantRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/get-shop", httptransport.NewServer(
+		ep.GetShopEndpoint,
+		decodeHTTPGetShopRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/purchase-item", httptransport.NewServer(
+		ep.PurchaseItemEndpoint,
+		decodeHTTPPurchaseItemRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/get-inventory", httptransport.NewServer(
+		ep.GetInventoryEndpoint,
+		decodeHTTPGetInventoryRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/internal/get-shop-items", httptransport.NewServer(
+		ep.InternalGetShopItemsEndpoint,
+		decodeHTTPInternalGetShopItemsRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/internal/create-shop-item", httptransport.NewServer(
+		ep.InternalCreateShopItemEndpoint,
+		decodeHTTPInternalCreateShopItemRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/internal/update-shop-item", httptransport.NewServer(
+		ep.InternalUpdateShopItemEndpoint,
+		decodeHTTPInternalUpdateShopItemRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/internal/delete-shop-item", httptransport.NewServer(
+		ep.InternalDeleteShopItemEndpoint,
+		decodeHTTPInternalDeleteShopItemRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/internal/get-multi-ratios", httptransport.NewServer(
+		ep.InternalGetMultiRatiosEndpoint,
+		decodeHTTPInternalGetMultiRatiosRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/internal/publish-multi-ratio", httptransport.NewServer(
+		ep.InternalPublishMultiRatioEndpoint,
+		decodeHTTPInternalPublishMultiRatioRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/set-goal", httptransport.NewServer(
+		ep.SetGoalEndpoint,
+		decodeHTTPSetGoalRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/get-goal-progress", httptransport.NewServer(
+		ep.GetGoalProgressEndpoint,
+		decodeHTTPGetGoalProgressRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/get-goal-history", httptransport.NewServer(
+		ep.GetGoalHistoryEndpoint,
+		decodeHTTPGetGoalHistoryRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/create-tag", httptransport.NewServer(
+		ep.CreateTagEndpoint,
+		decodeHTTPCreateTagRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/update-tag", httptransport.NewServer(
+		ep.UpdateTagEndpoint,
+		decodeHTTPUpdateTagRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/get-tags", httptransport.NewServer(
+		ep.GetTagsEndpoint,
+		decodeHTTPGetTagsRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/create-task", httptransport.NewServer(
+		ep.CreateTaskEndpoint,
+		decodeHTTPCreateTaskRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/update-task", httptransport.NewServer(
+		ep.UpdateTaskEndpoint,
+		decodeHTTPUpdateTaskRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/delete-task", httptransport.NewServer(
+		ep.DeleteTaskEndpoint,
+		decodeHTTPDeleteTaskRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/get-tasks", httptransport.NewServer(
+		ep.GetTasksEndpoint,
+		decodeHTTPGetTasksRequest,
+		encodeResponse,
+		options...,
+	))
+
+	m.Handle("/reorder-tasks", httptransport.NewServer(
+		ep.ReorderTasksEndpoint,
+		decodeHTTPReorderTasksRequest,
+		encodeResponse,
+		options...,
+	))
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/v1/", http.StripPrefix("/api/v1", m))
+	return mux
+}
+
+// decodeHealthCheckRequest check server health
+func decodeHealthCheckRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func decodeHTTPRegisterRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.RegisterRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+		}
+		if req.Email == "" {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("email is required"), "email is required")
+		}
+		if req.Password == "" {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("password is required"), "password is required")
+		}
+		if req.Password != req.RePassword {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("passwords is not same"), "passwords is not same")
+		}
+		// normalize email so lookups are case-insensitive
+		req.Email = utils.NormalizeEmail(req.Email)
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPVerifyMailRequest decode verify mail request
+func decodeHTTPVerifyMailRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.VerifyMailRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+		}
+		if req.Email == "" {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("email is required"), "email is required")
+		}
+		if req.Code == "" {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("code is required"), "code is required")
+		}
+		// normalize email so lookups are case-insensitive
+		req.Email = utils.NormalizeEmail(req.Email)
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPLoginRequest
+func decodeHTTPLoginRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.LoginRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+		}
+		if req.Email == "" {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("email is required"), "email is required")
+		}
+		if req.Password == "" {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("password is required"), "password is required")
+		}
+		// normalize email so lookups are case-insensitive
+		req.Email = utils.NormalizeEmail(req.Email)
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPLogoutRequest decode request
+func decodeHTTPLogoutRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.LogoutRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+		}
+		if req.RefreshToken == "" {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("refresh token is required"), "refresh token is required")
+		}
+		// normalize email so lookups are case-insensitive
+		req.Email = utils.NormalizeEmail(req.Email)
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGetUserRequest decode request
+func decodeHTTPGetUserRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.GetUserRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("access token is required"), "access token is required")
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGetProfileRequest decode request
+func decodeHTTPGetProfileRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.GetProfileRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("access token is required"), "access token is required")
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPUpdateProfileRequest decode request
+func decodeHTTPUpdateProfileRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.UpdateProfileRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("access token is required"), "access token is required")
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPUpdatePasswordRequest decode request
+func decodeHTTPUpdatePasswordRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.UpdatePasswordRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("access token is required"), "access token is required")
+		}
+		if req.OldPassword == "" {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("old password is required"), "old password is required")
+		}
+		if req.NewPassword == "" {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("new password is required"), "new password is required")
+		}
+		if req.ConfirmPassword == "" {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("confirm password is required"), "confirm password is required")
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGetForgetPasswordCodeRequest decode request
+func decodeHTTPGetForgetPasswordCodeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.GetForgetPasswordCodeRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+		}
+		if req.Email == "" {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("email is required"), "email is required")
+		}
+		// normalize email so lookups are case-insensitive
+		req.Email = utils.NormalizeEmail(req.Email)
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPResetPasswordRequest decode request
+func decodeHTTPResetPasswordRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.CreateNewPasswordWithCodeRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+		}
+		if req.Email == "" && req.Phone == "" {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("email or phone is required"), "email or phone is required")
+		}
+		if req.Code == "" {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("code is required"), "code is required")
+		}
+		if req.NewPassword == "" {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("new password is required"), "new password is required")
+		}
+		// normalize email so lookups are case-insensitive
+		req.Email = utils.NormalizeEmail(req.Email)
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGetMultiRatioDataRequest decode request
+func decodeHTTPGetMultiRatioDataRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+// decodeHTTPInsertEarnScoreRequest decode request
+func decodeHTTPInsertEarnScoreRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.InsertEarnScoreRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("access token is required"), "access token is required")
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGetEarnScoreRequest decode request
+func decodeHTTPGetEarnScoreRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.GetEarnScoreRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("access token is required"), "access token is required")
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGenerateAccessTokenRequest decode request
+func decodeHTTPGenerateAccessTokenRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.GenerateAccessTokenRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.RefreshToken == "" {
+			return nil, utils.NewErrorResponse(utils.RefreshTokenRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPReauthenticateRequest decode request
+func decodeHTTPReauthenticateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.ReauthenticateRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.Password == "" {
+			return nil, utils.NewErrorResponse(utils.PasswordRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPRequestEmailChangeRequest decode request
+func decodeHTTPRequestEmailChangeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.RequestEmailChangeRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.Password == "" {
+			return nil, utils.NewErrorResponse(utils.PasswordRequired)
+		}
+		if req.NewEmail == "" {
+			return nil, utils.NewErrorResponse(utils.MailRequired)
+		}
+		// normalize email so lookups are case-insensitive
+		req.NewEmail = utils.NormalizeEmail(req.NewEmail)
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPConfirmEmailChangeRequest decode request
+func decodeHTTPConfirmEmailChangeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.ConfirmEmailChangeRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.Code == "" {
+			return nil, utils.NewErrorResponse(utils.CodeRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPUndoEmailChangeRequest decode request
+func decodeHTTPUndoEmailChangeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.UndoEmailChangeRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.Token == "" {
+			return nil, utils.NewErrorResponse(utils.CodeRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPSetUsernameRequest decode request
+func decodeHTTPSetUsernameRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.SetUsernameRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.Username == "" {
+			return nil, utils.NewErrorResponse(utils.UsernameRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGetPublicProfileRequest decode request
+func decodeHTTPGetPublicProfileRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.GetPublicProfileRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.Username == "" {
+			return nil, utils.NewErrorResponse(utils.UsernameRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPSocialLoginRequest decode request
+func decodeHTTPSocialLoginRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.SocialLoginRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.Provider == "" {
+			return nil, utils.NewErrorResponse(utils.UnknownProvider)
+		}
+		if req.IDToken == "" {
+			return nil, utils.NewErrorResponse(utils.IDTokenRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPEmailCodeLoginRequest decode request
+func decodeHTTPEmailCodeLoginRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.EmailCodeLoginRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.Email == "" {
+			return nil, utils.NewErrorResponse(utils.MailRequired)
+		}
+		// normalize email so lookups are case-insensitive
+		req.Email = utils.NormalizeEmail(req.Email)
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPVerifyEmailCodeLoginRequest decode request, either email and code or the magic link token
+func decodeHTTPVerifyEmailCodeLoginRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.VerifyEmailCodeLoginRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.Token == "" {
+			if req.Email == "" {
+				return nil, utils.NewErrorResponse(utils.MailRequired)
+			}
+			if req.Code == "" {
+				return nil, utils.NewErrorResponse(utils.CodeRequired)
+			}
+		}
+		// normalize email so lookups are case-insensitive
+		req.Email = utils.NormalizeEmail(req.Email)
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGuestSignUpRequest decode request, guest signup has no body
+func decodeHTTPGuestSignUpRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		return nil, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGuestLoginRequest decode request
+func decodeHTTPGuestLoginRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.GuestLoginRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.UserID == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.DeviceSecret == "" {
+			return nil, utils.NewErrorResponse(utils.DeviceSecretRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPUpgradeGuestRequest decode request, either email and password or a social login ID token
+func decodeHTTPUpgradeGuestRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.UpgradeGuestRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.IDToken != "" {
+			if req.Provider == "" {
+				return nil, utils.NewErrorResponse(utils.UnknownProvider)
+			}
+			return req, nil
+		}
+		if req.Email == "" {
+			return nil, utils.NewErrorResponse(utils.MailRequired)
+		}
+		if req.Password == "" {
+			return nil, utils.NewErrorResponse(utils.PasswordRequired)
+		}
+		// normalize email so lookups are case-insensitive
+		req.Email = utils.NormalizeEmail(req.Email)
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGetSignInMethodsRequest decode request
+func decodeHTTPGetSignInMethodsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.GetSignInMethodsRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPLinkIdentityRequest decode request
+func decodeHTTPLinkIdentityRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.LinkIdentityRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.Provider == "" {
+			return nil, utils.NewErrorResponse(utils.UnknownProvider)
+		}
+		if req.IDToken == "" {
+			return nil, utils.NewErrorResponse(utils.IDTokenRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPLinkPasswordRequest decode request
+func decodeHTTPLinkPasswordRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.LinkPasswordRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.Password == "" {
+			return nil, utils.NewErrorResponse(utils.PasswordRequired)
+		}
+		if req.RePassword == "" {
+			return nil, utils.NewErrorResponse(utils.ConfirmPasswordRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPUnlinkSignInMethodRequest decode request
+func decodeHTTPUnlinkSignInMethodRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.UnlinkSignInMethodRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.Type != authorization.SignInMethodPassword && req.Type != authorization.SignInMethodIdentity {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.Type == authorization.SignInMethodIdentity && req.IdentityID == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPCreatePersonalAccessTokenRequest decode request
+func decodeHTTPCreatePersonalAccessTokenRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.CreatePersonalAccessTokenRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if len(req.Scopes) == 0 {
+			return nil, utils.NewErrorResponse(utils.InvalidScope)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGetPersonalAccessTokensRequest decode request
+func decodeHTTPGetPersonalAccessTokensRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.GetPersonalAccessTokensRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPRevokePersonalAccessTokenRequest decode request
+func decodeHTTPRevokePersonalAccessTokenRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.RevokePersonalAccessTokenRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.ID == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPInternalGetUserRequest decode request
+func decodeHTTPInternalGetUserRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.InternalGetUserRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.UserID == "" && req.Email == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		req.Email = utils.NormalizeEmail(req.Email)
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPInternalGetEarnScoreRequest decode request
+func decodeHTTPInternalGetEarnScoreRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.InternalGetEarnScoreRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.UserID == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGetOAuthClientRequest decode request
+func decodeHTTPGetOAuthClientRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.GetOAuthClientRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.ClientID == "" && req.UserCode == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPOAuthAuthorizeRequest decode request
+func decodeHTTPOAuthAuthorizeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.OAuthAuthorizeRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.ClientID == "" || req.RedirectURI == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPOAuthTokenRequest decode the form of the token endpoint. Confidential
+// clients authenticate with HTTP basic auth or with the client_secret parameter.
+func decodeHTTPOAuthTokenRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		if err := r.ParseForm(); err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		clientID, clientSecret := oauthClientCredentials(r)
+		return authorization.OAuthTokenRequest{
+			GrantType:    r.PostForm.Get("grant_type"),
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Code:         r.PostForm.Get("code"),
+			RedirectURI:  r.PostForm.Get("redirect_uri"),
+			CodeVerifier: r.PostForm.Get("code_verifier"),
+			RefreshToken: r.PostForm.Get("refresh_token"),
+			DeviceCode:   r.PostForm.Get("device_code"),
+		}, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPOAuthDeviceCodeRequest decode the form of the device authorization endpoint
+func decodeHTTPOAuthDeviceCodeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		if err := r.ParseForm(); err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		clientID, clientSecret := oauthClientCredentials(r)
+		return authorization.OAuthDeviceCodeRequest{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Scope:        r.PostForm.Get("scope"),
+		}, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// oauthClientCredentials returns the client id and secret from basic auth or the form
+func oauthClientCredentials(r *http.Request) (string, string) {
+	if clientID, clientSecret, ok := r.BasicAuth(); ok {
+		return clientID, clientSecret
+	}
+	return r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+}
+
+// decodeHTTPVerifyDeviceCodeRequest decode request
+func decodeHTTPVerifyDeviceCodeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.VerifyDeviceCodeRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.UserCode == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGetOAuthConsentsRequest decode request
+func decodeHTTPGetOAuthConsentsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.GetOAuthConsentsRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPRevokeOAuthConsentRequest decode request
+func decodeHTTPRevokeOAuthConsentRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.RevokeOAuthConsentRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.ClientID == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPCreateOAuthClientRequest decode request
+func decodeHTTPCreateOAuthClientRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.CreateOAuthClientRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if len(req.Scopes) == 0 {
+			return nil, utils.NewErrorResponse(utils.InvalidScope)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPSendPhoneCodeRequest decode request
+func decodeHTTPSendPhoneCodeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.SendPhoneCodeRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.Phone == "" {
+			return nil, utils.NewErrorResponse(utils.PhoneRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPVerifyPhoneRequest decode request
+func decodeHTTPVerifyPhoneRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.VerifyPhoneRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.Code == "" {
+			return nil, utils.NewErrorResponse(utils.CodeRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGetForgetPasswordCodeBySMSRequest decode request
+func decodeHTTPGetForgetPasswordCodeBySMSRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.GetForgetPasswordCodeBySMSRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.Phone == "" {
+			return nil, utils.NewErrorResponse(utils.PhoneRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGetFocusStatsRequest decode request
+func decodeHTTPGetFocusStatsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.GetFocusStatsRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.Period == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGetAchievementsRequest decode request
+func decodeHTTPGetAchievementsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.GetAchievementsRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGetLeaderboardRequest decode request
+func decodeHTTPGetLeaderboardRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.GetLeaderboardRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGetPrivacySettingsRequest decode request
+func decodeHTTPGetPrivacySettingsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.GetPrivacySettingsRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPUpdatePrivacySettingsRequest decode request
+func decodeHTTPUpdatePrivacySettingsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.UpdatePrivacySettingsRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPAddFriendRequest decode request
+func decodeHTTPAddFriendRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.FriendRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.Username == "" {
+			return nil, utils.NewErrorResponse(utils.UsernameRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPRemoveFriendRequest decode request
+func decodeHTTPRemoveFriendRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.FriendRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.Username == "" {
+			return nil, utils.NewErrorResponse(utils.UsernameRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGetFriendsRequest decode request
+func decodeHTTPGetFriendsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.GetFriendsRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGetPlantSpeciesRequest decode request
+func decodeHTTPGetPlantSpeciesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.GetGardenRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPGetGardenRequest decode request
+func decodeHTTPGetGardenRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.GetGardenRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPPlantSeedRequest decode request
+func decodeHTTPPlantSeedRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.PlantSeedRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.SpeciesID == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPWaterPlantRequest decode request
+func decodeHTTPWaterPlantRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.WaterPlantRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.PlantID == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
 }
 
-// decodeHealthCheckRequest check server health
-func decodeHealthCheckRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	return nil, nil
+// decodeHTTPGrowPlantRequest decode request
+func decodeHTTPGrowPlantRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.GrowPlantRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.PlantID == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
 }
 
-func decodeHTTPRegisterRequest(_ context.Context, r *http.Request) (interface{}, error) {
+// decodeHTTPGetShopRequest decode request
+func decodeHTTPGetShopRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	if r.Method == "POST" {
-		var req authorization.RegisterRequest
+		var req authorization.GetShopRequest
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
-		if req.Email == "" {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("email is required"), "email is required")
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
 		}
-		if req.Password == "" {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("password is required"), "password is required")
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPPurchaseItemRequest decode request
+func decodeHTTPPurchaseItemRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.PurchaseItemRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
-		if req.Password != req.RePassword {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("passwords is not same"), "passwords is not same")
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.ItemID == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.IdempotencyKey == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
-		// change caplock to lowercase
-		req.Email = strings.ToLower(req.Email)
 		return req, nil
 	} else {
-		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
 		return nil, cusErr
 	}
 }
 
-// decodeHTTPVerifyMailRequest decode verify mail request
-func decodeHTTPVerifyMailRequest(_ context.Context, r *http.Request) (interface{}, error) {
+// decodeHTTPGetInventoryRequest decode request
+func decodeHTTPGetInventoryRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	if r.Method == "POST" {
-		var req authorization.VerifyMailRequest
+		var req authorization.GetShopRequest
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
-		if req.Email == "" {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("email is required"), "email is required")
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
 		}
-		if req.Code == "" {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("code is required"), "code is required")
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPInternalGetShopItemsRequest decode request
+func decodeHTTPInternalGetShopItemsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.InternalGetShopItemsRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
-		// change caplock to lowercase
-		req.Email = strings.ToLower(req.Email)
 		return req, nil
 	} else {
-		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
 		return nil, cusErr
 	}
 }
 
-// decodeHTTPLoginRequest
-func decodeHTTPLoginRequest(_ context.Context, r *http.Request) (interface{}, error) {
+// decodeHTTPInternalCreateShopItemRequest decode request
+func decodeHTTPInternalCreateShopItemRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	if r.Method == "POST" {
-		var req authorization.LoginRequest
+		var req authorization.ShopItemRequest
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
-		if req.Email == "" {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("email is required"), "email is required")
+		if req.ID == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
-		if req.Password == "" {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("password is required"), "password is required")
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPInternalUpdateShopItemRequest decode request
+func decodeHTTPInternalUpdateShopItemRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.ShopItemRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.ID == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
-		// change caplock to lowercase
-		req.Email = strings.ToLower(req.Email)
 		return req, nil
 	} else {
-		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
 		return nil, cusErr
 	}
 }
 
-// decodeHTTPLogoutRequest decode request
-func decodeHTTPLogoutRequest(_ context.Context, r *http.Request) (interface{}, error) {
+// decodeHTTPInternalDeleteShopItemRequest decode request
+func decodeHTTPInternalDeleteShopItemRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	if r.Method == "POST" {
-		var req authorization.LogoutRequest
+		var req authorization.InternalDeleteShopItemRequest
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
-		if req.RefreshToken == "" {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("refresh token is required"), "refresh token is required")
+		if req.ID == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
-		// change caplock to lowercase
-		req.Email = strings.ToLower(req.Email)
 		return req, nil
 	} else {
-		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
 		return nil, cusErr
 	}
 }
 
-// decodeHTTPGetUserRequest decode request
-func decodeHTTPGetUserRequest(_ context.Context, r *http.Request) (interface{}, error) {
+// decodeHTTPInternalGetMultiRatiosRequest decode request
+func decodeHTTPInternalGetMultiRatiosRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	if r.Method == "POST" {
-		var req authorization.GetUserRequest
+		var req authorization.InternalGetMultiRatiosRequest
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
-		if req.AccessToken == "" {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("access token is required"), "access token is required")
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPInternalPublishMultiRatioRequest decode request
+func decodeHTTPInternalPublishMultiRatioRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.PublishMultiRatioRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
 		return req, nil
 	} else {
-		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
 		return nil, cusErr
 	}
 }
 
-// decodeHTTPGetProfileRequest decode request
-func decodeHTTPGetProfileRequest(_ context.Context, r *http.Request) (interface{}, error) {
+// decodeHTTPSetGoalRequest decode request
+func decodeHTTPSetGoalRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	if r.Method == "POST" {
-		var req authorization.GetProfileRequest
+		var req authorization.SetGoalRequest
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
 		if req.AccessToken == "" {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("access token is required"), "access token is required")
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
 		}
 		return req, nil
 	} else {
-		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
 		return nil, cusErr
 	}
 }
 
-// decodeHTTPUpdateProfileRequest decode request
-func decodeHTTPUpdateProfileRequest(_ context.Context, r *http.Request) (interface{}, error) {
+// decodeHTTPGetGoalProgressRequest decode request
+func decodeHTTPGetGoalProgressRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	if r.Method == "POST" {
-		var req authorization.UpdateProfileRequest
+		var req authorization.GetGoalProgressRequest
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
 		if req.AccessToken == "" {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("access token is required"), "access token is required")
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
 		}
 		return req, nil
 	} else {
-		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
 		return nil, cusErr
 	}
 }
 
-// decodeHTTPUpdatePasswordRequest decode request
-func decodeHTTPUpdatePasswordRequest(_ context.Context, r *http.Request) (interface{}, error) {
+// decodeHTTPGetGoalHistoryRequest decode request
+func decodeHTTPGetGoalHistoryRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	if r.Method == "POST" {
-		var req authorization.UpdatePasswordRequest
+		var req authorization.GetGoalHistoryRequest
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
 		if req.AccessToken == "" {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("access token is required"), "access token is required")
-		}
-		if req.OldPassword == "" {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("old password is required"), "old password is required")
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
 		}
-		if req.NewPassword == "" {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("new password is required"), "new password is required")
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPCreateTagRequest decode request
+func decodeHTTPCreateTagRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.CreateTagRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
-		if req.ConfirmPassword == "" {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("confirm password is required"), "confirm password is required")
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
 		}
 		return req, nil
 	} else {
-		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
 		return nil, cusErr
 	}
 }
 
-// decodeHTTPGetForgetPasswordCodeRequest decode request
-func decodeHTTPGetForgetPasswordCodeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+// decodeHTTPUpdateTagRequest decode request
+func decodeHTTPUpdateTagRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	if r.Method == "POST" {
-		var req authorization.GetForgetPasswordCodeRequest
+		var req authorization.UpdateTagRequest
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
-		if req.Email == "" {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("email is required"), "email is required")
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.TagID == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
 		return req, nil
 	} else {
-		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
 		return nil, cusErr
 	}
 }
 
-// decodeHTTPResetPasswordRequest decode request
-func decodeHTTPResetPasswordRequest(_ context.Context, r *http.Request) (interface{}, error) {
+// decodeHTTPGetTagsRequest decode request
+func decodeHTTPGetTagsRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	if r.Method == "POST" {
-		var req authorization.CreateNewPasswordWithCodeRequest
+		var req authorization.GetTagsRequest
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
-		if req.Email == "" {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("email is required"), "email is required")
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
 		}
-		if req.Code == "" {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("code is required"), "code is required")
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
+}
+
+// decodeHTTPCreateTaskRequest decode request
+func decodeHTTPCreateTaskRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.CreateTaskRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
-		if req.NewPassword == "" {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("new password is required"), "new password is required")
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
 		}
 		return req, nil
 	} else {
-		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
 		return nil, cusErr
 	}
 }
 
-// decodeHTTPGetMultiRatioDataRequest decode request
-func decodeHTTPGetMultiRatioDataRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	return nil, nil
+// decodeHTTPUpdateTaskRequest decode request
+func decodeHTTPUpdateTaskRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if r.Method == "POST" {
+		var req authorization.UpdateTaskRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.TaskID == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
+		}
+		return req, nil
+	} else {
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
+		return nil, cusErr
+	}
 }
 
-// decodeHTTPInsertEarnScoreRequest decode request
-func decodeHTTPInsertEarnScoreRequest(_ context.Context, r *http.Request) (interface{}, error) {
+// decodeHTTPDeleteTaskRequest decode request
+func decodeHTTPDeleteTaskRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	if r.Method == "POST" {
-		var req authorization.InsertEarnScoreRequest
+		var req authorization.TaskRequest
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
 		if req.AccessToken == "" {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("access token is required"), "access token is required")
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
+		}
+		if req.TaskID == "" {
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
 		return req, nil
 	} else {
-		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
 		return nil, cusErr
 	}
 }
 
-// decodeHTTPGetEarnScoreRequest decode request
-func decodeHTTPGetEarnScoreRequest(_ context.Context, r *http.Request) (interface{}, error) {
+// decodeHTTPGetTasksRequest decode request
+func decodeHTTPGetTasksRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	if r.Method == "POST" {
-		var req authorization.GetEarnScoreRequest
+		var req authorization.GetTasksRequest
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("invalid request body"), "invalid request body")
+			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
 		if req.AccessToken == "" {
-			return nil, utils.NewErrorWrapper(http.StatusBadRequest, errors.New("access token is required"), "access token is required")
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
 		}
 		return req, nil
 	} else {
-		cusErr := utils.NewErrorWrapper(http.StatusBadRequest, errors.New("bad Request"), "Bad Request")
+		cusErr := utils.NewErrorResponse(utils.MethodNotAllowed)
 		return nil, cusErr
 	}
 }
 
-// decodeHTTPGenerateAccessTokenRequest decode request
-func decodeHTTPGenerateAccessTokenRequest(_ context.Context, r *http.Request) (interface{}, error) {
+// decodeHTTPReorderTasksRequest decode request
+func decodeHTTPReorderTasksRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	if r.Method == "POST" {
-		var req authorization.GenerateAccessTokenRequest
+		var req authorization.ReorderTasksRequest
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
 			return nil, utils.NewErrorResponse(utils.BadRequest)
 		}
-		if req.RefreshToken == "" {
-			return nil, utils.NewErrorResponse(utils.RefreshTokenRequired)
+		if req.AccessToken == "" {
+			return nil, utils.NewErrorResponse(utils.AccessTokenRequired)
 		}
 		return req, nil
 	} else {
@@ -436,6 +2189,32 @@ func errEncoder(ctx context.Context, err error, w http.ResponseWriter) {
 		Status:    false,
 		ErrorCode: cusErr.Code,
 		Message:   cusErr.Message,
+		Details:   cusErr.Details,
 	}
 	json.NewEncoder(w).Encode(res)
 }
+
+// encodeOAuthResponse writes the response as is, without the GenericResponse envelope
+func encodeOAuthResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(response)
+}
+
+// oauthErrEncoder writes errors in the format of RFC 6749
+func oauthErrEncoder(ctx context.Context, err error, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+
+	var oauthErr *authorization.OAuthError
+	if !errors.As(err, &oauthErr) {
+		oauthErr = &authorization.OAuthError{Code: "invalid_request", Description: err.Error(), Status: http.StatusBadRequest}
+		var errResp utils.ErrorResponse
+		if errors.As(err, &errResp) && errResp.ErrorType == utils.QuicklyRequest {
+			oauthErr = &authorization.OAuthError{Code: "temporarily_unavailable", Description: err.Error(), Status: http.StatusTooManyRequests}
+		}
+	}
+	w.WriteHeader(oauthErr.Status)
+	json.NewEncoder(w).Encode(oauthErr)
+}
@@ -0,0 +1,75 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/pkg/authorization/middleware"
+	"context"
+	"strings"
+	"time"
+)
+
+// SetUsername claims or changes the username of the user.
+func (s *userService) SetUsername(ctx context.Context, request *SetUsernameRequest) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	username, normalized, err := s.usernamePolicy.Validate(request.Username)
+	if err != nil {
+		s.logger.Error("Invalid username", "error", err)
+		return err.Error(), err
+	}
+	if normalized == user.UsernameNormalized && username == user.Username {
+		return SetUsernameResponse{Username: user.Username}, nil
+	}
+	// The first claim is free, later changes have to wait for the cooldown
+	if user.UsernameChangedAt != nil {
+		nextChange := user.UsernameChangedAt.AddDate(0, 0, s.configs.UsernameChangeCooldown)
+		if time.Now().Before(nextChange) {
+			s.logger.Error("Username change cooldown", "userID", userID, "nextChange", nextChange)
+			cusErr := utils.NewErrorResponse(utils.UsernameChangeCooldown)
+			return cusErr.Error(), cusErr
+		}
+	}
+	err = s.repo.UpdateUsername(ctx, userID, username, normalized)
+	if err != nil {
+		s.logger.Error("Cannot update username", "error", err)
+		if strings.Contains(err.Error(), utils.PgDuplicateKeyMsg) {
+			cusErr := utils.NewErrorResponse(utils.ExistUserName)
+			return cusErr.Error(), cusErr
+		}
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	s.logger.Info("Username updated", "userID", userID)
+	return SetUsernameResponse{Username: username}, nil
+}
+
+// GetPublicProfile returns the public profile of the user with the given username.
+func (s *userService) GetPublicProfile(ctx context.Context, request *GetPublicProfileRequest) (interface{}, error) {
+	user, err := s.repo.GetUserByUsername(ctx, utils.NormalizeUsername(request.Username))
+	if err != nil || user.Banned {
+		s.logger.Debug("Public profile not found", "username", request.Username)
+		cusErr := utils.NewErrorResponse(utils.NotFound)
+		return cusErr.Error(), cusErr
+	}
+	response := PublicProfileResponse{
+		Username: user.Username,
+	}
+	// A user may not have a profile or earn score row yet
+	if profile, err := s.repo.GetProfileByID(ctx, user.ID); err == nil {
+		response.AvatarURL = profile.AvatarURL
+	}
+	if earnScore, err := s.repo.GetEarnScore(ctx, user.ID); err == nil {
+		response.WaterScore = earnScore.WaterScore
+		response.LightScore = earnScore.LightScore
+		response.SeedScore = earnScore.SeedScore
+	}
+	return response, nil
+}
@@ -4,10 +4,11 @@ import (
 	"LoveLetterProject/internal"
 	"LoveLetterProject/internal/database"
 	"LoveLetterProject/pkg/authorization/middleware"
+	"LoveLetterProject/pkg/oidc"
 	"context"
+	"crypto/subtle"
 	"errors"
 	"github.com/hashicorp/go-hclog"
-	"golang.org/x/crypto/bcrypt"
 	"strings"
 	"time"
 )
@@ -17,7 +18,19 @@ type userService struct {
 	configs     *utils.Configurations
 	repo        database.UserRepository
 	mailService MailService
+	smsService  SMSService
 	auth        middleware.Authentication
+	hasher      utils.PasswordHasher
+	policy      *utils.PasswordPolicy
+	// usernamePolicy validates claimed usernames
+	usernamePolicy *utils.UsernamePolicy
+	// disposableDomains rejects throwaway email providers at signup
+	disposableDomains *utils.DisposableDomains
+	// oidcVerifier validates ID tokens for social login
+	oidcVerifier *oidc.Verifier
+	// dummyHash is verified against for unknown users so that the response
+	// time does not reveal whether an account exists.
+	dummyHash string
 }
 
 // NewUserService creates a new user service.
@@ -25,13 +38,30 @@ func NewUserService(logger hclog.Logger,
 	configs *utils.Configurations,
 	repo database.UserRepository,
 	mailService MailService,
-	auth middleware.Authentication) *userService {
+	smsService SMSService,
+	auth middleware.Authentication,
+	hasher utils.PasswordHasher,
+	policy *utils.PasswordPolicy,
+	usernamePolicy *utils.UsernamePolicy,
+	disposableDomains *utils.DisposableDomains,
+	oidcVerifier *oidc.Verifier) *userService {
+	dummyHash, err := hasher.Hash(utils.GenerateRandomString(16))
+	if err != nil {
+		logger.Error("unable to create dummy password hash", "error", err)
+	}
 	return &userService{
-		logger:      logger,
-		configs:     configs,
-		repo:        repo,
-		mailService: mailService,
-		auth:        auth,
+		logger:            logger,
+		configs:           configs,
+		repo:              repo,
+		mailService:       mailService,
+		smsService:        smsService,
+		auth:              auth,
+		hasher:            hasher,
+		policy:            policy,
+		usernamePolicy:    usernamePolicy,
+		disposableDomains: disposableDomains,
+		oidcVerifier:      oidcVerifier,
+		dummyHash:         dummyHash,
 	}
 }
 
@@ -43,6 +73,21 @@ func (s *userService) HealthCheck(ctx context.Context) error {
 
 // SignUp creates a new user.
 func (s *userService) SignUp(ctx context.Context, request *RegisterRequest) (string, error) {
+	if s.disposableDomains.IsDisposable(request.Email) {
+		s.logger.Error("Disposable email rejected", "email", request.Email)
+		cusErr := utils.NewErrorResponse(utils.DisposableEmail)
+		return cusErr.Error(), cusErr
+	}
+	if request.Password != request.RePassword {
+		s.logger.Error("Password and re-password are not the same")
+		cusErr := utils.NewErrorResponse(utils.PasswordNotMatch)
+		return cusErr.Error(), cusErr
+	}
+	// Check password against the password policy
+	if err := s.policy.Validate(request.Password, request.Email); err != nil {
+		s.logger.Error("Password does not satisfy the policy", "error", err)
+		return "Password does not satisfy the password policy", err
+	}
 	// Pass data from request to user struct
 	user := database.User{
 		Email:    request.Email,
@@ -50,7 +95,7 @@ func (s *userService) SignUp(ctx context.Context, request *RegisterRequest) (str
 	}
 
 	// Hash password before saving
-	hashedPassword, err := user.HashPassword()
+	hashedPassword, err := s.hasher.Hash(user.Password)
 	if err != nil {
 		s.logger.Error("Error hashing password", "error", err)
 		return "Cannot hash password", err
@@ -60,6 +105,11 @@ func (s *userService) SignUp(ctx context.Context, request *RegisterRequest) (str
 	err = s.repo.CreateUser(ctx, &user)
 	if err != nil {
 		s.logger.Error("Error creating user", "error", err)
+		if s.configs.EnumerationSafeMode && strings.Contains(err.Error(), utils.PgDuplicateKeyMsg) {
+			// Tell the owner instead of the caller that the account exists
+			go s.sendMail(user.Email, AccountExists, &MailData{})
+			return "success created user.", nil
+		}
 		return "Cannot create user", err
 	}
 	// Send email to user
@@ -203,12 +253,18 @@ func (s *userService) verify(ctx context.Context, actualVerificationData *databa
 	return true, nil
 }
 
-//Login authenticates a user.
+// Login authenticates a user.
 func (s *userService) Login(ctx context.Context, request *LoginRequest) (interface{}, error) {
 	// Get user from database
 	user, err := s.repo.GetUserByEmail(ctx, request.Email)
 	if err != nil {
 		s.logger.Error("Error getting user", "error", err)
+		if s.configs.EnumerationSafeMode {
+			// Spend the same hashing time as a real password check
+			s.auth.ComparePassword(s.dummyHash, request.Password)
+			cusErr := utils.NewErrorResponse(utils.InvalidCredentials)
+			return cusErr.Error(), cusErr
+		}
 		return "Cannot get user", err
 	}
 	// Check if user is verified
@@ -216,8 +272,9 @@ func (s *userService) Login(ctx context.Context, request *LoginRequest) (interfa
 		s.logger.Error("User is not verified", "error", err)
 		//return "User is not verified", err
 	}
-	// Check if user is banned
-	if user.Banned {
+	// Check if user is banned. In enumeration safe mode this is checked
+	// after the password so the ban is only revealed to the owner.
+	if user.Banned && !s.configs.EnumerationSafeMode {
 		s.logger.Error("User is banned", "error", err)
 		return "User is banned", err
 	}
@@ -236,6 +293,12 @@ func (s *userService) Login(ctx context.Context, request *LoginRequest) (interfa
 	// Check if limit is reached
 	if limitData.NumOfLogin > s.configs.LoginLimit {
 		s.logger.Error("Login limit reached", "error", err)
+		if s.configs.EnumerationSafeMode {
+			// Unknown emails are never locked out, so a locked out account answers like one
+			s.auth.ComparePassword(s.dummyHash, request.Password)
+			cusErr := utils.NewErrorResponse(utils.InvalidCredentials)
+			return cusErr.Error(), cusErr
+		}
 		return "You've tried to Sign-in too many times. try again tomorrow", errors.New("you've tried to sign in too many times. try again tomorrow")
 	}
 	// Update limit data
@@ -248,10 +311,23 @@ func (s *userService) Login(ctx context.Context, request *LoginRequest) (interfa
 	// Check if password is correct
 	if isSame := s.auth.ComparePassword(user.Password, request.Password); !isSame {
 		s.logger.Error("Password is incorrect", "error", err)
+		if s.configs.EnumerationSafeMode {
+			cusErr := utils.NewErrorResponse(utils.InvalidCredentials)
+			return cusErr.Error(), cusErr
+		}
 		return "Password is incorrect. Please try again.", err
 	}
+	if user.Banned {
+		s.logger.Error("User is banned")
+		cusErr := utils.NewErrorResponse(utils.Forbidden)
+		return "User is banned", cusErr
+	}
+	// Upgrade the stored hash when it was made with outdated parameters
+	if s.hasher.NeedsRehash(user.Password) {
+		s.rehashPassword(ctx, user, request.Password)
+	}
 	// Generate accessToken
-	accessToken, err := s.auth.GenerateAccessToken(user)
+	accessToken, err := s.auth.GenerateAuthenticatedAccessToken(user)
 	if err != nil {
 		s.logger.Error("Error generating accessToken", "error", err)
 		return "Internal Error, Please Try Again Later.", err
@@ -338,6 +414,7 @@ func (s *userService) GetUser(ctx context.Context) (interface{}, error) {
 		Email:    user.Email,
 		Username: user.Username,
 		Verified: user.Verified,
+		Role:     user.Role,
 	}
 	return userResponse, nil
 }
@@ -355,16 +432,18 @@ func (s *userService) GetProfile(ctx context.Context) (interface{}, error) {
 	}
 	// Make response data
 	profileResponse := GetProfileResponse{
-		Email:     profile.Email,
-		FirstName: profile.FirstName,
-		LastName:  profile.LastName,
-		AvatarURL: profile.AvatarURL,
-		Phone:     profile.Phone,
-		Street:    profile.Street,
-		City:      profile.City,
-		State:     profile.State,
-		ZipCode:   profile.ZipCode,
-		Country:   profile.Country,
+		Email:         profile.Email,
+		FirstName:     profile.FirstName,
+		LastName:      profile.LastName,
+		AvatarURL:     profile.AvatarURL,
+		Phone:         profile.Phone,
+		PhoneVerified: profile.PhoneVerified,
+		Street:        profile.Street,
+		City:          profile.City,
+		State:         profile.State,
+		ZipCode:       profile.ZipCode,
+		Country:       profile.Country,
+		Timezone:      profile.Timezone,
 	}
 	return profileResponse, nil
 }
@@ -403,7 +482,17 @@ func (s *userService) UpdateProfile(ctx context.Context, request *UpdateProfileR
 		profile.AvatarURL = request.AvatarURL
 	}
 	if request.Phone != "" {
-		profile.Phone = request.Phone
+		phone, err := utils.NormalizePhone(request.Phone, s.configs.PhoneDefaultCountryCode)
+		if err != nil {
+			s.logger.Error("Invalid phone number", "error", err)
+			cusErr := utils.NewErrorResponse(utils.InvalidPhone)
+			return nil, cusErr
+		}
+		// A new number has to be verified again
+		if phone != profile.Phone {
+			profile.Phone = phone
+			profile.PhoneVerified = false
+		}
 	}
 	if request.Street != "" {
 		profile.Street = request.Street
@@ -420,6 +509,14 @@ func (s *userService) UpdateProfile(ctx context.Context, request *UpdateProfileR
 	if request.Country != "" {
 		profile.Country = request.Country
 	}
+	if request.Timezone != "" {
+		if _, err := loadTimezone(request.Timezone); err != nil {
+			s.logger.Error("Invalid timezone", "error", err)
+			cusErr := utils.NewErrorResponse(utils.InvalidTimezone)
+			return nil, cusErr
+		}
+		profile.Timezone = request.Timezone
+	}
 	// Update profile
 	err = s.repo.UpdateProfile(ctx, profile)
 	if err != nil {
@@ -428,16 +525,18 @@ func (s *userService) UpdateProfile(ctx context.Context, request *UpdateProfileR
 	}
 	// Make response data
 	profileResponse := GetProfileResponse{
-		Email:     profile.Email,
-		FirstName: profile.FirstName,
-		LastName:  profile.LastName,
-		AvatarURL: profile.AvatarURL,
-		Phone:     profile.Phone,
-		Street:    profile.Street,
-		City:      profile.City,
-		State:     profile.State,
-		ZipCode:   profile.ZipCode,
-		Country:   profile.Country,
+		Email:         profile.Email,
+		FirstName:     profile.FirstName,
+		LastName:      profile.LastName,
+		AvatarURL:     profile.AvatarURL,
+		Phone:         profile.Phone,
+		PhoneVerified: profile.PhoneVerified,
+		Street:        profile.Street,
+		City:          profile.City,
+		State:         profile.State,
+		ZipCode:       profile.ZipCode,
+		Country:       profile.Country,
+		Timezone:      profile.Timezone,
 	}
 	s.logger.Info("Profile updated", "userID", userID)
 	return profileResponse, nil
@@ -497,8 +596,13 @@ func (s *userService) UpdatePassword(ctx context.Context, request *UpdatePasswor
 		err := errors.New("password is incorrect")
 		return err.Error(), err
 	}
+	// Check new password against the password policy
+	if err := s.policy.Validate(request.NewPassword, user.Email); err != nil {
+		s.logger.Error("New password does not satisfy the policy", "error", err)
+		return "Password does not satisfy the password policy", err
+	}
 	// Hash new password
-	hashedPassword, err := s.hashPassword(request.NewPassword)
+	hashedPassword, err := s.hasher.Hash(request.NewPassword)
 	if err != nil {
 		s.logger.Error("Cannot hash password", "error", err)
 		err := errors.New("internal server error. Please try again later")
@@ -549,14 +653,21 @@ func (s *userService) UpdatePassword(ctx context.Context, request *UpdatePasswor
 	return "Password changed", nil
 }
 
-// hashPassword hashes password.
-func (s *userService) hashPassword(password string) (string, error) {
-	hashedPass, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+// rehashPassword re-hashes the user's password with the current hasher parameters.
+// Failures are logged only, the user keeps the old hash and is upgraded on a later login.
+func (s *userService) rehashPassword(ctx context.Context, user *database.User, password string) {
+	hashedPassword, err := s.hasher.Hash(password)
 	if err != nil {
-		s.logger.Error("unable to hash password", "error", err)
-		return "", err
+		s.logger.Error("unable to rehash password", "error", err)
+		return
 	}
-	return string(hashedPass), nil
+	err = s.repo.UpdatePasswordHash(ctx, user.ID, hashedPassword)
+	if err != nil {
+		s.logger.Error("unable to store rehashed password", "error", err)
+		return
+	}
+	user.Password = hashedPassword
+	s.logger.Debug("password hash upgraded", "userID", user.ID)
 }
 
 // GetForgetPasswordCode gets forget password code.
@@ -565,8 +676,22 @@ func (s *userService) GetForgetPasswordCode(ctx context.Context, email string) e
 	user, err := s.repo.GetUserByEmail(ctx, email)
 	if err != nil {
 		s.logger.Error("Email is not registered", "error", err)
+		if s.configs.EnumerationSafeMode {
+			return nil
+		}
 		return errors.New("email is not registered")
 	}
+	err = s.mailPasswordResetCode(ctx, user)
+	if err != nil && s.configs.EnumerationSafeMode {
+		// Failures only happen for registered emails, answering them would reveal the account
+		s.logger.Error("Cannot mail password reset code", "userID", user.ID, "error", err)
+		return nil
+	}
+	return err
+}
+
+// mailPasswordResetCode stores a new password reset code of the user and mails it.
+func (s *userService) mailPasswordResetCode(ctx context.Context, user *database.User) error {
 	// Get limit data
 	isInsert := false
 	limitData, err := s.repo.GetLimitData(ctx, user.ID)
@@ -608,33 +733,76 @@ func (s *userService) GetForgetPasswordCode(ctx context.Context, email string) e
 		return errors.New("unable to store password reset verification data")
 	}
 	// Send verification mail
-	from := s.configs.MailSender
-	to := []string{user.Email}
-	subject := s.configs.MailTitle
-	mailType := PassReset
 	mailData := &MailData{
 		Username: user.Username,
 		Code:     forgetPasswordCode,
 	}
-	mailReq := s.mailService.NewMail(from, to, subject, mailType, mailData)
-	err = s.mailService.SendMail(mailReq)
+	if s.configs.EnumerationSafeMode {
+		// Sending in the background keeps the response time independent of the mail provider
+		go s.sendMail(user.Email, PassReset, mailData)
+		return nil
+	}
+	err = s.sendMail(user.Email, PassReset, mailData)
 	if err != nil {
-		s.logger.Error("unable to send mail", "error", err)
 		return errors.New("unable to send mail")
 	}
 	s.logger.Debug("successfully mailed password reset code")
 	return nil
 }
 
+// sendMail sends a mail of the given type to a single recipient.
+func (s *userService) sendMail(email string, mailType MailType, mailData *MailData) error {
+	from := s.configs.MailSender
+	to := []string{email}
+	subject := s.configs.MailTitle
+	mailReq := s.mailService.NewMail(from, to, subject, mailType, mailData)
+	err := s.mailService.SendMail(mailReq)
+	if err != nil {
+		s.logger.Error("unable to send mail", "error", err, "type", mailType)
+		return err
+	}
+	return nil
+}
+
 // ResetPassword creates new password with code.
 func (s *userService) ResetPassword(ctx context.Context, request *CreateNewPasswordWithCodeRequest) error {
+	// A code sent by sms is redeemed with the verified phone instead of the email
+	if request.Email == "" {
+		phone, err := utils.NormalizePhone(request.Phone, s.configs.PhoneDefaultCountryCode)
+		if err != nil {
+			s.logger.Error("Invalid phone number", "error", err)
+			return errors.New("invalid code")
+		}
+		user, err := s.userByVerifiedPhone(ctx, phone)
+		if err != nil {
+			s.logger.Error("Phone number is not verified", "error", err)
+			return errors.New("invalid code")
+		}
+		request.Email = user.Email
+	}
 	actualVerificationData, err := s.repo.GetVerificationData(ctx, request.Email, database.PassReset)
 	if err != nil {
 		s.logger.Error("unable to get verification data", "error", err)
 		return errors.New("internal server error. Please try again later")
 	}
-	if actualVerificationData.Code != request.Code {
-		s.logger.Error("invalid code", "error", err)
+	user, err := s.repo.GetUserByEmail(ctx, request.Email)
+	if err != nil {
+		s.logger.Error("unable to get user", "error", err)
+		return errors.New("internal server error. Please try again later")
+	}
+	// Code guesses count towards the login limit, the code is dropped once it is reached
+	limitData, err := s.increaseLoginCount(ctx, user.ID)
+	if err != nil {
+		if !errors.Is(err, utils.NewErrorResponse(utils.TooManyRequests)) {
+			return errors.New("internal server error. Please try again later")
+		}
+		if err := s.repo.DeleteVerificationData(ctx, actualVerificationData.Email, actualVerificationData.Type); err != nil {
+			s.logger.Error("unable to delete verification data from db", "error", err)
+		}
+		return errors.New("too many attempts. Please request a new code")
+	}
+	if subtle.ConstantTimeCompare([]byte(actualVerificationData.Code), []byte(request.Code)) != 1 {
+		s.logger.Error("invalid code", "userID", user.ID)
 		return errors.New("invalid code")
 	}
 	if actualVerificationData.ExpiresAt.Before(time.Now()) {
@@ -643,13 +811,13 @@ func (s *userService) ResetPassword(ctx context.Context, request *CreateNewPassw
 		s.logger.Error("unable to delete verification data from db", "error", err)
 		return errors.New("verification data provided is expired")
 	}
-	user, err := s.repo.GetUserByEmail(ctx, request.Email)
-	if err != nil {
-		s.logger.Error("unable to get user", "error", err)
-		return errors.New("internal server error. Please try again later")
+	// Check new password against the password policy
+	if err := s.policy.Validate(request.NewPassword, user.Email); err != nil {
+		s.logger.Error("New password does not satisfy the policy", "error", err)
+		return err
 	}
 	// Hash new password
-	hashedPassword, err := s.hashPassword(request.NewPassword)
+	hashedPassword, err := s.hasher.Hash(request.NewPassword)
 	if err != nil {
 		s.logger.Error("Cannot hash password", "error", err)
 		return errors.New("internal server error. Please try again later")
@@ -684,21 +852,12 @@ func (s *userService) ResetPassword(ctx context.Context, request *CreateNewPassw
 		s.logger.Error("Cannot update password into list of passwords", "error", err)
 		return errors.New("internal server error. Please try again later")
 	}
-	// Get limit data
-	isInsert := false
-	limitData, err := s.repo.GetLimitData(ctx, user.ID)
-	if err != nil {
-		s.logger.Error("Empty row get limit data", "error", err)
-		// No row, need insert
-		isInsert = true
-		limitData.UserID = user.ID
-		limitData.NumOfChangePassword = 1
-	} else {
-		limitData.NumOfChangePassword += 1
-	}
-	err = s.repo.InsertOrUpdateLimitData(ctx, limitData, isInsert)
+	// The reset proves the user owns the account, so earlier guesses are forgiven
+	limitData.NumOfLogin = 0
+	limitData.NumOfChangePassword += 1
+	err = s.repo.InsertOrUpdateLimitData(ctx, limitData, false)
 	if err != nil {
-		s.logger.Error("Cannot delete limit data", "error", err)
+		s.logger.Error("Cannot update limit data", "error", err)
 		return errors.New("internal server error. Please try again later")
 	}
 	// delete the VerificationData from db
@@ -711,9 +870,11 @@ func (s *userService) ResetPassword(ctx context.Context, request *CreateNewPassw
 	return nil
 }
 
-// GetMultiRatioData returns the multi ratio data of user.
+// GetMultiRatioData returns the multi ratio version in effect, and the next version when one
+// is scheduled, so clients know which version to report scores with.
 func (s *userService) GetMultiRatioData(ctx context.Context) (interface{}, error) {
-	data, err := s.repo.GetMultiRatioData(ctx)
+	now := time.Now()
+	data, err := s.repo.GetMultiRatioData(ctx, now)
 	if err != nil {
 		s.logger.Error("unable to get multi ratio data", "error", err)
 		// check if no row found
@@ -722,9 +883,34 @@ func (s *userService) GetMultiRatioData(ctx context.Context) (interface{}, error
 		}
 		return nil, errors.New("internal server error. Please try again later")
 	}
-	return data, nil
+	response := newMultiRatioResponse(data)
+	ratios, err := s.repo.GetMultiRatios(ctx)
+	if err != nil {
+		s.logger.Error("unable to get multi ratio versions", "error", err)
+		return nil, errors.New("internal server error. Please try again later")
+	}
+	for i := range ratios {
+		if ratios[i].EffectiveFrom.After(now) && (response.Next == nil || ratios[i].EffectiveFrom.Before(response.Next.EffectiveFrom)) {
+			response.Next = newMultiRatioResponse(&ratios[i])
+		}
+	}
+	return response, nil
 }
 
+func newMultiRatioResponse(ratio *database.MultiRatioData) *MultiRatioResponse {
+	return &MultiRatioResponse{
+		Version:       ratio.Version,
+		WaterRatio:    ratio.WaterRatio,
+		LightRatio:    ratio.LightRatio,
+		SeedRatio:     ratio.SeedRatio,
+		EffectiveFrom: ratio.EffectiveFrom,
+	}
+}
+
+// focusClockSkew is how far in the future reported focus times and published ratios may lie
+// before they are rejected, to tolerate client clocks running ahead
+const focusClockSkew = 5 * time.Minute
+
 // InsertEarnScore inserts earn score into db.
 func (s *userService) InsertEarnScore(ctx context.Context, request *InsertEarnScoreRequest) error {
 	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
@@ -743,20 +929,35 @@ func (s *userService) InsertEarnScore(ctx context.Context, request *InsertEarnSc
 		return errors.New("user is banned")
 	}
 
-	// get multi ratio data
-	multiRatioData, err := s.repo.GetMultiRatioData(ctx)
+	// Every session earns water, empty sessions would count towards goals for free
+	if request.WaterScore < 1 {
+		s.logger.Error("Invalid earn score", "waterScore", request.WaterScore)
+		return errors.New("invalid earn score")
+	}
+
+	// Scores are validated against the multi ratio in effect when the focus happened
+	focusedAt := time.Now()
+	if request.FocusedAt != nil {
+		backdateLimit := time.Hour * time.Duration(s.configs.FocusBackdateLimit)
+		if request.FocusedAt.After(focusedAt.Add(focusClockSkew)) || request.FocusedAt.Before(focusedAt.Add(-backdateLimit)) {
+			s.logger.Error("Invalid focus time", "focusedAt", request.FocusedAt)
+			return utils.NewErrorResponse(utils.InvalidFocusTime)
+		}
+		focusedAt = *request.FocusedAt
+	}
+	multiRatioData, err := s.repo.GetMultiRatioData(ctx, focusedAt)
 	if err != nil {
-		// check if no row found
 		if strings.Contains(err.Error(), utils.PgNoRowsMsg) {
-			multiRatioData = &database.MultiRatioData{
-				WaterRatio: 10,
-				LightRatio: 20,
-				SeedRatio:  40,
-			}
-		} else {
-			s.logger.Error("Cannot get multi ratio data", "error", err)
-			return errors.New("internal server error. Please try again later")
+			s.logger.Error("No multi ratio in effect", "focusedAt", focusedAt)
+			return utils.NewErrorResponse(utils.RatioNotConfigured)
 		}
+		s.logger.Error("Cannot get multi ratio data", "error", err)
+		return errors.New("internal server error. Please try again later")
+	}
+	// Clients computing scores with another version than the one in effect must refresh
+	if request.RatioVersion != 0 && request.RatioVersion != multiRatioData.Version {
+		s.logger.Error("Outdated multi ratio version", "version", request.RatioVersion, "current", multiRatioData.Version)
+		return utils.NewErrorResponse(utils.RatioVersionMismatch)
 	}
 	// Compare scores with water ratio
 	watterMinutes := request.WaterScore * multiRatioData.WaterRatio
@@ -767,34 +968,43 @@ func (s *userService) InsertEarnScore(ctx context.Context, request *InsertEarnSc
 		return errors.New("invalid earn score")
 	}
 
-	// Get current earn score
-	currentEarnScoreData, err := s.repo.GetEarnScore(ctx, user.ID)
+	tagIDs, err := s.sessionTagsOf(ctx, user.ID, request.TagIDs)
 	if err != nil {
-		// check if no row found
-		if strings.Contains(err.Error(), utils.PgNoRowsMsg) {
-			currentEarnScoreData = &database.EarnScore{
-				UserID:     user.ID,
-				WaterScore: 0,
-				LightScore: 0,
-				SeedScore:  0,
-			}
-		} else {
-			s.logger.Error("Cannot get earn score", "error", err)
-			return errors.New("internal server error. Please try again later")
-		}
+		return err
 	}
-	// Insert earn score
-	earnScore := &database.EarnScore{
-		UserID:     user.ID,
-		WaterScore: request.WaterScore + currentEarnScoreData.WaterScore,
-		LightScore: request.LightScore + currentEarnScoreData.LightScore,
-		SeedScore:  request.SeedScore + currentEarnScoreData.SeedScore,
+	taskID, err := s.sessionTaskOf(ctx, user.ID, request.TaskID)
+	if err != nil {
+		return err
 	}
-	err = s.repo.InsertEarnScore(ctx, earnScore)
+
+	// Record the session, its scores are added to the earn score
+	session := &database.FocusSession{
+		UserID:     user.ID,
+		Minutes:    watterMinutes,
+		WaterScore: request.WaterScore,
+		LightScore: request.LightScore,
+		SeedScore:  request.SeedScore,
+		CreatedAt:  focusedAt,
+		// The version is recorded so the scores can be audited after the ratios change
+		RatioVersion: multiRatioData.Version,
+		TaskID:       taskID,
+	}
+	err = s.repo.RecordFocusSession(ctx, session, tagIDs)
 	if err != nil {
 		s.logger.Error("Cannot insert earn score", "error", err)
 		return errors.New("internal server error. Please try again later")
 	}
+	// The session is credited already, a failing streak update must not fail the request
+	if err := s.creditStreak(ctx, user.ID); err != nil {
+		s.logger.Error("Cannot update streak", "error", err, "userID", user.ID)
+	}
+	if _, err := s.evaluateAchievements(ctx, user.ID); err != nil {
+		s.logger.Error("Cannot evaluate achievements", "error", err, "userID", user.ID)
+	}
+	// Goals met by the session are credited their bonus
+	if _, err := s.goalProgressOf(ctx, user.ID, s.goalLocationOf(ctx, user.ID)); err != nil {
+		s.logger.Error("Cannot credit goals", "error", err, "userID", user.ID)
+	}
 	s.logger.Info("Earn score inserted", "userID", user.ID)
 	return nil
 }
@@ -816,29 +1026,7 @@ func (s *userService) GetEarnScore(ctx context.Context) (interface{}, error) {
 		s.logger.Error("User is banned", "error", err)
 		return nil, errors.New("user is banned")
 	}
-	// Get earn score
-	earnScore, err := s.repo.GetEarnScore(ctx, user.ID)
-	if err != nil {
-		// check if no row found
-		if strings.Contains(err.Error(), utils.PgNoRowsMsg) {
-			earnScore = &database.EarnScore{
-				UserID:     user.ID,
-				WaterScore: 0,
-				LightScore: 0,
-				SeedScore:  0,
-			}
-		} else {
-			s.logger.Error("Cannot get earn score", "error", err)
-			return nil, errors.New("internal server error. Please try again later")
-		}
-	}
-	// make response data
-	response := &database.EarnScoreResponse{
-		WaterScore: earnScore.WaterScore,
-		LightScore: earnScore.LightScore,
-		SeedScore:  earnScore.SeedScore,
-	}
-	return response, nil
+	return s.earnScoreOf(ctx, user.ID)
 }
 
 // GenerateAccessToken generate access token
@@ -868,6 +1056,49 @@ func (s *userService) GenerateAccessToken(ctx context.Context) (interface{}, err
 	}, nil
 }
 
+// Reauthenticate confirms the user's password and returns an access token with a fresh auth_time.
+func (s *userService) Reauthenticate(ctx context.Context, request *ReauthenticateRequest) (interface{}, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey{}).(string)
+	if !ok {
+		s.logger.Error("Error getting userID from context")
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	// Common check user status
+	user, err := s.commonCheckUserStatusByUserId(ctx, userID)
+	if err != nil {
+		return err.Error(), err
+	}
+	// Password guesses count towards the login limit
+	limitData, err := s.increaseLoginCount(ctx, user.ID)
+	if err != nil {
+		return err.Error(), err
+	}
+	if isSame := s.auth.ComparePassword(user.Password, request.Password); !isSame {
+		s.logger.Error("Password is incorrect", "userID", user.ID)
+		cusErr := utils.NewErrorResponse(utils.PasswordIncorrect)
+		return cusErr.Error(), cusErr
+	}
+	accessToken, err := s.auth.GenerateAuthenticatedAccessToken(user)
+	if err != nil {
+		s.logger.Error("unable to generate access token", "error", err)
+		cusErr := utils.NewErrorResponse(utils.InternalServerError)
+		return cusErr.Error(), cusErr
+	}
+	// Reset limit data
+	limitData.NumOfLogin = 0
+	err = s.repo.InsertOrUpdateLimitData(ctx, limitData, false)
+	if err != nil {
+		s.logger.Error("Cannot reset number of login", "error", err)
+	}
+
+	s.logger.Debug("user reauthenticated", "userID", user.ID)
+	return ReauthenticateResponse{
+		AccessToken: accessToken,
+		AuthTime:    time.Now().Unix(),
+	}, nil
+}
+
 func (s *userService) commonCheckUserStatusByUserId(ctx context.Context, userID string) (*database.User, error) {
 	// Get user from database
 	user, err := s.repo.GetUserByID(ctx, userID)
@@ -890,3 +1121,73 @@ func (s *userService) commonCheckUserStatusByUserId(ctx context.Context, userID
 
 	return user, nil
 }
+
+// increaseLoginCount counts a sign in attempt towards the daily login limit.
+func (s *userService) increaseLoginCount(ctx context.Context, userID string) (*database.LimitData, error) {
+	isInsert := false
+	limitData, err := s.repo.GetLimitData(ctx, userID)
+	if err != nil {
+		// No row, need insert
+		isInsert = true
+		limitData.UserID = userID
+		limitData.NumOfLogin = 1
+	} else {
+		limitData.NumOfLogin += 1
+	}
+	if limitData.NumOfLogin > s.configs.LoginLimit {
+		s.logger.Error("Login limit reached", "userID", userID)
+		return nil, utils.NewErrorResponse(utils.TooManyRequests)
+	}
+	err = s.repo.InsertOrUpdateLimitData(ctx, limitData, isInsert)
+	if err != nil {
+		s.logger.Error("Cannot insert or update limit data", "error", err)
+		return nil, utils.NewErrorResponse(utils.InternalServerError)
+	}
+	return limitData, nil
+}
+
+// generateTokens returns an access token with a fresh auth_time and a refresh token for the user.
+func (s *userService) generateTokens(user *database.User) (string, string, error) {
+	accessToken, err := s.auth.GenerateAuthenticatedAccessToken(user)
+	if err != nil {
+		s.logger.Error("Error generating accessToken", "error", err)
+		return "", "", utils.NewErrorResponse(utils.InternalServerError)
+	}
+	refreshToken, err := s.auth.GenerateRefreshToken(user)
+	if err != nil {
+		s.logger.Error("Error generating refreshToken", "error", err)
+		return "", "", utils.NewErrorResponse(utils.InternalServerError)
+	}
+	return accessToken, refreshToken, nil
+}
+
+// earnScoreOf returns the earn score of the user, zero when none was earned yet
+func (s *userService) earnScoreOf(ctx context.Context, userID string) (*database.EarnScoreResponse, error) {
+	earnScore, err := s.repo.GetEarnScore(ctx, userID)
+	if err != nil {
+		// check if no row found
+		if strings.Contains(err.Error(), utils.PgNoRowsMsg) {
+			earnScore = &database.EarnScore{
+				UserID:     userID,
+				WaterScore: 0,
+				LightScore: 0,
+				SeedScore:  0,
+			}
+		} else {
+			s.logger.Error("Cannot get earn score", "error", err)
+			return nil, errors.New("internal server error. Please try again later")
+		}
+	}
+	// make response data
+	streak, err := s.streakResponseOf(ctx, userID)
+	if err != nil {
+		return nil, errors.New("internal server error. Please try again later")
+	}
+	response := &database.EarnScoreResponse{
+		WaterScore: earnScore.WaterScore,
+		LightScore: earnScore.LightScore,
+		SeedScore:  earnScore.SeedScore,
+		Streak:     streak,
+	}
+	return response, nil
+}
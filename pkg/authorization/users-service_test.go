@@ -0,0 +1,144 @@
+package authorization
+
+import (
+	"LoveLetterProject/internal"
+	"LoveLetterProject/internal/database"
+	"testing"
+	"time"
+)
+
+func TestLoginLockoutIsEnumerationSafe(t *testing.T) {
+	s, _, _, ctx := newUserTest(t)
+	s.configs.EnumerationSafeMode = true
+
+	for _, email := range []string{"known@example.com", "unknown@example.com"} {
+		for i := 0; i <= s.configs.LoginLimit+1; i++ {
+			message, err := s.Login(ctx, &LoginRequest{Email: email, Password: "Wrong-Password1"})
+			if errorType(err) != utils.InvalidCredentials {
+				t.Fatalf("%s attempt %d: got %v (%v), want invalid credentials", email, i+1, message, err)
+			}
+		}
+	}
+	// The owner is locked out as well, without learning why
+	_, err := s.Login(ctx, &LoginRequest{Email: "known@example.com", Password: "Correct-Password1"})
+	if errorType(err) != utils.InvalidCredentials {
+		t.Fatalf("locked out login: got %v, want invalid credentials", err)
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	s, _, _, ctx := newUserTest(t)
+
+	for i := 0; i < s.configs.LoginLimit; i++ {
+		s.Login(ctx, &LoginRequest{Email: "known@example.com", Password: "Wrong-Password1"})
+	}
+	message, err := s.Login(ctx, &LoginRequest{Email: "known@example.com", Password: "Correct-Password1"})
+	if err == nil || message != "You've tried to Sign-in too many times. try again tomorrow" {
+		t.Fatalf("got %v (%v), want the lockout message", message, err)
+	}
+}
+
+func TestGetForgetPasswordCodeIsEnumerationSafe(t *testing.T) {
+	s, _, _, ctx := newUserTest(t)
+	s.configs.EnumerationSafeMode = true
+
+	for _, email := range []string{"known@example.com", "unknown@example.com"} {
+		for i := 0; i <= s.configs.SendMailLimit+1; i++ {
+			if err := s.GetForgetPasswordCode(ctx, email); err != nil {
+				t.Fatalf("%s attempt %d: got %v, want no error", email, i+1, err)
+			}
+		}
+	}
+}
+
+func TestGetForgetPasswordCodeLimit(t *testing.T) {
+	s, _, _, ctx := newUserTest(t)
+
+	if err := s.GetForgetPasswordCode(ctx, "unknown@example.com"); err == nil {
+		t.Fatal("unknown email got no error")
+	}
+	for i := 0; i < s.configs.SendMailLimit; i++ {
+		if err := s.GetForgetPasswordCode(ctx, "known@example.com"); err != nil {
+			t.Fatalf("attempt %d: %v", i+1, err)
+		}
+	}
+	if err := s.GetForgetPasswordCode(ctx, "known@example.com"); err == nil {
+		t.Fatal("mail over the limit got no error")
+	}
+}
+
+func TestReauthenticate(t *testing.T) {
+	s, _, _, ctx := newUserTest(t)
+
+	for i := 0; i < s.configs.LoginLimit-1; i++ {
+		if _, err := s.Reauthenticate(ctx, &ReauthenticateRequest{Password: "Wrong-Password1"}); errorType(err) != utils.PasswordIncorrect {
+			t.Fatalf("attempt %d: got %v, want password incorrect", i+1, err)
+		}
+	}
+	response, err := s.Reauthenticate(ctx, &ReauthenticateRequest{Password: "Correct-Password1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	authTime, err := s.auth.GetAccessTokenAuthTime(response.(ReauthenticateResponse).AccessToken)
+	if err != nil || authTime.IsZero() {
+		t.Fatalf("got auth time %v (%v), want the time of the reauthentication", authTime, err)
+	}
+	// A success resets the count, so the limit applies to consecutive guesses only
+	for i := 0; i < s.configs.LoginLimit; i++ {
+		if _, err := s.Reauthenticate(ctx, &ReauthenticateRequest{Password: "Wrong-Password1"}); errorType(err) != utils.PasswordIncorrect {
+			t.Fatalf("attempt %d after success: got %v, want password incorrect", i+1, err)
+		}
+	}
+	if _, err := s.Reauthenticate(ctx, &ReauthenticateRequest{Password: "Correct-Password1"}); errorType(err) != utils.TooManyRequests {
+		t.Fatalf("got %v, want too many requests", err)
+	}
+}
+
+func TestInsertEarnScoreFocusedAt(t *testing.T) {
+	s, repo, _, ctx := newUserTest(t)
+	now := time.Now()
+	repo.ratios = []database.MultiRatioData{
+		{Version: 1, WaterRatio: 5, LightRatio: 10, SeedRatio: 20, EffectiveFrom: now.Add(-48 * time.Hour)},
+		{Version: 2, WaterRatio: 10, LightRatio: 10, SeedRatio: 30, EffectiveFrom: now.Add(-2 * time.Hour)},
+	}
+
+	// A backdated session is validated against the ratio of its time and recorded at it
+	focusedAt := now.Add(-3 * time.Hour).Truncate(time.Second)
+	err := s.InsertEarnScore(ctx, &InsertEarnScoreRequest{WaterScore: 4, LightScore: 2, SeedScore: 1, FocusedAt: &focusedAt, RatioVersion: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := repo.sessions[len(repo.sessions)-1]
+	if !session.CreatedAt.Equal(focusedAt) || session.RatioVersion != 1 || session.Minutes != 20 {
+		t.Fatalf("got session at %v with version %d and %d minutes, want %v, 1 and 20", session.CreatedAt, session.RatioVersion, session.Minutes, focusedAt)
+	}
+	if err := s.InsertEarnScore(ctx, &InsertEarnScoreRequest{WaterScore: 3, LightScore: 3, SeedScore: 1}); err != nil {
+		t.Fatal(err)
+	}
+	session = repo.sessions[len(repo.sessions)-1]
+	if session.CreatedAt.Before(now) || session.RatioVersion != 2 {
+		t.Fatalf("got session at %v with version %d, want now and 2", session.CreatedAt, session.RatioVersion)
+	}
+
+	tests := []struct {
+		name    string
+		request InsertEarnScoreRequest
+		want    utils.ErrorType
+	}{
+		{"before the backdate limit", InsertEarnScoreRequest{WaterScore: 4, LightScore: 2, SeedScore: 1, FocusedAt: timeAt(now.Add(-25 * time.Hour))}, utils.InvalidFocusTime},
+		{"in the future", InsertEarnScoreRequest{WaterScore: 3, LightScore: 3, SeedScore: 1, FocusedAt: timeAt(now.Add(time.Hour))}, utils.InvalidFocusTime},
+		{"outdated version", InsertEarnScoreRequest{WaterScore: 3, LightScore: 3, SeedScore: 1, RatioVersion: 1}, utils.RatioVersionMismatch},
+	}
+	for _, test := range tests {
+		if err := s.InsertEarnScore(ctx, &test.request); errorType(err) != test.want {
+			t.Fatalf("%s: got %v, want %v", test.name, err, utils.NewErrorResponse(test.want))
+		}
+	}
+	if len(repo.sessions) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(repo.sessions))
+	}
+}
+
+func timeAt(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,290 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// jwksCacheTTL is how long fetched signing keys are trusted before refetching
+	jwksCacheTTL = time.Hour
+	// jwksMinRefresh limits refetches triggered by unknown key ids
+	jwksMinRefresh = time.Minute
+	// clockSkew is the leeway allowed on exp, iat and nbf
+	clockSkew = 2 * time.Minute
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown identity provider")
+	ErrInvalidIDToken  = errors.New("invalid id token")
+)
+
+// Provider is the configuration of a trusted OpenID Connect provider
+type Provider struct {
+	Name      string   `json:"name"`
+	Issuer    string   `json:"issuer"`
+	JWKSURL   string   `json:"jwks_url"`  // discovered from the issuer when empty
+	Audiences []string `json:"audiences"` // our client ids registered with the provider
+}
+
+// LoadProviders reads the provider list from a JSON file. An empty path returns no providers.
+func LoadProviders(path string) ([]Provider, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var providers []Provider
+	if err := json.Unmarshal(data, &providers); err != nil {
+		return nil, err
+	}
+	return providers, nil
+}
+
+// IDTokenClaims are the claims we read from a provider ID token
+type IDTokenClaims struct {
+	Issuer        string       `json:"iss"`
+	Subject       string       `json:"sub"`
+	Audience      audience     `json:"aud"`
+	ExpiresAt     int64        `json:"exp"`
+	IssuedAt      int64        `json:"iat"`
+	NotBefore     int64        `json:"nbf,omitempty"`
+	Email         string       `json:"email"`
+	EmailVerified flexibleBool `json:"email_verified"`
+	Name          string       `json:"name,omitempty"`
+	Nonce         string       `json:"nonce,omitempty"`
+}
+
+// Valid checks the time based claims, issuer and audience are checked by the Verifier
+func (c *IDTokenClaims) Valid() error {
+	now := time.Now()
+	if c.ExpiresAt == 0 || now.After(time.Unix(c.ExpiresAt, 0).Add(clockSkew)) {
+		return errors.New("id token is expired")
+	}
+	if c.IssuedAt != 0 && now.Add(clockSkew).Before(time.Unix(c.IssuedAt, 0)) {
+		return errors.New("id token is issued in the future")
+	}
+	if c.NotBefore != 0 && now.Add(clockSkew).Before(time.Unix(c.NotBefore, 0)) {
+		return errors.New("id token is not valid yet")
+	}
+	if c.Subject == "" {
+		return errors.New("id token has no subject")
+	}
+	return nil
+}
+
+// Verifier validates ID tokens from the configured providers
+type Verifier struct {
+	providers map[string]Provider
+	client    *http.Client
+
+	mu   sync.Mutex
+	keys map[string]*keySet // by provider name
+}
+
+type keySet struct {
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// NewVerifier returns a Verifier trusting the given providers
+func NewVerifier(providers []Provider, client *http.Client) *Verifier {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	byName := make(map[string]Provider, len(providers))
+	for _, p := range providers {
+		byName[p.Name] = p
+	}
+	return &Verifier{
+		providers: byName,
+		client:    client,
+		keys:      map[string]*keySet{},
+	}
+}
+
+// Verify validates the signature, issuer, audience and lifetime of the ID token
+// issued by the named provider and returns its claims.
+func (v *Verifier) Verify(ctx context.Context, providerName string, rawIDToken string) (*IDTokenClaims, error) {
+	provider, ok := v.providers[providerName]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	claims := &IDTokenClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return v.publicKey(ctx, provider, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if claims.Issuer != provider.Issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidIDToken, claims.Issuer)
+	}
+	if !claims.Audience.containsAny(provider.Audiences) {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+// publicKey returns the signing key with the given id, refreshing the cached JWKS when needed
+func (v *Verifier) publicKey(ctx context.Context, provider Provider, kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	set := v.keys[provider.Name]
+	if set != nil && time.Since(set.fetchedAt) < jwksCacheTTL {
+		if key := set.lookup(kid); key != nil {
+			return key, nil
+		}
+		if time.Since(set.fetchedAt) < jwksMinRefresh {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+	}
+
+	set, err := v.fetchKeys(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	v.keys[provider.Name] = set
+	if key := set.lookup(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+// lookup returns the key by id, or the only key when the token has no key id
+func (s *keySet) lookup(kid string) *rsa.PublicKey {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key
+		}
+	}
+	return s.keys[kid]
+}
+
+// fetchKeys downloads the provider JWKS, discovering its URL from the issuer if needed
+func (v *Verifier) fetchKeys(ctx context.Context, provider Provider) (*keySet, error) {
+	jwksURL := provider.JWKSURL
+	if jwksURL == "" {
+		var discovery struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		discoveryURL := strings.TrimSuffix(provider.Issuer, "/") + "/.well-known/openid-configuration"
+		if err := v.getJSON(ctx, discoveryURL, &discovery); err != nil {
+			return nil, err
+		}
+		jwksURL = discovery.JWKSURI
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := v.getJSON(ctx, jwksURL, &jwks); err != nil {
+		return nil, err
+	}
+
+	set := &keySet{keys: map[string]*rsa.PublicKey{}, fetchedAt: time.Now()}
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		set.keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return set, nil
+}
+
+func (v *Verifier) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// audience accepts the aud claim as a single string or an array
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a audience) containsAny(allowed []string) bool {
+	for _, aud := range a {
+		for _, other := range allowed {
+			if aud == other {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// flexibleBool accepts true and "true", some providers send email_verified as a string
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	var value bool
+	if err := json.Unmarshal(data, &value); err == nil {
+		*b = flexibleBool(value)
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	*b = flexibleBool(text == "true")
+	return nil
+}
@@ -0,0 +1,90 @@
+package oidc_test
+
+import (
+	"LoveLetterProject/pkg/oidc"
+	"LoveLetterProject/pkg/oidc/oidctest"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	idp, server, err := oidctest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	verifier := oidc.NewVerifier([]oidc.Provider{idp.Provider("test", "our-client")}, server.Client())
+
+	valid := oidctest.TokenOptions{
+		Subject:       "subject-1",
+		Email:         "user@example.com",
+		EmailVerified: true,
+		Audience:      "our-client",
+	}
+	tests := []struct {
+		name    string
+		options func(opts *oidctest.TokenOptions)
+		valid   bool
+	}{
+		{"valid token", func(opts *oidctest.TokenOptions) {}, true},
+		{"wrong audience", func(opts *oidctest.TokenOptions) { opts.Audience = "other-client" }, false},
+		{"wrong issuer", func(opts *oidctest.TokenOptions) { opts.Issuer = "https://attacker.example.com" }, false},
+		{"expired token", func(opts *oidctest.TokenOptions) { opts.ExpiresIn = -time.Hour }, false},
+		{"unknown key id", func(opts *oidctest.TokenOptions) { opts.KeyID = "rotated-away" }, false},
+		{"no subject", func(opts *oidctest.TokenOptions) { opts.Subject = "" }, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := valid
+			test.options(&opts)
+			token, err := idp.MintIDToken(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := verifier.Verify(context.Background(), "test", token)
+			if !test.valid {
+				if !errors.Is(err, oidc.ErrInvalidIDToken) {
+					t.Fatalf("got %v, want an invalid id token error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.Subject != opts.Subject || claims.Email != opts.Email || !bool(claims.EmailVerified) {
+				t.Fatalf("got claims %+v", claims)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsTokenOfAnotherIdP(t *testing.T) {
+	idp, server, err := oidctest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	// Same issuer and key id, but a different signing key
+	forger, err := oidctest.NewIdP(idp.Issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := oidc.NewVerifier([]oidc.Provider{idp.Provider("test", "our-client")}, server.Client())
+
+	token, err := forger.MintIDToken(oidctest.TokenOptions{Subject: "subject-1", Audience: "our-client"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Verify(context.Background(), "test", token); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Fatalf("got %v, want an invalid id token error", err)
+	}
+}
+
+func TestVerifyUnknownProvider(t *testing.T) {
+	verifier := oidc.NewVerifier(nil, nil)
+	if _, err := verifier.Verify(context.Background(), "missing", "token"); !errors.Is(err, oidc.ErrUnknownProvider) {
+		t.Fatalf("got %v, want unknown provider", err)
+	}
+}
@@ -0,0 +1,137 @@
+// Package oidctest provides a minimal OpenID Connect provider to test social login
+// without a real identity provider.
+package oidctest
+
+import (
+	"LoveLetterProject/pkg/oidc"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const keyID = "oidctest"
+
+// IdP serves the discovery document and JWKS of a single signing key and mints ID tokens
+type IdP struct {
+	Issuer string
+	key    *rsa.PrivateKey
+}
+
+// TokenOptions are the claims of a minted ID token. Zero values get sensible defaults.
+type TokenOptions struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Audience      string
+	Issuer        string        // defaults to the IdP issuer
+	ExpiresIn     time.Duration // defaults to one hour, negative for an expired token
+	KeyID         string        // defaults to the id of the IdP key
+}
+
+// NewIdP returns an IdP with a fresh signing key for the given issuer URL
+func NewIdP(issuer string) (*IdP, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return &IdP{Issuer: strings.TrimSuffix(issuer, "/"), key: key}, nil
+}
+
+// NewServer starts an IdP on a local test server, the caller must close the server
+func NewServer() (*IdP, *httptest.Server, error) {
+	idp, err := NewIdP("")
+	if err != nil {
+		return nil, nil, err
+	}
+	server := httptest.NewServer(idp)
+	idp.Issuer = server.URL
+	return idp, server, nil
+}
+
+// Provider returns the provider configuration trusting this IdP
+func (idp *IdP) Provider(name string, audiences ...string) oidc.Provider {
+	return oidc.Provider{
+		Name:      name,
+		Issuer:    idp.Issuer,
+		Audiences: audiences,
+	}
+}
+
+// MintIDToken returns an ID token signed with the IdP key
+func (idp *IdP) MintIDToken(opts TokenOptions) (string, error) {
+	issuer := opts.Issuer
+	if issuer == "" {
+		issuer = idp.Issuer
+	}
+	expiresIn := opts.ExpiresIn
+	if expiresIn == 0 {
+		expiresIn = time.Hour
+	}
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            issuer,
+		"sub":            opts.Subject,
+		"aud":            opts.Audience,
+		"iat":            now.Unix(),
+		"exp":            now.Add(expiresIn).Unix(),
+		"email":          opts.Email,
+		"email_verified": opts.EmailVerified,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	if opts.KeyID != "" {
+		token.Header["kid"] = opts.KeyID
+	}
+	return token.SignedString(idp.key)
+}
+
+// ServeHTTP serves the discovery document, the JWKS and a /mint endpoint for manual testing
+func (idp *IdP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		writeJSON(w, map[string]interface{}{
+			"issuer":                                idp.Issuer,
+			"jwks_uri":                              idp.Issuer + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	case "/jwks":
+		writeJSON(w, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": keyID,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+			}},
+		})
+	case "/mint":
+		query := r.URL.Query()
+		token, err := idp.MintIDToken(TokenOptions{
+			Subject:       query.Get("sub"),
+			Email:         query.Get("email"),
+			EmailVerified: query.Get("email_verified") != "false",
+			Audience:      query.Get("aud"),
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]string{"id_token": token})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}